@@ -4,6 +4,7 @@ import (
 	"crypto/ecdsa"
 	"crypto/rsa"
 	"crypto/x509"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"time"
@@ -112,6 +113,9 @@ func toCertKeyMetadata(certificate *x509.Certificate) certgraphapi.CertKeyMetada
 		NotBefore:          certificate.NotBefore.Format(time.RFC3339),
 		NotAfter:           certificate.NotAfter.Format(time.RFC3339),
 		ValidityDuration:   duration.HumanDuration(certificate.NotAfter.Sub(certificate.NotBefore)),
+		SubjectKeyID:       hex.EncodeToString(certificate.SubjectKeyId),
+		AuthorityKeyID:     hex.EncodeToString(certificate.AuthorityKeyId),
+		RawCertificate:     certificate.Raw,
 	}
 
 	switch publicKey := certificate.PublicKey.(type) {
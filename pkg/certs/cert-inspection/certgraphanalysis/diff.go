@@ -0,0 +1,341 @@
+package certgraphanalysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openshift/library-go/pkg/certs/cert-inspection/certgraphapi"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// PKIListDiff is the difference between two PKILists, usually gathered before and after an upgrade.
+type PKIListDiff struct {
+	AddedCertKeyPairs   []certgraphapi.CertKeyPair
+	RemovedCertKeyPairs []certgraphapi.CertKeyPair
+	// ReissuedCertKeyPairs are certificates replaced by a new certificate, either signed again for the same key or
+	// stored in the same location with a new key.
+	ReissuedCertKeyPairs []CertKeyPairDiff
+	// ChangedCertKeyPairs are the same certificate with different metadata, usually only different locations.
+	ChangedCertKeyPairs []CertKeyPairDiff
+
+	AddedCABundles   []certgraphapi.CertificateAuthorityBundle
+	RemovedCABundles []certgraphapi.CertificateAuthorityBundle
+	ChangedCABundles []CABundleDiff
+}
+
+type CertKeyPairDiff struct {
+	Before  certgraphapi.CertKeyPair
+	After   certgraphapi.CertKeyPair
+	Changes []FieldChange
+}
+
+type CABundleDiff struct {
+	Before certgraphapi.CertificateAuthorityBundle
+	After  certgraphapi.CertificateAuthorityBundle
+	// AddedCertificates and RemovedCertificates are CommonName::SerialNumber of the certificates in the bundle.
+	AddedCertificates   []string
+	RemovedCertificates []string
+	Changes             []FieldChange
+}
+
+// FieldChange is a single changed value, rendered as a string.
+type FieldChange struct {
+	Field  string
+	Before string
+	After  string
+}
+
+// IsEmpty returns true if nothing changed.
+func (d *PKIListDiff) IsEmpty() bool {
+	return len(d.AddedCertKeyPairs) == 0 && len(d.RemovedCertKeyPairs) == 0 &&
+		len(d.ReissuedCertKeyPairs) == 0 && len(d.ChangedCertKeyPairs) == 0 &&
+		len(d.AddedCABundles) == 0 && len(d.RemovedCABundles) == 0 && len(d.ChangedCABundles) == 0
+}
+
+// DiffPKILists compares two PKILists.
+// Cert/key pairs are deduplicated the same way as during collection, so a certificate that only moved between secrets
+// or files is reported as changed instead of as removed and added.
+//  1. cert/key pairs with the same public key are the same certificate.  A different serial number means it was reissued.
+//  2. unmatched cert/key pairs sharing a secret or file were reissued with a new key.
+//  3. everything else was added or removed.
+//
+// CA bundles are matched by shared configmap or file first and by name second, because the name changes with the content.
+func DiffPKILists(before, after *certgraphapi.PKIList) *PKIListDiff {
+	ret := &PKIListDiff{}
+	diffCertKeyPairs(ret, deduplicateCertKeyPairList(&before.CertKeyPairs).Items, deduplicateCertKeyPairList(&after.CertKeyPairs).Items)
+	diffCABundles(ret, deduplicateCABundlesList(&before.CertificateAuthorityBundles).Items, deduplicateCABundlesList(&after.CertificateAuthorityBundles).Items)
+	return ret
+}
+
+func diffCertKeyPairs(ret *PKIListDiff, before, after []certgraphapi.CertKeyPair) {
+	matchedBefore := sets.New[int]()
+	matchedAfter := sets.New[int]()
+
+	for i := range before {
+		for j := range after {
+			if matchedAfter.Has(j) || !sameKey(&before[i], &after[j]) {
+				continue
+			}
+			matchedBefore.Insert(i)
+			matchedAfter.Insert(j)
+
+			diff := CertKeyPairDiff{Before: before[i], After: after[j], Changes: certKeyPairChanges(&before[i], &after[j])}
+			switch {
+			case before[i].Spec.CertMetadata.CertIdentifier.SerialNumber != after[j].Spec.CertMetadata.CertIdentifier.SerialNumber:
+				ret.ReissuedCertKeyPairs = append(ret.ReissuedCertKeyPairs, diff)
+			case len(diff.Changes) > 0:
+				ret.ChangedCertKeyPairs = append(ret.ChangedCertKeyPairs, diff)
+			}
+			break
+		}
+	}
+
+	for i := range before {
+		if matchedBefore.Has(i) {
+			continue
+		}
+		for j := range after {
+			if matchedAfter.Has(j) || !shareCertKeyPairLocation(&before[i], &after[j]) {
+				continue
+			}
+			matchedBefore.Insert(i)
+			matchedAfter.Insert(j)
+			ret.ReissuedCertKeyPairs = append(ret.ReissuedCertKeyPairs, CertKeyPairDiff{
+				Before:  before[i],
+				After:   after[j],
+				Changes: certKeyPairChanges(&before[i], &after[j]),
+			})
+			break
+		}
+	}
+
+	for i := range before {
+		if !matchedBefore.Has(i) {
+			ret.RemovedCertKeyPairs = append(ret.RemovedCertKeyPairs, before[i])
+		}
+	}
+	for j := range after {
+		if !matchedAfter.Has(j) {
+			ret.AddedCertKeyPairs = append(ret.AddedCertKeyPairs, after[j])
+		}
+	}
+
+	sortCertKeyPairs(ret.AddedCertKeyPairs)
+	sortCertKeyPairs(ret.RemovedCertKeyPairs)
+	sortCertKeyPairDiffs(ret.ReissuedCertKeyPairs)
+	sortCertKeyPairDiffs(ret.ChangedCertKeyPairs)
+}
+
+func sameKey(lhs, rhs *certgraphapi.CertKeyPair) bool {
+	lhsModulus := lhs.Spec.CertMetadata.CertIdentifier.PubkeyModulus
+	return len(lhsModulus) > 0 && lhsModulus == rhs.Spec.CertMetadata.CertIdentifier.PubkeyModulus
+}
+
+func shareCertKeyPairLocation(lhs, rhs *certgraphapi.CertKeyPair) bool {
+	for _, lhsLocation := range lhs.Spec.SecretLocations {
+		for _, rhsLocation := range rhs.Spec.SecretLocations {
+			if lhsLocation == rhsLocation {
+				return true
+			}
+		}
+	}
+	for _, lhsLocation := range lhs.Spec.OnDiskLocations {
+		for _, rhsLocation := range rhs.Spec.OnDiskLocations {
+			if len(lhsLocation.Cert.Path) > 0 && lhsLocation.Cert.Path == rhsLocation.Cert.Path {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func certKeyPairChanges(before, after *certgraphapi.CertKeyPair) []FieldChange {
+	ret := []FieldChange{}
+	ret = appendCertKeyMetadataChanges(ret, &before.Spec.CertMetadata, &after.Spec.CertMetadata)
+	ret = appendFieldChange(ret, "CertType", before.Spec.Details.CertType, after.Spec.Details.CertType)
+	ret = appendFieldChange(ret, "SecretLocations", secretLocationsString(before.Spec.SecretLocations), secretLocationsString(after.Spec.SecretLocations))
+	ret = appendFieldChange(ret, "OnDiskLocations", certOnDiskLocationsString(before.Spec.OnDiskLocations), certOnDiskLocationsString(after.Spec.OnDiskLocations))
+	return ret
+}
+
+func appendCertKeyMetadataChanges(changes []FieldChange, before, after *certgraphapi.CertKeyMetadata) []FieldChange {
+	changes = appendFieldChange(changes, "SerialNumber", before.CertIdentifier.SerialNumber, after.CertIdentifier.SerialNumber)
+	changes = appendFieldChange(changes, "PublicKeyAlgorithm", before.PublicKeyAlgorithm, after.PublicKeyAlgorithm)
+	changes = appendFieldChange(changes, "PublicKeyBitSize", before.PublicKeyBitSize, after.PublicKeyBitSize)
+	changes = appendFieldChange(changes, "SignatureAlgorithm", before.SignatureAlgorithm, after.SignatureAlgorithm)
+	changes = appendFieldChange(changes, "Signer", signerString(before), signerString(after))
+	changes = appendFieldChange(changes, "NotBefore", before.NotBefore, after.NotBefore)
+	changes = appendFieldChange(changes, "NotAfter", before.NotAfter, after.NotAfter)
+	changes = appendFieldChange(changes, "ValidityDuration", before.ValidityDuration, after.ValidityDuration)
+	return changes
+}
+
+func appendFieldChange(changes []FieldChange, field, before, after string) []FieldChange {
+	if before == after {
+		return changes
+	}
+	return append(changes, FieldChange{Field: field, Before: before, After: after})
+}
+
+func signerString(metadata *certgraphapi.CertKeyMetadata) string {
+	if metadata.CertIdentifier.Issuer == nil {
+		return ""
+	}
+	return metadata.CertIdentifier.Issuer.CommonName
+}
+
+func secretLocationsString(locations []certgraphapi.InClusterSecretLocation) string {
+	ret := []string{}
+	for _, curr := range locations {
+		ret = append(ret, fmt.Sprintf("secrets/%s[%s]", curr.Name, curr.Namespace))
+	}
+	sort.Strings(ret)
+	return strings.Join(ret, ", ")
+}
+
+func certOnDiskLocationsString(locations []certgraphapi.OnDiskCertKeyPairLocation) string {
+	ret := []string{}
+	for _, curr := range locations {
+		if len(curr.Cert.Path) > 0 {
+			ret = append(ret, curr.Cert.Path)
+		}
+	}
+	sort.Strings(ret)
+	return strings.Join(ret, ", ")
+}
+
+func sortCertKeyPairs(in []certgraphapi.CertKeyPair) {
+	sort.SliceStable(in, func(i, j int) bool {
+		return in[i].Name < in[j].Name
+	})
+}
+
+func sortCertKeyPairDiffs(in []CertKeyPairDiff) {
+	sort.SliceStable(in, func(i, j int) bool {
+		return in[i].Before.Name < in[j].Before.Name
+	})
+}
+
+func diffCABundles(ret *PKIListDiff, before, after []certgraphapi.CertificateAuthorityBundle) {
+	matchedBefore := sets.New[int]()
+	matchedAfter := sets.New[int]()
+	addChange := func(i, j int) {
+		matchedBefore.Insert(i)
+		matchedAfter.Insert(j)
+		if diff := caBundleDiff(&before[i], &after[j]); diff != nil {
+			ret.ChangedCABundles = append(ret.ChangedCABundles, *diff)
+		}
+	}
+
+	for i := range before {
+		for j := range after {
+			if matchedAfter.Has(j) || !shareCABundleLocation(&before[i], &after[j]) {
+				continue
+			}
+			addChange(i, j)
+			break
+		}
+	}
+	for i := range before {
+		if matchedBefore.Has(i) {
+			continue
+		}
+		for j := range after {
+			if matchedAfter.Has(j) || before[i].Name != after[j].Name {
+				continue
+			}
+			addChange(i, j)
+			break
+		}
+	}
+
+	for i := range before {
+		if !matchedBefore.Has(i) {
+			ret.RemovedCABundles = append(ret.RemovedCABundles, before[i])
+		}
+	}
+	for j := range after {
+		if !matchedAfter.Has(j) {
+			ret.AddedCABundles = append(ret.AddedCABundles, after[j])
+		}
+	}
+
+	sortCABundles(ret.AddedCABundles)
+	sortCABundles(ret.RemovedCABundles)
+	sort.SliceStable(ret.ChangedCABundles, func(i, j int) bool {
+		return ret.ChangedCABundles[i].Before.Name < ret.ChangedCABundles[j].Before.Name
+	})
+}
+
+func shareCABundleLocation(lhs, rhs *certgraphapi.CertificateAuthorityBundle) bool {
+	for _, lhsLocation := range lhs.Spec.ConfigMapLocations {
+		for _, rhsLocation := range rhs.Spec.ConfigMapLocations {
+			if lhsLocation == rhsLocation {
+				return true
+			}
+		}
+	}
+	for _, lhsLocation := range lhs.Spec.OnDiskLocations {
+		for _, rhsLocation := range rhs.Spec.OnDiskLocations {
+			if lhsLocation == rhsLocation {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// caBundleDiff returns nil if the bundles are the same.
+func caBundleDiff(before, after *certgraphapi.CertificateAuthorityBundle) *CABundleDiff {
+	beforeCerts := caBundleCertificateNames(before)
+	afterCerts := caBundleCertificateNames(after)
+
+	changes := []FieldChange{}
+	changes = appendFieldChange(changes, "ConfigMapLocations", configMapLocationsString(before.Spec.ConfigMapLocations), configMapLocationsString(after.Spec.ConfigMapLocations))
+	changes = appendFieldChange(changes, "OnDiskLocations", onDiskLocationsString(before.Spec.OnDiskLocations), onDiskLocationsString(after.Spec.OnDiskLocations))
+
+	ret := &CABundleDiff{
+		Before:              *before,
+		After:               *after,
+		AddedCertificates:   sets.List(afterCerts.Difference(beforeCerts)),
+		RemovedCertificates: sets.List(beforeCerts.Difference(afterCerts)),
+		Changes:             changes,
+	}
+	if len(ret.AddedCertificates) == 0 && len(ret.RemovedCertificates) == 0 && len(ret.Changes) == 0 {
+		return nil
+	}
+	return ret
+}
+
+func caBundleCertificateNames(bundle *certgraphapi.CertificateAuthorityBundle) sets.Set[string] {
+	ret := sets.New[string]()
+	for _, curr := range bundle.Spec.CertificateMetadata {
+		ret.Insert(fmt.Sprintf("%v::%v", curr.CertIdentifier.CommonName, curr.CertIdentifier.SerialNumber))
+	}
+	return ret
+}
+
+func configMapLocationsString(locations []certgraphapi.InClusterConfigMapLocation) string {
+	ret := []string{}
+	for _, curr := range locations {
+		ret = append(ret, fmt.Sprintf("configmaps/%s[%s]", curr.Name, curr.Namespace))
+	}
+	sort.Strings(ret)
+	return strings.Join(ret, ", ")
+}
+
+func onDiskLocationsString(locations []certgraphapi.OnDiskLocation) string {
+	ret := []string{}
+	for _, curr := range locations {
+		ret = append(ret, curr.Path)
+	}
+	sort.Strings(ret)
+	return strings.Join(ret, ", ")
+}
+
+func sortCABundles(in []certgraphapi.CertificateAuthorityBundle) {
+	sort.SliceStable(in, func(i, j int) bool {
+		return in[i].Name < in[j].Name
+	})
+}
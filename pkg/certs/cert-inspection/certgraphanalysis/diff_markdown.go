@@ -0,0 +1,159 @@
+package certgraphanalysis
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openshift/library-go/pkg/certs/cert-inspection/certgraphapi"
+	"github.com/openshift/library-go/pkg/markdown"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// PKIListDiffMarkdown renders the diff with a section for every kind of change.  Use Bytes for a standalone document
+// or ExactBytes to embed it.
+func PKIListDiffMarkdown(title string, diff *PKIListDiff) *markdown.Markdown {
+	md := markdown.NewMarkdown(title)
+
+	md.Title(2, "Summary")
+	if diff.IsEmpty() {
+		md.Text("No changes.")
+		md.Text("")
+		return md
+	}
+	markdownTableRow(md, "Change", "Certificates", "CA Bundles")
+	markdownTableRow(md, "---", "---", "---")
+	markdownTableRow(md, "Added", fmt.Sprint(len(diff.AddedCertKeyPairs)), fmt.Sprint(len(diff.AddedCABundles)))
+	markdownTableRow(md, "Removed", fmt.Sprint(len(diff.RemovedCertKeyPairs)), fmt.Sprint(len(diff.RemovedCABundles)))
+	markdownTableRow(md, "Reissued", fmt.Sprint(len(diff.ReissuedCertKeyPairs)), "")
+	markdownTableRow(md, "Changed", fmt.Sprint(len(diff.ChangedCertKeyPairs)), fmt.Sprint(len(diff.ChangedCABundles)))
+	md.Text("")
+
+	writeCertKeyPairsMarkdown(md, "Added Certificates", diff.AddedCertKeyPairs)
+	writeCertKeyPairsMarkdown(md, "Removed Certificates", diff.RemovedCertKeyPairs)
+	writeCertKeyPairDiffsMarkdown(md, "Reissued Certificates", diff.ReissuedCertKeyPairs)
+	writeCertKeyPairDiffsMarkdown(md, "Changed Certificates", diff.ChangedCertKeyPairs)
+
+	writeCABundlesMarkdown(md, "Added CA Bundles", diff.AddedCABundles)
+	writeCABundlesMarkdown(md, "Removed CA Bundles", diff.RemovedCABundles)
+	if len(diff.ChangedCABundles) > 0 {
+		md.Title(2, fmt.Sprintf("Changed CA Bundles (%d)", len(diff.ChangedCABundles)))
+		markdownTableRow(md, "Name", "Locations", "Added Certificates", "Removed Certificates", "Other Changes")
+		markdownTableRow(md, "---", "---", "---", "---", "---")
+		for _, curr := range diff.ChangedCABundles {
+			markdownTableRow(md,
+				curr.After.Name,
+				caBundleLocationsString(&curr.After),
+				strings.Join(curr.AddedCertificates, "<br/>"),
+				strings.Join(curr.RemovedCertificates, "<br/>"),
+				fieldChangesString(curr.Changes),
+			)
+		}
+		md.Text("")
+	}
+
+	return md
+}
+
+func writeCertKeyPairsMarkdown(md *markdown.Markdown, title string, certKeyPairs []certgraphapi.CertKeyPair) {
+	if len(certKeyPairs) == 0 {
+		return
+	}
+	md.Title(2, fmt.Sprintf("%s (%d)", title, len(certKeyPairs)))
+	markdownTableRow(md, "Name", "Type", "Signer", "Key", "Validity", "Locations")
+	markdownTableRow(md, "---", "---", "---", "---", "---", "---")
+	for _, curr := range certKeyPairs {
+		metadata := curr.Spec.CertMetadata
+		markdownTableRow(md,
+			curr.Name,
+			curr.Spec.Details.CertType,
+			signerString(&metadata),
+			strings.TrimSpace(metadata.PublicKeyAlgorithm+" "+metadata.PublicKeyBitSize),
+			metadata.ValidityDuration,
+			certKeyPairLocationsString(&curr),
+		)
+	}
+	md.Text("")
+}
+
+func writeCertKeyPairDiffsMarkdown(md *markdown.Markdown, title string, diffs []CertKeyPairDiff) {
+	if len(diffs) == 0 {
+		return
+	}
+	md.Title(2, fmt.Sprintf("%s (%d)", title, len(diffs)))
+	markdownTableRow(md, "Before", "After", "Locations", "Changes")
+	markdownTableRow(md, "---", "---", "---", "---")
+	for _, curr := range diffs {
+		markdownTableRow(md,
+			curr.Before.Name,
+			curr.After.Name,
+			certKeyPairLocationsString(&curr.After),
+			fieldChangesString(curr.Changes),
+		)
+	}
+	md.Text("")
+}
+
+func writeCABundlesMarkdown(md *markdown.Markdown, title string, caBundles []certgraphapi.CertificateAuthorityBundle) {
+	if len(caBundles) == 0 {
+		return
+	}
+	md.Title(2, fmt.Sprintf("%s (%d)", title, len(caBundles)))
+	markdownTableRow(md, "Name", "Certificates", "Locations")
+	markdownTableRow(md, "---", "---", "---")
+	for _, curr := range caBundles {
+		markdownTableRow(md,
+			curr.Name,
+			strings.Join(sortedCABundleCertificateNames(&curr), "<br/>"),
+			caBundleLocationsString(&curr),
+		)
+	}
+	md.Text("")
+}
+
+// markdownTableRow writes a row, escaping the content of every cell.  <br/> is kept to allow multiple lines in a cell.
+func markdownTableRow(md *markdown.Markdown, columns ...string) {
+	for i, column := range columns {
+		if i > 0 {
+			md.Exact(" ")
+		}
+		md.NextTableColumn()
+		column = strings.ReplaceAll(column, "|", `\|`)
+		column = markdown.EscapeForLiteral(column)
+		md.Exact(strings.ReplaceAll(column, `\<br/>`, "<br/>"))
+	}
+	md.EndTableRow()
+}
+
+func fieldChangesString(changes []FieldChange) string {
+	ret := []string{}
+	for _, curr := range changes {
+		ret = append(ret, fmt.Sprintf("%s: %q -> %q", curr.Field, curr.Before, curr.After))
+	}
+	return strings.Join(ret, "<br/>")
+}
+
+func certKeyPairLocationsString(certKeyPair *certgraphapi.CertKeyPair) string {
+	ret := []string{}
+	if locations := secretLocationsString(certKeyPair.Spec.SecretLocations); len(locations) > 0 {
+		ret = append(ret, locations)
+	}
+	if locations := certOnDiskLocationsString(certKeyPair.Spec.OnDiskLocations); len(locations) > 0 {
+		ret = append(ret, locations)
+	}
+	return strings.Join(ret, ", ")
+}
+
+func caBundleLocationsString(caBundle *certgraphapi.CertificateAuthorityBundle) string {
+	ret := []string{}
+	if locations := configMapLocationsString(caBundle.Spec.ConfigMapLocations); len(locations) > 0 {
+		ret = append(ret, locations)
+	}
+	if locations := onDiskLocationsString(caBundle.Spec.OnDiskLocations); len(locations) > 0 {
+		ret = append(ret, locations)
+	}
+	return strings.Join(ret, ", ")
+}
+
+func sortedCABundleCertificateNames(caBundle *certgraphapi.CertificateAuthorityBundle) []string {
+	return sets.List(caBundleCertificateNames(caBundle))
+}
@@ -0,0 +1,199 @@
+package certgraphanalysis
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openshift/library-go/pkg/certs/cert-inspection/certgraphapi"
+)
+
+func newDiffTestCertificate(t *testing.T, serial int64, commonName string, key *ecdsa.PrivateKey) *x509.Certificate {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), crypto.Signer(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certificate
+}
+
+func newDiffTestKey(t *testing.T, curve elliptic.Curve) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func diffTestCertKeyPair(t *testing.T, certificate *x509.Certificate, secretNames ...string) *certgraphapi.CertKeyPair {
+	t.Helper()
+	ret, err := toCertKeyPair(certificate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range secretNames {
+		ret = addSecretLocation(ret, "ns", name)
+	}
+	return ret
+}
+
+func diffTestCABundle(t *testing.T, name string, certificates ...*x509.Certificate) *certgraphapi.CertificateAuthorityBundle {
+	t.Helper()
+	ret, err := toCABundle(certificates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addConfigMapLocation(ret, "ns", name)
+}
+
+func TestDiffPKILists(t *testing.T) {
+	movedKey := newDiffTestKey(t, elliptic.P256())
+	resignedKey := newDiffTestKey(t, elliptic.P256())
+	removedKey := newDiffTestKey(t, elliptic.P256())
+	moved := newDiffTestCertificate(t, 1, "moved", movedKey)
+	resignedBefore := newDiffTestCertificate(t, 2, "resigned", resignedKey)
+	resignedAfter := newDiffTestCertificate(t, 3, "resigned", resignedKey)
+	rotatedBefore := newDiffTestCertificate(t, 4, "rotated", newDiffTestKey(t, elliptic.P256()))
+	rotatedAfter := newDiffTestCertificate(t, 5, "rotated", newDiffTestKey(t, elliptic.P384()))
+	removed := newDiffTestCertificate(t, 6, "removed", removedKey)
+	added := newDiffTestCertificate(t, 7, "added", newDiffTestKey(t, elliptic.P256()))
+
+	before := PKIListFromParts(context.TODO(), nil,
+		[]*certgraphapi.CertKeyPair{
+			diffTestCertKeyPair(t, moved, "moved-a"),
+			diffTestCertKeyPair(t, moved, "moved-b"),
+			diffTestCertKeyPair(t, resignedBefore, "resigned"),
+			diffTestCertKeyPair(t, rotatedBefore, "rotated"),
+			diffTestCertKeyPair(t, removed, "removed"),
+		},
+		[]*certgraphapi.CertificateAuthorityBundle{
+			diffTestCABundle(t, "unchanged", moved),
+			diffTestCABundle(t, "trust", moved, removed),
+			diffTestCABundle(t, "removed", removed),
+		},
+	)
+	after := PKIListFromParts(context.TODO(), nil,
+		[]*certgraphapi.CertKeyPair{
+			diffTestCertKeyPair(t, moved, "moved-b", "moved-c"),
+			diffTestCertKeyPair(t, resignedAfter, "resigned"),
+			diffTestCertKeyPair(t, rotatedAfter, "rotated"),
+			diffTestCertKeyPair(t, added, "added"),
+		},
+		[]*certgraphapi.CertificateAuthorityBundle{
+			diffTestCABundle(t, "unchanged", moved),
+			diffTestCABundle(t, "trust", moved, added),
+		},
+	)
+
+	diff := DiffPKILists(before, after)
+
+	names := func(certKeyPairs []certgraphapi.CertKeyPair) []string {
+		ret := []string{}
+		for _, curr := range certKeyPairs {
+			ret = append(ret, curr.Name)
+		}
+		return ret
+	}
+	if actual, expected := names(diff.AddedCertKeyPairs), []string{"added::7"}; !cmp.Equal(actual, expected) {
+		t.Errorf("unexpected added: %s", cmp.Diff(expected, actual))
+	}
+	if actual, expected := names(diff.RemovedCertKeyPairs), []string{"removed::6"}; !cmp.Equal(actual, expected) {
+		t.Errorf("unexpected removed: %s", cmp.Diff(expected, actual))
+	}
+
+	expectedReissued := []CertKeyPairDiff{
+		{Before: before.CertKeyPairs.Items[1], After: after.CertKeyPairs.Items[1], Changes: []FieldChange{
+			{Field: "SerialNumber", Before: "2", After: "3"},
+		}},
+		{Before: before.CertKeyPairs.Items[2], After: after.CertKeyPairs.Items[2], Changes: []FieldChange{
+			{Field: "SerialNumber", Before: "4", After: "5"},
+			{Field: "PublicKeyBitSize", Before: "256 bit, P-256 curve", After: "384 bit, P-384 curve"},
+			{Field: "SignatureAlgorithm", Before: "ECDSA-SHA256", After: "ECDSA-SHA384"},
+		}},
+	}
+	if len(diff.ReissuedCertKeyPairs) != len(expectedReissued) {
+		t.Fatalf("expected %d reissued, got %#v", len(expectedReissued), diff.ReissuedCertKeyPairs)
+	}
+	for i, expected := range expectedReissued {
+		actual := diff.ReissuedCertKeyPairs[i]
+		if actual.Before.Name != expected.Before.Name || actual.After.Name != expected.After.Name {
+			t.Errorf("reissued %d: expected %q -> %q, got %q -> %q", i, expected.Before.Name, expected.After.Name, actual.Before.Name, actual.After.Name)
+		}
+		if !cmp.Equal(actual.Changes, expected.Changes) {
+			t.Errorf("reissued %d: unexpected changes: %s", i, cmp.Diff(expected.Changes, actual.Changes))
+		}
+	}
+
+	if len(diff.ChangedCertKeyPairs) != 1 {
+		t.Fatalf("expected one changed certificate, got %#v", diff.ChangedCertKeyPairs)
+	}
+	expectedChanges := []FieldChange{
+		{Field: "SecretLocations", Before: "secrets/moved-a[ns], secrets/moved-b[ns]", After: "secrets/moved-b[ns], secrets/moved-c[ns]"},
+	}
+	if actual := diff.ChangedCertKeyPairs[0].Changes; !cmp.Equal(actual, expectedChanges) {
+		t.Errorf("unexpected changes: %s", cmp.Diff(expectedChanges, actual))
+	}
+
+	if len(diff.AddedCABundles) != 0 {
+		t.Errorf("unexpected added CA bundles: %#v", diff.AddedCABundles)
+	}
+	if len(diff.RemovedCABundles) != 1 || diff.RemovedCABundles[0].Name != "removed" {
+		t.Errorf("unexpected removed CA bundles: %#v", diff.RemovedCABundles)
+	}
+	if len(diff.ChangedCABundles) != 1 {
+		t.Fatalf("expected one changed CA bundle, got %#v", diff.ChangedCABundles)
+	}
+	if actual := diff.ChangedCABundles[0]; !cmp.Equal(actual.AddedCertificates, []string{"added::7"}) || !cmp.Equal(actual.RemovedCertificates, []string{"removed::6"}) {
+		t.Errorf("unexpected CA bundle membership change: added %v, removed %v", actual.AddedCertificates, actual.RemovedCertificates)
+	}
+
+	md := string(PKIListDiffMarkdown("Upgrade", diff).Bytes())
+	for _, expected := range []string{
+		"## Reissued Certificates (2)",
+		"| resigned::2 | resigned::3 | secrets/resigned[ns] | SerialNumber: \"2\" -> \"3\" |",
+		"| moved\\|added | configmaps/trust[ns] | added::7 | removed::6 |  |",
+	} {
+		if !strings.Contains(md, expected) {
+			t.Errorf("expected %q in:\n%s", expected, md)
+		}
+	}
+}
+
+func TestDiffPKIListsEmpty(t *testing.T) {
+	certificate := newDiffTestCertificate(t, 1, "same", newDiffTestKey(t, elliptic.P256()))
+	pkiList := PKIListFromParts(context.TODO(), nil,
+		[]*certgraphapi.CertKeyPair{diffTestCertKeyPair(t, certificate, "same")},
+		[]*certgraphapi.CertificateAuthorityBundle{diffTestCABundle(t, "same", certificate)},
+	)
+
+	diff := DiffPKILists(pkiList, pkiList)
+	if !diff.IsEmpty() {
+		t.Errorf("expected no changes, got %#v", diff)
+	}
+	if md := string(PKIListDiffMarkdown("Upgrade", diff).Bytes()); !strings.Contains(md, "No changes.") {
+		t.Errorf("unexpected markdown:\n%s", md)
+	}
+}
@@ -162,6 +162,20 @@ type CertKeyMetadata struct {
 	ValidityDuration   string
 	Usages             []string
 	ExtendedUsages     []string
+
+	// SubjectKeyID and AuthorityKeyID are the hex encoded key identifier extensions.  They link a certificate to its signer.
+	SubjectKeyID   string `json:",omitempty"`
+	AuthorityKeyID string `json:",omitempty"`
+
+	// RawCertificate is the DER encoded certificate.  It is only available in memory and allows verifying signatures.
+	RawCertificate []byte `json:"-"`
+}
+
+// copyUnserializedFields copies the fields that do not survive a JSON round trip.
+func (t *CertKeyMetadata) copyUnserializedFields(in *CertKeyMetadata) {
+	t.NotBefore = in.NotBefore
+	t.NotAfter = in.NotAfter
+	t.RawCertificate = in.RawCertificate
 }
 
 // do better
@@ -175,6 +189,7 @@ func (t *CertKeyPair) DeepCopy() *CertKeyPair {
 	if err := json.Unmarshal(jsonBytes, ret); err != nil {
 		panic(err)
 	}
+	ret.Spec.CertMetadata.copyUnserializedFields(&t.Spec.CertMetadata)
 
 	return ret
 }
@@ -190,6 +205,9 @@ func (t *CertificateAuthorityBundle) DeepCopy() *CertificateAuthorityBundle {
 	if err := json.Unmarshal(jsonBytes, ret); err != nil {
 		panic(err)
 	}
+	for i := range ret.Spec.CertificateMetadata {
+		ret.Spec.CertificateMetadata[i].copyUnserializedFields(&t.Spec.CertificateMetadata[i])
+	}
 
 	return ret
 }
@@ -0,0 +1,206 @@
+package certgraphtrust
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift/library-go/pkg/certs/cert-inspection/certgraphapi"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// ProblemType identifies a kind of problem found by BuildTrustGraph.
+type ProblemType string
+
+const (
+	// ProblemOrphanedCertificate is a certificate that is not self-signed and whose issuer was not found.
+	ProblemOrphanedCertificate ProblemType = "OrphanedCertificate"
+	// ProblemUntrustedCertificate is a certificate with a known issuer that no CA bundle contains.
+	ProblemUntrustedCertificate ProblemType = "UntrustedCertificate"
+	// ProblemCABundleMissingSigner is a CA bundle containing a certificate whose issuer is not in the bundle.
+	ProblemCABundleMissingSigner ProblemType = "CABundleMissingSigner"
+	// ProblemSignerExpiresFirst is a certificate that expires after its signer, so it stops being trusted early.
+	ProblemSignerExpiresFirst ProblemType = "SignerExpiresBeforeCertificate"
+	// ProblemExpired is a certificate that has expired.
+	ProblemExpired ProblemType = "Expired"
+	// ProblemExpiringSoon is a certificate that expires within the warning window.
+	ProblemExpiringSoon ProblemType = "ExpiringSoon"
+	// ProblemServingCertSANMismatch is a serving certificate that does not cover a hostname it is used for.
+	ProblemServingCertSANMismatch ProblemType = "ServingCertSANMismatch"
+)
+
+// Problem is a single finding.  Subject is the Name of the certificate or CA bundle it is about.
+type Problem struct {
+	Type    ProblemType `json:"type"`
+	Subject string      `json:"subject"`
+	Message string      `json:"message"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s: %s", p.Type, p.Subject, p.Message)
+}
+
+func findProblems(pkiList *certgraphapi.PKIList, graph *TrustGraph, options TrustOptions) []Problem {
+	ret := []Problem{}
+
+	for i := range graph.Certificates {
+		curr := &graph.Certificates[i]
+		location := describeLocations(curr.Locations)
+
+		switch {
+		case curr.SelfSigned:
+		case len(curr.Issuer) == 0:
+			ret = append(ret, Problem{
+				Type:    ProblemOrphanedCertificate,
+				Subject: curr.Name,
+				Message: fmt.Sprintf("issuer %q was not found (%s)", issuerCommonName(curr), location),
+			})
+		case len(curr.TrustedBy) == 0 && !curr.InCABundleOnly:
+			ret = append(ret, Problem{
+				Type:    ProblemUntrustedCertificate,
+				Subject: curr.Name,
+				Message: fmt.Sprintf("no CA bundle contains issuer %q (%s)", curr.Issuer, location),
+			})
+		}
+
+		if !curr.notAfter.IsZero() {
+			switch {
+			case !curr.notAfter.After(options.Now):
+				ret = append(ret, Problem{
+					Type:    ProblemExpired,
+					Subject: curr.Name,
+					Message: fmt.Sprintf("expired at %s (%s)", curr.NotAfter, location),
+				})
+			case curr.notAfter.Before(options.Now.Add(options.ExpiryWarningWindow)):
+				ret = append(ret, Problem{
+					Type:    ProblemExpiringSoon,
+					Subject: curr.Name,
+					Message: fmt.Sprintf("expires at %s (%s)", curr.NotAfter, location),
+				})
+			}
+		}
+
+		if issuer := graph.Certificate(curr.Issuer); issuer != nil && !issuer.notAfter.IsZero() && !curr.notAfter.IsZero() {
+			if issuer.notAfter.Before(curr.notAfter) {
+				ret = append(ret, Problem{
+					Type:    ProblemSignerExpiresFirst,
+					Subject: curr.Name,
+					Message: fmt.Sprintf("signer %q expires at %s, %s before the certificate (%s)",
+						issuer.Name, issuer.NotAfter, curr.notAfter.Sub(issuer.notAfter).Round(time.Second), location),
+				})
+			}
+		}
+
+		if curr.certKeyPair != nil && curr.certKeyPair.Spec.Details.ServingCertDetails != nil {
+			servingDetails := curr.certKeyPair.Spec.Details.ServingCertDetails
+			for _, hostname := range options.ExpectedHostnames(pkiList, curr.certKeyPair) {
+				if servingCertCovers(servingDetails, hostname) {
+					continue
+				}
+				ret = append(ret, Problem{
+					Type:    ProblemServingCertSANMismatch,
+					Subject: curr.Name,
+					Message: fmt.Sprintf("hostname %q is not in the SANs %v (%s)", hostname, servingCertSANs(servingDetails), location),
+				})
+			}
+		}
+	}
+
+	for _, bundle := range graph.CABundles {
+		inBundle := sets.New(bundle.Certificates...)
+		for _, name := range bundle.Certificates {
+			curr := graph.Certificate(name)
+			if curr == nil || curr.SelfSigned {
+				continue
+			}
+			if len(curr.Issuer) > 0 && inBundle.Has(curr.Issuer) {
+				continue
+			}
+			issuer := curr.Issuer
+			if len(issuer) == 0 {
+				issuer = issuerCommonName(curr)
+			}
+			ret = append(ret, Problem{
+				Type:    ProblemCABundleMissingSigner,
+				Subject: bundle.Name,
+				Message: fmt.Sprintf("%q is issued by %q which is not in the bundle (%s)", curr.Name, issuer, describeLocations(bundle.Locations)),
+			})
+		}
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Type != ret[j].Type {
+			return ret[i].Type < ret[j].Type
+		}
+		return ret[i].Subject < ret[j].Subject
+	})
+	return ret
+}
+
+func issuerCommonName(node *CertificateNode) string {
+	if node.metadata.CertIdentifier.Issuer == nil {
+		return ""
+	}
+	return node.metadata.CertIdentifier.Issuer.CommonName
+}
+
+// HostnamesFromAnnotations returns the hostnames recorded in the collected annotations of the secrets holding the
+// cert/key pair.  The annotations are only present when they were requested during collection.
+//  1. auth.openshift.io/certificate-hostnames is a comma separated list of hostnames.
+//  2. service.beta.openshift.io/originating-service-name and its alpha form are the service the certificate is for.
+func HostnamesFromAnnotations(pkiList *certgraphapi.PKIList, certKeyPair *certgraphapi.CertKeyPair) []string {
+	ret := sets.New[string]()
+	for _, secretLocation := range certKeyPair.Spec.SecretLocations {
+		for _, inCluster := range pkiList.InClusterResourceData.CertKeyPairs {
+			if inCluster.SecretLocation != secretLocation {
+				continue
+			}
+			for _, annotation := range inCluster.CertKeyInfo.SelectedCertMetadataAnnotations {
+				switch annotation.Key {
+				case "auth.openshift.io/certificate-hostnames":
+					for _, hostname := range strings.Split(annotation.Value, ",") {
+						if hostname = strings.TrimSpace(hostname); len(hostname) > 0 {
+							ret.Insert(hostname)
+						}
+					}
+				case "service.beta.openshift.io/originating-service-name", "service.alpha.openshift.io/originating-service-name":
+					ret.Insert(fmt.Sprintf("%s.%s.svc", annotation.Value, secretLocation.Namespace))
+				}
+			}
+		}
+	}
+	return sets.List(ret)
+}
+
+func servingCertCovers(details *certgraphapi.ServingCertDetails, hostname string) bool {
+	if ip := net.ParseIP(hostname); ip != nil {
+		for _, curr := range details.IPAddresses {
+			if certIP := net.ParseIP(curr); certIP != nil && certIP.Equal(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	hostname = strings.ToLower(strings.TrimSuffix(hostname, "."))
+	for _, curr := range details.DNSNames {
+		pattern := strings.ToLower(strings.TrimSuffix(curr, "."))
+		if pattern == hostname {
+			return true
+		}
+		// a wildcard only matches a single label.
+		if suffix, isWildcard := strings.CutPrefix(pattern, "*."); isWildcard {
+			if label, rest, found := strings.Cut(hostname, "."); found && len(label) > 0 && rest == suffix {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func servingCertSANs(details *certgraphapi.ServingCertDetails) []string {
+	ret := append([]string{}, details.DNSNames...)
+	return append(ret, details.IPAddresses...)
+}
@@ -0,0 +1,139 @@
+package certgraphtrust
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gonum/graph/encoding/dot"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcegraph"
+)
+
+// Report returns a human readable summary: every certificate with its issuer and the bundles that trust it, followed
+// by the problems.
+func (g *TrustGraph) Report() string {
+	lines := []string{}
+	lines = append(lines, fmt.Sprintf("%d certificates, %d CA bundles, %d problems", len(g.Certificates), len(g.CABundles), len(g.Problems)))
+
+	lines = append(lines, "", "Certificates:")
+	for _, curr := range g.Certificates {
+		if curr.InCABundleOnly {
+			continue
+		}
+		issuer := "issuer not found"
+		switch {
+		case curr.SelfSigned:
+			issuer = "self-signed"
+		case len(curr.Issuer) > 0:
+			issuer = fmt.Sprintf("issued by %s (matched by %s)", curr.Issuer, curr.IssuerMatch)
+		}
+		lines = append(lines, fmt.Sprintf("  %s: %s, expires %s", curr.Name, issuer, curr.NotAfter))
+		for _, location := range curr.Locations {
+			lines = append(lines, "    at "+location)
+		}
+		for _, bundle := range curr.TrustedBy {
+			lines = append(lines, "    trusted by "+bundle)
+		}
+	}
+
+	lines = append(lines, "", "CA bundles:")
+	for _, curr := range g.CABundles {
+		lines = append(lines, fmt.Sprintf("  %s: %d certificates", curr.Name, len(curr.Certificates)))
+		for _, location := range curr.Locations {
+			lines = append(lines, "    at "+location)
+		}
+	}
+
+	lines = append(lines, "", "Problems:")
+	if len(g.Problems) == 0 {
+		lines = append(lines, "  none")
+	}
+	for _, problem := range g.Problems {
+		lines = append(lines, "  "+problem.String())
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// JSON returns the graph, including problems, as indented JSON.
+func (g *TrustGraph) JSON() ([]byte, error) {
+	return json.MarshalIndent(g, "", "    ")
+}
+
+// DOT renders the graph with resourcegraph.  Certificates point to the certificates they issued and to the CA bundles
+// that contain them.
+func (g *TrustGraph) DOT() ([]byte, error) {
+	return dot.Marshal(g.Resources().NewGraph(), "trust", "", "  ", false)
+}
+
+// Resources returns the graph as resourcegraph.Resources.  Secrets, configmaps, and files are used as coordinates
+// where they are known, so the nodes match other resource graphs.
+func (g *TrustGraph) Resources() resourcegraph.Resources {
+	resources := resourcegraph.NewResources()
+	problemsBySubject := map[string][]string{}
+	for _, problem := range g.Problems {
+		problemsBySubject[problem.Subject] = append(problemsBySubject[problem.Subject], string(problem.Type))
+	}
+
+	usedCoordinates := map[resourcegraph.ResourceCoordinates]bool{}
+	uniqueCoordinates := func(coordinates resourcegraph.ResourceCoordinates, name string) resourcegraph.ResourceCoordinates {
+		// multiple certificates can share a secret or file.
+		if usedCoordinates[coordinates] {
+			coordinates.Name = fmt.Sprintf("%s (%s)", coordinates.Name, name)
+		}
+		usedCoordinates[coordinates] = true
+		return coordinates
+	}
+
+	certificateResources := map[string]resourcegraph.Resource{}
+	for _, curr := range g.Certificates {
+		coordinates := resourcegraph.NewCoordinates("", "certificates", "", curr.Name)
+		switch {
+		case curr.certKeyPair != nil && len(curr.certKeyPair.Spec.SecretLocations) > 0:
+			location := curr.certKeyPair.Spec.SecretLocations[0]
+			coordinates = resourcegraph.NewCoordinates("", "secrets", location.Namespace, location.Name)
+		case curr.certKeyPair != nil && len(curr.certKeyPair.Spec.OnDiskLocations) > 0:
+			coordinates = resourcegraph.NewCoordinates("", "files", "", curr.certKeyPair.Spec.OnDiskLocations[0].Cert.Path)
+		}
+		note := curr.Name
+		if curr.InCABundleOnly {
+			note += "\n(only in CA bundles)"
+		}
+		if problems := problemsBySubject[curr.Name]; len(problems) > 0 {
+			note += "\n" + strings.Join(problems, ",")
+		}
+		certificateResources[curr.Name] = resourcegraph.NewResource(uniqueCoordinates(coordinates, curr.Name)).
+			Note(note).
+			Add(resources)
+	}
+	for _, curr := range g.Certificates {
+		if len(curr.Issuer) > 0 {
+			certificateResources[curr.Name].From(certificateResources[curr.Issuer])
+		}
+	}
+
+	for _, curr := range g.CABundles {
+		coordinates := resourcegraph.NewCoordinates("", "cabundles", "", curr.Name)
+		switch {
+		case len(curr.caBundle.Spec.ConfigMapLocations) > 0:
+			location := curr.caBundle.Spec.ConfigMapLocations[0]
+			coordinates = resourcegraph.NewCoordinates("", "configmaps", location.Namespace, location.Name)
+		case len(curr.caBundle.Spec.OnDiskLocations) > 0:
+			coordinates = resourcegraph.NewCoordinates("", "files", "", curr.caBundle.Spec.OnDiskLocations[0].Path)
+		}
+		note := ""
+		if problems := problemsBySubject[curr.Name]; len(problems) > 0 {
+			note = strings.Join(problems, ",")
+		}
+		bundleResource := resourcegraph.NewResource(uniqueCoordinates(coordinates, curr.Name)).
+			Note(note).
+			Add(resources)
+		for _, certificate := range curr.Certificates {
+			if source, ok := certificateResources[certificate]; ok {
+				bundleResource.From(source)
+			}
+		}
+	}
+
+	return resources
+}
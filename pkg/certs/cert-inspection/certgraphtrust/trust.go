@@ -0,0 +1,316 @@
+package certgraphtrust
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift/library-go/pkg/certs/cert-inspection/certgraphapi"
+)
+
+// IssuerMatch describes how the issuer of a certificate was found, from strongest to weakest.
+type IssuerMatch string
+
+const (
+	// IssuerMatchSignature means the signature of the certificate was verified with the public key of the issuer.
+	IssuerMatchSignature IssuerMatch = "Signature"
+	// IssuerMatchKeyID means the authority key ID of the certificate matches the subject key ID of the issuer.
+	IssuerMatchKeyID IssuerMatch = "KeyID"
+	// IssuerMatchName means only the issuer common name matched.  Rotated signers share names, so this is a guess.
+	IssuerMatchName IssuerMatch = "Name"
+)
+
+// TrustGraph links the certificates and CA bundles of a PKIList.
+type TrustGraph struct {
+	Certificates []CertificateNode `json:"certificates"`
+	CABundles    []CABundleNode    `json:"caBundles"`
+	Problems     []Problem         `json:"problems"`
+}
+
+// CertificateNode is a certificate from the PKIList.  Certificates that only appear in CA bundles are included so that
+// they can be issuers, but they have no Locations.
+type CertificateNode struct {
+	// Name is CommonName::SerialNumber, the same as certgraphapi.CertKeyPair.Name.
+	Name        string   `json:"name"`
+	LogicalName string   `json:"logicalName,omitempty"`
+	CertType    string   `json:"certType,omitempty"`
+	Locations   []string `json:"locations,omitempty"`
+	NotAfter    string   `json:"notAfter,omitempty"`
+
+	SelfSigned bool `json:"selfSigned,omitempty"`
+	// Issuer is the Name of the CertificateNode that issued this certificate.  Empty for self-signed certificates and
+	// for certificates whose issuer could not be found.
+	Issuer      string      `json:"issuer,omitempty"`
+	IssuerMatch IssuerMatch `json:"issuerMatch,omitempty"`
+	// TrustedBy is the Name of every CA bundle that contains the issuer of this certificate, or the certificate itself
+	// when it is self-signed.
+	TrustedBy []string `json:"trustedBy,omitempty"`
+	// InCABundleOnly is true for certificates that were found in CA bundles, but not as a cert/key pair.
+	InCABundleOnly bool `json:"inCABundleOnly,omitempty"`
+
+	metadata    *certgraphapi.CertKeyMetadata
+	certKeyPair *certgraphapi.CertKeyPair
+	certificate *x509.Certificate
+	notAfter    time.Time
+}
+
+// CABundleNode is a CA bundle from the PKIList.
+type CABundleNode struct {
+	Name        string   `json:"name"`
+	LogicalName string   `json:"logicalName,omitempty"`
+	Locations   []string `json:"locations,omitempty"`
+	// Certificates is the Name of the CertificateNode for every certificate in the bundle.
+	Certificates []string `json:"certificates"`
+
+	caBundle *certgraphapi.CertificateAuthorityBundle
+}
+
+// TrustOptions controls the analysis.
+type TrustOptions struct {
+	// Now is used to find expired and expiring certificates.  Defaults to the current time.
+	Now time.Time
+	// ExpiryWarningWindow reports certificates expiring within the window.  Defaults to 30 days.
+	ExpiryWarningWindow time.Duration
+	// ExpectedHostnames returns the hostnames a serving certificate is used for.  Defaults to
+	// HostnamesFromAnnotations.
+	ExpectedHostnames func(pkiList *certgraphapi.PKIList, certKeyPair *certgraphapi.CertKeyPair) []string
+}
+
+// BuildTrustGraph resolves which signer issued each certificate and which CA bundles trust it, then checks the result
+// for problems.
+// Issuers are resolved by signature verification when the certificates were collected in this process, by key
+// identifiers when they are available, and by issuer name otherwise.
+func BuildTrustGraph(pkiList *certgraphapi.PKIList, options TrustOptions) *TrustGraph {
+	if options.Now.IsZero() {
+		options.Now = time.Now()
+	}
+	if options.ExpiryWarningWindow == 0 {
+		options.ExpiryWarningWindow = 30 * 24 * time.Hour
+	}
+	if options.ExpectedHostnames == nil {
+		options.ExpectedHostnames = HostnamesFromAnnotations
+	}
+
+	ret := &TrustGraph{}
+	certsByName := map[string]int{}
+	for i := range pkiList.CertKeyPairs.Items {
+		certKeyPair := &pkiList.CertKeyPairs.Items[i]
+		if _, exists := certsByName[certKeyPair.Name]; exists {
+			continue
+		}
+		node := newCertificateNode(certKeyPair.Name, &certKeyPair.Spec.CertMetadata)
+		node.LogicalName = certKeyPair.LogicalName
+		node.CertType = certKeyPair.Spec.Details.CertType
+		node.Locations = certKeyPairLocations(certKeyPair)
+		node.certKeyPair = certKeyPair
+		certsByName[node.Name] = len(ret.Certificates)
+		ret.Certificates = append(ret.Certificates, node)
+	}
+	for i := range pkiList.CertificateAuthorityBundles.Items {
+		caBundle := &pkiList.CertificateAuthorityBundles.Items[i]
+		bundleNode := CABundleNode{
+			Name:        caBundle.Name,
+			LogicalName: caBundle.LogicalName,
+			Locations:   caBundleLocations(caBundle),
+			caBundle:    caBundle,
+		}
+		for j := range caBundle.Spec.CertificateMetadata {
+			metadata := &caBundle.Spec.CertificateMetadata[j]
+			name := certificateName(metadata)
+			bundleNode.Certificates = append(bundleNode.Certificates, name)
+			if _, exists := certsByName[name]; exists {
+				continue
+			}
+			node := newCertificateNode(name, metadata)
+			node.InCABundleOnly = true
+			certsByName[name] = len(ret.Certificates)
+			ret.Certificates = append(ret.Certificates, node)
+		}
+		ret.CABundles = append(ret.CABundles, bundleNode)
+	}
+
+	for i := range ret.Certificates {
+		curr := &ret.Certificates[i]
+		if isSelfSigned(curr) {
+			curr.SelfSigned = true
+			continue
+		}
+		if issuer, match := findIssuer(curr, ret.Certificates); issuer != nil {
+			curr.Issuer = issuer.Name
+			curr.IssuerMatch = match
+		}
+	}
+
+	for i := range ret.Certificates {
+		curr := &ret.Certificates[i]
+		trustAnchor := curr.Issuer
+		if curr.SelfSigned {
+			trustAnchor = curr.Name
+		}
+		if len(trustAnchor) == 0 {
+			continue
+		}
+		for _, bundle := range ret.CABundles {
+			for _, bundleCert := range bundle.Certificates {
+				if bundleCert == trustAnchor {
+					curr.TrustedBy = append(curr.TrustedBy, bundle.Name)
+					break
+				}
+			}
+		}
+	}
+
+	ret.Problems = findProblems(pkiList, ret, options)
+	return ret
+}
+
+// Certificate returns the node with the given name or nil.
+func (g *TrustGraph) Certificate(name string) *CertificateNode {
+	for i := range g.Certificates {
+		if g.Certificates[i].Name == name {
+			return &g.Certificates[i]
+		}
+	}
+	return nil
+}
+
+// CABundle returns the node with the given name or nil.
+func (g *TrustGraph) CABundle(name string) *CABundleNode {
+	for i := range g.CABundles {
+		if g.CABundles[i].Name == name {
+			return &g.CABundles[i]
+		}
+	}
+	return nil
+}
+
+func newCertificateNode(name string, metadata *certgraphapi.CertKeyMetadata) CertificateNode {
+	ret := CertificateNode{
+		Name:     name,
+		NotAfter: metadata.NotAfter,
+		metadata: metadata,
+	}
+	if notAfter, err := time.Parse(time.RFC3339, metadata.NotAfter); err == nil {
+		ret.notAfter = notAfter
+	}
+	if len(metadata.RawCertificate) > 0 {
+		if certificate, err := x509.ParseCertificate(metadata.RawCertificate); err == nil {
+			ret.certificate = certificate
+		}
+	}
+	return ret
+}
+
+func certificateName(metadata *certgraphapi.CertKeyMetadata) string {
+	return fmt.Sprintf("%v::%v", metadata.CertIdentifier.CommonName, metadata.CertIdentifier.SerialNumber)
+}
+
+func isSelfSigned(node *CertificateNode) bool {
+	if node.certificate != nil {
+		// CheckSignatureFrom requires a CA, self-signed leaf certificates are still self-signed.
+		return bytes.Equal(node.certificate.RawIssuer, node.certificate.RawSubject) &&
+			node.certificate.CheckSignature(node.certificate.SignatureAlgorithm, node.certificate.RawTBSCertificate, node.certificate.Signature) == nil
+	}
+	if len(node.metadata.AuthorityKeyID) > 0 {
+		return node.metadata.AuthorityKeyID == node.metadata.SubjectKeyID
+	}
+	issuer := node.metadata.CertIdentifier.Issuer
+	return issuer != nil && issuer.CommonName == node.metadata.CertIdentifier.CommonName
+}
+
+// findIssuer returns the best candidate, preferring certificates with keys over certificates only found in CA bundles.
+func findIssuer(node *CertificateNode, candidates []CertificateNode) (*CertificateNode, IssuerMatch) {
+	var bestIssuer *CertificateNode
+	var bestMatch IssuerMatch
+	for i := range candidates {
+		candidate := &candidates[i]
+		if candidate.Name == node.Name {
+			continue
+		}
+		match := issuerMatch(node, candidate)
+		if len(match) == 0 {
+			continue
+		}
+		if bestIssuer == nil ||
+			issuerMatchStrength(match) > issuerMatchStrength(bestMatch) ||
+			(match == bestMatch && bestIssuer.InCABundleOnly && !candidate.InCABundleOnly) {
+			bestIssuer = candidate
+			bestMatch = match
+		}
+	}
+	return bestIssuer, bestMatch
+}
+
+func issuerMatch(node, candidate *CertificateNode) IssuerMatch {
+	if node.certificate != nil && candidate.certificate != nil {
+		// avoid verifying signatures against every certificate, the issuer must at least have the right subject.
+		if !bytes.Equal(node.certificate.RawIssuer, candidate.certificate.RawSubject) {
+			return ""
+		}
+		if node.certificate.CheckSignatureFrom(candidate.certificate) == nil {
+			return IssuerMatchSignature
+		}
+		// the keys are known, so nothing weaker is considered.
+		return ""
+	}
+	if len(node.metadata.AuthorityKeyID) > 0 && len(candidate.metadata.SubjectKeyID) > 0 {
+		if node.metadata.AuthorityKeyID == candidate.metadata.SubjectKeyID {
+			return IssuerMatchKeyID
+		}
+		return ""
+	}
+	issuer := node.metadata.CertIdentifier.Issuer
+	if issuer != nil && len(issuer.CommonName) > 0 && issuer.CommonName == candidate.metadata.CertIdentifier.CommonName {
+		return IssuerMatchName
+	}
+	return ""
+}
+
+func issuerMatchStrength(match IssuerMatch) int {
+	switch match {
+	case IssuerMatchSignature:
+		return 3
+	case IssuerMatchKeyID:
+		return 2
+	case IssuerMatchName:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func certKeyPairLocations(certKeyPair *certgraphapi.CertKeyPair) []string {
+	ret := []string{}
+	for _, location := range certKeyPair.Spec.SecretLocations {
+		ret = append(ret, fmt.Sprintf("secrets/%s[%s]", location.Name, location.Namespace))
+	}
+	for _, location := range certKeyPair.Spec.OnDiskLocations {
+		if len(location.Cert.Path) > 0 {
+			ret = append(ret, "file://"+location.Cert.Path)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func caBundleLocations(caBundle *certgraphapi.CertificateAuthorityBundle) []string {
+	ret := []string{}
+	for _, location := range caBundle.Spec.ConfigMapLocations {
+		ret = append(ret, fmt.Sprintf("configmaps/%s[%s]", location.Name, location.Namespace))
+	}
+	for _, location := range caBundle.Spec.OnDiskLocations {
+		ret = append(ret, "file://"+location.Path)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func describeLocations(locations []string) string {
+	if len(locations) == 0 {
+		return "no locations"
+	}
+	return strings.Join(locations, ", ")
+}
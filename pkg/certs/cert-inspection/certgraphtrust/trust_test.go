@@ -0,0 +1,262 @@
+package certgraphtrust
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/certs/cert-inspection/certgraphanalysis"
+	"github.com/openshift/library-go/pkg/certs/cert-inspection/certgraphapi"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var testNow = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+type testCert struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+}
+
+func newTestCert(t *testing.T, serial int64, commonName string, isCA bool, notAfter time.Time, dnsNames []string, issuer *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             testNow.Add(-24 * time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		DNSNames:              dnsNames,
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+	}
+	if isCA {
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	}
+	parent, signer := template, key
+	if issuer != nil {
+		parent, signer = issuer.certificate, issuer.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCert{certificate: certificate, key: key}
+}
+
+func pemEncode(certs ...*testCert) []byte {
+	ret := []byte{}
+	for _, curr := range certs {
+		ret = append(ret, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: curr.certificate.Raw})...)
+	}
+	return ret
+}
+
+func inspectSecret(t *testing.T, namespace, name string, certs ...*testCert) []*certgraphapi.CertKeyPair {
+	t.Helper()
+	ret, err := certgraphanalysis.InspectSecret(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Data:       map[string][]byte{"tls.crt": pemEncode(certs...)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ret
+}
+
+func inspectConfigMap(t *testing.T, namespace, name string, certs ...*testCert) *certgraphapi.CertificateAuthorityBundle {
+	t.Helper()
+	ret, err := certgraphanalysis.InspectConfigMap(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Data:       map[string]string{"ca-bundle.crt": string(pemEncode(certs...))},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ret
+}
+
+func newTestPKIList(t *testing.T) *certgraphapi.PKIList {
+	signer := newTestCert(t, 1, "signer", true, testNow.Add(365*24*time.Hour), nil, nil)
+	otherSigner := newTestCert(t, 2, "other-signer", true, testNow.Add(365*24*time.Hour), nil, nil)
+	serving := newTestCert(t, 10, "serving", false, testNow.Add(90*24*time.Hour), []string{"*.ns.svc"}, signer)
+	expired := newTestCert(t, 11, "expired", false, testNow.Add(-time.Hour), []string{"expired.ns.svc"}, signer)
+	expiring := newTestCert(t, 12, "expiring", false, testNow.Add(24*time.Hour), []string{"expiring.ns.svc"}, signer)
+	outlives := newTestCert(t, 13, "outlives", false, testNow.Add(2*365*24*time.Hour), []string{"outlives.ns.svc"}, signer)
+	untrusted := newTestCert(t, 14, "untrusted", false, testNow.Add(90*24*time.Hour), []string{"untrusted.ns.svc"}, otherSigner)
+	orphanSigner := newTestCert(t, 3, "orphan-signer", true, testNow.Add(365*24*time.Hour), nil, nil)
+	orphan := newTestCert(t, 15, "orphan", false, testNow.Add(90*24*time.Hour), []string{"orphan.example.com"}, orphanSigner)
+
+	certs := []*certgraphapi.CertKeyPair{}
+	certs = append(certs, inspectSecret(t, "ns", "signer", signer)...)
+	certs = append(certs, inspectSecret(t, "ns", "other-signer", otherSigner)...)
+	certs = append(certs, inspectSecret(t, "ns", "serving", serving)...)
+	certs = append(certs, inspectSecret(t, "ns", "expired", expired)...)
+	certs = append(certs, inspectSecret(t, "ns", "expiring", expiring)...)
+	certs = append(certs, inspectSecret(t, "ns", "outlives", outlives)...)
+	certs = append(certs, inspectSecret(t, "ns", "untrusted", untrusted)...)
+	certs = append(certs, inspectSecret(t, "ns", "orphan", orphan)...)
+
+	caBundles := []*certgraphapi.CertificateAuthorityBundle{
+		inspectConfigMap(t, "ns", "ca-bundle", signer),
+		inspectConfigMap(t, "ns", "incomplete-bundle", serving),
+	}
+	return certgraphanalysis.PKIListFromParts(context.TODO(), nil, certs, caBundles)
+}
+
+func testHostnames(_ *certgraphapi.PKIList, certKeyPair *certgraphapi.CertKeyPair) []string {
+	if len(certKeyPair.Spec.SecretLocations) == 0 {
+		return nil
+	}
+	return []string{certKeyPair.Spec.SecretLocations[0].Name + ".ns.svc"}
+}
+
+func TestBuildTrustGraph(t *testing.T) {
+	graph := BuildTrustGraph(newTestPKIList(t), TrustOptions{
+		Now:               testNow,
+		ExpectedHostnames: testHostnames,
+	})
+
+	issuers := map[string]string{
+		"signer::1":       "",
+		"serving::10":     "signer::1",
+		"expired::11":     "signer::1",
+		"untrusted::14":   "other-signer::2",
+		"orphan::15":      "",
+		"other-signer::2": "",
+	}
+	for name, expectedIssuer := range issuers {
+		node := graph.Certificate(name)
+		if node == nil {
+			t.Errorf("missing certificate %q", name)
+			continue
+		}
+		if node.Issuer != expectedIssuer {
+			t.Errorf("%q: expected issuer %q, got %q", name, expectedIssuer, node.Issuer)
+		}
+		if len(expectedIssuer) > 0 && node.IssuerMatch != IssuerMatchSignature {
+			t.Errorf("%q: expected a signature match, got %q", name, node.IssuerMatch)
+		}
+	}
+	if !graph.Certificate("signer::1").SelfSigned {
+		t.Errorf("expected the signer to be self-signed")
+	}
+	if trustedBy := graph.Certificate("serving::10").TrustedBy; len(trustedBy) != 1 {
+		t.Errorf("expected the serving cert to be trusted by one bundle, got %v", trustedBy)
+	}
+
+	expectedProblems := []Problem{
+		{Type: ProblemCABundleMissingSigner, Subject: graph.CABundles[1].Name},
+		{Type: ProblemExpired, Subject: "expired::11"},
+		{Type: ProblemExpiringSoon, Subject: "expiring::12"},
+		{Type: ProblemOrphanedCertificate, Subject: "orphan::15"},
+		{Type: ProblemServingCertSANMismatch, Subject: "orphan::15"},
+		{Type: ProblemSignerExpiresFirst, Subject: "outlives::13"},
+		{Type: ProblemUntrustedCertificate, Subject: "untrusted::14"},
+	}
+	if len(graph.Problems) != len(expectedProblems) {
+		t.Fatalf("expected %d problems, got:\n%s", len(expectedProblems), graph.Report())
+	}
+	for i, expected := range expectedProblems {
+		actual := graph.Problems[i]
+		if actual.Type != expected.Type || actual.Subject != expected.Subject {
+			t.Errorf("problem %d: expected %s for %q, got %v", i, expected.Type, expected.Subject, actual)
+		}
+	}
+}
+
+func TestServingCertCovers(t *testing.T) {
+	details := &certgraphapi.ServingCertDetails{
+		DNSNames:    []string{"*.ns.svc", "api.example.com."},
+		IPAddresses: []string{"10.0.0.1"},
+	}
+	tests := []struct {
+		hostname string
+		expected bool
+	}{
+		{hostname: "foo.ns.svc", expected: true},
+		{hostname: "FOO.ns.svc", expected: true},
+		{hostname: "foo.bar.ns.svc", expected: false},
+		{hostname: "ns.svc", expected: false},
+		{hostname: "api.example.com", expected: true},
+		{hostname: "10.0.0.1", expected: true},
+		{hostname: "10.0.0.2", expected: false},
+	}
+	for _, test := range tests {
+		t.Run(test.hostname, func(t *testing.T) {
+			if actual := servingCertCovers(details, test.hostname); actual != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIssuerMatchWithoutRawCertificates(t *testing.T) {
+	// simulate a PKIList read back from JSON, which has no raw certificates.
+	pkiList := newTestPKIList(t)
+	serialized, err := json.Marshal(pkiList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTripped := &certgraphapi.PKIList{}
+	if err := json.Unmarshal(serialized, roundTripped); err != nil {
+		t.Fatal(err)
+	}
+
+	graph := BuildTrustGraph(roundTripped, TrustOptions{Now: testNow, ExpectedHostnames: testHostnames})
+	serving := graph.Certificate("serving::10")
+	if serving.Issuer != "signer::1" || serving.IssuerMatch != IssuerMatchKeyID {
+		t.Errorf("expected a key ID match to signer::1, got %q by %q", serving.Issuer, serving.IssuerMatch)
+	}
+}
+
+func TestRender(t *testing.T) {
+	graph := BuildTrustGraph(newTestPKIList(t), TrustOptions{Now: testNow, ExpectedHostnames: testHostnames})
+
+	if report := graph.Report(); !strings.Contains(report, "serving::10: issued by signer::1 (matched by Signature)") {
+		t.Errorf("unexpected report:\n%s", report)
+	}
+
+	jsonBytes, err := graph.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTripped := &TrustGraph{}
+	if err := json.Unmarshal(jsonBytes, roundTripped); err != nil {
+		t.Fatal(err)
+	}
+	if len(roundTripped.Problems) != len(graph.Problems) {
+		t.Errorf("expected %d problems, got %d", len(graph.Problems), len(roundTripped.Problems))
+	}
+
+	dotBytes, err := graph.DOT()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(dotBytes, []byte("digraph trust {")) {
+		t.Errorf("unexpected DOT output:\n%s", dotBytes)
+	}
+	if !bytes.Contains(dotBytes, []byte("ca-bundle")) {
+		t.Errorf("expected the CA bundle in the DOT output:\n%s", dotBytes)
+	}
+}
@@ -0,0 +1,347 @@
+package leaderelection
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blang/semver/v4"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	coordinationv1beta1 "k8s.io/api/coordination/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+// NewestBinaryVersion is the strategy of candidates that prefer the candidate with the newest binary version
+// as the leader. The kube-apiserver does not implement it, the leader hands the lease off to a candidate with
+// a newer binary version instead, so the newest version of an operator leads during a rolling upgrade.
+const NewestBinaryVersion coordinationv1.CoordinatedLeaseStrategy = "openshift.io/NewestBinaryVersion"
+
+// Options configures the handoff of the lease and the candidates of a leader election.
+type Options struct {
+	// Successor is the identity of the instance the leader hands the lease off to when it stops leading,
+	// so that the successor takes over at its next retry instead of waiting for the lease to expire.
+	// When empty, the lease is handed off to the newest candidate with the NewestBinaryVersion strategy
+	// and released otherwise. It cannot be combined with the OldestEmulationVersion strategy.
+	Successor string
+
+	// Strategy registers the instance as a LeaseCandidate for the lease.
+	// OldestEmulationVersion uses the coordinated leader election of the kube-apiserver, which requires
+	// the CoordinatedLeaderElection feature gate.
+	// NewestBinaryVersion makes the leader step down in favour of a candidate with a newer binary version.
+	// It is disabled when the kube-apiserver does not serve coordination.k8s.io/v1beta1 LeaseCandidates.
+	// Empty disables the candidates.
+	Strategy coordinationv1.CoordinatedLeaseStrategy
+	// BinaryVersion is the version of the instance, required by the strategies.
+	BinaryVersion string
+	// EmulationVersion is the version the instance emulates, BinaryVersion if unset.
+	EmulationVersion string
+}
+
+// ToLeaderElectionWithHandoff returns a "leases" based leader election config like ToLeaderElectionWithLease
+// and the coordinator that must be used to run it.
+// Instead of releasing the lease when it stops leading, the leader hands it off to its successor.
+// When options.Strategy is set, the identity is used as the name of the LeaseCandidate of the instance,
+// so it must be a valid resource name once underscores are replaced with dashes.
+//
+// Don't forget the callbacks!
+func ToLeaderElectionWithHandoff(clientConfig *rest.Config, config configv1.LeaderElection, component, identity string, options Options) (leaderelection.LeaderElectionConfig, *Coordinator, error) {
+	if err := validateOptions(&options); err != nil {
+		return leaderelection.LeaderElectionConfig{}, nil, err
+	}
+	if len(options.Strategy) > 0 {
+		if len(identity) == 0 {
+			identity = defaultIdentity()
+		}
+		// the kube-apiserver makes the name of the elected candidate the holder of the lease
+		identity = strings.ToLower(strings.ReplaceAll(identity, "_", "-"))
+		if errs := validation.IsDNS1123Subdomain(identity); len(errs) > 0 {
+			return leaderelection.LeaderElectionConfig{}, nil, fmt.Errorf("identity %q is not a valid lease candidate name: %s", identity, strings.Join(errs, ", "))
+		}
+	}
+
+	ret, err := ToLeaderElectionWithLease(clientConfig, config, component, identity)
+	if err != nil {
+		return leaderelection.LeaderElectionConfig{}, nil, err
+	}
+	kubeClient, err := kubernetes.NewForConfig(clientConfig)
+	if err != nil {
+		return leaderelection.LeaderElectionConfig{}, nil, err
+	}
+
+	coordinator := newCoordinator(kubeClient, config, ret.Lock.Identity(), options, clock.RealClock{})
+	ret.Lock = &handoffLock{Interface: ret.Lock, coordinator: coordinator}
+	ret.Coordinated = options.Strategy == coordinationv1.OldestEmulationVersion
+	return ret, coordinator, nil
+}
+
+func validateOptions(options *Options) error {
+	switch options.Strategy {
+	case "":
+		return nil
+	case coordinationv1.OldestEmulationVersion, NewestBinaryVersion:
+	default:
+		return fmt.Errorf("unsupported leader election strategy %q", options.Strategy)
+	}
+	if len(options.BinaryVersion) == 0 {
+		return fmt.Errorf("the binary version is required by the %s strategy", options.Strategy)
+	}
+	if options.Strategy == coordinationv1.OldestEmulationVersion && len(options.Successor) > 0 {
+		return fmt.Errorf("a successor cannot be combined with the %s strategy, the kube-apiserver elects the leader", options.Strategy)
+	}
+	if len(options.EmulationVersion) == 0 {
+		options.EmulationVersion = options.BinaryVersion
+	}
+	if options.Strategy == NewestBinaryVersion {
+		if _, err := semver.ParseTolerant(options.BinaryVersion); err != nil {
+			return fmt.Errorf("invalid binary version %q: %w", options.BinaryVersion, err)
+		}
+	}
+	return nil
+}
+
+// Coordinator runs a leader election, registers the instance as a candidate for its lease and picks the
+// successor the lease is handed off to.
+type Coordinator struct {
+	client    kubernetes.Interface
+	namespace string
+	leaseName string
+	identity  string
+	options   Options
+
+	leaseDuration time.Duration
+	retryPeriod   time.Duration
+	clock         clock.PassiveClock
+}
+
+func newCoordinator(client kubernetes.Interface, config configv1.LeaderElection, identity string, options Options, clock clock.PassiveClock) *Coordinator {
+	return &Coordinator{
+		client:        client,
+		namespace:     config.Namespace,
+		leaseName:     config.Name,
+		identity:      identity,
+		options:       options,
+		leaseDuration: config.LeaseDuration.Duration,
+		retryPeriod:   config.RetryPeriod.Duration,
+		clock:         clock,
+	}
+}
+
+// RunOrDie registers the candidate of the instance and runs the leader election until the context is done.
+// With the NewestBinaryVersion strategy, the leader steps down as soon as a candidate with a newer binary
+// version is renewing its LeaseCandidate and hands the lease off to it.
+func (c *Coordinator) RunOrDie(ctx context.Context, config leaderelection.LeaderElectionConfig) {
+	electionCtx, stepDown := context.WithCancel(ctx)
+	defer stepDown()
+
+	switch c.options.Strategy {
+	case coordinationv1.OldestEmulationVersion:
+		candidate, _, err := leaderelection.NewCandidate(c.client, c.namespace, c.identity, c.leaseName, c.options.BinaryVersion, c.options.EmulationVersion, c.options.Strategy)
+		if err != nil {
+			klog.Warningf("unable to register lease candidate %s/%s: %v", c.namespace, c.identity, err)
+		} else {
+			go candidate.Run(ctx)
+		}
+
+	case NewestBinaryVersion:
+		if !c.leaseCandidatesServed() {
+			klog.Warningf("the %s strategy of lease %s/%s is disabled, the kube-apiserver does not serve %s LeaseCandidates", c.options.Strategy, c.namespace, c.leaseName, coordinationv1beta1.SchemeGroupVersion)
+			// the lease is not handed off to the newest candidate either
+			c.options.Strategy = ""
+			break
+		}
+		go wait.UntilWithContext(ctx, c.renewCandidate, c.retryPeriod)
+
+		onStartedLeading := config.Callbacks.OnStartedLeading
+		config.Callbacks.OnStartedLeading = func(ctx context.Context) {
+			go wait.UntilWithContext(ctx, func(ctx context.Context) {
+				if c.newerCandidateExists(ctx) {
+					stepDown()
+				}
+			}, c.retryPeriod)
+			onStartedLeading(ctx)
+		}
+	}
+
+	leaderelection.RunOrDie(electionCtx, config)
+}
+
+// leaseCandidatesServed returns true unless discovery shows that the kube-apiserver does not serve the
+// LeaseCandidates the NewestBinaryVersion strategy relies on. They are not served by default.
+func (c *Coordinator) leaseCandidatesServed() bool {
+	resources, err := c.client.Discovery().ServerResourcesForGroupVersion(coordinationv1beta1.SchemeGroupVersion.String())
+	if errors.IsNotFound(err) {
+		return false
+	}
+	if err != nil {
+		klog.Warningf("unable to discover the LeaseCandidate API, assuming it is served: %v", err)
+		return true
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name == "leasecandidates" {
+			return true
+		}
+	}
+	return false
+}
+
+// successor returns the identity the lease is handed off to, or empty if the lease should be released.
+func (c *Coordinator) successor(ctx context.Context) string {
+	if len(c.options.Successor) > 0 && c.options.Successor != c.identity {
+		return c.options.Successor
+	}
+	if c.options.Strategy != NewestBinaryVersion {
+		return ""
+	}
+	candidate, err := c.newestCandidate(ctx, nil)
+	if err != nil {
+		klog.Warningf("unable to list the candidates for lease %s/%s: %v", c.namespace, c.leaseName, err)
+		return ""
+	}
+	if candidate == nil {
+		return ""
+	}
+	return candidate.Name
+}
+
+// handoffLeaseDurationSeconds is the duration of the lease handed off to the successor. It is short so that
+// the other candidates acquire the lease quickly if the successor is gone, the successor renews the lease
+// with its own duration once it takes over.
+func (c *Coordinator) handoffLeaseDurationSeconds() int {
+	seconds := int(2 * c.retryPeriod / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// newerCandidateExists returns true if a candidate has a newer binary version than the instance.
+func (c *Coordinator) newerCandidateExists(ctx context.Context) bool {
+	version, err := semver.ParseTolerant(c.options.BinaryVersion)
+	if err != nil {
+		return false
+	}
+	candidate, err := c.newestCandidate(ctx, &version)
+	if err != nil {
+		klog.Warningf("unable to list the candidates for lease %s/%s: %v", c.namespace, c.leaseName, err)
+		return false
+	}
+	if candidate == nil {
+		return false
+	}
+	klog.Infof("stepping down as leader of lease %s/%s in favour of %s with the newer binary version %s", c.namespace, c.leaseName, candidate.Name, candidate.Spec.BinaryVersion)
+	return true
+}
+
+// newestCandidate returns the candidate with the newest binary version that renewed its LeaseCandidate within
+// the lease duration, ignoring the instance itself and the candidates not newer than the given version.
+func (c *Coordinator) newestCandidate(ctx context.Context, newerThan *semver.Version) (*coordinationv1beta1.LeaseCandidate, error) {
+	candidates, err := c.client.CoordinationV1beta1().LeaseCandidates(c.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var newest *coordinationv1beta1.LeaseCandidate
+	var newestVersion semver.Version
+	for i := range candidates.Items {
+		candidate := &candidates.Items[i]
+		if candidate.Name == c.identity || candidate.Spec.LeaseName != c.leaseName || candidate.Spec.Strategy != NewestBinaryVersion {
+			continue
+		}
+		if candidate.Spec.RenewTime == nil || c.clock.Since(candidate.Spec.RenewTime.Time) > c.leaseDuration {
+			continue
+		}
+		version, err := semver.ParseTolerant(candidate.Spec.BinaryVersion)
+		if err != nil {
+			klog.V(2).Infof("ignoring lease candidate %s/%s with invalid binary version %q: %v", c.namespace, candidate.Name, candidate.Spec.BinaryVersion, err)
+			continue
+		}
+		if newerThan != nil && !version.GT(*newerThan) {
+			continue
+		}
+		if newest == nil || version.GT(newestVersion) ||
+			(version.EQ(newestVersion) && candidate.Spec.RenewTime.After(newest.Spec.RenewTime.Time)) {
+			newest, newestVersion = candidate, version
+		}
+	}
+	return newest, nil
+}
+
+func (c *Coordinator) renewCandidate(ctx context.Context) {
+	err := c.applyCandidate(ctx)
+	switch {
+	case err == nil:
+	case errors.IsNotFound(err):
+		klog.V(2).Infof("unable to renew lease candidate %s/%s, the LeaseCandidate API is not served: %v", c.namespace, c.identity, err)
+	default:
+		klog.Warningf("unable to renew lease candidate %s/%s: %v", c.namespace, c.identity, err)
+	}
+}
+
+// applyCandidate creates or renews the LeaseCandidate of the instance.
+func (c *Coordinator) applyCandidate(ctx context.Context) error {
+	now := metav1.NewMicroTime(c.clock.Now())
+	spec := coordinationv1beta1.LeaseCandidateSpec{
+		LeaseName:        c.leaseName,
+		RenewTime:        &now,
+		BinaryVersion:    c.options.BinaryVersion,
+		EmulationVersion: c.options.EmulationVersion,
+		Strategy:         c.options.Strategy,
+	}
+
+	client := c.client.CoordinationV1beta1().LeaseCandidates(c.namespace)
+	existing, err := client.Get(ctx, c.identity, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = client.Create(ctx, &coordinationv1beta1.LeaseCandidate{
+			ObjectMeta: metav1.ObjectMeta{Namespace: c.namespace, Name: c.identity},
+			Spec:       spec,
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	updated := existing.DeepCopy()
+	spec.PingTime = existing.Spec.PingTime
+	updated.Spec = spec
+	_, err = client.Update(ctx, updated, metav1.UpdateOptions{})
+	return err
+}
+
+// handoffLock hands the lease off to the successor when the leader elector releases it.
+type handoffLock struct {
+	resourcelock.Interface
+	coordinator *Coordinator
+}
+
+func (l *handoffLock) Update(ctx context.Context, record resourcelock.LeaderElectionRecord) error {
+	if len(record.HolderIdentity) > 0 {
+		return l.Interface.Update(ctx, record)
+	}
+
+	successor := l.coordinator.successor(ctx)
+	if len(successor) == 0 {
+		return l.Interface.Update(ctx, record)
+	}
+	record.HolderIdentity = successor
+	record.LeaseDurationSeconds = l.coordinator.handoffLeaseDurationSeconds()
+	if err := l.Interface.Update(ctx, record); err != nil {
+		return err
+	}
+	klog.Infof("handed off lease %s to %s", l.Describe(), successor)
+	l.RecordEvent(fmt.Sprintf("handed off leadership to %s", successor))
+	return nil
+}
@@ -0,0 +1,251 @@
+package leaderelection
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	coordinationv1beta1 "k8s.io/api/coordination/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+)
+
+var testLeaderElection = configv1.LeaderElection{
+	Namespace:     "test",
+	Name:          "lock",
+	LeaseDuration: metav1.Duration{Duration: 137 * time.Second},
+	RenewDeadline: metav1.Duration{Duration: 107 * time.Second},
+	RetryPeriod:   metav1.Duration{Duration: 26 * time.Second},
+}
+
+func heldLease(holder string, now time.Time) *coordinationv1.Lease {
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "lock"},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       ptr.To(holder),
+			LeaseDurationSeconds: ptr.To[int32](137),
+			AcquireTime:          &metav1.MicroTime{Time: now},
+			RenewTime:            &metav1.MicroTime{Time: now},
+		},
+	}
+}
+
+func candidate(name, leaseName, version string, renewTime time.Time) *coordinationv1beta1.LeaseCandidate {
+	return &coordinationv1beta1.LeaseCandidate{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: name},
+		Spec: coordinationv1beta1.LeaseCandidateSpec{
+			LeaseName:     leaseName,
+			RenewTime:     &metav1.MicroTime{Time: renewTime},
+			BinaryVersion: version,
+			Strategy:      NewestBinaryVersion,
+		},
+	}
+}
+
+// release releases the lease through the handoff lock the way the leader elector does.
+func release(t *testing.T, client *fake.Clientset, coordinator *Coordinator) *coordinationv1.Lease {
+	lock, err := resourcelock.New(resourcelock.LeasesResourceLock, "test", "lock", client.CoreV1(), client.CoordinationV1(), resourcelock.ResourceLockConfig{Identity: coordinator.identity})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handoff := &handoffLock{Interface: lock, coordinator: coordinator}
+	if _, _, err := handoff.Get(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	now := metav1.NewTime(time.Now())
+	if err := handoff.Update(context.TODO(), resourcelock.LeaderElectionRecord{LeaseDurationSeconds: 1, AcquireTime: now, RenewTime: now}); err != nil {
+		t.Fatal(err)
+	}
+	lease, err := client.CoordinationV1().Leases("test").Get(context.TODO(), "lock", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lease
+}
+
+func TestHandoff(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name             string
+		options          Options
+		candidates       []runtime.Object
+		expectedHolder   string
+		expectedDuration int32
+	}{
+		{
+			name:             "no successor releases the lease",
+			expectedDuration: 1,
+		},
+		{
+			name:             "named successor",
+			options:          Options{Successor: "b"},
+			expectedHolder:   "b",
+			expectedDuration: 52,
+		},
+		{
+			name:             "the instance itself is not a successor",
+			options:          Options{Successor: "a"},
+			expectedDuration: 1,
+		},
+		{
+			name:    "newest fresh candidate",
+			options: Options{Strategy: NewestBinaryVersion, BinaryVersion: "4.16.0"},
+			candidates: []runtime.Object{
+				candidate("a", "lock", "4.21.0", now),
+				candidate("b", "lock", "4.17.0", now),
+				candidate("c", "lock", "4.18.0", now.Add(-time.Minute)),
+				candidate("d", "lock", "4.18.0", now),
+				candidate("e", "lock", "4.19.0", now.Add(-time.Hour)),
+				candidate("f", "other", "4.20.0", now),
+				candidate("g", "lock", "invalid", now),
+			},
+			expectedHolder:   "d",
+			expectedDuration: 52,
+		},
+		{
+			name:             "no fresh candidate releases the lease",
+			options:          Options{Strategy: NewestBinaryVersion, BinaryVersion: "4.16.0"},
+			candidates:       []runtime.Object{candidate("b", "lock", "4.17.0", now.Add(-time.Hour))},
+			expectedDuration: 1,
+		},
+		{
+			name:             "candidates are not successors without the strategy",
+			candidates:       []runtime.Object{candidate("b", "lock", "4.17.0", now)},
+			expectedDuration: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(append(tc.candidates, heldLease("a", now))...)
+			coordinator := newCoordinator(client, testLeaderElection, "a", tc.options, clocktesting.NewFakePassiveClock(now))
+
+			lease := release(t, client, coordinator)
+			if holder := ptr.Deref(lease.Spec.HolderIdentity, ""); holder != tc.expectedHolder {
+				t.Errorf("expected the lease to be held by %q, got %q", tc.expectedHolder, holder)
+			}
+			if duration := ptr.Deref(lease.Spec.LeaseDurationSeconds, 0); duration != tc.expectedDuration {
+				t.Errorf("expected a lease duration of %ds, got %ds", tc.expectedDuration, duration)
+			}
+		})
+	}
+}
+
+func TestSuccessorTakesOverHandedOffLease(t *testing.T) {
+	client := fake.NewSimpleClientset(heldLease("a", time.Now()))
+	release(t, client, newCoordinator(client, testLeaderElection, "a", Options{Successor: "b"}, clocktesting.NewFakePassiveClock(time.Now())))
+
+	lock, err := resourcelock.New(resourcelock.LeasesResourceLock, "test", "lock", client.CoreV1(), client.CoordinationV1(), resourcelock.ResourceLockConfig{Identity: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	started := make(chan struct{})
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: testLeaderElection.LeaseDuration.Duration,
+		RenewDeadline: testLeaderElection.RenewDeadline.Duration,
+		RetryPeriod:   testLeaderElection.RetryPeriod.Duration,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(context.Context) { close(started) },
+			OnStoppedLeading: func() {},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go elector.Run(ctx)
+
+	// the lease is still valid, only the successor can acquire it before it expires
+	select {
+	case <-started:
+	case <-ctx.Done():
+		t.Fatal("expected the successor to take over the lease at its first attempt")
+	}
+}
+
+func TestCandidate(t *testing.T) {
+	clock := clocktesting.NewFakePassiveClock(time.Now())
+	client := fake.NewSimpleClientset(candidate("b", "lock", "4.17.0", clock.Now()))
+	options := Options{Strategy: NewestBinaryVersion, BinaryVersion: "4.16.0"}
+	if err := validateOptions(&options); err != nil {
+		t.Fatal(err)
+	}
+	coordinator := newCoordinator(client, testLeaderElection, "a", options, clock)
+
+	for i := 0; i < 2; i++ {
+		clock.SetTime(clock.Now().Add(time.Second))
+		if err := coordinator.applyCandidate(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		registered, err := client.CoordinationV1beta1().LeaseCandidates("test").Get(context.TODO(), "a", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if registered.Spec.LeaseName != "lock" || registered.Spec.BinaryVersion != "4.16.0" || registered.Spec.EmulationVersion != "4.16.0" ||
+			registered.Spec.Strategy != NewestBinaryVersion || !registered.Spec.RenewTime.Time.Equal(clock.Now()) {
+			t.Errorf("unexpected lease candidate %#v", registered.Spec)
+		}
+	}
+
+	if !coordinator.newerCandidateExists(context.TODO()) {
+		t.Errorf("expected the leader to step down in favour of the newer candidate")
+	}
+	coordinator.options.BinaryVersion = "4.17.0"
+	if coordinator.newerCandidateExists(context.TODO()) {
+		t.Errorf("expected the leader not to step down in favour of a candidate of the same version")
+	}
+}
+
+func TestRunWithoutLeaseCandidateAPI(t *testing.T) {
+	now := time.Now()
+	client := fake.NewSimpleClientset(heldLease("a", now), candidate("b", "lock", "4.17.0", now))
+	coordinator := newCoordinator(client, testLeaderElection, "a", Options{Strategy: NewestBinaryVersion, BinaryVersion: "4.16.0"}, clocktesting.NewFakePassiveClock(now))
+	lock, err := resourcelock.New(resourcelock.LeasesResourceLock, "test", "lock", client.CoreV1(), client.CoordinationV1(), resourcelock.ResourceLockConfig{Identity: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	coordinator.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: testLeaderElection.LeaseDuration.Duration,
+		RenewDeadline: testLeaderElection.RenewDeadline.Duration,
+		RetryPeriod:   testLeaderElection.RetryPeriod.Duration,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(context.Context) {},
+			OnStoppedLeading: func() {},
+		},
+	})
+
+	for _, action := range client.Actions() {
+		if action.GetResource().Resource == "leasecandidates" {
+			t.Errorf("expected the LeaseCandidates not to be used, got %s", action.GetVerb())
+		}
+	}
+	if successor := coordinator.successor(context.TODO()); len(successor) > 0 {
+		t.Errorf("expected the lease not to be handed off, got successor %s", successor)
+	}
+}
+
+func TestValidateOptions(t *testing.T) {
+	for _, options := range []Options{
+		{Strategy: "Unknown", BinaryVersion: "4.16.0"},
+		{Strategy: NewestBinaryVersion},
+		{Strategy: NewestBinaryVersion, BinaryVersion: "latest"},
+		{Strategy: coordinationv1.OldestEmulationVersion},
+		{Strategy: coordinationv1.OldestEmulationVersion, BinaryVersion: "4.16.0", Successor: "b"},
+	} {
+		if err := validateOptions(&options); err == nil {
+			t.Errorf("expected options %#v to be invalid", options)
+		}
+	}
+}
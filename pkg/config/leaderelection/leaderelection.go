@@ -39,13 +39,7 @@ func ToLeaderElectionWithLease(clientConfig *rest.Config, config configv1.Leader
 	}
 
 	if len(identity) == 0 {
-		if hostname, err := os.Hostname(); err != nil {
-			// on errors, make sure we're unique
-			identity = string(uuid.NewUUID())
-		} else {
-			// add a uniquifier so that two processes on the same host don't accidentally both become active
-			identity = hostname + "_" + string(uuid.NewUUID())
-		}
+		identity = defaultIdentity()
 	}
 	if len(config.Namespace) == 0 {
 		return leaderelection.LeaderElectionConfig{}, fmt.Errorf("namespace may not be empty")
@@ -87,6 +81,16 @@ func ToLeaderElectionWithLease(clientConfig *rest.Config, config configv1.Leader
 	}, nil
 }
 
+func defaultIdentity() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		// on errors, make sure we're unique
+		return string(uuid.NewUUID())
+	}
+	// add a uniquifier so that two processes on the same host don't accidentally both become active
+	return hostname + "_" + string(uuid.NewUUID())
+}
+
 // LeaderElectionDefaulting applies what we think are reasonable defaults.  It does not mutate the original.
 // We do defaulting outside the API so that we can change over time and know whether the user intended to override our values
 // as opposed to simply getting the defaulted serialization at some point.
@@ -0,0 +1,129 @@
+package serving
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/crypto"
+)
+
+// CRLFileChecker checks certificates against a PEM encoded certificate revocation list file, e.g. a mounted
+// config map maintained by certrotation.CRLConfigMap. The list must be signed by a CA of the given CA bundle file.
+// Both files are re-read when they change. A missing revocation list file means that nothing is revoked.
+type CRLFileChecker struct {
+	crlFile      string
+	caBundleFile string
+
+	lock        sync.Mutex
+	crlModTime  time.Time
+	caModTime   time.Time
+	crl         *x509.RevocationList
+	lastLoadErr error
+}
+
+// NewCRLFileChecker returns a checker for the given certificate revocation list and CA bundle files.
+func NewCRLFileChecker(crlFile, caBundleFile string) *CRLFileChecker {
+	return &CRLFileChecker{
+		crlFile:      crlFile,
+		caBundleFile: caBundleFile,
+	}
+}
+
+// CheckRevocation returns an error if the certificate is revoked, or if the certificate revocation list exists but
+// cannot be loaded or is not signed by a trusted CA.
+func (c *CRLFileChecker) CheckRevocation(certificate *x509.Certificate) error {
+	crl, err := c.load()
+	if err != nil {
+		return err
+	}
+	if crl == nil {
+		return nil
+	}
+	if crypto.IsRevoked(crl, certificate) {
+		return fmt.Errorf("certificate %q with serial number %s is revoked", certificate.Subject.CommonName, certificate.SerialNumber)
+	}
+	return nil
+}
+
+// load returns the current certificate revocation list, reading the files again if they changed.
+func (c *CRLFileChecker) load() (*x509.RevocationList, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	crlInfo, err := os.Stat(c.crlFile)
+	if os.IsNotExist(err) {
+		c.crl, c.lastLoadErr, c.crlModTime = nil, nil, time.Time{}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	caInfo, err := os.Stat(c.caBundleFile)
+	if err != nil {
+		return nil, err
+	}
+	if crlInfo.ModTime().Equal(c.crlModTime) && caInfo.ModTime().Equal(c.caModTime) {
+		return c.crl, c.lastLoadErr
+	}
+
+	c.crlModTime, c.caModTime = crlInfo.ModTime(), caInfo.ModTime()
+	c.crl, c.lastLoadErr = loadCRL(c.crlFile, c.caBundleFile)
+	if c.lastLoadErr != nil {
+		klog.Warningf("Failed to load certificate revocation list %s: %v", c.crlFile, c.lastLoadErr)
+		return nil, c.lastLoadErr
+	}
+	if c.crl.NextUpdate.Before(time.Now()) {
+		klog.Warningf("Certificate revocation list %s is stale, its next update was due at %v", c.crlFile, c.crl.NextUpdate)
+	}
+	return c.crl, nil
+}
+
+func loadCRL(crlFile, caBundleFile string) (*x509.RevocationList, error) {
+	crlBytes, err := os.ReadFile(crlFile)
+	if err != nil {
+		return nil, err
+	}
+	crl, err := crypto.ParseCRLPEM(crlBytes)
+	if err != nil {
+		return nil, err
+	}
+	caCerts, err := cert.CertsFromFile(caBundleFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := crypto.VerifyCRL(crl, caCerts); err != nil {
+		return nil, err
+	}
+	return crl, nil
+}
+
+// WithRevocationCheck wraps the authenticator such that requests presenting a revoked client certificate are rejected.
+func WithRevocationCheck(delegate authenticator.Request, checker *CRLFileChecker) authenticator.Request {
+	return authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		if req.TLS != nil && len(req.TLS.PeerCertificates) > 0 {
+			if err := checker.CheckRevocation(req.TLS.PeerCertificates[0]); err != nil {
+				return nil, false, err
+			}
+		}
+		return delegate.AuthenticateRequest(req)
+	})
+}
+
+// ApplyClientCertificateRevocation makes the server built from the config reject client certificates listed in the
+// given certificate revocation list file, which must be signed by a CA of the given CA bundle file.
+// It must be called after the authentication of the config is set up, e.g. by ToServerConfig.
+func ApplyClientCertificateRevocation(config *genericapiserver.Config, crlFile, caBundleFile string) {
+	if config.Authentication.Authenticator == nil {
+		return
+	}
+	config.Authentication.Authenticator = WithRevocationCheck(config.Authentication.Authenticator, NewCRLFileChecker(crlFile, caBundleFile))
+}
@@ -0,0 +1,248 @@
+package serving
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+
+	"github.com/openshift/library-go/pkg/crypto"
+)
+
+type revocationFixture struct {
+	ca        *crypto.CA
+	revoked   *x509.Certificate
+	valid     *x509.Certificate
+	crlFile   string
+	caFile    string
+	crlNumber int64
+}
+
+func newRevocationFixture(t *testing.T) *revocationFixture {
+	t.Helper()
+	ca := newTestCA(t, "signer")
+	dir := t.TempDir()
+	f := &revocationFixture{
+		ca:      ca,
+		revoked: newTestClientCertificate(t, ca, "revoked"),
+		valid:   newTestClientCertificate(t, ca, "valid"),
+		crlFile: filepath.Join(dir, "ca-bundle.crl"),
+		caFile:  filepath.Join(dir, "ca-bundle.crt"),
+	}
+	caBytes, err := crypto.EncodeCertificates(ca.Config.Certs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(f.caFile, caBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func newTestCA(t *testing.T, name string) *crypto.CA {
+	t.Helper()
+	caConfig, err := crypto.MakeSelfSignedCAConfigForDuration(name, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &crypto.CA{Config: caConfig, SerialGenerator: &crypto.RandomSerialGenerator{}}
+}
+
+func newTestClientCertificate(t *testing.T, ca *crypto.CA, name string) *x509.Certificate {
+	t.Helper()
+	clientConfig, err := ca.MakeClientCertificateForDuration(&user.DefaultInfo{Name: name}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return clientConfig.Certs[0]
+}
+
+// writeCRL writes a list of the given certificates signed by the given CA. The modification time is moved forward
+// on every write, such that changes are detected regardless of the resolution of file times.
+func (f *revocationFixture) writeCRL(t *testing.T, signer *crypto.CA, revoked ...*x509.Certificate) {
+	t.Helper()
+	entries := []x509.RevocationListEntry{}
+	for _, certificate := range revoked {
+		entries = append(entries, x509.RevocationListEntry{SerialNumber: certificate.SerialNumber, RevocationTime: time.Now()})
+	}
+	f.crlNumber++
+	crlBytes, err := signer.MakeCRLForDuration(entries, big.NewInt(f.crlNumber), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(f.crlFile, crlBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(time.Duration(f.crlNumber) * time.Second)
+	if err := os.Chtimes(f.crlFile, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCRLFileChecker(t *testing.T) {
+	f := newRevocationFixture(t)
+	checker := NewCRLFileChecker(f.crlFile, f.caFile)
+	f.writeCRL(t, f.ca, f.revoked)
+
+	if err := checker.CheckRevocation(f.revoked); err == nil || !strings.Contains(err.Error(), "is revoked") {
+		t.Errorf("expected the revoked certificate to be rejected, got %v", err)
+	}
+	if err := checker.CheckRevocation(f.valid); err != nil {
+		t.Errorf("expected the certificate which is not revoked to be accepted, got %v", err)
+	}
+}
+
+func TestCRLFileCheckerMissingCRL(t *testing.T) {
+	f := newRevocationFixture(t)
+	checker := NewCRLFileChecker(f.crlFile, f.caFile)
+
+	// without a revocation list nothing is revoked
+	if err := checker.CheckRevocation(f.revoked); err != nil {
+		t.Errorf("expected certificates to be accepted without a revocation list, got %v", err)
+	}
+}
+
+func TestCRLFileCheckerInvalidCRL(t *testing.T) {
+	sameNameCA := newTestCA(t, "signer")
+	otherCA := newTestCA(t, "other-signer")
+
+	tests := []struct {
+		name          string
+		setup         func(t *testing.T, f *revocationFixture)
+		expectedError string
+	}{
+		{
+			name: "unreadable",
+			setup: func(t *testing.T, f *revocationFixture) {
+				if err := os.Mkdir(f.crlFile, 0700); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expectedError: "is a directory",
+		},
+		{
+			name: "not a revocation list",
+			setup: func(t *testing.T, f *revocationFixture) {
+				if err := os.WriteFile(f.crlFile, []byte("garbage"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expectedError: "does not contain a PEM encoded certificate revocation list",
+		},
+		{
+			name: "bad signature",
+			setup: func(t *testing.T, f *revocationFixture) {
+				f.writeCRL(t, sameNameCA)
+			},
+			expectedError: "is not signed by a trusted CA",
+		},
+		{
+			name: "wrong issuer",
+			setup: func(t *testing.T, f *revocationFixture) {
+				f.writeCRL(t, otherCA)
+			},
+			expectedError: "is not signed by a trusted CA",
+		},
+		{
+			name: "missing CA bundle",
+			setup: func(t *testing.T, f *revocationFixture) {
+				f.writeCRL(t, f.ca)
+				if err := os.Remove(f.caFile); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expectedError: "no such file or directory",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newRevocationFixture(t)
+			test.setup(t, f)
+			checker := NewCRLFileChecker(f.crlFile, f.caFile)
+
+			// a revocation list which cannot be trusted rejects all certificates
+			for _, certificate := range []*x509.Certificate{f.revoked, f.valid} {
+				err := checker.CheckRevocation(certificate)
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Errorf("expected certificate %q to be rejected with %q, got %v", certificate.Subject.CommonName, test.expectedError, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCRLFileCheckerReload(t *testing.T) {
+	f := newRevocationFixture(t)
+	checker := NewCRLFileChecker(f.crlFile, f.caFile)
+
+	f.writeCRL(t, f.ca)
+	if err := checker.CheckRevocation(f.revoked); err != nil {
+		t.Fatalf("expected the certificate to be accepted before it is revoked, got %v", err)
+	}
+
+	f.writeCRL(t, f.ca, f.revoked)
+	if err := checker.CheckRevocation(f.revoked); err == nil {
+		t.Errorf("expected the certificate to be rejected once the list changed")
+	}
+
+	if err := os.Remove(f.crlFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := checker.CheckRevocation(f.revoked); err != nil {
+		t.Errorf("expected the certificate to be accepted once the list was removed, got %v", err)
+	}
+}
+
+func TestWithRevocationCheck(t *testing.T) {
+	f := newRevocationFixture(t)
+	f.writeCRL(t, f.ca, f.revoked)
+
+	delegated := 0
+	delegate := authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		delegated++
+		return &authenticator.Response{User: &user.DefaultInfo{Name: "user"}}, true, nil
+	})
+	config := &genericapiserver.Config{}
+	config.Authentication.Authenticator = delegate
+	ApplyClientCertificateRevocation(config, f.crlFile, f.caFile)
+
+	tests := []struct {
+		name              string
+		tls               *tls.ConnectionState
+		expectedError     bool
+		expectedDelegated bool
+	}{
+		{name: "revoked", tls: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{f.revoked}}, expectedError: true},
+		{name: "not revoked", tls: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{f.valid}}, expectedDelegated: true},
+		{name: "without client certificate", tls: &tls.ConnectionState{}, expectedDelegated: true},
+		{name: "without TLS", expectedDelegated: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			delegated = 0
+			_, ok, err := config.Authentication.Authenticator.AuthenticateRequest(&http.Request{TLS: test.tls})
+			if (err != nil) != test.expectedError || ok == test.expectedError {
+				t.Errorf("expected error %v, got %v (authenticated: %v)", test.expectedError, err, ok)
+			}
+			if (delegated > 0) != test.expectedDelegated {
+				t.Errorf("expected delegation %v, got %d calls", test.expectedDelegated, delegated)
+			}
+		})
+	}
+
+	// servers without authentication are left alone
+	config = &genericapiserver.Config{}
+	ApplyClientCertificateRevocation(config, f.crlFile, f.caFile)
+	if config.Authentication.Authenticator != nil {
+		t.Errorf("expected no authenticator, got %v", config.Authentication.Authenticator)
+	}
+}
@@ -17,10 +17,14 @@ import (
 	"github.com/openshift/library-go/pkg/config/configdefaults"
 	leaderelectionconverter "github.com/openshift/library-go/pkg/config/leaderelection"
 	"github.com/openshift/library-go/pkg/config/serving"
+	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/controller/fileobserver"
+	"github.com/openshift/library-go/pkg/controller/manager"
 	"github.com/openshift/library-go/pkg/operator/events"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/apiserver/pkg/authorization/union"
@@ -28,7 +32,6 @@ import (
 	"k8s.io/apiserver/pkg/server/healthz"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/component-base/metrics"
 	"k8s.io/component-base/metrics/legacyregistry"
@@ -60,6 +63,28 @@ type ControllerContext struct {
 
 	// Namespace where the operator runs. Either specified on the command line or autodetected.
 	OperatorNamespace string
+
+	reloader         *configReloader
+	controllerHealth *manager.ControllersHealthCheck
+}
+
+// AddReloadFunc adds a function called with the changes of the config file observed by WithReloadOnChange.
+// Without a ReloadFunc, a change of the config file restarts the process.
+func (c *ControllerContext) AddReloadFunc(reload ReloadFunc) {
+	if c.reloader == nil {
+		klog.Warningf("The config file is not reloaded, its changes restart the process")
+		return
+	}
+	c.reloader.addReloadFunc(reload)
+}
+
+// AddHealthCheckedControllers adds the controllers to the "controllers" health check enabled by
+// WithControllerHealthCheck. Controllers not created by the factory package are ignored.
+func (c *ControllerContext) AddHealthCheckedControllers(controllers ...factory.Controller) {
+	if c.controllerHealth == nil {
+		return
+	}
+	c.controllerHealth.Add(controllers...)
 }
 
 // defaultObserverInterval specifies the default interval that file observer will do rehash the files it watches and react to any changes
@@ -71,8 +96,10 @@ type ControllerBuilder struct {
 	kubeAPIServerConfigFile *string
 	clientOverrides         *client.ClientConnectionOverrides
 	leaderElection          *configv1.LeaderElection
+	leaderElectionOptions   leaderelectionconverter.Options
 	fileObserver            fileobserver.Observer
 	fileObserverReactorFn   func(file string, action fileobserver.ActionType) error
+	configReloader          *configReloader
 	eventRecorderOptions    record.CorrelatorOptions
 	componentOwnerReference *corev1.ObjectReference
 	clock                   clock.Clock
@@ -87,6 +114,7 @@ type ControllerBuilder struct {
 	authenticationConfig *operatorv1alpha1.DelegatedAuthentication
 	authorizationConfig  *operatorv1alpha1.DelegatedAuthorization
 	healthChecks         []healthz.HealthChecker
+	controllerHealth     *manager.ControllersHealthCheck
 
 	versionInfo *version.Info
 
@@ -167,16 +195,53 @@ func (b *ControllerBuilder) WithRestartOnChange(stopCh chan<- struct{}, starting
 	return b
 }
 
+// WithReloadOnChange observes the config file and hands the changes to the ReloadFuncs added to the ControllerContext
+// instead of restarting the process. The config is decoded with ReadYAML when a scheme is given. The specified channel
+// is closed to restart the process when no ReloadFunc was added, when a ReloadFunc fails and when the server or
+// leader election config changes.
+func (b *ControllerBuilder) WithReloadOnChange(stopCh chan<- struct{}, configFile string, startingContent []byte, configScheme *runtime.Scheme, versions ...schema.GroupVersion) *ControllerBuilder {
+	if len(configFile) == 0 {
+		return b
+	}
+	if b.fileObserver == nil {
+		observer, err := fileobserver.NewObserver(b.observerInterval)
+		if err != nil {
+			panic(err)
+		}
+		b.fileObserver = observer
+	}
+	var once sync.Once
+
+	b.configReloader = &configReloader{
+		configFile: configFile,
+		scheme:     configScheme,
+		versions:   versions,
+		content:    startingContent,
+		restart: func(reason string) {
+			once.Do(func() {
+				klog.Warning(fmt.Sprintf("Restart triggered because %s", reason))
+				close(stopCh)
+			})
+		},
+	}
+	b.fileObserver.AddReactor(b.configReloader.reactor, map[string][]byte{configFile: startingContent}, configFile)
+	return b
+}
+
 func (b *ControllerBuilder) WithComponentNamespace(ns string) *ControllerBuilder {
 	b.componentNamespace = ns
 	return b
 }
 
-// WithLeaderElection adds leader election options
-func (b *ControllerBuilder) WithLeaderElection(leaderElection configv1.LeaderElection, defaultNamespace, defaultName string) *ControllerBuilder {
+// WithLeaderElection adds leader election options. The optional handoff options name the successor the lease is
+// handed off to on shutdown and register the instance as a lease candidate, the last one given is used.
+func (b *ControllerBuilder) WithLeaderElection(leaderElection configv1.LeaderElection, defaultNamespace, defaultName string, handoffOptions ...leaderelectionconverter.Options) *ControllerBuilder {
 	if leaderElection.Disable {
 		return b
 	}
+	if len(handoffOptions) > 0 {
+		b.leaderElectionOptions = handoffOptions[len(handoffOptions)-1]
+	}
 
 	// Set flag that SNO leader election configs can be used since user provided no timing configs
 	b.userExplicitlySetLeaderElectionValues = leaderElection.LeaseDuration.Duration != 0 ||
@@ -199,7 +264,8 @@ func (b *ControllerBuilder) WithVersion(info version.Info) *ControllerBuilder {
 	return b
 }
 
-// WithServer adds a server that provides metrics and healthz
+// WithServer adds a server that provides metrics and healthz. The serving certificate and key files are watched
+// and rotated in place without a restart.
 func (b *ControllerBuilder) WithServer(servingInfo configv1.HTTPServingInfo, authenticationConfig operatorv1alpha1.DelegatedAuthentication, authorizationConfig operatorv1alpha1.DelegatedAuthorization) *ControllerBuilder {
 	b.servingInfo = servingInfo.DeepCopy()
 	configdefaults.SetRecommendedHTTPServingInfoDefaults(b.servingInfo)
@@ -220,6 +286,14 @@ func (b *ControllerBuilder) WithHealthChecks(healthChecks ...healthz.HealthCheck
 	return b
 }
 
+// WithControllerHealthCheck adds the "controllers" health check to the server. It fails when a controller added with
+// ControllerContext.AddHealthCheckedControllers has pending work but has not synced successfully for longer than
+// maxSyncAge.
+func (b *ControllerBuilder) WithControllerHealthCheck(maxSyncAge time.Duration) *ControllerBuilder {
+	b.controllerHealth = manager.NewControllersHealthCheck(maxSyncAge)
+	return b
+}
+
 // WithKubeConfigFile sets an optional kubeconfig file. inclusterconfig will be used if filename is empty
 func (b *ControllerBuilder) WithKubeConfigFile(kubeConfigFilename string, defaults *client.ClientConnectionOverrides) *ControllerBuilder {
 	b.kubeAPIServerConfigFile = &kubeConfigFilename
@@ -287,6 +361,10 @@ func (b *ControllerBuilder) Run(ctx context.Context, config *unstructured.Unstru
 		}
 	}
 
+	if b.configReloader != nil {
+		b.configReloader.run(ctx, eventRecorder)
+	}
+
 	// report the binary version metrics to prometheus
 	if b.versionInfo != nil {
 		buildInfo := metrics.NewGaugeVec(
@@ -322,6 +400,9 @@ func (b *ControllerBuilder) Run(ctx context.Context, config *unstructured.Unstru
 			serverConfig.Authorization.Authorizer,
 		)
 		serverConfig.HealthzChecks = append(serverConfig.HealthzChecks, b.healthChecks...)
+		if b.controllerHealth != nil {
+			serverConfig.HealthzChecks = append(serverConfig.HealthzChecks, b.controllerHealth)
+		}
 
 		server, err = serverConfig.Complete(nil).New(b.componentName, genericapiserver.NewEmptyDelegate())
 		if err != nil {
@@ -348,6 +429,8 @@ func (b *ControllerBuilder) Run(ctx context.Context, config *unstructured.Unstru
 		EventRecorder:     eventRecorder,
 		Server:            server,
 		OperatorNamespace: namespace,
+		reloader:          b.configReloader,
+		controllerHealth:  b.controllerHealth,
 	}
 
 	if b.leaderElection == nil {
@@ -365,6 +448,7 @@ func (b *ControllerBuilder) Run(ctx context.Context, config *unstructured.Unstru
 		} else {
 			snoLeaderElection := topologyLeaderElection(topology, *b.leaderElection)
 			b.leaderElection = &snoLeaderElection
+			b.leaderElectionOptions = topologyLeaderElectionOptions(topology, b.leaderElectionOptions)
 		}
 	}
 
@@ -372,7 +456,7 @@ func (b *ControllerBuilder) Run(ctx context.Context, config *unstructured.Unstru
 	leaderConfig := rest.CopyConfig(protoConfig)
 	leaderConfig.Timeout = b.leaderElection.RenewDeadline.Duration
 
-	leaderElection, err := leaderelectionconverter.ToLeaderElectionWithLease(leaderConfig, *b.leaderElection, b.componentName, b.instanceIdentity)
+	leaderElection, coordinator, err := leaderelectionconverter.ToLeaderElectionWithHandoff(leaderConfig, *b.leaderElection, b.componentName, b.instanceIdentity, b.leaderElectionOptions)
 	if err != nil {
 		return err
 	}
@@ -382,7 +466,7 @@ func (b *ControllerBuilder) Run(ctx context.Context, config *unstructured.Unstru
 	// NOTE: The pod must set the termination graceful time.
 	leaderElection.Callbacks.OnStartedLeading = b.getOnStartedLeadingFunc(controllerContext, 10*time.Second)
 
-	leaderelection.RunOrDie(ctx, leaderElection)
+	coordinator.RunOrDie(ctx, leaderElection)
 	return nil
 }
 
@@ -448,3 +532,12 @@ func topologyLeaderElection(topology configv1.TopologyMode, original configv1.Le
 	}
 	return original
 }
+
+// topologyLeaderElectionOptions drops the handoff and the candidates of a single replica topology, where there is
+// no other instance to hand the lease off to.
+func topologyLeaderElectionOptions(topology configv1.TopologyMode, original leaderelectionconverter.Options) leaderelectionconverter.Options {
+	if topology == configv1.SingleReplicaTopologyMode {
+		return leaderelectionconverter.Options{}
+	}
+	return original
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -247,3 +248,28 @@ func TestInfraStatusTopologyLeaderElection(t *testing.T) {
 		})
 	}
 }
+
+type fakeHealthCheckedController struct {
+	factory.Controller
+	err error
+}
+
+func (c *fakeHealthCheckedController) CheckHealth(maxSyncAge time.Duration) error {
+	return c.err
+}
+
+func TestControllerHealthCheck(t *testing.T) {
+	builder := NewController("test", nil, nil).WithControllerHealthCheck(time.Minute)
+	controllerContext := &ControllerContext{controllerHealth: builder.controllerHealth}
+
+	controllerContext.AddHealthCheckedControllers(&fakeHealthCheckedController{})
+	if err := builder.controllerHealth.Check(nil); err != nil {
+		t.Fatalf("expected healthy controllers, got %v", err)
+	}
+
+	unhealthy := &fakeHealthCheckedController{err: fmt.Errorf("test controller has not synced successfully for 2m0s")}
+	controllerContext.AddHealthCheckedControllers(unhealthy)
+	if err := builder.controllerHealth.Check(nil); err == nil || !strings.Contains(err.Error(), "has not synced successfully") {
+		t.Errorf("expected the unhealthy controller to fail the check, got %v", err)
+	}
+}
@@ -17,6 +17,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/apiserver/pkg/server"
@@ -28,6 +29,7 @@ import (
 	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
 
 	"github.com/openshift/library-go/pkg/config/configdefaults"
+	leaderelectionconverter "github.com/openshift/library-go/pkg/config/leaderelection"
 	"github.com/openshift/library-go/pkg/controller/fileobserver"
 	"github.com/openshift/library-go/pkg/crypto"
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -68,12 +70,20 @@ type ControllerCommandConfig struct {
 	// between tries of actions.
 	RetryPeriod metav1.Duration
 
+	// LeaderElectionOptions names the successor the lease is handed off to on shutdown and registers the
+	// instance as a candidate for the lease.
+	LeaderElectionOptions leaderelectionconverter.Options
+
 	// TopologyDetector is used to plug in topology detection.
 	TopologyDetector TopologyDetector
 
 	ComponentOwnerReference *corev1.ObjectReference
 	healthChecks            []healthz.HealthChecker
+	controllerMaxSyncAge    time.Duration
 	eventRecorderOptions    record.CorrelatorOptions
+
+	configScheme   *runtime.Scheme
+	configVersions []schema.GroupVersion
 }
 
 // NewControllerConfig returns a new ControllerCommandConfig which can be used to wire up all the boiler plate of a controller
@@ -104,11 +114,29 @@ func (c *ControllerCommandConfig) WithHealthChecks(healthChecks ...healthz.Healt
 	return c
 }
 
+// WithControllerHealthCheck adds the "controllers" health check, see ControllerBuilder.WithControllerHealthCheck.
+func (c *ControllerCommandConfig) WithControllerHealthCheck(maxSyncAge time.Duration) *ControllerCommandConfig {
+	c.controllerMaxSyncAge = maxSyncAge
+	return c
+}
+
 func (c *ControllerCommandConfig) WithTopologyDetector(topologyDetector TopologyDetector) *ControllerCommandConfig {
 	c.TopologyDetector = topologyDetector
 	return c
 }
 
+// WithConfigScheme sets the scheme the config file is decoded with when it is reloaded, see ControllerContext.AddReloadFunc.
+func (c *ControllerCommandConfig) WithConfigScheme(configScheme *runtime.Scheme, versions ...schema.GroupVersion) *ControllerCommandConfig {
+	c.configScheme = configScheme
+	c.configVersions = versions
+	return c
+}
+
+func (c *ControllerCommandConfig) WithLeaderElectionOptions(options leaderelectionconverter.Options) *ControllerCommandConfig {
+	c.LeaderElectionOptions = options
+	return c
+}
+
 func (c *ControllerCommandConfig) WithEventRecorderOptions(eventRecorderOptions record.CorrelatorOptions) *ControllerCommandConfig {
 	c.eventRecorderOptions = eventRecorderOptions
 	return c
@@ -225,13 +253,9 @@ func hasServiceServingCerts(certDir string) bool {
 func (c *ControllerCommandConfig) AddDefaultRotationToConfig(config *operatorv1alpha1.GenericOperatorConfig, configContent []byte) (map[string][]byte, []string, error) {
 	certDir := "/var/run/secrets/serving-cert"
 
-	observedFiles := []string{
-		// We observe these, so we they are created or modified by service serving cert signer, we can react and restart the process
-		// that will pick these up instead of generating the self-signed certs.
-		// NOTE: We are not observing the temporary, self-signed certificates.
-		filepath.Join(certDir, "tls.crt"),
-		filepath.Join(certDir, "tls.key"),
-	}
+	// The serving certificates are rotated in place by the server, so the service serving certs only need to be observed
+	// while we use self-signed certificates.
+	observedFiles := []string{}
 	// startingFileContent holds hardcoded starting content.  If we generate our own certificates, then we want to specify empty
 	// content to avoid a starting race.  When we consume them, the race is really about as good as we can do since we don't know
 	// what's actually been read.
@@ -257,6 +281,10 @@ func (c *ControllerCommandConfig) AddDefaultRotationToConfig(config *operatorv1a
 			klog.Warningf("Using insecure, self-signed certificates")
 			// If we generate our own certificates, then we want to specify empty content to avoid a starting race.  This way,
 			// if any change comes in, we will properly restart
+			// We observe these, so when they are created by service serving cert signer, we can react and restart the process
+			// that will pick these up instead of the self-signed certs.
+			// NOTE: We are not observing the temporary, self-signed certificates.
+			observedFiles = append(observedFiles, filepath.Join(certDir, "tls.crt"), filepath.Join(certDir, "tls.key"))
 			startingFileContent[filepath.Join(certDir, "tls.crt")] = []byte{}
 			startingFileContent[filepath.Join(certDir, "tls.key")] = []byte{}
 
@@ -309,12 +337,23 @@ func (c *ControllerCommandConfig) StartController(ctx context.Context) error {
 		config.ServingInfo.BindAddress = c.basicFlags.BindAddress
 	}
 
+	// the config file is reloaded, the other files restart the process
+	restartFiles := []string{}
+	for _, file := range observedFiles {
+		if file != c.basicFlags.ConfigFile {
+			restartFiles = append(restartFiles, file)
+		}
+	}
+
 	exitOnChangeReactorCh := make(chan struct{})
+	exitOnReloadFailureCh := make(chan struct{})
 	controllerCtx, cancel := context.WithCancel(ctx)
 	go func() {
 		select {
 		case <-exitOnChangeReactorCh:
 			cancel()
+		case <-exitOnReloadFailureCh:
+			cancel()
 		case <-ctx.Done():
 			cancel()
 		}
@@ -328,11 +367,12 @@ func (c *ControllerCommandConfig) StartController(ctx context.Context) error {
 	builder := NewController(c.componentName, c.startFunc, c.clock).
 		WithKubeConfigFile(c.basicFlags.KubeConfigFile, nil).
 		WithComponentNamespace(c.basicFlags.Namespace).
-		WithLeaderElection(config.LeaderElection, c.basicFlags.Namespace, c.componentName+"-lock").
+		WithLeaderElection(config.LeaderElection, c.basicFlags.Namespace, c.componentName+"-lock", c.LeaderElectionOptions).
 		WithVersion(c.version).
 		WithHealthChecks(c.healthChecks...).
 		WithEventRecorderOptions(c.eventRecorderOptions).
-		WithRestartOnChange(exitOnChangeReactorCh, startingFileContent, observedFiles...).
+		WithRestartOnChange(exitOnChangeReactorCh, startingFileContent, restartFiles...).
+		WithReloadOnChange(exitOnReloadFailureCh, c.basicFlags.ConfigFile, configContent, c.configScheme, c.configVersions...).
 		WithComponentOwnerReference(c.ComponentOwnerReference)
 
 	if !c.DisableServing {
@@ -342,6 +382,10 @@ func (c *ControllerCommandConfig) StartController(ctx context.Context) error {
 		}
 	}
 
+	if c.controllerMaxSyncAge > 0 {
+		builder = builder.WithControllerHealthCheck(c.controllerMaxSyncAge)
+	}
+
 	if c.TopologyDetector != nil {
 		builder = builder.WithTopologyDetector(c.TopologyDetector)
 	}
@@ -71,16 +71,24 @@ func (f *ControllerFlags) ToConfigObj() ([]byte, *unstructured.Unstructured, err
 		return nil, nil, err
 	}
 
-	data, err := kyaml.ToJSON(content)
+	config, err := readUnstructuredConfig(content)
 	if err != nil {
 		return nil, nil, err
 	}
+	return content, config, nil
+}
+
+// readUnstructuredConfig decodes the content of a config file, which may be of any kind.
+func readUnstructuredConfig(content []byte) (*unstructured.Unstructured, error) {
+	data, err := kyaml.ToJSON(content)
+	if err != nil {
+		return nil, err
+	}
 	uncastObj, err := runtime.Decode(unstructured.UnstructuredJSONScheme, data)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-
-	return content, uncastObj.(*unstructured.Unstructured), nil
+	return uncastObj.(*unstructured.Unstructured), nil
 }
 
 // ToClientConfig given completed flags, returns a rest.Config.  overrides are optional
@@ -0,0 +1,180 @@
+package controllercmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/controller/fileobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+// ReloadFunc is called with the changes of the config file. It restarts the subsystems affected by the changes
+// in place. Returning an error restarts the process instead.
+type ReloadFunc func(ctx context.Context, diff *ConfigDiff) error
+
+// ConfigDiff describes a change of the config file.
+type ConfigDiff struct {
+	// OldConfig and NewConfig are the config before and after the change. They are nil for an empty file.
+	OldConfig *unstructured.Unstructured
+	NewConfig *unstructured.Unstructured
+
+	// OldObject and NewObject are the config before and after the change decoded with the scheme given to
+	// WithReloadOnChange, nil without a scheme or for an empty file.
+	OldObject runtime.Object
+	NewObject runtime.Object
+
+	// ChangedFields are the top level fields of the config that changed, e.g. "servingInfo".
+	ChangedFields sets.Set[string]
+}
+
+// Changed returns true if any of the given top level fields of the config changed.
+func (d *ConfigDiff) Changed(fields ...string) bool {
+	return d.ChangedFields.HasAny(fields...)
+}
+
+// restartConfigFields are the fields of the GenericOperatorConfig used by the builder to set up the server and the
+// leader election, which can only change with a restart.
+var restartConfigFields = sets.New("servingInfo", "authentication", "authorization", "leaderElection")
+
+// configReloader reacts to the changes of the config file by calling the reload functions added to the controller
+// context, or by restarting the process when they cannot handle the change.
+type configReloader struct {
+	configFile string
+	scheme     *runtime.Scheme
+	versions   []schema.GroupVersion
+	restart    func(reason string)
+
+	lock          sync.Mutex
+	ctx           context.Context
+	eventRecorder events.Recorder
+	content       []byte
+	reloadFuncs   []ReloadFunc
+}
+
+func (r *configReloader) addReloadFunc(fn ReloadFunc) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.reloadFuncs = append(r.reloadFuncs, fn)
+}
+
+func (r *configReloader) run(ctx context.Context, eventRecorder events.Recorder) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.ctx = ctx
+	r.eventRecorder = eventRecorder
+}
+
+func (r *configReloader) reactor(filename string, action fileobserver.ActionType) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if action == fileobserver.FileDeleted {
+		r.restartLocked(action.String(filename))
+		return nil
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		r.restartLocked(fmt.Sprintf("%s and cannot be read: %v", action.String(filename), err))
+		return nil
+	}
+	if len(r.reloadFuncs) == 0 || r.ctx == nil {
+		r.restartLocked(action.String(filename))
+		return nil
+	}
+
+	diff, err := r.diff(r.content, content)
+	if err != nil {
+		// keep running with the current config rather than restarting into a config that does not load
+		klog.Warningf("Unable to reload %s: %v", filename, err)
+		r.warningf("ConfigReloadFailed", "Unable to reload %s, keeping the current config: %v", filename, err)
+		return nil
+	}
+	if len(diff.ChangedFields) == 0 {
+		r.content = content
+		return nil
+	}
+	if fields := diff.ChangedFields.Intersection(restartConfigFields); len(fields) > 0 {
+		r.restartLocked(fmt.Sprintf("%s changed in %s", strings.Join(sets.List(fields), ", "), filename))
+		return nil
+	}
+
+	for _, reload := range r.reloadFuncs {
+		if err := reload(r.ctx, diff); err != nil {
+			r.restartLocked(fmt.Sprintf("the reload of %s failed: %v", filename, err))
+			return nil
+		}
+	}
+	r.content = content
+	klog.Infof("Reloaded %s, changed %s", filename, strings.Join(sets.List(diff.ChangedFields), ", "))
+	if r.eventRecorder != nil {
+		r.eventRecorder.Eventf("ConfigReloaded", "Reloaded %s, changed %s", filename, strings.Join(sets.List(diff.ChangedFields), ", "))
+	}
+	return nil
+}
+
+func (r *configReloader) restartLocked(reason string) {
+	r.warningf("OperatorRestart", "Restarted because %s", reason)
+	r.restart(reason)
+}
+
+func (r *configReloader) warningf(reason, messageFmt string, args ...interface{}) {
+	if r.eventRecorder != nil {
+		r.eventRecorder.Warningf(reason, messageFmt, args...)
+	}
+}
+
+// diff decodes the old and the new content of the config file and compares their top level fields.
+func (r *configReloader) diff(oldContent, newContent []byte) (*ConfigDiff, error) {
+	diff := &ConfigDiff{ChangedFields: sets.New[string]()}
+	var err error
+	if diff.OldConfig, diff.OldObject, err = r.decode(oldContent); err != nil {
+		return nil, fmt.Errorf("the current config: %w", err)
+	}
+	if diff.NewConfig, diff.NewObject, err = r.decode(newContent); err != nil {
+		return nil, err
+	}
+
+	oldFields, newFields := map[string]interface{}{}, map[string]interface{}{}
+	if diff.OldConfig != nil {
+		oldFields = diff.OldConfig.Object
+	}
+	if diff.NewConfig != nil {
+		newFields = diff.NewConfig.Object
+	}
+	for _, fields := range []map[string]interface{}{oldFields, newFields} {
+		for field := range fields {
+			if !equality.Semantic.DeepEqual(oldFields[field], newFields[field]) {
+				diff.ChangedFields.Insert(field)
+			}
+		}
+	}
+	return diff, nil
+}
+
+func (r *configReloader) decode(content []byte) (*unstructured.Unstructured, runtime.Object, error) {
+	if len(content) == 0 {
+		return nil, nil, nil
+	}
+	config, err := readUnstructuredConfig(content)
+	if err != nil {
+		return nil, nil, err
+	}
+	if r.scheme == nil {
+		return config, nil, nil
+	}
+	obj, err := ReadYAML(content, r.scheme, r.versions...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return config, obj, nil
+}
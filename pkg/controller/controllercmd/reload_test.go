@@ -0,0 +1,133 @@
+package controllercmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/openshift/library-go/pkg/controller/fileobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+const startingConfig = `apiVersion: operator.openshift.io/v1alpha1
+kind: GenericOperatorConfig
+servingInfo:
+  bindAddress: 0.0.0.0:8443
+operand:
+  replicas: 1
+`
+
+func newTestReloader(t *testing.T, withScheme bool) (*configReloader, string, *[]string, events.InMemoryRecorder) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	restarts := &[]string{}
+	reloader := &configReloader{
+		configFile: configFile,
+		content:    []byte(startingConfig),
+		restart:    func(reason string) { *restarts = append(*restarts, reason) },
+	}
+	if withScheme {
+		reloader.scheme = runtime.NewScheme()
+		if err := operatorv1alpha1.Install(reloader.scheme); err != nil {
+			t.Fatal(err)
+		}
+		reloader.versions = []schema.GroupVersion{operatorv1alpha1.GroupVersion}
+	}
+	recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+	reloader.run(context.TODO(), recorder)
+	return reloader, configFile, restarts, recorder
+}
+
+func changeConfig(t *testing.T, reloader *configReloader, configFile, content string) {
+	if err := os.WriteFile(configFile, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloader.reactor(configFile, fileobserver.FileModified); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigReloaderRestartsWithoutReloadFunc(t *testing.T) {
+	reloader, configFile, restarts, _ := newTestReloader(t, false)
+	changeConfig(t, reloader, configFile, startingConfig+"other: true\n")
+	if len(*restarts) != 1 {
+		t.Errorf("expected a restart without a reload function, got %v", *restarts)
+	}
+}
+
+func TestConfigReloader(t *testing.T) {
+	reloader, configFile, restarts, recorder := newTestReloader(t, true)
+	var diffs []*ConfigDiff
+	reloader.addReloadFunc(func(ctx context.Context, diff *ConfigDiff) error {
+		diffs = append(diffs, diff)
+		return nil
+	})
+
+	// an unchanged config is not reloaded
+	changeConfig(t, reloader, configFile, startingConfig+"# comment\n")
+	if len(diffs) != 0 || len(*restarts) != 0 {
+		t.Fatalf("expected no reload and no restart, got %d reloads and restarts %v", len(diffs), *restarts)
+	}
+
+	changeConfig(t, reloader, configFile, `apiVersion: operator.openshift.io/v1alpha1
+kind: GenericOperatorConfig
+servingInfo:
+  bindAddress: 0.0.0.0:8443
+operand:
+  replicas: 3
+`)
+	if len(*restarts) != 0 || len(diffs) != 1 {
+		t.Fatalf("expected a reload without a restart, got %d reloads and restarts %v", len(diffs), *restarts)
+	}
+	diff := diffs[0]
+	if !diff.Changed("operand") || diff.Changed("servingInfo") || diff.ChangedFields.Len() != 1 {
+		t.Errorf("expected only the operand to change, got %v", diff.ChangedFields.UnsortedList())
+	}
+	replicas, _, _ := unstructured.NestedInt64(diff.NewConfig.Object, "operand", "replicas")
+	if replicas != 3 {
+		t.Errorf("expected the new config to have 3 replicas, got %d", replicas)
+	}
+	if _, ok := diff.NewObject.(*operatorv1alpha1.GenericOperatorConfig); !ok {
+		t.Errorf("expected the new config to be decoded with the scheme, got %T", diff.NewObject)
+	}
+
+	// an invalid config is not loaded
+	changeConfig(t, reloader, configFile, "operand: [")
+	if len(diffs) != 1 || len(*restarts) != 0 {
+		t.Errorf("expected an invalid config to be ignored, got %d reloads and restarts %v", len(diffs), *restarts)
+	}
+	if events := recorder.Events(); len(events) != 2 || events[0].Reason != "ConfigReloaded" || events[1].Reason != "ConfigReloadFailed" {
+		t.Errorf("unexpected events %v", events)
+	}
+
+	// the server config cannot be reloaded
+	changeConfig(t, reloader, configFile, `apiVersion: operator.openshift.io/v1alpha1
+kind: GenericOperatorConfig
+servingInfo:
+  bindAddress: 0.0.0.0:9443
+operand:
+  replicas: 3
+`)
+	if len(diffs) != 1 || len(*restarts) != 1 {
+		t.Errorf("expected a change of the serving info to restart, got %d reloads and restarts %v", len(diffs), *restarts)
+	}
+}
+
+func TestConfigReloaderRestartsOnReloadFailure(t *testing.T) {
+	reloader, configFile, restarts, _ := newTestReloader(t, false)
+	reloader.addReloadFunc(func(ctx context.Context, diff *ConfigDiff) error {
+		return fmt.Errorf("unable to reload")
+	})
+	changeConfig(t, reloader, configFile, startingConfig+"other: true\n")
+	if len(*restarts) != 1 {
+		t.Errorf("expected a restart when the reload fails, got %v", *restarts)
+	}
+}
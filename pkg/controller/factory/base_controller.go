@@ -11,11 +11,13 @@ import (
 
 	"github.com/robfig/cron"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
-	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 	"github.com/openshift/library-go/pkg/operator/management"
@@ -28,48 +30,67 @@ var SyntheticRequeueError = errors.New("synthetic requeue request")
 
 var defaultCacheSyncTimeout = 10 * time.Minute
 
+// queueDepthSampleInterval is how often the queue depth metric is sampled.
+var queueDepthSampleInterval = 10 * time.Second
+
 // baseController represents generic Kubernetes controller boiler-plate
 type baseController struct {
 	name                   string
 	controllerInstanceName string
 	cachesToSync           []cache.InformerSynced
 	sync                   func(ctx context.Context, controllerContext SyncContext) error
+	keyed                  bool
 	syncContext            SyncContext
 	syncDegradedClient     operatorv1helpers.OperatorClient
 	resyncEvery            time.Duration
 	resyncSchedules        []cron.Schedule
 	postStartHooks         []PostStartHook
 	cacheSyncTimeout       time.Duration
+
+	// syncedKeys are the keys synced by a keyed controller, which are enqueued on resync. Nil for other controllers.
+	syncedKeys *syncedKeys
+
+	clock clock.PassiveClock
+	// health tracks the progress of the controller for CheckHealth.
+	healthLock         sync.Mutex
+	started            time.Time
+	lastSuccessfulSync time.Time
+	// failingSince maps the queue keys whose last sync failed to the last successful sync of the controller before
+	// their first failure (zero if there was none), such that a failing key is not hidden by other keys which sync
+	// successfully.
+	failingSince map[string]time.Time
+	activeSyncs  int
 }
 
 var _ Controller = &baseController{}
+var _ HealthChecker = &baseController{}
 
 // Name returns a controller name.
-func (c baseController) Name() string {
+func (c *baseController) Name() string {
 	return c.name
 }
 
 // ControllerInstanceName specifies the controller instance.
 // Useful when the same controller is used multiple times.
-func (c baseController) ControllerInstanceName() string {
+func (c *baseController) ControllerInstanceName() string {
 	return c.controllerInstanceName
 }
 
 type scheduledJob struct {
-	queue workqueue.RateLimitingInterface
-	name  string
+	name   string
+	resync func()
 }
 
-func newScheduledJob(name string, queue workqueue.RateLimitingInterface) cron.Job {
+func newScheduledJob(name string, resync func()) cron.Job {
 	return &scheduledJob{
-		queue: queue,
-		name:  name,
+		name:   name,
+		resync: resync,
 	}
 }
 
 func (s *scheduledJob) Run() {
 	klog.V(4).Infof("Triggering scheduled %q controller run", s.name)
-	s.queue.Add(DefaultQueueKey)
+	s.resync()
 }
 
 func waitForNamedCacheSync(controllerName string, stopCh <-chan struct{}, cacheSyncs ...cache.InformerSynced) error {
@@ -88,6 +109,10 @@ func (c *baseController) Run(ctx context.Context, workers int) {
 	// HandleCrash recovers panics
 	defer utilruntime.HandleCrash(c.degradedPanicHandler)
 
+	c.healthLock.Lock()
+	c.started = c.now()
+	c.healthLock.Unlock()
+
 	// give caches 10 minutes to sync
 	cacheSyncCtx, cacheSyncCancel := context.WithTimeout(ctx, c.cacheSyncTimeout)
 	defer cacheSyncCancel()
@@ -127,11 +152,20 @@ func (c *baseController) Run(ctx context.Context, workers int) {
 		}()
 	}
 
+	// sample the queue depth independently from the workers, such that it is up to date when they are stuck or idle
+	workerWg.Add(1)
+	go func() {
+		defer workerWg.Done()
+		wait.UntilWithContext(ctx, func(ctx context.Context) {
+			metrics.observeQueueDepth(c.name, c.controllerInstanceName, c.syncContext.Queue().Len())
+		}, queueDepthSampleInterval)
+	}()
+
 	// if scheduled run is requested, run the cron scheduler
 	if c.resyncSchedules != nil {
 		scheduler := cron.New()
 		for _, s := range c.resyncSchedules {
-			scheduler.Schedule(s, newScheduledJob(c.name, c.syncContext.Queue()))
+			scheduler.Schedule(s, newScheduledJob(c.name, c.resync))
 		}
 		scheduler.Start()
 		defer scheduler.Stop()
@@ -147,7 +181,7 @@ func (c *baseController) Run(ctx context.Context, workers int) {
 		}
 		go func() {
 			defer workerWg.Done()
-			wait.UntilWithContext(ctx, func(ctx context.Context) { c.syncContext.Queue().Add(DefaultQueueKey) }, c.resyncEvery)
+			wait.UntilWithContext(ctx, func(ctx context.Context) { c.resync() }, c.resyncEvery)
 		}()
 	}
 
@@ -181,6 +215,183 @@ func (c *baseController) Run(ctx context.Context, workers int) {
 	klog.Infof("Shutting down %s ...", c.name)
 }
 
+// CheckHealth returns an error if the controller has pending work, i.e. queued keys, a sync in progress or a failed
+// last sync, but has not synced successfully for longer than maxSyncAge. Idle controllers are always healthy.
+// Keys whose last sync failed are unhealthy once they have been failing for longer than maxSyncAge, regardless
+// of other keys.
+func (c *baseController) CheckHealth(maxSyncAge time.Duration) error {
+	c.healthLock.Lock()
+	defer c.healthLock.Unlock()
+	if c.started.IsZero() {
+		return nil
+	}
+
+	failingKey, since := "", c.lastSuccessfulSync
+	for key, keySince := range c.failingSince {
+		if len(failingKey) == 0 || keySince.Before(since) || (keySince.Equal(since) && key < failingKey) {
+			failingKey, since = key, keySince
+		}
+	}
+	pending := len(failingKey) > 0 || c.activeSyncs > 0 || c.syncContext.Queue().Len() > 0
+	if !pending {
+		return nil
+	}
+	if since.IsZero() {
+		if age := c.now().Sub(c.started); age > maxSyncAge {
+			return fmt.Errorf("%s controller has not synced%s successfully since it was started %s ago", c.name, c.describeKey(failingKey), age.Round(time.Second))
+		}
+		return nil
+	}
+	if age := c.now().Sub(since); age > maxSyncAge {
+		return fmt.Errorf("%s controller has not synced%s successfully for %s", c.name, c.describeKey(failingKey), age.Round(time.Second))
+	}
+	return nil
+}
+
+// describeKey returns the failing key for health messages of keyed controllers.
+func (c *baseController) describeKey(key string) string {
+	if !c.keyed || len(key) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" %q", key)
+}
+
+func (c *baseController) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock.Now()
+}
+
+// syncStarted records the start of a sync for health checks and returns its start time.
+func (c *baseController) syncStarted() time.Time {
+	c.healthLock.Lock()
+	defer c.healthLock.Unlock()
+	c.activeSyncs++
+	return c.now()
+}
+
+// syncFinished records the result of a sync of the key which started at the given time for health checks and metrics.
+func (c *baseController) syncFinished(key string, start time.Time, err error) {
+	c.healthLock.Lock()
+	defer c.healthLock.Unlock()
+	c.activeSyncs--
+	end := c.now()
+	if err == SyntheticRequeueError {
+		// the controller is waiting for something, which is neither a failure nor progress
+		return
+	}
+	metrics.observeSync(c.name, c.controllerInstanceName, start, end, err)
+	if err == nil {
+		delete(c.failingSince, key)
+		c.lastSuccessfulSync = end
+		return
+	}
+	if _, failing := c.failingSince[key]; failing {
+		return
+	}
+	if c.failingSince == nil {
+		c.failingSince = map[string]time.Time{}
+	}
+	c.failingSince[key] = c.lastSuccessfulSync
+}
+
+// resync enqueues DefaultQueueKey, or all keys synced so far for keyed controllers.
+func (c *baseController) resync() {
+	if !c.keyed {
+		c.syncContext.Queue().Add(DefaultQueueKey)
+		return
+	}
+	for _, key := range c.syncedKeys.list() {
+		c.syncContext.Queue().Add(key)
+	}
+}
+
+// syncedKeys tracks the keys of a keyed controller for resyncs. The keys of deleted objects are dropped once they
+// have been synced for the deletion, such that they are not resynced for the life of the process.
+// It also tracks the keys whose last sync failed, such that the degraded condition is shared by all keys.
+type syncedKeys struct {
+	lock sync.Mutex
+	keys sets.Set[string]
+	// deleted are the keys of deleted objects which are enqueued but not synced yet.
+	deleted sets.Set[string]
+	// failed are the errors of the keys whose last sync failed.
+	failed map[string]error
+}
+
+func newSyncedKeys() *syncedKeys {
+	return &syncedKeys{keys: sets.New[string](), deleted: sets.New[string](), failed: map[string]error{}}
+}
+
+// objectChanged is called with the keys of added and updated objects, which might have been deleted before.
+func (k *syncedKeys) objectChanged(keys ...string) {
+	if k == nil {
+		return
+	}
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	k.deleted.Delete(keys...)
+}
+
+// objectDeleted is called with the keys of deleted objects before they are enqueued.
+func (k *syncedKeys) objectDeleted(keys ...string) {
+	if k == nil {
+		return
+	}
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	k.keys.Delete(keys...)
+	k.deleted.Insert(keys...)
+	for _, key := range keys {
+		delete(k.failed, key)
+	}
+}
+
+// syncResult records the result of the last sync of the key and returns the errors of all keys whose last sync
+// failed, such that a failing key is reported until it syncs successfully or its object is deleted.
+func (k *syncedKeys) syncResult(key string, err error) error {
+	if k == nil {
+		return err
+	}
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if err == nil {
+		delete(k.failed, key)
+	} else {
+		k.failed[key] = err
+	}
+
+	failedKeys := sets.List(sets.KeySet(k.failed))
+	errs := make([]error, 0, len(failedKeys))
+	for _, failedKey := range failedKeys {
+		errs = append(errs, fmt.Errorf("%s: %w", failedKey, k.failed[failedKey]))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// synced remembers the key for resyncs, unless its object was deleted.
+func (k *syncedKeys) synced(key string) {
+	if k == nil {
+		return
+	}
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if k.deleted.Has(key) {
+		k.deleted.Delete(key)
+		return
+	}
+	k.keys.Insert(key)
+}
+
+func (k *syncedKeys) list() []string {
+	if k == nil {
+		return nil
+	}
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	return sets.List(k.keys)
+}
+
 func (c *baseController) Sync(ctx context.Context, syncCtx SyncContext) error {
 	return c.sync(ctx, syncCtx)
 }
@@ -206,9 +417,18 @@ func (c *baseController) runWorker(queueCtx context.Context) {
 }
 
 // reconcile wraps the sync() call and if operator client is set, it handle the degraded condition if sync() returns an error.
+// Keyed controllers are degraded as long as the last sync of any key failed.
 func (c *baseController) reconcile(ctx context.Context, syncCtx SyncContext) error {
 	err := c.sync(ctx, syncCtx)
-	degradedErr := c.reportDegraded(ctx, err)
+	reportedErr := err
+	if c.syncDegradedClient != nil {
+		reportedErr = c.syncedKeys.syncResult(syncCtx.QueueKey(), err)
+	}
+	degradedErr := c.reportDegraded(ctx, reportedErr)
+	if reportedErr != nil {
+		// failing keys other than this one are retried on their own
+		return err
+	}
 	if apierrors.IsNotFound(degradedErr) && management.IsOperatorRemovable() {
 		// The operator tolerates missing CR, therefore don't report it up.
 		return err
@@ -268,7 +488,13 @@ func (c *baseController) processNextWorkItem(queueCtx context.Context) {
 		return
 	}
 
-	if err := c.reconcile(queueCtx, syncCtx); err != nil {
+	c.syncedKeys.synced(syncCtx.queueKey)
+
+	// the queue never hands out a key which is being processed, so a key is never synced concurrently
+	start := c.syncStarted()
+	err := c.reconcile(queueCtx, syncCtx)
+	c.syncFinished(syncCtx.queueKey, start, err)
+	if err != nil {
 		if err == SyntheticRequeueError {
 			// logging this helps detecting wedged controllers with missing pre-requirements
 			klog.V(5).Infof("%q controller requested synthetic requeue with key %q", c.name, key)
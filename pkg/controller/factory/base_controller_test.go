@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
@@ -132,6 +133,66 @@ func TestBaseController_Reconcile(t *testing.T) {
 	}
 }
 
+func TestBaseController_ReconcileKeyed(t *testing.T) {
+	operatorClient := v1helpers.NewFakeOperatorClient(
+		&operatorv1.OperatorSpec{},
+		&operatorv1.OperatorStatus{},
+		nil,
+	)
+	syncErrs := map[string]error{}
+	c := &baseController{
+		name:               "TestController",
+		keyed:              true,
+		syncedKeys:         newSyncedKeys(),
+		syncContext:        NewSyncContext("TestController", eventstesting.NewTestingEventRecorder(t)),
+		syncDegradedClient: operatorClient,
+		sync: func(ctx context.Context, syncCtx SyncContext) error {
+			return syncErrs[syncCtx.QueueKey()]
+		},
+	}
+	syncKey := func(key string) {
+		t.Helper()
+		syncCtx := c.syncContext.(syncContext)
+		syncCtx.queueKey = key
+		if err := c.reconcile(context.TODO(), syncCtx); err != syncErrs[key] {
+			t.Fatalf("expected sync of %q to return %v, got %v", key, syncErrs[key], err)
+		}
+	}
+	expectDegraded := func(status operatorv1.ConditionStatus, message string) {
+		t.Helper()
+		_, operatorStatus, _, err := operatorClient.GetOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		condition := v1helpers.FindOperatorCondition(operatorStatus.Conditions, "TestControllerDegraded")
+		if condition == nil || condition.Status != status || condition.Message != message {
+			t.Fatalf("expected TestControllerDegraded to be %s with message %q, got %#v", status, message, condition)
+		}
+	}
+
+	syncErrs["ns/a"] = fmt.Errorf("a failed")
+	syncKey("ns/a")
+	expectDegraded(operatorv1.ConditionTrue, "ns/a: a failed")
+
+	// a key which syncs successfully does not hide the failing one
+	syncKey("ns/b")
+	expectDegraded(operatorv1.ConditionTrue, "ns/a: a failed")
+
+	syncErrs["ns/b"] = fmt.Errorf("b failed")
+	syncKey("ns/b")
+	expectDegraded(operatorv1.ConditionTrue, "[ns/a: a failed, ns/b: b failed]")
+
+	delete(syncErrs, "ns/a")
+	syncKey("ns/a")
+	expectDegraded(operatorv1.ConditionTrue, "ns/b: b failed")
+
+	// deleted objects are not reported anymore
+	c.syncedKeys.objectDeleted("ns/b")
+	delete(syncErrs, "ns/b")
+	syncKey("ns/a")
+	expectDegraded(operatorv1.ConditionFalse, "")
+}
+
 func TestBaseController_Run(t *testing.T) {
 	informer := &fakeInformer{hasSyncedDelay: 200 * time.Millisecond}
 	controllerCtx, cancel := context.WithCancel(context.Background())
@@ -184,3 +245,97 @@ func TestBaseController_Run(t *testing.T) {
 		t.Errorf("expected the post start hook to be terminated when context is cancelled")
 	}
 }
+
+func TestBaseController_CheckHealth(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	syncErr := fmt.Errorf("sync failed")
+	c := &baseController{
+		name:        "test",
+		syncContext: NewSyncContext("test", eventstesting.NewTestingEventRecorder(t)),
+		clock:       fakeClock,
+		sync: func(ctx context.Context, syncCtx SyncContext) error {
+			return syncErr
+		},
+	}
+
+	if err := c.CheckHealth(time.Minute); err != nil {
+		t.Errorf("expected controller which is not started to be healthy, got %v", err)
+	}
+	c.started = fakeClock.Now()
+
+	// idle controllers are healthy
+	fakeClock.Step(time.Hour)
+	if err := c.CheckHealth(time.Minute); err != nil {
+		t.Errorf("expected idle controller to be healthy, got %v", err)
+	}
+
+	// a failing sync makes it unhealthy once the window passed
+	c.syncContext.Queue().Add(DefaultQueueKey)
+	c.processNextWorkItem(context.TODO())
+	if err := c.CheckHealth(time.Minute); err == nil || err.Error() != "test controller has not synced successfully since it was started 1h0m0s ago" {
+		t.Errorf("expected controller without successful sync to be unhealthy, got %v", err)
+	}
+
+	syncErr = nil
+	c.syncContext.Queue().Forget(DefaultQueueKey)
+	c.syncContext.Queue().Add(DefaultQueueKey)
+	c.processNextWorkItem(context.TODO())
+	if err := c.CheckHealth(time.Minute); err != nil {
+		t.Errorf("expected synced controller to be healthy, got %v", err)
+	}
+
+	// pending work which is not synced within the window
+	c.syncContext.Queue().Add(DefaultQueueKey)
+	fakeClock.Step(30 * time.Second)
+	if err := c.CheckHealth(time.Minute); err != nil {
+		t.Errorf("expected controller within the window to be healthy, got %v", err)
+	}
+	fakeClock.Step(time.Minute)
+	if err := c.CheckHealth(time.Minute); err == nil || err.Error() != "test controller has not synced successfully for 1m30s" {
+		t.Errorf("expected stuck controller to be unhealthy, got %v", err)
+	}
+}
+
+func TestBaseController_CheckHealthKeyed(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	syncErrs := map[string]error{}
+	c := &baseController{
+		name:        "test",
+		keyed:       true,
+		syncedKeys:  newSyncedKeys(),
+		syncContext: NewSyncContext("test", eventstesting.NewTestingEventRecorder(t)),
+		clock:       fakeClock,
+		sync: func(ctx context.Context, syncCtx SyncContext) error {
+			return syncErrs[syncCtx.QueueKey()]
+		},
+	}
+	c.started = fakeClock.Now()
+	syncKey := func(key string) {
+		t.Helper()
+		c.syncContext.Queue().Add(key)
+		c.processNextWorkItem(context.TODO())
+		// the failed key is retried by the test, not by the rate limited queue
+		c.syncContext.Queue().Forget(key)
+	}
+
+	syncKey("ns/a")
+	syncKey("ns/b")
+	fakeClock.Step(time.Minute)
+	syncErrs["ns/a"] = fmt.Errorf("a failed")
+	syncKey("ns/a")
+
+	// the failing key is not hidden by another key which keeps syncing successfully
+	for i := 0; i < 3; i++ {
+		fakeClock.Step(30 * time.Second)
+		syncKey("ns/b")
+	}
+	if err := c.CheckHealth(time.Minute); err == nil || err.Error() != `test controller has not synced "ns/a" successfully for 2m30s` {
+		t.Errorf("expected controller with a failing key to be unhealthy, got %v", err)
+	}
+
+	delete(syncErrs, "ns/a")
+	syncKey("ns/a")
+	if err := c.CheckHealth(time.Minute); err != nil {
+		t.Errorf("expected controller to be healthy once the key synced, got %v", err)
+	}
+}
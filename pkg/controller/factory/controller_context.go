@@ -20,6 +20,8 @@ type syncContext struct {
 	eventRecorder events.Recorder
 	queue         workqueue.RateLimitingInterface
 	queueKey      string
+	// syncedKeys tracks the keys of deleted objects for keyed controllers, nil otherwise.
+	syncedKeys *syncedKeys
 }
 
 var _ SyncContext = syncContext{}
@@ -27,7 +29,7 @@ var _ SyncContext = syncContext{}
 // NewSyncContext gives new sync context.
 func NewSyncContext(name string, recorder events.Recorder) SyncContext {
 	return syncContext{
-		queue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), name),
+		queue:         workqueue.NewNamedRateLimitingQueue(newMetricsRateLimiter(name, workqueue.DefaultControllerRateLimiter()), name),
 		eventRecorder: recorder.WithComponentSuffix(strings.ToLower(name)),
 	}
 }
@@ -53,7 +55,9 @@ func (c syncContext) eventHandler(queueKeysFunc ObjectQueueKeysFunc, filter Even
 				utilruntime.HandleError(fmt.Errorf("added object %+v is not runtime Object", obj))
 				return
 			}
-			c.enqueueKeys(queueKeysFunc(runtimeObj)...)
+			keys := queueKeysFunc(runtimeObj)
+			c.syncedKeys.objectChanged(keys...)
+			c.enqueueKeys(keys...)
 		},
 		UpdateFunc: func(old, new interface{}) {
 			runtimeObj, ok := new.(runtime.Object)
@@ -61,20 +65,26 @@ func (c syncContext) eventHandler(queueKeysFunc ObjectQueueKeysFunc, filter Even
 				utilruntime.HandleError(fmt.Errorf("updated object %+v is not runtime Object", runtimeObj))
 				return
 			}
-			c.enqueueKeys(queueKeysFunc(runtimeObj)...)
+			keys := queueKeysFunc(runtimeObj)
+			c.syncedKeys.objectChanged(keys...)
+			c.enqueueKeys(keys...)
 		},
 		DeleteFunc: func(obj interface{}) {
 			runtimeObj, ok := obj.(runtime.Object)
 			if !ok {
 				if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
-					c.enqueueKeys(queueKeysFunc(tombstone.Obj.(runtime.Object))...)
+					keys := queueKeysFunc(tombstone.Obj.(runtime.Object))
+					c.syncedKeys.objectDeleted(keys...)
+					c.enqueueKeys(keys...)
 
 					return
 				}
 				utilruntime.HandleError(fmt.Errorf("updated object %+v is not runtime Object", runtimeObj))
 				return
 			}
-			c.enqueueKeys(queueKeysFunc(runtimeObj)...)
+			keys := queueKeysFunc(runtimeObj)
+			c.syncedKeys.objectDeleted(keys...)
+			c.enqueueKeys(keys...)
 		},
 	}
 	if filter == nil {
@@ -7,10 +7,13 @@ import (
 	"time"
 
 	"github.com/robfig/cron"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	errorutil "k8s.io/apimachinery/pkg/util/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/clock"
 
 	"github.com/openshift/library-go/pkg/operator/events"
 	operatorv1helpers "github.com/openshift/library-go/pkg/operator/v1helpers"
@@ -24,10 +27,33 @@ func DefaultQueueKeysFunc(_ runtime.Object) []string {
 	return []string{DefaultQueueKey}
 }
 
+// ObjectKeyQueueKeysFunc returns a slice with the "namespace/name" key of the object, or just "name" for cluster
+// scoped objects. It is the default for informers of controllers with WithKeyedSync.
+func ObjectKeyQueueKeysFunc(obj runtime.Object) []string {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("unable to get queue key of %T: %w", obj, err))
+		return nil
+	}
+	return []string{key}
+}
+
+// NamespaceQueueKeysFunc returns a slice with the namespace of the object. It can be used for controllers which
+// reconcile all objects of a namespace at once.
+func NamespaceQueueKeysFunc(obj runtime.Object) []string {
+	metaObj, err := meta.Accessor(obj)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("unable to get namespace of %T: %w", obj, err))
+		return nil
+	}
+	return []string{metaObj.GetNamespace()}
+}
+
 // Factory is generator that generate standard Kubernetes controllers.
 // Factory is really generic and should be only used for simple controllers that does not require special stuff..
 type Factory struct {
 	sync                   SyncFunc
+	keyed                  bool
 	syncContext            SyncContext
 	syncDegradedClient     operatorv1helpers.OperatorClient
 	resyncInterval         time.Duration
@@ -95,6 +121,21 @@ func (f *Factory) WithSync(syncFn SyncFunc) *Factory {
 	return f
 }
 
+// WithKeyedSync is used to set the controller synchronization function of controllers which reconcile one object per
+// queue key, instead of everything on any change. Informers registered without queue key function enqueue the
+// "namespace/name" key of the changed object instead of DefaultQueueKey, such that keys are synced in parallel by all
+// workers passed to Run(). One key is never synced by two workers at the same time.
+//
+// ResyncEvery and ResyncSchedule enqueue all keys which were synced before, except for the keys of objects deleted
+// since. The sync function has to handle keys of deleted objects.
+func (f *Factory) WithKeyedSync(syncFn KeyedSyncFunc) *Factory {
+	f.sync = func(ctx context.Context, controllerContext SyncContext) error {
+		return syncFn(ctx, controllerContext, controllerContext.QueueKey())
+	}
+	f.keyed = true
+	return f
+}
+
 // WithInformers is used to register event handlers and get the caches synchronized functions.
 // Pass informers you want to use to react to changes on resources. If informer event is observed, then the Sync() function
 // is called.
@@ -255,7 +296,12 @@ type informerHandleTuple struct {
 // Controller produce a runnable controller.
 func (f *Factory) ToController(name string, eventRecorder events.Recorder) Controller {
 	if f.sync == nil {
-		panic(fmt.Errorf("WithSync() must be used before calling ToController() in %q", name))
+		panic(fmt.Errorf("WithSync() or WithKeyedSync() must be used before calling ToController() in %q", name))
+	}
+
+	defaultQueueKeysFn := DefaultQueueKeysFunc
+	if f.keyed {
+		defaultQueueKeysFn = ObjectKeyQueueKeysFunc
 	}
 
 	var ctx SyncContext
@@ -264,6 +310,13 @@ func (f *Factory) ToController(name string, eventRecorder events.Recorder) Contr
 	} else {
 		ctx = NewSyncContext(name, eventRecorder)
 	}
+	var keys *syncedKeys
+	if f.keyed {
+		keys = newSyncedKeys()
+		keyedCtx := ctx.(syncContext)
+		keyedCtx.syncedKeys = keys
+		ctx = keyedCtx
+	}
 
 	var cronSchedules []cron.Schedule
 	if len(f.resyncSchedules) > 0 {
@@ -285,12 +338,15 @@ func (f *Factory) ToController(name string, eventRecorder events.Recorder) Contr
 		controllerInstanceName: f.controllerInstanceName,
 		syncDegradedClient:     f.syncDegradedClient,
 		sync:                   f.sync,
+		keyed:                  f.keyed,
+		syncedKeys:             keys,
 		resyncEvery:            f.resyncInterval,
 		resyncSchedules:        cronSchedules,
 		cachesToSync:           append([]cache.InformerSynced{}, f.cachesToSync...),
 		syncContext:            ctx,
 		postStartHooks:         f.postStartHooks,
 		cacheSyncTimeout:       defaultCacheSyncTimeout,
+		clock:                  clock.RealClock{},
 	}
 
 	// avoid adding an informer more than once
@@ -322,7 +378,7 @@ func (f *Factory) ToController(name string, eventRecorder events.Recorder) Contr
 			}
 			if !informerSet.Has(tuple) {
 				sets.Insert(informerSet, tuple)
-				informer.AddEventHandler(c.syncContext.(syncContext).eventHandler(DefaultQueueKeysFunc, f.informers[i].filter))
+				informer.AddEventHandler(c.syncContext.(syncContext).eventHandler(defaultQueueKeysFn, f.informers[i].filter))
 			}
 			c.cachesToSync = append(c.cachesToSync, informer.HasSynced)
 		}
@@ -333,7 +389,7 @@ func (f *Factory) ToController(name string, eventRecorder events.Recorder) Contr
 	}
 
 	for i := range f.namespaceInformers {
-		f.namespaceInformers[i].informer.AddEventHandler(c.syncContext.(syncContext).eventHandler(DefaultQueueKeysFunc, f.namespaceInformers[i].nsFilter))
+		f.namespaceInformers[i].informer.AddEventHandler(c.syncContext.(syncContext).eventHandler(defaultQueueKeysFn, f.namespaceInformers[i].nsFilter))
 		c.cachesToSync = append(c.cachesToSync, f.namespaceInformers[i].informer.HasSynced)
 	}
 
@@ -323,3 +323,126 @@ func TestControllerWithQueueFunction(t *testing.T) {
 		t.Fatal("test timeout")
 	}
 }
+
+func TestKeyedController(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	kubeInformers := informers.NewSharedInformerFactoryWithOptions(kubeClient, 1*time.Minute, informers.WithNamespace("test"))
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	go kubeInformers.Start(ctx.Done())
+
+	var lock sync.Mutex
+	active := map[string]int{}
+	syncs := map[string]int{}
+	maxActive, maxActivePerKey := 0, 0
+
+	controller := New().
+		WithInformers(kubeInformers.Core().V1().Secrets().Informer()).
+		ResyncEvery(time.Second).
+		WithKeyedSync(func(ctx context.Context, syncContext SyncContext, key string) error {
+			lock.Lock()
+			active[key]++
+			syncs[key]++
+			total := 0
+			for _, n := range active {
+				total += n
+			}
+			maxActive = max(maxActive, total)
+			maxActivePerKey = max(maxActivePerKey, active[key])
+			lock.Unlock()
+
+			// requeue the key while it is being synced
+			syncContext.Queue().Add(key)
+			time.Sleep(100 * time.Millisecond)
+
+			lock.Lock()
+			active[key]--
+			lock.Unlock()
+			return nil
+		}).ToController("KeyedController", events.NewInMemoryRecorder("keyed-controller", clocktesting.NewFakePassiveClock(time.Now())))
+
+	go controller.Run(ctx, 4)
+
+	for i := 0; i < 4; i++ {
+		secret := makeFakeSecret()
+		secret.Name = fmt.Sprintf("secret-%d", i)
+		if _, err := kubeClient.CoreV1().Secrets("test").Create(ctx, secret, meta.CreateOptions{}); err != nil {
+			t.Fatalf("failed to create fake secret: %v", err)
+		}
+	}
+
+	if err := wait.PollImmediate(100*time.Millisecond, 30*time.Second, func() (bool, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		for i := 0; i < 4; i++ {
+			if syncs[fmt.Sprintf("test/secret-%d", i)] < 3 {
+				return false, nil
+			}
+		}
+		return true, nil
+	}); err != nil {
+		t.Fatalf("expected all secrets to be synced repeatedly, got %v", syncs)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if _, ok := syncs[DefaultQueueKey]; ok {
+		t.Errorf("expected no sync of %q, got %v", DefaultQueueKey, syncs)
+	}
+	if maxActivePerKey != 1 {
+		t.Errorf("expected a key never to be synced concurrently, got %d concurrent syncs", maxActivePerKey)
+	}
+	if maxActive < 2 {
+		t.Errorf("expected keys to be synced in parallel, got at most %d concurrent syncs", maxActive)
+	}
+}
+
+func TestKeyedControllerResync(t *testing.T) {
+	c := New().WithKeyedSync(func(ctx context.Context, syncContext SyncContext, key string) error {
+		return nil
+	}).ToController("test", eventstesting.NewTestingEventRecorder(t)).(*baseController)
+	queue := c.syncContext.Queue()
+
+	// nothing synced yet
+	c.resync()
+	if queue.Len() != 0 {
+		t.Fatalf("expected empty queue, got %d keys", queue.Len())
+	}
+
+	queue.Add("ns/a")
+	queue.Add("ns/b")
+	c.processNextWorkItem(context.TODO())
+	c.processNextWorkItem(context.TODO())
+	c.resync()
+	if queue.Len() != 2 {
+		t.Fatalf("expected the synced keys to be enqueued, got %d keys", queue.Len())
+	}
+	c.processNextWorkItem(context.TODO())
+	c.processNextWorkItem(context.TODO())
+
+	// the key of a deleted object is synced once more for the deletion, but not resynced
+	handler := c.syncContext.(syncContext).eventHandler(ObjectKeyQueueKeysFunc, nil)
+	deleted := makeFakeSecret()
+	deleted.Namespace, deleted.Name = "ns", "a"
+	handler.OnDelete(deleted)
+	if queue.Len() != 1 {
+		t.Fatalf("expected the deleted key to be enqueued, got %d keys", queue.Len())
+	}
+	c.processNextWorkItem(context.TODO())
+	c.resync()
+	if queue.Len() != 1 {
+		t.Fatalf("expected only the remaining key to be resynced, got %d keys", queue.Len())
+	}
+	if key, _ := queue.Get(); key != "ns/b" {
+		t.Errorf("expected ns/b to be resynced, got %v", key)
+	}
+	queue.Done("ns/b")
+
+	// a recreated object is resynced again
+	handler.OnAdd(deleted, false)
+	c.processNextWorkItem(context.TODO())
+	c.resync()
+	if queue.Len() != 2 {
+		t.Fatalf("expected the recreated key to be resynced, got %d keys", queue.Len())
+	}
+}
@@ -3,6 +3,7 @@ package factory
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"k8s.io/client-go/util/workqueue"
 
@@ -27,6 +28,13 @@ type Controller interface {
 	Name() string
 }
 
+// HealthChecker is implemented by the controllers created by the factory to report whether they make progress.
+type HealthChecker interface {
+	// CheckHealth returns an error if the controller has pending work but has not synced successfully
+	// for longer than maxSyncAge.
+	CheckHealth(maxSyncAge time.Duration) error
+}
+
 // SyncContext interface represents a context given to the Sync() function where the main controller logic happen.
 // SyncContext exposes controller name and give user access to the queue (for manual requeue).
 // SyncContext also provides metadata about object that informers observed as changed.
@@ -47,6 +55,11 @@ type SyncContext interface {
 // The syncContext provides access to controller name, queue and event recorder.
 type SyncFunc func(ctx context.Context, controllerContext SyncContext) error
 
+// KeyedSyncFunc is a SyncFunc for controllers which reconcile one object per queue key. The key is the queue key
+// of the object, by default "namespace/name" (see ObjectKeyQueueKeysFunc). A key is never synced by two workers at
+// the same time, but different keys are synced in parallel by all workers.
+type KeyedSyncFunc func(ctx context.Context, controllerContext SyncContext, key string) error
+
 func ControllerFieldManager(controllerName, usageName string) string {
 	return fmt.Sprintf("%s-%s", controllerName, usageName)
 }
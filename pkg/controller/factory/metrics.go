@@ -0,0 +1,161 @@
+package factory
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	k8smetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	metricsNamespace = "openshift"
+	metricsSubsystem = "controller"
+)
+
+// metrics provides access to all controller metrics.
+var metrics *controllerMetrics
+
+func init() {
+	metrics = newControllerMetrics(legacyregistry.Register)
+}
+
+// controllerMetrics instruments the controllers created by the factory with prometheus metrics.
+type controllerMetrics struct {
+	requeues   *k8smetrics.CounterVec
+	keyRetries *k8smetrics.GaugeVec
+	keyBackoff *k8smetrics.GaugeVec
+
+	syncDuration       *k8smetrics.HistogramVec
+	syncErrors         *k8smetrics.CounterVec
+	queueDepth         *k8smetrics.GaugeVec
+	lastSuccessfulSync *k8smetrics.GaugeVec
+}
+
+func newControllerMetrics(registerFunc func(k8smetrics.Registerable) error) *controllerMetrics {
+	requeues := k8smetrics.NewCounterVec(
+		&k8smetrics.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "rate_limited_requeues_total",
+			Help:      "The total number of queue keys requeued with rate limiting after a failed sync, labeled with the controller name",
+		}, []string{"controller"})
+	registerFunc(requeues)
+
+	// the per-key metrics only exist while the key is failing, which keeps their cardinality low
+	keyRetries := k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "key_retries",
+			Help:      "The number of consecutive failed syncs of a queue key, labeled with the controller name and the queue key",
+		}, []string{"controller", "key"})
+	registerFunc(keyRetries)
+
+	keyBackoff := k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "key_backoff_seconds",
+			Help:      "The rate limiting delay of the next sync of a failing queue key in seconds, labeled with the controller name and the queue key",
+		}, []string{"controller", "key"})
+	registerFunc(keyBackoff)
+
+	syncDuration := k8smetrics.NewHistogramVec(
+		&k8smetrics.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "sync_duration_seconds",
+			Help:      "How long a sync takes in seconds, labeled with the controller name, the controller instance name and the result",
+			Buckets:   k8smetrics.ExponentialBuckets(0.001, 2, 18),
+		}, []string{"controller", "instance", "result"})
+	registerFunc(syncDuration)
+
+	syncErrors := k8smetrics.NewCounterVec(
+		&k8smetrics.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "sync_errors_total",
+			Help:      "The total number of failed syncs, labeled with the controller name and the controller instance name",
+		}, []string{"controller", "instance"})
+	registerFunc(syncErrors)
+
+	queueDepth := k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "queue_depth",
+			Help:      "The number of queue keys waiting to be synced, labeled with the controller name and the controller instance name",
+		}, []string{"controller", "instance"})
+	registerFunc(queueDepth)
+
+	// the time since the last successful sync is time() minus this timestamp
+	lastSuccessfulSync := k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "last_successful_sync_timestamp_seconds",
+			Help:      "The Unix time of the last successful sync, labeled with the controller name and the controller instance name",
+		}, []string{"controller", "instance"})
+	registerFunc(lastSuccessfulSync)
+
+	return &controllerMetrics{
+		requeues:           requeues,
+		keyRetries:         keyRetries,
+		keyBackoff:         keyBackoff,
+		syncDuration:       syncDuration,
+		syncErrors:         syncErrors,
+		queueDepth:         queueDepth,
+		lastSuccessfulSync: lastSuccessfulSync,
+	}
+}
+
+// observeSync records the duration and result of a sync which started at the given time.
+func (m *controllerMetrics) observeSync(controller, instance string, start, end time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+		m.syncErrors.WithLabelValues(controller, instance).Inc()
+	} else {
+		m.lastSuccessfulSync.WithLabelValues(controller, instance).Set(float64(end.Unix()))
+	}
+	m.syncDuration.WithLabelValues(controller, instance, result).Observe(end.Sub(start).Seconds())
+}
+
+// observeQueueDepth records the number of queue keys waiting to be synced.
+func (m *controllerMetrics) observeQueueDepth(controller, instance string, depth int) {
+	m.queueDepth.WithLabelValues(controller, instance).Set(float64(depth))
+}
+
+// metricsRateLimiter exports the retries and backoff of the queue keys of a controller.
+type metricsRateLimiter struct {
+	workqueue.RateLimiter
+
+	controller string
+	metrics    *controllerMetrics
+}
+
+func newMetricsRateLimiter(controller string, rateLimiter workqueue.RateLimiter) workqueue.RateLimiter {
+	return &metricsRateLimiter{
+		RateLimiter: rateLimiter,
+		controller:  controller,
+		metrics:     metrics,
+	}
+}
+
+func (r *metricsRateLimiter) When(item interface{}) time.Duration {
+	delay := r.RateLimiter.When(item)
+	key := fmt.Sprintf("%v", item)
+	r.metrics.requeues.WithLabelValues(r.controller).Inc()
+	r.metrics.keyRetries.WithLabelValues(r.controller, key).Set(float64(r.RateLimiter.NumRequeues(item)))
+	r.metrics.keyBackoff.WithLabelValues(r.controller, key).Set(delay.Seconds())
+	return delay
+}
+
+func (r *metricsRateLimiter) Forget(item interface{}) {
+	r.RateLimiter.Forget(item)
+	labels := map[string]string{"controller": r.controller, "key": fmt.Sprintf("%v", item)}
+	r.metrics.keyRetries.Delete(labels)
+	r.metrics.keyBackoff.Delete(labels)
+}
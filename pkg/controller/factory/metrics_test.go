@@ -0,0 +1,134 @@
+package factory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+	k8smetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/testutil"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+)
+
+func TestMetricsRateLimiter(t *testing.T) {
+	registry := k8smetrics.NewKubeRegistry()
+	m := newControllerMetrics(registry.Register)
+	rateLimiter := &metricsRateLimiter{
+		RateLimiter: workqueue.NewItemExponentialFailureRateLimiter(time.Second, time.Minute),
+		controller:  "test",
+		metrics:     m,
+	}
+
+	rateLimiter.When("ns/a")
+	rateLimiter.When("ns/a")
+	rateLimiter.When("ns/b")
+
+	expectGauge := func(vec *k8smetrics.GaugeVec, key string, expected float64) {
+		t.Helper()
+		actual, err := testutil.GetGaugeMetricValue(vec.WithLabelValues("test", key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Errorf("expected %v for %q, got %v", expected, key, actual)
+		}
+	}
+	expectGauge(m.keyRetries, "ns/a", 2)
+	expectGauge(m.keyBackoff, "ns/a", 2)
+	expectGauge(m.keyRetries, "ns/b", 1)
+	expectGauge(m.keyBackoff, "ns/b", 1)
+
+	requeues, err := testutil.GetCounterMetricValue(m.requeues.WithLabelValues("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requeues != 3 {
+		t.Errorf("expected 3 requeues, got %v", requeues)
+	}
+
+	// the per-key metrics are removed when the key succeeds
+	rateLimiter.Forget("ns/a")
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "openshift_controller_key_retries" {
+			continue
+		}
+		if len(family.GetMetric()) != 1 {
+			t.Errorf("expected only the retries of ns/b, got %v", family.GetMetric())
+		}
+	}
+}
+
+func TestObserveSync(t *testing.T) {
+	registry := k8smetrics.NewKubeRegistry()
+	m := newControllerMetrics(registry.Register)
+
+	start := time.Unix(1000, 0)
+	m.observeSync("test", "instance", start, start.Add(2*time.Second), nil)
+	m.observeSync("test", "instance", start, start.Add(3*time.Second), fmt.Errorf("failed"))
+	m.observeQueueDepth("test", "instance", 5)
+
+	lastSuccessfulSync, err := testutil.GetGaugeMetricValue(m.lastSuccessfulSync.WithLabelValues("test", "instance"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastSuccessfulSync != 1002 {
+		t.Errorf("expected last successful sync at 1002, got %v", lastSuccessfulSync)
+	}
+	syncErrors, err := testutil.GetCounterMetricValue(m.syncErrors.WithLabelValues("test", "instance"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if syncErrors != 1 {
+		t.Errorf("expected 1 sync error, got %v", syncErrors)
+	}
+	queueDepth, err := testutil.GetGaugeMetricValue(m.queueDepth.WithLabelValues("test", "instance"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if queueDepth != 5 {
+		t.Errorf("expected queue depth 5, got %v", queueDepth)
+	}
+	for _, result := range []string{"success", "error"} {
+		count, err := testutil.GetHistogramMetricCount(m.syncDuration.WithLabelValues("test", "instance", result))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Errorf("expected one %s sync observed, got %d", result, count)
+		}
+	}
+}
+
+func TestQueueDepthSampledWhileWorkersAreStuck(t *testing.T) {
+	defer func(interval time.Duration) { queueDepthSampleInterval = interval }(queueDepthSampleInterval)
+	queueDepthSampleInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	stuck := make(chan struct{})
+	defer cancel()
+	defer close(stuck)
+	controller := New().WithKeyedSync(func(ctx context.Context, syncContext SyncContext, key string) error {
+		<-stuck
+		return nil
+	}).ToController("QueueDepthController", eventstesting.NewTestingEventRecorder(t)).(*baseController)
+	for _, key := range []string{"ns/a", "ns/b", "ns/c"} {
+		controller.syncContext.Queue().Add(key)
+	}
+	go controller.Run(ctx, 1)
+
+	// the only worker is stuck syncing the first key
+	if err := wait.PollImmediate(10*time.Millisecond, 10*time.Second, func() (bool, error) {
+		depth, err := testutil.GetGaugeMetricValue(metrics.queueDepth.WithLabelValues("QueueDepthController", ""))
+		return err == nil && depth == 2, err
+	}); err != nil {
+		t.Fatalf("expected the queue depth of the stuck controller to be sampled: %v", err)
+	}
+}
@@ -71,7 +71,31 @@ func TerminateOnChangeReactor(terminateFn func()) ReactorFn {
 	}
 }
 
-func NewObserver(interval time.Duration) (Observer, error) {
+// ObserverOption configures the observer returned by NewObserver.
+type ObserverOption func(*observerOptions)
+
+type observerOptions struct {
+	inotify bool
+}
+
+// WithInotify makes NewObserver return an observer that reacts to inotify events in the directories of the observed
+// files, see NewInotifyObserver. The interval is used as resync interval then.
+func WithInotify() ObserverOption {
+	return func(o *observerOptions) {
+		o.inotify = true
+	}
+}
+
+// NewObserver returns an observer that hashes all observed files every interval, unless options select a
+// different implementation.
+func NewObserver(interval time.Duration, opts ...ObserverOption) (Observer, error) {
+	options := &observerOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.inotify {
+		return NewInotifyObserver(interval)
+	}
 	return &pollingObserver{
 		interval: interval,
 		reactors: map[string][]ReactorFn{},
@@ -0,0 +1,191 @@
+package fileobserver
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+)
+
+// inotifyEventDelay is how long events are collected before the affected files are checked.
+const inotifyEventDelay = 100 * time.Millisecond
+
+// inotifyObserver reacts to inotify events instead of hashing all observed files on every interval.
+//
+// It watches the parent directories of the observed files rather than the files themselves. Kubelet updates
+// projected volumes by atomically swapping the "..data" symlink the observed files point to, which is only
+// visible as event in the directory. On any event in a directory all observed files in it are re-hashed, so
+// reactors see exactly the same actions as with the polling observer.
+//
+// The observed files are also re-hashed every resync interval to catch anything inotify missed (e.g. on
+// queue overflow or for directories that did not exist yet). When the system runs out of inotify instances or
+// watches, the observer falls back to polling in that interval.
+type inotifyObserver struct {
+	*pollingObserver
+
+	// reactorsAdded is notified when reactors are added, to watch their directories right away.
+	reactorsAdded chan struct{}
+	// newWatcher creates the inotify watcher, replaced in unit tests.
+	newWatcher func() (*fsnotify.Watcher, error)
+}
+
+// NewInotifyObserver returns an observer that reacts to inotify events in the directories of the observed files.
+// All observed files are additionally re-hashed every resyncInterval, which is also the polling interval in case
+// inotify instances or watches run out.
+func NewInotifyObserver(resyncInterval time.Duration) (Observer, error) {
+	if resyncInterval <= 0 {
+		return nil, fmt.Errorf("resync interval must be positive, got %s", resyncInterval)
+	}
+	return &inotifyObserver{
+		pollingObserver: &pollingObserver{
+			interval: resyncInterval,
+			reactors: map[string][]ReactorFn{},
+			files:    map[string]fileHashAndState{},
+		},
+		reactorsAdded: make(chan struct{}, 1),
+		newWatcher:    fsnotify.NewWatcher,
+	}, nil
+}
+
+// AddReactor will add new reactor to this observer.
+func (o *inotifyObserver) AddReactor(reaction ReactorFn, startingFileContent map[string][]byte, files ...string) Observer {
+	o.pollingObserver.AddReactor(reaction, startingFileContent, files...)
+	select {
+	case o.reactorsAdded <- struct{}{}:
+	default:
+	}
+	return o
+}
+
+// Run will start a new observer.
+func (o *inotifyObserver) Run(stopChan <-chan struct{}) {
+	watcher, err := o.newWatcher()
+	if err != nil {
+		klog.Warningf("Unable to create inotify watcher, falling back to polling every %s: %v", o.interval, err)
+		o.pollingObserver.Run(stopChan)
+		return
+	}
+	defer watcher.Close()
+
+	klog.Info("Starting inotify file observer")
+	defer klog.Infof("Shutting down inotify file observer")
+
+	if err := o.watchDirectories(watcher); err != nil {
+		o.fallBackToPolling(watcher, stopChan, err)
+		return
+	}
+	o.checkFiles(nil)
+	o.setSynced()
+
+	resync := time.NewTicker(o.interval)
+	defer resync.Stop()
+
+	// events are collected for a short while such that a file is not hashed in the middle of being written
+	pendingDirs := sets.New[string]()
+	var pendingTimer <-chan time.Time
+	for {
+		select {
+		case <-stopChan:
+			return
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			klog.V(5).Infof("Observed inotify event %s", event)
+			// the event might also be about an observed directory itself, e.g. when it is removed
+			pendingDirs.Insert(filepath.Dir(event.Name), event.Name)
+			if pendingTimer == nil {
+				pendingTimer = time.After(inotifyEventDelay)
+			}
+
+		case <-pendingTimer:
+			o.checkFiles(pendingDirs)
+			pendingDirs = sets.New[string]()
+			pendingTimer = nil
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				klog.Warningf("Inotify event queue overflowed, re-checking all observed files")
+				o.checkFiles(nil)
+				continue
+			}
+			klog.Errorf("Inotify watcher failed: %v", err)
+
+		case <-o.reactorsAdded:
+			if err := o.watchDirectories(watcher); err != nil {
+				o.fallBackToPolling(watcher, stopChan, err)
+				return
+			}
+			o.checkFiles(nil)
+
+		case <-resync.C:
+			if err := o.watchDirectories(watcher); err != nil {
+				o.fallBackToPolling(watcher, stopChan, err)
+				return
+			}
+			o.checkFiles(nil)
+		}
+	}
+}
+
+// fallBackToPolling closes the watcher and polls the observed files until stopChan is closed.
+func (o *inotifyObserver) fallBackToPolling(watcher *fsnotify.Watcher, stopChan <-chan struct{}, err error) {
+	klog.Warningf("Unable to watch observed directories, falling back to polling every %s: %v", o.interval, err)
+	if err := watcher.Close(); err != nil {
+		klog.Warningf("Failed to close inotify watcher: %v", err)
+	}
+	o.processReactors(stopChan)
+}
+
+// watchDirectories adds watches for all directories of observed files which are not watched yet. Directories that
+// do not exist are skipped, they are retried on the next resync. It only fails when inotify watches run out.
+func (o *inotifyObserver) watchDirectories(watcher *fsnotify.Watcher) error {
+	watched := sets.New(watcher.WatchList()...)
+	for _, dir := range sets.List(o.observedDirectories().Difference(watched)) {
+		err := watcher.Add(dir)
+		switch {
+		case err == nil:
+			klog.V(3).Infof("Watching directory %q", dir)
+		case errors.Is(err, syscall.ENOSPC), errors.Is(err, syscall.EMFILE):
+			return err
+		default:
+			klog.V(3).Infof("Unable to watch directory %q, retrying in %s: %v", dir, o.interval, err)
+		}
+	}
+	return nil
+}
+
+// observedDirectories returns the parent directories of all observed files.
+func (o *inotifyObserver) observedDirectories() sets.Set[string] {
+	o.reactorsMutex.RLock()
+	defer o.reactorsMutex.RUnlock()
+	dirs := sets.New[string]()
+	for filename := range o.reactors {
+		dirs.Insert(filepath.Dir(filename))
+	}
+	return dirs
+}
+
+// checkFiles re-hashes the observed files in the given directories, or all observed files if dirs is nil,
+// and calls the reactors of the changed ones.
+func (o *inotifyObserver) checkFiles(dirs sets.Set[string]) {
+	o.reactorsMutex.RLock()
+	defer o.reactorsMutex.RUnlock()
+	for filename, reactors := range o.reactors {
+		if dirs != nil && !dirs.Has(filepath.Dir(filename)) {
+			continue
+		}
+		if err := o.checkFile(filename, reactors); err != nil {
+			klog.Fatalf("file observer failed: %v", err)
+		}
+	}
+}
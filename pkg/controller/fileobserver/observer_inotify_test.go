@@ -0,0 +1,185 @@
+package fileobserver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func waitForReactions(t *testing.T, reactions *reactionRecorder, file string, expected ...ActionType) {
+	t.Helper()
+	if err := wait.PollImmediate(50*time.Millisecond, 5*time.Second, func() (bool, error) {
+		actual := reactions.get(file)
+		if len(actual) < len(expected) {
+			return false, nil
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			return true, fmt.Errorf("expected %v, got %v", expected, actual)
+		}
+		return true, nil
+	}); err != nil {
+		t.Fatalf("unexpected reactions for %s: %v (%v)", file, err, reactions.get(file))
+	}
+}
+
+func startInotifyObserver(t *testing.T, resyncInterval time.Duration, newWatcher func() (*fsnotify.Watcher, error), files ...string) *reactionRecorder {
+	t.Helper()
+	o, err := NewInotifyObserver(resyncInterval)
+	if err != nil {
+		t.Fatalf("observer: %v", err)
+	}
+	if newWatcher != nil {
+		o.(*inotifyObserver).newWatcher = newWatcher
+	}
+
+	reactions := newReactionRecorder()
+	o.AddReactor(func(f string, action ActionType) error {
+		reactions.add(f, action)
+		return nil
+	}, nil, files...)
+
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	go o.Run(stopCh)
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) { return o.HasSynced(), nil }); err != nil {
+		t.Fatalf("observer did not sync: %v", err)
+	}
+	return reactions
+}
+
+func TestObserverInotify(t *testing.T) {
+	dir := t.TempDir()
+	testFile := filepath.Join(dir, "test-file-1")
+	otherFile := filepath.Join(dir, "test-file-2")
+	if err := os.WriteFile(otherFile, []byte("other"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	// a resync interval of an hour makes sure the changes are observed through inotify
+	reactions := startInotifyObserver(t, time.Hour, nil, testFile, otherFile)
+
+	if err := os.WriteFile(testFile, []byte("foo"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	waitForReactions(t, reactions, testFile, FileCreated)
+
+	if err := os.WriteFile(testFile, []byte("bar"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	waitForReactions(t, reactions, testFile, FileCreated, FileModified)
+
+	if err := os.Remove(testFile); err != nil {
+		t.Fatal(err)
+	}
+	waitForReactions(t, reactions, testFile, FileCreated, FileModified, FileDeleted)
+
+	// the other file in the same directory did not change
+	if actual := reactions.get(otherFile); len(actual) != 0 {
+		t.Errorf("expected no reactions for unchanged file, got %v", actual)
+	}
+}
+
+// TestObserverInotifyAtomicWriter simulates how kubelet updates projected volumes: the observed file is a symlink
+// into the "..data" directory symlink, which is atomically replaced by a rename.
+func TestObserverInotifyAtomicWriter(t *testing.T) {
+	dir := t.TempDir()
+	writeVersion := func(version, content string) {
+		t.Helper()
+		versionDir := filepath.Join(dir, version)
+		if err := os.Mkdir(versionDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(versionDir, "tls.crt"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(version, filepath.Join(dir, "..data_tmp")); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeVersion("..2024_01_01_00_00_00.1", "first")
+	testFile := filepath.Join(dir, "tls.crt")
+	if err := os.Symlink(filepath.Join("..data", "tls.crt"), testFile); err != nil {
+		t.Fatal(err)
+	}
+
+	reactions := startInotifyObserver(t, time.Hour, nil, testFile)
+
+	writeVersion("..2024_01_01_00_00_00.2", "second")
+	if err := os.RemoveAll(filepath.Join(dir, "..2024_01_01_00_00_00.1")); err != nil {
+		t.Fatal(err)
+	}
+	waitForReactions(t, reactions, testFile, FileModified)
+}
+
+func TestObserverInotifyMissingDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "not-yet-existing")
+	testFile := filepath.Join(dir, "test-file")
+
+	// the directory is watched on resync once it exists
+	reactions := startInotifyObserver(t, 200*time.Millisecond, nil, testFile)
+
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(testFile, []byte("foo"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	waitForReactions(t, reactions, testFile, FileCreated)
+
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	waitForReactions(t, reactions, testFile, FileCreated, FileDeleted)
+}
+
+func TestObserverInotifyFallbackToPolling(t *testing.T) {
+	dir := t.TempDir()
+	testFile := filepath.Join(dir, "test-file")
+
+	reactions := startInotifyObserver(t, 200*time.Millisecond, func() (*fsnotify.Watcher, error) {
+		return nil, syscall.EMFILE
+	}, testFile)
+
+	if err := os.WriteFile(testFile, []byte("foo"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	waitForReactions(t, reactions, testFile, FileCreated)
+
+	if err := os.WriteFile(testFile, []byte("bar"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	waitForReactions(t, reactions, testFile, FileCreated, FileModified)
+}
+
+func TestNewObserverWithInotify(t *testing.T) {
+	o, err := NewObserver(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := o.(*pollingObserver); !ok {
+		t.Errorf("expected a polling observer by default, got %T", o)
+	}
+
+	o, err = NewObserver(time.Second, WithInotify())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := o.(*inotifyObserver); !ok {
+		t.Errorf("expected an inotify observer, got %T", o)
+	}
+
+	if _, err := NewObserver(0, WithInotify()); err == nil {
+		t.Errorf("expected an error for a zero resync interval")
+	}
+}
@@ -92,48 +92,11 @@ func (o *pollingObserver) processReactors(stopCh <-chan struct{}) {
 		o.reactorsMutex.RLock()
 		defer o.reactorsMutex.RUnlock()
 		for filename, reactors := range o.reactors {
-			currentFileState, err := calculateFileHash(filename)
-			if err != nil && !os.IsNotExist(err) {
+			if err := o.checkFile(filename, reactors); err != nil {
 				return false, err
 			}
-
-			lastKnownFileState := o.files[filename]
-			o.files[filename] = currentFileState
-
-			for i := range reactors {
-				var action ActionType
-				switch {
-				case !lastKnownFileState.exists && !currentFileState.exists:
-					// skip non-existing file
-					continue
-				case !lastKnownFileState.exists && currentFileState.exists && (len(currentFileState.hash) > 0 || currentFileState.isEmpty):
-					// if we see a new file created that has content or its empty, trigger FileCreate action
-					klog.Infof("Observed file %q has been created (hash=%q)", filename, currentFileState.hash)
-					action = FileCreated
-				case lastKnownFileState.exists && !currentFileState.exists:
-					klog.Infof("Observed file %q has been deleted", filename)
-					action = FileDeleted
-				case lastKnownFileState.hash == currentFileState.hash:
-					// skip if the hashes are the same
-					continue
-				case lastKnownFileState.hash != currentFileState.hash:
-					klog.Infof("Observed file %q has been modified (old=%q, new=%q)", filename, lastKnownFileState.hash, currentFileState.hash)
-					action = FileModified
-				}
-				// increment metrics counter for this file
-				observerActionsMetrics.WithLabelValues(filename, action.name()).Inc()
-				// execute the register reactor
-				if err := reactors[i](filename, action); err != nil {
-					klog.Errorf("Reactor for %q failed: %v", filename, err)
-				}
-			}
-		}
-		if !o.HasSynced() {
-			o.syncedMutex.Lock()
-			o.hasSynced = true
-			o.syncedMutex.Unlock()
-			klog.V(3).Info("File observer successfully synced")
 		}
+		o.setSynced()
 		return false, nil
 	})
 	if err != nil {
@@ -141,6 +104,57 @@ func (o *pollingObserver) processReactors(stopCh <-chan struct{}) {
 	}
 }
 
+// checkFile compares the current state of the file with the last known state and calls the reactors when it changed.
+// The caller must hold the reactorsMutex.
+func (o *pollingObserver) checkFile(filename string, reactors []ReactorFn) error {
+	currentFileState, err := calculateFileHash(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	lastKnownFileState := o.files[filename]
+	o.files[filename] = currentFileState
+
+	for i := range reactors {
+		var action ActionType
+		switch {
+		case !lastKnownFileState.exists && !currentFileState.exists:
+			// skip non-existing file
+			continue
+		case !lastKnownFileState.exists && currentFileState.exists && (len(currentFileState.hash) > 0 || currentFileState.isEmpty):
+			// if we see a new file created that has content or its empty, trigger FileCreate action
+			klog.Infof("Observed file %q has been created (hash=%q)", filename, currentFileState.hash)
+			action = FileCreated
+		case lastKnownFileState.exists && !currentFileState.exists:
+			klog.Infof("Observed file %q has been deleted", filename)
+			action = FileDeleted
+		case lastKnownFileState.hash == currentFileState.hash:
+			// skip if the hashes are the same
+			continue
+		case lastKnownFileState.hash != currentFileState.hash:
+			klog.Infof("Observed file %q has been modified (old=%q, new=%q)", filename, lastKnownFileState.hash, currentFileState.hash)
+			action = FileModified
+		}
+		// increment metrics counter for this file
+		observerActionsMetrics.WithLabelValues(filename, action.name()).Inc()
+		// execute the register reactor
+		if err := reactors[i](filename, action); err != nil {
+			klog.Errorf("Reactor for %q failed: %v", filename, err)
+		}
+	}
+	return nil
+}
+
+// setSynced marks the observer as synced after all observed files were checked once.
+func (o *pollingObserver) setSynced() {
+	if !o.HasSynced() {
+		o.syncedMutex.Lock()
+		o.hasSynced = true
+		o.syncedMutex.Unlock()
+		klog.V(3).Info("File observer successfully synced")
+	}
+}
+
 var observerActionsMetrics = metrics.NewCounterVec(&metrics.CounterOpts{
 	Subsystem:      "fileobserver",
 	Name:           "action_count",
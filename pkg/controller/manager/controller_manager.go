@@ -3,7 +3,9 @@ package manager
 import (
 	"context"
 	"sync"
+	"time"
 
+	"k8s.io/apiserver/pkg/server/healthz"
 	"k8s.io/klog/v2"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
@@ -24,23 +26,40 @@ type runnableController struct {
 	run          func(ctx context.Context, workers int)
 	workersCount int
 	name         string
+	controller   factory.Controller
 }
 
 type controllerManager struct {
-	controllers []runnableController
+	controllers  []runnableController
+	healthChecks []*ControllersHealthCheck
 }
 
 var _ ControllerManager = &controllerManager{}
+var _ HealthCheckProvider = &controllerManager{}
 
 func (c *controllerManager) WithController(controller factory.Controller, workers int) ControllerManager {
 	c.controllers = append(c.controllers, runnableController{
 		run:          controller.Run,
 		workersCount: workers,
 		name:         controller.Name(),
+		controller:   controller,
 	})
+	for _, health := range c.healthChecks {
+		health.Add(controller)
+	}
 	return c
 }
 
+// HealthCheck returns a ControllersHealthCheck of the managed controllers, including the ones added later.
+func (c *controllerManager) HealthCheck(maxSyncAge time.Duration) healthz.HealthChecker {
+	health := NewControllersHealthCheck(maxSyncAge)
+	for i := range c.controllers {
+		health.Add(c.controllers[i].controller)
+	}
+	c.healthChecks = append(c.healthChecks, health)
+	return health
+}
+
 // Start will run all managed controllers and block until all controllers shutdown.
 // When the context passed is cancelled, all controllers are signalled to shutdown.
 func (c controllerManager) Start(ctx context.Context) {
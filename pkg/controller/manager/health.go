@@ -0,0 +1,63 @@
+package manager
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apiserver/pkg/server/healthz"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+)
+
+// HealthCheckProvider is implemented by the ControllerManager returned by NewControllerManager.
+type HealthCheckProvider interface {
+	// HealthCheck returns a ControllersHealthCheck of the managed controllers.
+	HealthCheck(maxSyncAge time.Duration) healthz.HealthChecker
+}
+
+// ControllersHealthCheck is the "controllers" health check. It fails when any of its controllers has pending work but
+// has not synced successfully for longer than maxSyncAge. Controllers not created by the factory package are ignored.
+type ControllersHealthCheck struct {
+	maxSyncAge time.Duration
+
+	lock        sync.Mutex
+	controllers []factory.HealthChecker
+}
+
+var _ healthz.HealthChecker = &ControllersHealthCheck{}
+
+// NewControllersHealthCheck returns a health check of the given controllers, more can be added later.
+func NewControllersHealthCheck(maxSyncAge time.Duration, controllers ...factory.Controller) *ControllersHealthCheck {
+	h := &ControllersHealthCheck{maxSyncAge: maxSyncAge}
+	h.Add(controllers...)
+	return h
+}
+
+// Add adds the controllers to the health check.
+func (h *ControllersHealthCheck) Add(controllers ...factory.Controller) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for _, controller := range controllers {
+		if health, ok := controller.(factory.HealthChecker); ok {
+			h.controllers = append(h.controllers, health)
+		}
+	}
+}
+
+func (h *ControllersHealthCheck) Name() string {
+	return "controllers"
+}
+
+func (h *ControllersHealthCheck) Check(_ *http.Request) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	var errs []error
+	for _, controller := range h.controllers {
+		if err := controller.CheckHealth(h.maxSyncAge); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,40 @@
+package manager
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+)
+
+type fakeHealthCheckedController struct {
+	factory.Controller
+	err error
+}
+
+func (c *fakeHealthCheckedController) Name() string {
+	return "fake"
+}
+
+func (c *fakeHealthCheckedController) CheckHealth(maxSyncAge time.Duration) error {
+	return c.err
+}
+
+func TestControllerManagerHealthCheck(t *testing.T) {
+	m := NewControllerManager().WithController(&fakeHealthCheckedController{}, 1)
+	health := m.(HealthCheckProvider).HealthCheck(time.Minute)
+	if name := health.Name(); name != "controllers" {
+		t.Errorf("expected the health check to be named controllers, got %q", name)
+	}
+	if err := health.Check(nil); err != nil {
+		t.Fatalf("expected healthy controllers, got %v", err)
+	}
+
+	// controllers added after the health check was created are checked too
+	m.WithController(&fakeHealthCheckedController{err: fmt.Errorf("fake controller has not synced successfully for 2m0s")}, 1)
+	if err := health.Check(nil); err == nil || !strings.Contains(err.Error(), "has not synced successfully") {
+		t.Errorf("expected the unhealthy controller to fail the check, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// CRLPEMBlockType is the PEM block type of an encoded certificate revocation list.
+const CRLPEMBlockType = "X509 CRL"
+
+// CanSignCRL returns true if the CA certificate allows signing certificate revocation lists.
+// CAs created before the CRL sign key usage was added to signing certificates cannot.
+func (ca *CA) CanSignCRL() bool {
+	return ca.Config.Certs[0].KeyUsage&x509.KeyUsageCRLSign != 0
+}
+
+// MakeCRLForDuration creates a PEM encoded certificate revocation list with the given number, signed by the CA,
+// which lists the given revoked certificates and is valid for the given lifetime.
+func (ca *CA) MakeCRLForDuration(revoked []x509.RevocationListEntry, number *big.Int, lifetime time.Duration) ([]byte, error) {
+	if !ca.CanSignCRL() {
+		return nil, fmt.Errorf("CA %q is not allowed to sign certificate revocation lists", ca.Config.Certs[0].Subject.CommonName)
+	}
+	signer, ok := ca.Config.Key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported CA key type %T", ca.Config.Key)
+	}
+
+	now := time.Now()
+	template := &x509.RevocationList{
+		RevokedCertificateEntries: revoked,
+		Number:                    number,
+		ThisUpdate:                now.Add(-1 * time.Second),
+		NextUpdate:                now.Add(lifetime),
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, template, ca.Config.Certs[0], signer)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeCRL(der)
+}
+
+// EncodeCRL PEM encodes a DER encoded certificate revocation list.
+func EncodeCRL(der []byte) ([]byte, error) {
+	b := bytes.Buffer{}
+	if err := pem.Encode(&b, &pem.Block{Type: CRLPEMBlockType, Bytes: der}); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// ParseCRLPEM parses the first PEM encoded certificate revocation list in the given bytes.
+func ParseCRLPEM(pemBytes []byte) (*x509.RevocationList, error) {
+	for {
+		var block *pem.Block
+		block, pemBytes = pem.Decode(pemBytes)
+		if block == nil {
+			return nil, errors.New("data does not contain a PEM encoded certificate revocation list")
+		}
+		if block.Type == CRLPEMBlockType {
+			return x509.ParseRevocationList(block.Bytes)
+		}
+	}
+}
+
+// VerifyCRL checks that the certificate revocation list is signed by one of the given CA certificates.
+func VerifyCRL(crl *x509.RevocationList, caCerts []*x509.Certificate) error {
+	for _, caCert := range caCerts {
+		if !bytes.Equal(crl.RawIssuer, caCert.RawSubject) {
+			continue
+		}
+		if err := crl.CheckSignatureFrom(caCert); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("certificate revocation list issued by %q is not signed by a trusted CA", crl.Issuer.String())
+}
+
+// IsRevoked returns true if the certificate revocation list contains the serial number of the given certificate.
+// Serial numbers are compared without the issuer, such that a list signed by a rotated signer also revokes
+// certificates of its predecessors, whose serial numbers are chosen randomly.
+func IsRevoked(crl *x509.RevocationList, cert *x509.Certificate) bool {
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+			return true
+		}
+	}
+	return false
+}
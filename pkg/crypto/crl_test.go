@@ -0,0 +1,72 @@
+package crypto
+
+import (
+	"crypto/x509"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestMakeCRL(t *testing.T) {
+	for _, algorithm := range []KeyAlgorithm{RSA2048KeyAlgorithm, ECDSAP256KeyAlgorithm, Ed25519KeyAlgorithm} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			caConfig, err := MakeSelfSignedCAConfigForDurationWithKeyAlgorithm("root", time.Hour, algorithm)
+			require.NoError(t, err)
+			ca := &CA{Config: caConfig, SerialGenerator: &RandomSerialGenerator{}}
+			require.True(t, ca.CanSignCRL())
+
+			revokedServer, err := ca.MakeServerCertForDuration(sets.New("revoked.local"), time.Hour)
+			require.NoError(t, err)
+			server, err := ca.MakeServerCertForDuration(sets.New("valid.local"), time.Hour)
+			require.NoError(t, err)
+
+			crlBytes, err := ca.MakeCRLForDuration([]x509.RevocationListEntry{
+				{SerialNumber: revokedServer.Certs[0].SerialNumber, RevocationTime: time.Now()},
+			}, big.NewInt(42), time.Hour)
+			require.NoError(t, err)
+
+			crl, err := ParseCRLPEM(crlBytes)
+			require.NoError(t, err)
+			require.Equal(t, int64(42), crl.Number.Int64())
+			require.NoError(t, VerifyCRL(crl, caConfig.Certs))
+			require.True(t, IsRevoked(crl, revokedServer.Certs[0]))
+			require.False(t, IsRevoked(crl, server.Certs[0]))
+
+			otherCAConfig, err := MakeSelfSignedCAConfigForDurationWithKeyAlgorithm("root", time.Hour, algorithm)
+			require.NoError(t, err)
+			require.Error(t, VerifyCRL(crl, otherCAConfig.Certs))
+		})
+	}
+}
+
+func TestMakeCRLWithoutCRLSignKeyUsage(t *testing.T) {
+	caConfig, err := MakeSelfSignedCAConfigForDuration("root", time.Hour)
+	require.NoError(t, err)
+	caConfig.Certs[0].KeyUsage &^= x509.KeyUsageCRLSign
+	ca := &CA{Config: caConfig, SerialGenerator: &RandomSerialGenerator{}}
+
+	require.False(t, ca.CanSignCRL())
+	_, err = ca.MakeCRLForDuration(nil, big.NewInt(1), time.Hour)
+	require.Error(t, err)
+}
+
+func TestParseCRLPEM(t *testing.T) {
+	caConfig, err := MakeSelfSignedCAConfigForDuration("root", time.Hour)
+	require.NoError(t, err)
+	ca := &CA{Config: caConfig, SerialGenerator: &RandomSerialGenerator{}}
+	crlBytes, err := ca.MakeCRLForDuration(nil, big.NewInt(1), time.Hour)
+	require.NoError(t, err)
+	caBytes, err := EncodeCertificates(caConfig.Certs...)
+	require.NoError(t, err)
+
+	// other PEM blocks are skipped
+	crl, err := ParseCRLPEM(append(caBytes, crlBytes...))
+	require.NoError(t, err)
+	require.Empty(t, crl.RevokedCertificateEntries)
+
+	_, err = ParseCRLPEM(caBytes)
+	require.Error(t, err)
+}
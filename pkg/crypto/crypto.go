@@ -4,9 +4,9 @@ import (
 	"bytes"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/rsa"
-	"crypto/sha1"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
@@ -635,22 +635,27 @@ func MakeSelfSignedCAConfigForSubject(subject pkix.Name, lifetime time.Duration)
 	if lifetime > DefaultCACertificateLifetimeDuration {
 		warnAboutCertificateLifeTime(subject.CommonName, DefaultCACertificateLifetimeDuration)
 	}
-	return makeSelfSignedCAConfigForSubjectAndDuration(subject, time.Now, lifetime)
+	return makeSelfSignedCAConfigForSubjectAndDuration(subject, time.Now, lifetime, DefaultKeyAlgorithm)
 }
 
 func MakeSelfSignedCAConfigForDuration(name string, caLifetime time.Duration) (*TLSCertificateConfig, error) {
+	return MakeSelfSignedCAConfigForDurationWithKeyAlgorithm(name, caLifetime, DefaultKeyAlgorithm)
+}
+
+// MakeSelfSignedCAConfigForDurationWithKeyAlgorithm is like MakeSelfSignedCAConfigForDuration, but generates a key of the given algorithm.
+func MakeSelfSignedCAConfigForDurationWithKeyAlgorithm(name string, caLifetime time.Duration, algorithm KeyAlgorithm) (*TLSCertificateConfig, error) {
 	subject := pkix.Name{CommonName: name}
-	return makeSelfSignedCAConfigForSubjectAndDuration(subject, time.Now, caLifetime)
+	return makeSelfSignedCAConfigForSubjectAndDuration(subject, time.Now, caLifetime, algorithm)
 }
 
 func UnsafeMakeSelfSignedCAConfigForDurationAtTime(name string, currentTime func() time.Time, caLifetime time.Duration) (*TLSCertificateConfig, error) {
 	subject := pkix.Name{CommonName: name}
-	return makeSelfSignedCAConfigForSubjectAndDuration(subject, currentTime, caLifetime)
+	return makeSelfSignedCAConfigForSubjectAndDuration(subject, currentTime, caLifetime, DefaultKeyAlgorithm)
 }
 
-func makeSelfSignedCAConfigForSubjectAndDuration(subject pkix.Name, currentTime func() time.Time, caLifetime time.Duration) (*TLSCertificateConfig, error) {
+func makeSelfSignedCAConfigForSubjectAndDuration(subject pkix.Name, currentTime func() time.Time, caLifetime time.Duration, algorithm KeyAlgorithm) (*TLSCertificateConfig, error) {
 	// Create CA cert
-	rootcaPublicKey, rootcaPrivateKey, publicKeyHash, err := newKeyPairWithHash()
+	rootcaPublicKey, rootcaPrivateKey, publicKeyHash, err := newKeyPairWithHash(algorithm)
 	if err != nil {
 		return nil, err
 	}
@@ -670,8 +675,13 @@ func makeSelfSignedCAConfigForSubjectAndDuration(subject pkix.Name, currentTime
 }
 
 func MakeCAConfigForDuration(name string, caLifetime time.Duration, issuer *CA) (*TLSCertificateConfig, error) {
+	return MakeCAConfigForDurationWithKeyAlgorithm(name, caLifetime, issuer, DefaultKeyAlgorithm)
+}
+
+// MakeCAConfigForDurationWithKeyAlgorithm is like MakeCAConfigForDuration, but generates a key of the given algorithm.
+func MakeCAConfigForDurationWithKeyAlgorithm(name string, caLifetime time.Duration, issuer *CA, algorithm KeyAlgorithm) (*TLSCertificateConfig, error) {
 	// Create CA cert
-	signerPublicKey, signerPrivateKey, publicKeyHash, err := newKeyPairWithHash()
+	signerPublicKey, signerPrivateKey, publicKeyHash, err := newKeyPairWithHash(algorithm)
 	if err != nil {
 		return nil, err
 	}
@@ -785,7 +795,7 @@ func (ca *CA) MakeAndWriteServerCert(certFile, keyFile string, hostnames sets.Se
 type CertificateExtensionFunc func(*x509.Certificate) error
 
 func (ca *CA) MakeServerCert(hostnames sets.Set[string], lifetime time.Duration, fns ...CertificateExtensionFunc) (*TLSCertificateConfig, error) {
-	serverPublicKey, serverPrivateKey, publicKeyHash, _ := newKeyPairWithHash()
+	serverPublicKey, serverPrivateKey, publicKeyHash, _ := newKeyPairWithHash(DefaultKeyAlgorithm)
 	authorityKeyId := ca.Config.Certs[0].SubjectKeyId
 	subjectKeyId := publicKeyHash
 	serverTemplate := newServerCertificateTemplate(pkix.Name{CommonName: sets.List(hostnames)[0]}, sets.List(hostnames), lifetime, time.Now, authorityKeyId, subjectKeyId)
@@ -806,7 +816,15 @@ func (ca *CA) MakeServerCert(hostnames sets.Set[string], lifetime time.Duration,
 }
 
 func (ca *CA) MakeServerCertForDuration(hostnames sets.Set[string], lifetime time.Duration, fns ...CertificateExtensionFunc) (*TLSCertificateConfig, error) {
-	serverPublicKey, serverPrivateKey, publicKeyHash, _ := newKeyPairWithHash()
+	return ca.MakeServerCertForDurationWithKeyAlgorithm(hostnames, lifetime, DefaultKeyAlgorithm, fns...)
+}
+
+// MakeServerCertForDurationWithKeyAlgorithm is like MakeServerCertForDuration, but generates a key of the given algorithm.
+func (ca *CA) MakeServerCertForDurationWithKeyAlgorithm(hostnames sets.Set[string], lifetime time.Duration, algorithm KeyAlgorithm, fns ...CertificateExtensionFunc) (*TLSCertificateConfig, error) {
+	serverPublicKey, serverPrivateKey, publicKeyHash, err := newKeyPairWithHash(algorithm)
+	if err != nil {
+		return nil, err
+	}
 	authorityKeyId := ca.Config.Certs[0].SubjectKeyId
 	subjectKeyId := publicKeyHash
 	serverTemplate := newServerCertificateTemplateForDuration(pkix.Name{CommonName: sets.List(hostnames)[0]}, sets.List(hostnames), lifetime, time.Now, authorityKeyId, subjectKeyId)
@@ -895,7 +913,15 @@ func (ca *CA) MakeClientCertificate(certFile, keyFile string, u user.Info, lifet
 }
 
 func (ca *CA) MakeClientCertificateForDuration(u user.Info, lifetime time.Duration) (*TLSCertificateConfig, error) {
-	clientPublicKey, clientPrivateKey, _ := NewKeyPair()
+	return ca.MakeClientCertificateForDurationWithKeyAlgorithm(u, lifetime, DefaultKeyAlgorithm)
+}
+
+// MakeClientCertificateForDurationWithKeyAlgorithm is like MakeClientCertificateForDuration, but generates a key of the given algorithm.
+func (ca *CA) MakeClientCertificateForDurationWithKeyAlgorithm(u user.Info, lifetime time.Duration, algorithm KeyAlgorithm) (*TLSCertificateConfig, error) {
+	clientPublicKey, clientPrivateKey, err := NewKeyPairWithAlgorithm(algorithm)
+	if err != nil {
+		return nil, err
+	}
 	clientTemplate := NewClientCertificateTemplateForDuration(UserToSubject(u), lifetime, time.Now)
 	clientCrt, err := ca.SignCertificate(clientTemplate, clientPublicKey)
 	if err != nil {
@@ -968,26 +994,7 @@ func (ca *CA) SignCertificate(template *x509.Certificate, requestKey crypto.Publ
 }
 
 func NewKeyPair() (crypto.PublicKey, crypto.PrivateKey, error) {
-	return newRSAKeyPair()
-}
-
-func newKeyPairWithHash() (crypto.PublicKey, crypto.PrivateKey, []byte, error) {
-	publicKey, privateKey, err := newRSAKeyPair()
-	var publicKeyHash []byte
-	if err == nil {
-		hash := sha1.New()
-		hash.Write(publicKey.N.Bytes())
-		publicKeyHash = hash.Sum(nil)
-	}
-	return publicKey, privateKey, publicKeyHash, err
-}
-
-func newRSAKeyPair() (*rsa.PublicKey, *rsa.PrivateKey, error) {
-	privateKey, err := rsa.GenerateKey(rand.Reader, keyBits)
-	if err != nil {
-		return nil, nil, err
-	}
-	return &privateKey.PublicKey, privateKey, nil
+	return NewKeyPairWithAlgorithm(DefaultKeyAlgorithm)
 }
 
 // Can be used for CA or intermediate signing certs
@@ -1005,7 +1012,7 @@ func newSigningCertificateTemplateForDuration(subject pkix.Name, caLifetime time
 		// signing certificate is ever rotated.
 		SerialNumber: big.NewInt(randomSerialNumber()),
 
-		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
 		BasicConstraintsValid: true,
 		IsCA:                  true,
 
@@ -1139,6 +1146,13 @@ func warnAboutCertificateLifeTime(name string, defaultLifetimeDuration time.Dura
 }
 
 func signCertificate(template *x509.Certificate, requestKey crypto.PublicKey, issuer *x509.Certificate, issuerKey crypto.PrivateKey) (*x509.Certificate, error) {
+	// the templates default to RSA, adjust them to the actual keys
+	template.SignatureAlgorithm = signatureAlgorithmFor(issuerKey)
+	if _, isRSA := requestKey.(*rsa.PublicKey); !isRSA {
+		// key encipherment is only defined for RSA keys
+		template.KeyUsage &^= x509.KeyUsageKeyEncipherment
+	}
+
 	derBytes, err := x509.CreateCertificate(rand.Reader, template, issuer, requestKey, issuerKey)
 	if err != nil {
 		return nil, err
@@ -1177,6 +1191,14 @@ func EncodeKey(key crypto.PrivateKey) ([]byte, error) {
 		if err := pem.Encode(&b, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}); err != nil {
 			return []byte{}, err
 		}
+	case ed25519.PrivateKey:
+		keyBytes, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return []byte{}, err
+		}
+		if err := pem.Encode(&b, &pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}); err != nil {
+			return []byte{}, err
+		}
 	default:
 		return []byte{}, errors.New("unrecognized key type")
 
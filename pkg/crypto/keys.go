@@ -0,0 +1,156 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"fmt"
+)
+
+// KeyAlgorithm is the algorithm, and for RSA and ECDSA the size, of generated private keys.
+type KeyAlgorithm string
+
+const (
+	RSA2048KeyAlgorithm   KeyAlgorithm = "RSA-2048"
+	RSA3072KeyAlgorithm   KeyAlgorithm = "RSA-3072"
+	RSA4096KeyAlgorithm   KeyAlgorithm = "RSA-4096"
+	ECDSAP256KeyAlgorithm KeyAlgorithm = "ECDSA-P256"
+	ECDSAP384KeyAlgorithm KeyAlgorithm = "ECDSA-P384"
+	Ed25519KeyAlgorithm   KeyAlgorithm = "Ed25519"
+
+	// DefaultKeyAlgorithm is used whenever no key algorithm is specified.
+	DefaultKeyAlgorithm = RSA2048KeyAlgorithm
+)
+
+// SupportedKeyAlgorithms returns all key algorithms NewKeyPairWithAlgorithm can generate keys for.
+func SupportedKeyAlgorithms() []KeyAlgorithm {
+	return []KeyAlgorithm{
+		RSA2048KeyAlgorithm,
+		RSA3072KeyAlgorithm,
+		RSA4096KeyAlgorithm,
+		ECDSAP256KeyAlgorithm,
+		ECDSAP384KeyAlgorithm,
+		Ed25519KeyAlgorithm,
+	}
+}
+
+// NewKeyPairWithAlgorithm generates a new key pair of the given algorithm. An empty algorithm means DefaultKeyAlgorithm.
+func NewKeyPairWithAlgorithm(algorithm KeyAlgorithm) (crypto.PublicKey, crypto.PrivateKey, error) {
+	switch algorithm {
+	case "", RSA2048KeyAlgorithm:
+		return newRSAKeyPair(keyBits)
+	case RSA3072KeyAlgorithm:
+		return newRSAKeyPair(3072)
+	case RSA4096KeyAlgorithm:
+		return newRSAKeyPair(4096)
+	case ECDSAP256KeyAlgorithm:
+		return newECDSAKeyPair(elliptic.P256())
+	case ECDSAP384KeyAlgorithm:
+		return newECDSAKeyPair(elliptic.P384())
+	case Ed25519KeyAlgorithm:
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		return publicKey, privateKey, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported key algorithm %q", algorithm)
+	}
+}
+
+// KeyAlgorithmOf returns the key algorithm of the given public key.
+func KeyAlgorithmOf(publicKey crypto.PublicKey) (KeyAlgorithm, error) {
+	switch publicKey := publicKey.(type) {
+	case *rsa.PublicKey:
+		switch publicKey.N.BitLen() {
+		case 2048:
+			return RSA2048KeyAlgorithm, nil
+		case 3072:
+			return RSA3072KeyAlgorithm, nil
+		case 4096:
+			return RSA4096KeyAlgorithm, nil
+		}
+		return "", fmt.Errorf("unsupported RSA key size %d", publicKey.N.BitLen())
+	case *ecdsa.PublicKey:
+		switch publicKey.Curve {
+		case elliptic.P256():
+			return ECDSAP256KeyAlgorithm, nil
+		case elliptic.P384():
+			return ECDSAP384KeyAlgorithm, nil
+		}
+		return "", fmt.Errorf("unsupported ECDSA curve %s", publicKey.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return Ed25519KeyAlgorithm, nil
+	default:
+		return "", fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+}
+
+func newRSAKeyPair(bits int) (*rsa.PublicKey, *rsa.PrivateKey, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &privateKey.PublicKey, privateKey, nil
+}
+
+func newECDSAKeyPair(curve elliptic.Curve) (*ecdsa.PublicKey, *ecdsa.PrivateKey, error) {
+	privateKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &privateKey.PublicKey, privateKey, nil
+}
+
+func newKeyPairWithHash(algorithm KeyAlgorithm) (crypto.PublicKey, crypto.PrivateKey, []byte, error) {
+	publicKey, privateKey, err := NewKeyPairWithAlgorithm(algorithm)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	publicKeyHash, err := publicKeyHash(publicKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return publicKey, privateKey, publicKeyHash, nil
+}
+
+// publicKeyHash computes the key identifier used for the subject and authority key ID extensions.
+// For RSA it hashes the modulus like earlier releases did to keep key IDs stable.
+func publicKeyHash(publicKey crypto.PublicKey) ([]byte, error) {
+	hash := sha1.New()
+	switch publicKey := publicKey.(type) {
+	case *rsa.PublicKey:
+		hash.Write(publicKey.N.Bytes())
+	case *ecdsa.PublicKey:
+		ecdhKey, err := publicKey.ECDH()
+		if err != nil {
+			return nil, err
+		}
+		hash.Write(ecdhKey.Bytes())
+	case ed25519.PublicKey:
+		hash.Write(publicKey)
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+	return hash.Sum(nil), nil
+}
+
+// signatureAlgorithmFor returns the signature algorithm used by an issuer with the given private key.
+func signatureAlgorithmFor(issuerKey crypto.PrivateKey) x509.SignatureAlgorithm {
+	switch issuerKey := issuerKey.(type) {
+	case *ecdsa.PrivateKey:
+		if issuerKey.Curve == elliptic.P384() {
+			return x509.ECDSAWithSHA384
+		}
+		return x509.ECDSAWithSHA256
+	case ed25519.PrivateKey:
+		return x509.PureEd25519
+	default:
+		return x509.SHA256WithRSA
+	}
+}
@@ -0,0 +1,107 @@
+package crypto
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+func TestNewKeyPairWithAlgorithm(t *testing.T) {
+	for _, algorithm := range SupportedKeyAlgorithms() {
+		t.Run(string(algorithm), func(t *testing.T) {
+			publicKey, privateKey, err := NewKeyPairWithAlgorithm(algorithm)
+			require.NoError(t, err)
+			require.NotNil(t, privateKey)
+
+			actual, err := KeyAlgorithmOf(publicKey)
+			require.NoError(t, err)
+			require.Equal(t, algorithm, actual)
+		})
+	}
+
+	publicKey, _, err := NewKeyPairWithAlgorithm("")
+	require.NoError(t, err)
+	actual, err := KeyAlgorithmOf(publicKey)
+	require.NoError(t, err)
+	require.Equal(t, DefaultKeyAlgorithm, actual)
+
+	_, _, err = NewKeyPairWithAlgorithm("DSA-1024")
+	require.Error(t, err)
+}
+
+func TestCertificatesWithKeyAlgorithm(t *testing.T) {
+	tests := []struct {
+		caAlgorithm   KeyAlgorithm
+		leafAlgorithm KeyAlgorithm
+	}{
+		{caAlgorithm: RSA2048KeyAlgorithm, leafAlgorithm: RSA2048KeyAlgorithm},
+		{caAlgorithm: RSA4096KeyAlgorithm, leafAlgorithm: RSA3072KeyAlgorithm},
+		{caAlgorithm: ECDSAP256KeyAlgorithm, leafAlgorithm: ECDSAP256KeyAlgorithm},
+		{caAlgorithm: ECDSAP384KeyAlgorithm, leafAlgorithm: ECDSAP256KeyAlgorithm},
+		{caAlgorithm: Ed25519KeyAlgorithm, leafAlgorithm: Ed25519KeyAlgorithm},
+		{caAlgorithm: ECDSAP256KeyAlgorithm, leafAlgorithm: RSA2048KeyAlgorithm},
+		{caAlgorithm: RSA2048KeyAlgorithm, leafAlgorithm: ECDSAP256KeyAlgorithm},
+		{caAlgorithm: Ed25519KeyAlgorithm, leafAlgorithm: ECDSAP384KeyAlgorithm},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.caAlgorithm)+" signs "+string(test.leafAlgorithm), func(t *testing.T) {
+			caConfig, err := MakeSelfSignedCAConfigForDurationWithKeyAlgorithm("root", time.Hour, test.caAlgorithm)
+			require.NoError(t, err)
+			certBytes, keyBytes, err := caConfig.GetPEMBytes()
+			require.NoError(t, err)
+
+			// the CA survives a roundtrip through PEM
+			ca, err := GetCAFromBytes(certBytes, keyBytes)
+			require.NoError(t, err)
+			caAlgorithm, err := KeyAlgorithmOf(ca.Config.Certs[0].PublicKey)
+			require.NoError(t, err)
+			require.Equal(t, test.caAlgorithm, caAlgorithm)
+
+			intermediateConfig, err := MakeCAConfigForDurationWithKeyAlgorithm("intermediate", time.Hour, ca, test.leafAlgorithm)
+			require.NoError(t, err)
+			intermediate := &CA{Config: intermediateConfig, SerialGenerator: &RandomSerialGenerator{}}
+
+			server, err := intermediate.MakeServerCertForDurationWithKeyAlgorithm(sets.New("myserver.local"), time.Hour, test.leafAlgorithm)
+			require.NoError(t, err)
+			client, err := ca.MakeClientCertificateForDurationWithKeyAlgorithm(&user.DefaultInfo{Name: "client"}, time.Hour, test.leafAlgorithm)
+			require.NoError(t, err)
+
+			roots := x509.NewCertPool()
+			roots.AddCert(ca.Config.Certs[0])
+			intermediates := x509.NewCertPool()
+			intermediates.AddCert(intermediateConfig.Certs[0])
+
+			_, err = server.Certs[0].Verify(x509.VerifyOptions{
+				DNSName:       "myserver.local",
+				Roots:         roots,
+				Intermediates: intermediates,
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			})
+			require.NoError(t, err)
+			_, err = client.Certs[0].Verify(x509.VerifyOptions{
+				Roots:     roots,
+				KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			})
+			require.NoError(t, err)
+
+			for _, leaf := range []*TLSCertificateConfig{server, client} {
+				leafAlgorithm, err := KeyAlgorithmOf(leaf.Certs[0].PublicKey)
+				require.NoError(t, err)
+				require.Equal(t, test.leafAlgorithm, leafAlgorithm)
+
+				leafCertBytes, leafKeyBytes, err := leaf.GetPEMBytes()
+				require.NoError(t, err)
+				_, err = GetTLSCertificateConfigFromBytes(leafCertBytes, leafKeyBytes)
+				require.NoError(t, err)
+			}
+			require.Equal(t, intermediateConfig.Certs[0].SubjectKeyId, server.Certs[0].AuthorityKeyId)
+			require.Equal(t, signatureAlgorithmFor(intermediateConfig.Key), server.Certs[0].SignatureAlgorithm)
+			require.Equal(t, signatureAlgorithmFor(ca.Config.Key), client.Certs[0].SignatureAlgorithm)
+		})
+	}
+}
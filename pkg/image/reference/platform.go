@@ -0,0 +1,84 @@
+package reference
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Platform identifies the operating system and CPU an image runs on, as recorded for each
+// image in a manifest list.
+type Platform struct {
+	OS           string
+	Architecture string
+	Variant      string
+}
+
+// ParsePlatform parses a platform in the form os/architecture[/variant] used by container
+// tools, for example linux/amd64 or linux/arm64/v8.
+func ParsePlatform(spec string) (Platform, error) {
+	parts := strings.Split(spec, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Platform{}, fmt.Errorf("platform %q must be of the form os/architecture[/variant]", spec)
+	}
+	for _, part := range parts {
+		if len(part) == 0 {
+			return Platform{}, fmt.Errorf("platform %q must be of the form os/architecture[/variant]", spec)
+		}
+	}
+	platform := Platform{OS: strings.ToLower(parts[0]), Architecture: strings.ToLower(parts[1])}
+	if len(parts) == 3 {
+		platform.Variant = strings.ToLower(parts[2])
+	}
+	return platform, nil
+}
+
+// String returns the platform as os/architecture[/variant].
+func (p Platform) String() string {
+	if len(p.Variant) == 0 {
+		return fmt.Sprintf("%s/%s", p.OS, p.Architecture)
+	}
+	return fmt.Sprintf("%s/%s/%s", p.OS, p.Architecture, p.Variant)
+}
+
+// Normalize returns the platform with the common aliases for architectures and the default
+// variant of an architecture resolved, so that platforms that run the same images are equal.
+func (p Platform) Normalize() Platform {
+	p.OS = strings.ToLower(p.OS)
+	p.Architecture = strings.ToLower(p.Architecture)
+	p.Variant = strings.ToLower(p.Variant)
+	switch p.Architecture {
+	case "i386":
+		p.Architecture = "386"
+		p.Variant = ""
+	case "x86_64", "x86-64", "amd64":
+		p.Architecture = "amd64"
+		if p.Variant == "v1" {
+			p.Variant = ""
+		}
+	case "aarch64", "arm64":
+		p.Architecture = "arm64"
+		switch p.Variant {
+		case "8", "v8":
+			p.Variant = ""
+		}
+	case "armhf":
+		p.Architecture = "arm"
+		p.Variant = "v7"
+	case "armel":
+		p.Architecture = "arm"
+		p.Variant = "v6"
+	case "arm":
+		switch p.Variant {
+		case "", "7":
+			p.Variant = "v7"
+		case "5", "6", "8":
+			p.Variant = "v" + p.Variant
+		}
+	}
+	return p
+}
+
+// Matches returns true if an image built for other runs on p.
+func (p Platform) Matches(other Platform) bool {
+	return p.Normalize() == other.Normalize()
+}
@@ -0,0 +1,54 @@
+package reference
+
+import "testing"
+
+func TestParsePlatform(t *testing.T) {
+	testCases := []struct {
+		From     string
+		Expected Platform
+		String   string
+		Err      bool
+	}{
+		{From: "linux/amd64", Expected: Platform{OS: "linux", Architecture: "amd64"}, String: "linux/amd64"},
+		{From: "linux/arm64/v8", Expected: Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}, String: "linux/arm64/v8"},
+		{From: "Linux/ARM/v7", Expected: Platform{OS: "linux", Architecture: "arm", Variant: "v7"}, String: "linux/arm/v7"},
+		{From: "linux", Err: true},
+		{From: "linux/", Err: true},
+		{From: "linux/arm/v7/extra", Err: true},
+	}
+	for _, testCase := range testCases {
+		platform, err := ParsePlatform(testCase.From)
+		if (err != nil) != testCase.Err {
+			t.Errorf("%s: unexpected error: %v", testCase.From, err)
+			continue
+		}
+		if platform != testCase.Expected {
+			t.Errorf("%s: expected %#v, got %#v", testCase.From, testCase.Expected, platform)
+		}
+		if err == nil && platform.String() != testCase.String {
+			t.Errorf("%s: expected %s, got %s", testCase.From, testCase.String, platform.String())
+		}
+	}
+}
+
+func TestPlatformMatches(t *testing.T) {
+	testCases := []struct {
+		Platform, Other Platform
+		Matches         bool
+	}{
+		{Platform: Platform{OS: "linux", Architecture: "amd64"}, Other: Platform{OS: "linux", Architecture: "amd64"}, Matches: true},
+		{Platform: Platform{OS: "linux", Architecture: "amd64"}, Other: Platform{OS: "linux", Architecture: "x86_64"}, Matches: true},
+		{Platform: Platform{OS: "linux", Architecture: "amd64"}, Other: Platform{OS: "windows", Architecture: "amd64"}},
+		{Platform: Platform{OS: "linux", Architecture: "arm64"}, Other: Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}, Matches: true},
+		{Platform: Platform{OS: "linux", Architecture: "aarch64"}, Other: Platform{OS: "linux", Architecture: "arm64"}, Matches: true},
+		{Platform: Platform{OS: "linux", Architecture: "arm"}, Other: Platform{OS: "linux", Architecture: "arm", Variant: "v7"}, Matches: true},
+		{Platform: Platform{OS: "linux", Architecture: "arm", Variant: "v6"}, Other: Platform{OS: "linux", Architecture: "arm", Variant: "v7"}},
+		{Platform: Platform{OS: "linux", Architecture: "arm64"}, Other: Platform{OS: "linux", Architecture: "arm"}},
+		{Platform: Platform{OS: "linux", Architecture: "ppc64le"}, Other: Platform{OS: "linux", Architecture: "s390x"}},
+	}
+	for _, testCase := range testCases {
+		if actual := testCase.Platform.Matches(testCase.Other); actual != testCase.Matches {
+			t.Errorf("%s matches %s: expected %t, got %t", testCase.Platform, testCase.Other, testCase.Matches, actual)
+		}
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"k8s.io/klog/v2"
 
 	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest/manifestlist"
 	"github.com/distribution/distribution/v3/manifest/schema1"
 	"github.com/distribution/distribution/v3/reference"
 	"github.com/distribution/distribution/v3/registry/api/errcode"
@@ -302,7 +303,14 @@ func (c *Context) connectToRegistry(ctx context.Context, locator repositoryLocat
 	if limiter == nil {
 		limiter = rate.NewLimiter(rate.Limit(5), 5)
 	}
-	return NewLimitedRetryRepository(locator.ref, repo, c.Retries, limiter), nil
+	return &httpRepository{
+		RepositoryWithLocation: NewLimitedRetryRepository(locator.ref, repo, c.Retries, limiter),
+
+		client:  &http.Client{Transport: rt},
+		baseURL: src,
+		name:    path,
+		limiter: limiter,
+	}, nil
 }
 
 func (c *Context) ping(registry url.URL, insecure bool, transport http.RoundTripper) (*url.URL, error) {
@@ -730,6 +738,16 @@ func ContentDigestForManifest(manifest distribution.Manifest, algo digest.Algori
 			return "", fmt.Errorf("the schema1 manifest does not have a canonical representation")
 		}
 		return algo.FromBytes(t.Canonical), nil
+	case *DeserializedOCIManifest, *DeserializedArtifactManifest, *manifestlist.DeserializedManifestList:
+		// OCI manifests and indexes are identified by the exact bytes the registry served
+		_, payload, err := manifest.Payload()
+		if err != nil {
+			return "", err
+		}
+		if len(payload) == 0 {
+			return "", fmt.Errorf("the %T does not have a canonical representation", manifest)
+		}
+		return algo.FromBytes(payload), nil
 	default:
 		_, payload, err := manifest.Payload()
 		if err != nil {
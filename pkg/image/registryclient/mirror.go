@@ -0,0 +1,428 @@
+package registryclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"k8s.io/klog/v2"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest/manifestlist"
+	"github.com/distribution/distribution/v3/manifest/schema1"
+	"github.com/distribution/distribution/v3/manifest/schema2"
+	"github.com/distribution/distribution/v3/registry/client/auth"
+	"github.com/opencontainers/go-digest"
+
+	imagereference "github.com/openshift/library-go/pkg/image/reference"
+)
+
+const (
+	defaultParallelBlobs  = 4
+	defaultChunkSize      = 8 * 1024 * 1024
+	defaultUploadAttempts = 3
+)
+
+// MirrorOptions controls how Mirror copies an image.
+type MirrorOptions struct {
+	// FromInsecure allows HTTP and unverified HTTPS connections to the source registry.
+	FromInsecure bool
+	// ToInsecure allows HTTP and unverified HTTPS connections to the destination registry.
+	ToInsecure bool
+
+	// Platform copies only the image for this platform when the source is a manifest list. The
+	// whole manifest list is copied if it is nil.
+	Platform *imagereference.Platform
+
+	// ParallelBlobs is how many blobs are transferred at once. Defaults to 4.
+	ParallelBlobs int
+	// ChunkSize is the number of bytes sent in each upload request. An interrupted upload is
+	// resumed from the last byte the destination registry stored. Defaults to 8MiB.
+	ChunkSize int64
+	// UploadAttempts is how many times each chunk of a blob transfer is attempted before failing. Defaults to 3.
+	UploadAttempts int
+}
+
+// MirrorResult describes what Mirror copied.
+type MirrorResult struct {
+	// Digest is the digest of the manifest written to the destination.
+	Digest digest.Digest
+	// Manifests is the number of manifests written to the destination.
+	Manifests int
+	// BlobsExisting is the number of blobs that were already in the destination repository.
+	BlobsExisting int
+	// BlobsMounted is the number of blobs mounted from the source repository by the destination registry.
+	BlobsMounted int
+	// BlobsCopied is the number of blobs uploaded to the destination.
+	BlobsCopied int
+	// BytesCopied is the number of bytes uploaded to the destination.
+	BytesCopied int64
+}
+
+// Mirror copies the image from into the repository of to, tagging it with the tag of to. The
+// source is read through the alternate blob source strategy of the context, so an image can be
+// copied out of a mirror. Blobs are mounted when both repositories are on the same registry, and
+// otherwise streamed and checked against their digest. Manifests are written after all the blobs
+// they reference are present.
+func (c *Context) Mirror(ctx context.Context, from, to imagereference.DockerImageReference, options MirrorOptions) (*MirrorResult, error) {
+	if options.ParallelBlobs <= 0 {
+		options.ParallelBlobs = defaultParallelBlobs
+	}
+	if options.ChunkSize <= 0 {
+		options.ChunkSize = defaultChunkSize
+	}
+	if options.UploadAttempts <= 0 {
+		options.UploadAttempts = defaultUploadAttempts
+	}
+
+	src, err := c.Repository(ctx, from.RegistryURL(), from.RepositoryName(), options.FromInsecure)
+	if err != nil {
+		return nil, err
+	}
+	srcManifests, err := src.Manifests(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the destination is written directly and never through a mirror
+	toContext := c.Copy().WithActions("pull", "push").WithRequestModifiers(c.RequestModifiers...)
+	mountFrom := ""
+	if from.Registry == to.Registry && from.RepositoryName() != to.RepositoryName() {
+		mountFrom = from.RepositoryName()
+		toContext = toContext.WithScopes(append(append([]auth.Scope{}, c.Scopes...), auth.RepositoryScope{Repository: mountFrom, Actions: []string{"pull"}})...)
+	}
+	dst, err := toContext.RepositoryForRef(ctx, to.AsRepository(), options.ToInsecure)
+	if err != nil {
+		return nil, err
+	}
+	uploader, ok := dst.(*httpRepository)
+	if !ok {
+		return nil, fmt.Errorf("the repository %s does not support uploads", to.AsRepository().Exact())
+	}
+	dstManifests, err := dst.Manifests(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &mirror{
+		options:   options,
+		src:       src,
+		dst:       uploader,
+		mountFrom: mountFrom,
+		blobs:     make(map[digest.Digest]distribution.Descriptor),
+	}
+
+	var root distribution.Manifest
+	var rootDigest digest.Digest
+	if len(from.ID) > 0 {
+		rootDigest = digest.Digest(from.ID)
+		root, err = srcManifests.Get(ctx, rootDigest)
+	} else {
+		tag := from.Tag
+		if len(tag) == 0 {
+			tag = "latest"
+		}
+		root, err = srcManifests.Get(ctx, "", distribution.WithTag(tag))
+		if err == nil {
+			rootDigest, err = ContentDigestForManifest(root, digest.SHA256)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve the manifest for %s: %w", from.Exact(), err)
+	}
+	if options.Platform != nil {
+		root, rootDigest, err = ResolvePlatform(ctx, srcManifests, root, rootDigest, *options.Platform)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(to.ID) > 0 && digest.Digest(to.ID) != rootDigest {
+		return nil, fmt.Errorf("the destination %s does not match the source digest %s", to.Exact(), rootDigest)
+	}
+
+	if err := m.plan(ctx, srcManifests, root, rootDigest); err != nil {
+		return nil, err
+	}
+	if err := m.copyBlobs(ctx); err != nil {
+		return nil, err
+	}
+
+	for i, manifest := range m.manifests {
+		var options []distribution.ManifestServiceOption
+		if i == len(m.manifests)-1 && len(to.Tag) > 0 {
+			options = append(options, distribution.WithTag(to.Tag))
+		}
+		if _, err := dstManifests.Put(ctx, manifest.manifest, options...); err != nil {
+			return nil, fmt.Errorf("unable to write manifest %s to %s: %w", manifest.digest, to.AsRepository().Exact(), err)
+		}
+		klog.V(4).Infof("Wrote manifest %s to %s", manifest.digest, to.AsRepository().Exact())
+	}
+
+	return &MirrorResult{
+		Digest:        rootDigest,
+		Manifests:     len(m.manifests),
+		BlobsExisting: int(m.existing.Load()),
+		BlobsMounted:  int(m.mounted.Load()),
+		BlobsCopied:   int(m.copied.Load()),
+		BytesCopied:   m.bytes.Load(),
+	}, nil
+}
+
+// mirrorManifest is a manifest to write to the destination.
+type mirrorManifest struct {
+	manifest distribution.Manifest
+	digest   digest.Digest
+}
+
+// mirror holds the state of a single Mirror call.
+type mirror struct {
+	options   MirrorOptions
+	src       distribution.Repository
+	dst       *httpRepository
+	mountFrom string
+
+	// manifests are in the order they must be written, the manifests of a list before the list.
+	manifests []mirrorManifest
+	// blobs are the blobs referenced by the manifests.
+	blobs map[digest.Digest]distribution.Descriptor
+
+	existing, mounted, copied atomic.Int32
+	bytes                     atomic.Int64
+}
+
+// plan records the manifests and blobs that make up the image, children first.
+func (m *mirror) plan(ctx context.Context, ms distribution.ManifestService, manifest distribution.Manifest, dgst digest.Digest) error {
+	return m.planDepth(ctx, ms, manifest, dgst, 0)
+}
+
+func (m *mirror) planDepth(ctx context.Context, ms distribution.ManifestService, manifest distribution.Manifest, dgst digest.Digest, depth int) error {
+	switch t := manifest.(type) {
+	case *manifestlist.DeserializedManifestList:
+		if depth == maxIndexDepth {
+			return fmt.Errorf("manifest lists are nested more than %d deep", maxIndexDepth)
+		}
+		for _, descriptor := range t.Manifests {
+			child, err := ms.Get(ctx, descriptor.Digest)
+			if err != nil {
+				return fmt.Errorf("unable to retrieve manifest %s: %w", descriptor.Digest, err)
+			}
+			if err := m.planDepth(ctx, ms, child, descriptor.Digest, depth+1); err != nil {
+				return err
+			}
+		}
+	case *schema1.SignedManifest:
+		return fmt.Errorf("manifest %s is a schema1 manifest, which cannot be mirrored", dgst)
+	case *schema2.DeserializedManifest, *DeserializedOCIManifest, *DeserializedArtifactManifest:
+		for _, descriptor := range manifest.References() {
+			if len(descriptor.Digest) == 0 || isForeignLayer(descriptor) {
+				continue
+			}
+			m.blobs[descriptor.Digest] = descriptor
+		}
+	default:
+		return fmt.Errorf("manifest %s has unsupported type %T", dgst, manifest)
+	}
+	m.manifests = append(m.manifests, mirrorManifest{manifest: manifest, digest: dgst})
+	return nil
+}
+
+// isForeignLayer returns true for layers that registries do not store and must not be copied.
+func isForeignLayer(descriptor distribution.Descriptor) bool {
+	return descriptor.MediaType == schema2.MediaTypeForeignLayer || strings.Contains(descriptor.MediaType, "nondistributable")
+}
+
+// copyBlobs transfers the planned blobs in parallel, returning the first error.
+func (m *mirror) copyBlobs(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	work := make(chan distribution.Descriptor)
+	errs := make(chan error, len(m.blobs))
+	var wg sync.WaitGroup
+	for i := 0; i < m.options.ParallelBlobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, m.options.ChunkSize)
+			for descriptor := range work {
+				if err := m.copyBlob(ctx, descriptor, buf); err != nil {
+					errs <- fmt.Errorf("unable to copy blob %s: %w", descriptor.Digest, err)
+					cancel()
+				}
+			}
+		}()
+	}
+	for _, descriptor := range m.blobs {
+		select {
+		case work <- descriptor:
+		case <-ctx.Done():
+		}
+	}
+	close(work)
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// copyBlob ensures a blob is in the destination repository by mounting or uploading it.
+func (m *mirror) copyBlob(ctx context.Context, descriptor distribution.Descriptor, buf []byte) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if _, err := m.dst.Blobs(ctx).Stat(ctx, descriptor.Digest); err == nil {
+		m.existing.Add(1)
+		return nil
+	} else if !errors.Is(err, distribution.ErrBlobUnknown) {
+		return err
+	}
+
+	location, mounted, err := m.dst.startUpload(ctx, descriptor.Digest, m.mountFrom)
+	if err != nil {
+		return err
+	}
+	if mounted {
+		klog.V(5).Infof("Mounted blob %s from %s", descriptor.Digest, m.mountFrom)
+		m.mounted.Add(1)
+		return nil
+	}
+
+	size := descriptor.Size
+	if size <= 0 {
+		stat, err := m.src.Blobs(ctx).Stat(ctx, descriptor.Digest)
+		if err != nil {
+			return err
+		}
+		size = stat.Size
+	}
+
+	source := &blobSource{blobs: m.src.Blobs(ctx), dgst: descriptor.Digest}
+	defer source.close()
+
+	var offset int64
+	attempts := 1
+	for offset < size {
+		n := int64(len(buf))
+		if remaining := size - offset; remaining < n {
+			n = remaining
+		}
+		err := source.seek(ctx, offset)
+		if err == nil {
+			_, err = io.ReadFull(source, buf[:n])
+		}
+		if err != nil {
+			// the source cannot be resumed at an offset without losing verification, so it is reopened
+			source.close()
+			if isIntegrityError(err) || attempts >= m.options.UploadAttempts {
+				return err
+			}
+			attempts++
+			klog.V(4).Infof("Reading blob %s failed at offset %d, retrying: %v", descriptor.Digest, offset, err)
+			continue
+		}
+
+		next, err := m.dst.uploadChunk(ctx, location, offset, buf[:n])
+		if err != nil {
+			if attempts >= m.options.UploadAttempts || errors.Is(err, distribution.ErrBlobUploadUnknown) || ctx.Err() != nil {
+				return err
+			}
+			attempts++
+			resumed, stored, statusErr := m.dst.uploadStatus(ctx, location)
+			if statusErr != nil {
+				return fmt.Errorf("unable to resume upload after %v: %w", err, statusErr)
+			}
+			klog.V(4).Infof("Upload of blob %s was interrupted at offset %d, resuming at %d: %v", descriptor.Digest, offset+n, stored, err)
+			if stored > offset {
+				m.bytes.Add(stored - offset)
+			}
+			location, offset = resumed, stored
+			continue
+		}
+		location = next
+		offset += n
+		m.bytes.Add(n)
+		// the attempts are counted per chunk, a long upload may be interrupted more often than a short one
+		attempts = 1
+	}
+
+	// reading to the end of the source verifies its digest
+	if err := source.verify(ctx, size); err != nil {
+		return err
+	}
+	if err := m.dst.commitUpload(ctx, location, descriptor.Digest); err != nil {
+		return err
+	}
+	m.copied.Add(1)
+	return nil
+}
+
+// blobSource reads a blob from the source repository sequentially, so the verifying blob store
+// can check its digest. Moving to an offset other than the current one reopens the blob and
+// reads up to the offset.
+type blobSource struct {
+	blobs  distribution.BlobStore
+	dgst   digest.Digest
+	r      io.ReadCloser
+	offset int64
+}
+
+func (s *blobSource) seek(ctx context.Context, offset int64) error {
+	if s.r != nil && s.offset == offset {
+		return nil
+	}
+	if s.r == nil || offset < s.offset {
+		s.close()
+		r, err := s.blobs.Open(ctx, s.dgst)
+		if err != nil {
+			return err
+		}
+		s.r, s.offset = r, 0
+	}
+	n, err := io.CopyN(io.Discard, s.r, offset-s.offset)
+	s.offset += n
+	return err
+}
+
+func (s *blobSource) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.offset += int64(n)
+	return n, err
+}
+
+// verify reads past the end of the blob, which returns an error from the verifying blob store if
+// the content did not match the digest.
+func (s *blobSource) verify(ctx context.Context, size int64) error {
+	if err := s.seek(ctx, size); err != nil {
+		return err
+	}
+	n, err := s.r.Read(make([]byte, 1))
+	switch {
+	case n > 0:
+		return fmt.Errorf("the blob %s is larger than %d bytes", s.dgst, size)
+	case err == io.EOF:
+		return nil
+	case err == nil:
+		return fmt.Errorf("the blob %s did not end after %d bytes", s.dgst, size)
+	default:
+		return err
+	}
+}
+
+func (s *blobSource) close() {
+	if s.r != nil {
+		s.r.Close()
+		s.r = nil
+	}
+}
+
+// isIntegrityError returns true if content did not match its digest, which retrying cannot fix.
+func isIntegrityError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "content integrity error")
+}
@@ -0,0 +1,470 @@
+package registryclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest/manifestlist"
+	"github.com/distribution/distribution/v3/manifest/schema2"
+	"github.com/opencontainers/go-digest"
+
+	"k8s.io/client-go/rest"
+
+	imagereference "github.com/openshift/library-go/pkg/image/reference"
+)
+
+type registryManifest struct {
+	mediaType string
+	content   []byte
+}
+
+// fakeUploadRegistry implements the parts of the registry API used to pull and push images.
+type fakeUploadRegistry struct {
+	lock      sync.Mutex
+	manifests map[string]map[string]registryManifest
+	blobs     map[string]map[digest.Digest][]byte
+	uploads   map[string][]byte
+	nextID    int
+
+	// failPatches is the number of PATCH requests that store half of their data and then fail.
+	failPatches int
+	// failAlternatePatches makes every second PATCH request of each upload store half of its data and then fail.
+	failAlternatePatches bool
+	// patches counts the PATCH requests by upload.
+	patches map[string]int
+	// requests counts requests by method and kind.
+	requests map[string]int
+}
+
+func newFakeUploadRegistry() *fakeUploadRegistry {
+	return &fakeUploadRegistry{
+		manifests: make(map[string]map[string]registryManifest),
+		blobs:     make(map[string]map[digest.Digest][]byte),
+		uploads:   make(map[string][]byte),
+		requests:  make(map[string]int),
+		patches:   make(map[string]int),
+	}
+}
+
+func (r *fakeUploadRegistry) addBlob(repo string, data []byte) distribution.Descriptor {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.blobs[repo] == nil {
+		r.blobs[repo] = make(map[digest.Digest][]byte)
+	}
+	dgst := digest.FromBytes(data)
+	r.blobs[repo][dgst] = data
+	return distribution.Descriptor{MediaType: schema2.MediaTypeLayer, Digest: dgst, Size: int64(len(data))}
+}
+
+func (r *fakeUploadRegistry) addManifest(t *testing.T, repo, tag string, m distribution.Manifest) distribution.Descriptor {
+	t.Helper()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	mediaType, payload, err := m.Payload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.manifests[repo] == nil {
+		r.manifests[repo] = make(map[string]registryManifest)
+	}
+	dgst := digest.FromBytes(payload)
+	r.manifests[repo][dgst.String()] = registryManifest{mediaType: mediaType, content: payload}
+	if len(tag) > 0 {
+		r.manifests[repo][tag] = registryManifest{mediaType: mediaType, content: payload}
+	}
+	return distribution.Descriptor{MediaType: mediaType, Digest: dgst, Size: int64(len(payload))}
+}
+
+func (r *fakeUploadRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if req.URL.Path == "/v2/" {
+		return
+	}
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	for _, kind := range []string{"/blobs/uploads/", "/manifests/", "/blobs/"} {
+		i := strings.Index(path, kind)
+		if i == -1 {
+			continue
+		}
+		repo, rest := path[:i], path[i+len(kind):]
+		r.requests[req.Method+" "+strings.Trim(kind, "/")]++
+		switch kind {
+		case "/blobs/uploads/":
+			r.serveUpload(w, req, repo, rest)
+		case "/manifests/":
+			r.serveManifest(w, req, repo, rest)
+		case "/blobs/":
+			data, ok := r.blobs[repo][digest.Digest(rest)]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			w.Header().Set("Docker-Content-Digest", rest)
+			if req.Method == http.MethodGet {
+				w.Write(data)
+			}
+		}
+		return
+	}
+	http.Error(w, "unexpected request", http.StatusBadRequest)
+}
+
+func (r *fakeUploadRegistry) serveManifest(w http.ResponseWriter, req *http.Request, repo, reference string) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		m, ok := r.manifests[repo][reference]
+		if !ok {
+			http.Error(w, `{"errors":[{"code":"MANIFEST_UNKNOWN"}]}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", m.mediaType)
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes(m.content).String())
+		w.Write(m.content)
+	case http.MethodPut:
+		content, _ := io.ReadAll(req.Body)
+		m, _, err := distribution.UnmarshalManifest(req.Header.Get("Content-Type"), content)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, descriptor := range m.References() {
+			if _, ok := r.manifests[repo][descriptor.Digest.String()]; ok {
+				continue
+			}
+			if _, ok := r.blobs[repo][descriptor.Digest]; !ok {
+				http.Error(w, `{"errors":[{"code":"BLOB_UNKNOWN"}]}`, http.StatusBadRequest)
+				return
+			}
+		}
+		if r.manifests[repo] == nil {
+			r.manifests[repo] = make(map[string]registryManifest)
+		}
+		dgst := digest.FromBytes(content)
+		r.manifests[repo][dgst.String()] = registryManifest{mediaType: req.Header.Get("Content-Type"), content: content}
+		r.manifests[repo][reference] = registryManifest{mediaType: req.Header.Get("Content-Type"), content: content}
+		w.Header().Set("Docker-Content-Digest", dgst.String())
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+func (r *fakeUploadRegistry) serveUpload(w http.ResponseWriter, req *http.Request, repo, id string) {
+	switch req.Method {
+	case http.MethodPost:
+		if from, mount := req.URL.Query().Get("from"), digest.Digest(req.URL.Query().Get("mount")); len(from) > 0 {
+			if data, ok := r.blobs[from][mount]; ok {
+				if r.blobs[repo] == nil {
+					r.blobs[repo] = make(map[digest.Digest][]byte)
+				}
+				r.blobs[repo][mount] = data
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+		}
+		r.nextID++
+		id := strconv.Itoa(r.nextID)
+		r.uploads[id] = []byte{}
+		w.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/uploads/%s", repo, id))
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodPatch:
+		data, ok := r.uploads[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var start, end int
+		if _, err := fmt.Sscanf(req.Header.Get("Content-Range"), "%d-%d", &start, &end); err != nil || start != len(data) {
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		chunk, _ := io.ReadAll(req.Body)
+		r.patches[id]++
+		if r.failPatches > 0 || (r.failAlternatePatches && r.patches[id]%2 == 0) {
+			if r.failPatches > 0 {
+				r.failPatches--
+			}
+			r.uploads[id] = append(data, chunk[:len(chunk)/2]...)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		r.uploads[id] = append(data, chunk...)
+		w.Header().Set("Location", req.URL.Path)
+		w.Header().Set("Range", fmt.Sprintf("0-%d", len(r.uploads[id])-1))
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodGet:
+		data, ok := r.uploads[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if len(data) > 0 {
+			w.Header().Set("Range", fmt.Sprintf("0-%d", len(data)-1))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodPut:
+		data, ok := r.uploads[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		dgst := digest.Digest(req.URL.Query().Get("digest"))
+		if digest.FromBytes(data) != dgst {
+			http.Error(w, `{"errors":[{"code":"DIGEST_INVALID"}]}`, http.StatusBadRequest)
+			return
+		}
+		delete(r.uploads, id)
+		if r.blobs[repo] == nil {
+			r.blobs[repo] = make(map[digest.Digest][]byte)
+		}
+		r.blobs[repo][dgst] = data
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+func (r *fakeUploadRegistry) addImage(t *testing.T, repo, tag string, layers ...string) distribution.Descriptor {
+	t.Helper()
+	config := r.addBlob(repo, []byte(fmt.Sprintf(`{"layers":%d}`, len(layers))))
+	config.MediaType = schema2.MediaTypeImageConfig
+	m := schema2.Manifest{Versioned: schema2.SchemaVersion, Config: config}
+	for _, layer := range layers {
+		m.Layers = append(m.Layers, r.addBlob(repo, []byte(layer)))
+	}
+	deserialized, err := schema2.FromStruct(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r.addManifest(t, repo, tag, deserialized)
+}
+
+func TestMirror(t *testing.T) {
+	ctx := context.Background()
+	insecureTransport, err := rest.TransportFor(&rest.Config{TLSClientConfig: rest.TLSClientConfig{Insecure: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name          string
+		sameRegistry  bool
+		setup         func(t *testing.T, source, destination *fakeUploadRegistry) string
+		platform      string
+		expected      MirrorResult
+		expectedErr   string
+		expectedBlobs []string
+	}{
+		{
+			name: "resume interrupted uploads",
+			setup: func(t *testing.T, source, destination *fakeUploadRegistry) string {
+				source.addImage(t, "ns/source", "latest", "first layer", "second layer")
+				destination.failPatches = 2
+				return "latest"
+			},
+			expected:      MirrorResult{Manifests: 1, BlobsCopied: 3, BytesCopied: int64(len(`{"layers":2}`) + len("first layer") + len("second layer"))},
+			expectedBlobs: []string{"first layer", "second layer"},
+		},
+		{
+			name: "retry every chunk of a long upload",
+			setup: func(t *testing.T, source, destination *fakeUploadRegistry) string {
+				source.addImage(t, "ns/source", "latest", "a layer which is uploaded in many chunks")
+				destination.failAlternatePatches = true
+				return "latest"
+			},
+			expected:      MirrorResult{Manifests: 1, BlobsCopied: 2, BytesCopied: int64(len(`{"layers":1}`) + len("a layer which is uploaded in many chunks"))},
+			expectedBlobs: []string{"a layer which is uploaded in many chunks"},
+		},
+		{
+			name:         "mount blobs on the same registry",
+			sameRegistry: true,
+			setup: func(t *testing.T, source, destination *fakeUploadRegistry) string {
+				source.addImage(t, "ns/source", "latest", "first layer")
+				return "latest"
+			},
+			expected:      MirrorResult{Manifests: 1, BlobsMounted: 2},
+			expectedBlobs: []string{"first layer"},
+		},
+		{
+			name: "manifest list with shared layers and existing blobs",
+			setup: func(t *testing.T, source, destination *fakeUploadRegistry) string {
+				amd64 := source.addImage(t, "ns/source", "", "shared", "amd64")
+				arm64 := source.addImage(t, "ns/source", "", "shared", "arm64")
+				destination.addBlob("ns/destination", []byte("shared"))
+				list, err := manifestlist.FromDescriptors([]manifestlist.ManifestDescriptor{
+					{Descriptor: amd64, Platform: manifestlist.PlatformSpec{OS: "linux", Architecture: "amd64"}},
+					{Descriptor: arm64, Platform: manifestlist.PlatformSpec{OS: "linux", Architecture: "arm64"}},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				source.addManifest(t, "ns/source", "multi", list)
+				return "multi"
+			},
+			// the config of both images is the same blob
+			expected:      MirrorResult{Manifests: 3, BlobsExisting: 1, BlobsCopied: 3, BytesCopied: int64(len(`{"layers":2}`) + len("amd64") + len("arm64"))},
+			expectedBlobs: []string{"shared", "amd64", "arm64"},
+		},
+		{
+			name: "single platform of a manifest list",
+			setup: func(t *testing.T, source, destination *fakeUploadRegistry) string {
+				amd64 := source.addImage(t, "ns/source", "", "amd64")
+				arm64 := source.addImage(t, "ns/source", "", "arm64", "more")
+				list, err := manifestlist.FromDescriptors([]manifestlist.ManifestDescriptor{
+					{Descriptor: amd64, Platform: manifestlist.PlatformSpec{OS: "linux", Architecture: "amd64"}},
+					{Descriptor: arm64, Platform: manifestlist.PlatformSpec{OS: "linux", Architecture: "arm64"}},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				source.addManifest(t, "ns/source", "multi", list)
+				return "multi"
+			},
+			platform:      "linux/arm64",
+			expected:      MirrorResult{Manifests: 1, BlobsCopied: 3, BytesCopied: int64(len(`{"layers":2}`) + len("arm64") + len("more"))},
+			expectedBlobs: []string{"arm64", "more"},
+		},
+		{
+			name: "corrupt source blob",
+			setup: func(t *testing.T, source, destination *fakeUploadRegistry) string {
+				image := source.addImage(t, "ns/source", "latest", "layer")
+				source.blobs["ns/source"][digest.FromString("layer")] = []byte("LAYER")
+				return image.Digest.String()
+			},
+			expectedErr: "content integrity error",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			source := newFakeUploadRegistry()
+			sourceServer := httptest.NewTLSServer(source)
+			defer sourceServer.Close()
+			destination, destinationServer := source, sourceServer
+			if !testCase.sameRegistry {
+				destination = newFakeUploadRegistry()
+				destinationServer = httptest.NewTLSServer(destination)
+				defer destinationServer.Close()
+			}
+			tagOrDigest := testCase.setup(t, source, destination)
+
+			separator := ":"
+			if strings.HasPrefix(tagOrDigest, "sha256:") {
+				separator = "@"
+			}
+			from, err := imagereference.Parse(fmt.Sprintf("%s/ns/source%s%s", sourceServer.Listener.Addr().String(), separator, tagOrDigest))
+			if err != nil {
+				t.Fatal(err)
+			}
+			to, err := imagereference.Parse(fmt.Sprintf("%s/ns/destination:mirrored", destinationServer.Listener.Addr().String()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			options := MirrorOptions{FromInsecure: true, ToInsecure: true, ChunkSize: 4, ParallelBlobs: 2}
+			if len(testCase.platform) > 0 {
+				platform, err := imagereference.ParsePlatform(testCase.platform)
+				if err != nil {
+					t.Fatal(err)
+				}
+				options.Platform = &platform
+			}
+
+			result, err := NewContext(http.DefaultTransport, insecureTransport).WithRateLimiter(unlimited).Mirror(ctx, from, to, options)
+			if len(testCase.expectedErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), testCase.expectedErr) {
+					t.Fatalf("expected error %q, got %v", testCase.expectedErr, err)
+				}
+				if len(destination.manifests["ns/destination"]) > 0 {
+					t.Errorf("expected no manifests to be written, got %v", destination.manifests["ns/destination"])
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mirrored, ok := destination.manifests["ns/destination"]["mirrored"]
+			if !ok {
+				t.Fatalf("expected the destination to be tagged, got %v", destination.manifests["ns/destination"])
+			}
+			testCase.expected.Digest = digest.FromBytes(mirrored.content)
+			if *result != testCase.expected {
+				t.Errorf("expected %#v, got %#v", testCase.expected, *result)
+			}
+			for _, blob := range testCase.expectedBlobs {
+				if data := destination.blobs["ns/destination"][digest.FromString(blob)]; !bytes.Equal(data, []byte(blob)) {
+					t.Errorf("expected blob %q in the destination, got %q", blob, string(data))
+				}
+			}
+			if len(destination.uploads) > 0 {
+				t.Errorf("expected all uploads to be committed, got %d open", len(destination.uploads))
+			}
+		})
+	}
+}
+
+func TestMirrorFromAlternate(t *testing.T) {
+	ctx := context.Background()
+	insecureTransport, err := rest.TransportFor(&rest.Config{TLSClientConfig: rest.TLSClientConfig{Insecure: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := newFakeUploadRegistry()
+	sourceServer := httptest.NewTLSServer(source)
+	defer sourceServer.Close()
+	mirror := newFakeUploadRegistry()
+	mirrorServer := httptest.NewTLSServer(mirror)
+	defer mirrorServer.Close()
+	destination := newFakeUploadRegistry()
+	destinationServer := httptest.NewTLSServer(destination)
+	defer destinationServer.Close()
+
+	image := mirror.addImage(t, "mirror/source", "", "layer")
+	from, err := imagereference.Parse(fmt.Sprintf("%s/ns/source@%s", sourceServer.Listener.Addr().String(), image.Digest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	alternate, err := imagereference.Parse(fmt.Sprintf("%s/mirror/source", mirrorServer.Listener.Addr().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := imagereference.Parse(fmt.Sprintf("%s/ns/destination@%s", destinationServer.Listener.Addr().String(), image.Digest))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewContext(http.DefaultTransport, insecureTransport).WithRateLimiter(unlimited).WithAlternateBlobSourceStrategy(&fakeAlternateBlobStrategy{
+		FirstAlternates: []imagereference.DockerImageReference{alternate},
+	})
+	result, err := c.Mirror(ctx, from, to, MirrorOptions{FromInsecure: true, ToInsecure: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Digest != image.Digest || result.BlobsCopied != 2 {
+		t.Errorf("unexpected result %#v", result)
+	}
+	if _, ok := destination.manifests["ns/destination"][image.Digest.String()]; !ok {
+		t.Errorf("expected the manifest to be written by digest")
+	}
+	if len(source.requests) > 0 {
+		t.Errorf("expected all reads to go to the mirror, the source received %v", source.requests)
+	}
+
+	var written schema2.Manifest
+	if err := json.Unmarshal(destination.manifests["ns/destination"][image.Digest.String()].content, &written); err != nil {
+		t.Fatal(err)
+	}
+	if len(written.Layers) != 1 || written.Layers[0].Digest != digest.FromString("layer") {
+		t.Errorf("unexpected manifest %#v", written)
+	}
+}
@@ -0,0 +1,210 @@
+package registryclient
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest"
+	"github.com/distribution/distribution/v3/manifest/manifestlist"
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	// MediaTypeImageManifest is the media type of an OCI image manifest.
+	MediaTypeImageManifest = "application/vnd.oci.image.manifest.v1+json"
+	// MediaTypeImageIndex is the media type of an OCI image index.
+	MediaTypeImageIndex = "application/vnd.oci.image.index.v1+json"
+	// MediaTypeArtifactManifest is the media type of an OCI artifact manifest. Artifact manifests were
+	// dropped from the final OCI 1.1 specification but are still served by registries that accepted them.
+	MediaTypeArtifactManifest = "application/vnd.oci.artifact.manifest.v1+json"
+)
+
+func init() {
+	ociFunc := func(b []byte) (distribution.Manifest, distribution.Descriptor, error) {
+		m := new(DeserializedOCIManifest)
+		if err := m.UnmarshalJSON(b); err != nil {
+			return nil, distribution.Descriptor{}, err
+		}
+		return m, distribution.Descriptor{Digest: digest.FromBytes(b), Size: int64(len(b)), MediaType: MediaTypeImageManifest}, nil
+	}
+	if err := distribution.RegisterManifestSchema(MediaTypeImageManifest, ociFunc); err != nil {
+		panic(fmt.Sprintf("Unable to register OCI image manifest: %s", err))
+	}
+
+	artifactFunc := func(b []byte) (distribution.Manifest, distribution.Descriptor, error) {
+		m := new(DeserializedArtifactManifest)
+		if err := m.UnmarshalJSON(b); err != nil {
+			return nil, distribution.Descriptor{}, err
+		}
+		return m, distribution.Descriptor{Digest: digest.FromBytes(b), Size: int64(len(b)), MediaType: MediaTypeArtifactManifest}, nil
+	}
+	if err := distribution.RegisterManifestSchema(MediaTypeArtifactManifest, artifactFunc); err != nil {
+		panic(fmt.Sprintf("Unable to register OCI artifact manifest: %s", err))
+	}
+}
+
+// OCIManifest is an OCI image manifest. Artifacts such as cosign signatures are stored as image manifests
+// with an artifact type, and may point at the manifest they describe with a subject.
+type OCIManifest struct {
+	manifest.Versioned
+
+	// ArtifactType is the type of an artifact when the manifest is used for one.
+	ArtifactType string `json:"artifactType,omitempty"`
+
+	// Config references the configuration as a blob.
+	Config distribution.Descriptor `json:"config"`
+
+	// Layers lists descriptors for the layers referenced by the manifest.
+	Layers []distribution.Descriptor `json:"layers"`
+
+	// Subject is the manifest this manifest refers to, if any.
+	Subject *distribution.Descriptor `json:"subject,omitempty"`
+
+	// Annotations contains arbitrary metadata for the manifest.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// References returns the descriptors of this manifests references.
+func (m OCIManifest) References() []distribution.Descriptor {
+	references := make([]distribution.Descriptor, 0, 1+len(m.Layers))
+	references = append(references, m.Config)
+	references = append(references, m.Layers...)
+	return references
+}
+
+// DeserializedOCIManifest wraps OCIManifest with a copy of the original JSON.
+// It satisfies the distribution.Manifest interface.
+type DeserializedOCIManifest struct {
+	OCIManifest
+
+	// canonical is the canonical byte representation of the manifest.
+	canonical []byte
+}
+
+// UnmarshalJSON populates a new OCIManifest struct from JSON data.
+func (m *DeserializedOCIManifest) UnmarshalJSON(b []byte) error {
+	m.canonical = make([]byte, len(b))
+	copy(m.canonical, b)
+
+	var mfst OCIManifest
+	if err := json.Unmarshal(m.canonical, &mfst); err != nil {
+		return err
+	}
+	if mfst.MediaType != "" && mfst.MediaType != MediaTypeImageManifest {
+		return fmt.Errorf("if present, mediaType in image manifest should be '%s' not '%s'", MediaTypeImageManifest, mfst.MediaType)
+	}
+	m.OCIManifest = mfst
+	return nil
+}
+
+// MarshalJSON returns the contents of canonical. If canonical is empty,
+// marshals the inner contents.
+func (m *DeserializedOCIManifest) MarshalJSON() ([]byte, error) {
+	if len(m.canonical) > 0 {
+		return m.canonical, nil
+	}
+	return nil, fmt.Errorf("JSON representation not initialized in DeserializedOCIManifest")
+}
+
+// Payload returns the raw content of the manifest. The contents can be used to
+// calculate the content identifier.
+func (m DeserializedOCIManifest) Payload() (string, []byte, error) {
+	return MediaTypeImageManifest, m.canonical, nil
+}
+
+// ArtifactManifest is an OCI artifact manifest.
+type ArtifactManifest struct {
+	// MediaType is always MediaTypeArtifactManifest.
+	MediaType string `json:"mediaType"`
+
+	// ArtifactType is the type of the artifact.
+	ArtifactType string `json:"artifactType"`
+
+	// Blobs lists descriptors for the blobs of the artifact.
+	Blobs []distribution.Descriptor `json:"blobs,omitempty"`
+
+	// Subject is the manifest this artifact refers to, if any.
+	Subject *distribution.Descriptor `json:"subject,omitempty"`
+
+	// Annotations contains arbitrary metadata for the artifact.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// References returns the descriptors of this manifests references.
+func (m ArtifactManifest) References() []distribution.Descriptor {
+	return m.Blobs
+}
+
+// DeserializedArtifactManifest wraps ArtifactManifest with a copy of the original JSON.
+// It satisfies the distribution.Manifest interface.
+type DeserializedArtifactManifest struct {
+	ArtifactManifest
+
+	// canonical is the canonical byte representation of the manifest.
+	canonical []byte
+}
+
+// UnmarshalJSON populates a new ArtifactManifest struct from JSON data.
+func (m *DeserializedArtifactManifest) UnmarshalJSON(b []byte) error {
+	m.canonical = make([]byte, len(b))
+	copy(m.canonical, b)
+
+	var mfst ArtifactManifest
+	if err := json.Unmarshal(m.canonical, &mfst); err != nil {
+		return err
+	}
+	if mfst.MediaType != MediaTypeArtifactManifest {
+		return fmt.Errorf("mediaType in artifact manifest should be '%s' not '%s'", MediaTypeArtifactManifest, mfst.MediaType)
+	}
+	if len(mfst.ArtifactType) == 0 {
+		return fmt.Errorf("artifact manifest must have an artifactType")
+	}
+	m.ArtifactManifest = mfst
+	return nil
+}
+
+// MarshalJSON returns the contents of canonical. If canonical is empty,
+// marshals the inner contents.
+func (m *DeserializedArtifactManifest) MarshalJSON() ([]byte, error) {
+	if len(m.canonical) > 0 {
+		return m.canonical, nil
+	}
+	return nil, fmt.Errorf("JSON representation not initialized in DeserializedArtifactManifest")
+}
+
+// Payload returns the raw content of the manifest. The contents can be used to
+// calculate the content identifier.
+func (m DeserializedArtifactManifest) Payload() (string, []byte, error) {
+	return MediaTypeArtifactManifest, m.canonical, nil
+}
+
+// ManifestSubject returns the subject and artifact type of an OCI image manifest, image index or
+// artifact manifest. Manifests without a subject, including all Docker manifests, return nil.
+func ManifestSubject(manifest distribution.Manifest) (*distribution.Descriptor, string, error) {
+	switch t := manifest.(type) {
+	case *DeserializedOCIManifest:
+		return t.Subject, t.ArtifactType, nil
+	case *DeserializedArtifactManifest:
+		return t.Subject, t.ArtifactType, nil
+	case *manifestlist.DeserializedManifestList:
+		if t.MediaType == manifestlist.MediaTypeManifestList {
+			return nil, "", nil
+		}
+		// the manifest list type does not keep the OCI 1.1 fields of an image index
+		_, payload, err := t.Payload()
+		if err != nil {
+			return nil, "", err
+		}
+		var index struct {
+			ArtifactType string                   `json:"artifactType"`
+			Subject      *distribution.Descriptor `json:"subject"`
+		}
+		if err := json.Unmarshal(payload, &index); err != nil {
+			return nil, "", err
+		}
+		return index.Subject, index.ArtifactType, nil
+	default:
+		return nil, "", nil
+	}
+}
@@ -0,0 +1,104 @@
+package registryclient
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest/manifestlist"
+	"github.com/opencontainers/go-digest"
+)
+
+func TestManifestSubject(t *testing.T) {
+	subject := digest.FromString("subject")
+	testCases := []struct {
+		name                 string
+		mediaType            string
+		content              string
+		expectedSubject      digest.Digest
+		expectedArtifactType string
+		expectedType         string
+		err                  bool
+	}{
+		{
+			name:                 "image manifest",
+			mediaType:            MediaTypeImageManifest,
+			content:              fmt.Sprintf(`{"schemaVersion":2,"mediaType":%q,"artifactType":"application/example","config":{},"layers":[],"subject":{"mediaType":%q,"digest":%q,"size":1}}`, MediaTypeImageManifest, MediaTypeImageManifest, subject),
+			expectedSubject:      subject,
+			expectedArtifactType: "application/example",
+			expectedType:         "*registryclient.DeserializedOCIManifest",
+		},
+		{
+			name:                 "artifact manifest",
+			mediaType:            MediaTypeArtifactManifest,
+			content:              fmt.Sprintf(`{"mediaType":%q,"artifactType":"application/example","blobs":[{"mediaType":"text/plain","digest":%q,"size":5}],"subject":{"mediaType":%q,"digest":%q,"size":1}}`, MediaTypeArtifactManifest, digest.FromString("hello"), MediaTypeImageManifest, subject),
+			expectedSubject:      subject,
+			expectedArtifactType: "application/example",
+			expectedType:         "*registryclient.DeserializedArtifactManifest",
+		},
+		{
+			name:      "artifact manifest without a type",
+			mediaType: MediaTypeArtifactManifest,
+			content:   fmt.Sprintf(`{"mediaType":%q}`, MediaTypeArtifactManifest),
+			err:       true,
+		},
+		{
+			name:                 "image index",
+			mediaType:            MediaTypeImageIndex,
+			content:              fmt.Sprintf(`{"schemaVersion":2,"mediaType":%q,"artifactType":"application/example","manifests":[],"subject":{"mediaType":%q,"digest":%q,"size":1}}`, MediaTypeImageIndex, MediaTypeImageManifest, subject),
+			expectedSubject:      subject,
+			expectedArtifactType: "application/example",
+			expectedType:         "*manifestlist.DeserializedManifestList",
+		},
+		{
+			name:         "docker manifest list",
+			mediaType:    manifestlist.MediaTypeManifestList,
+			content:      fmt.Sprintf(`{"schemaVersion":2,"mediaType":%q,"manifests":[],"subject":{"digest":%q}}`, manifestlist.MediaTypeManifestList, subject),
+			expectedType: "*manifestlist.DeserializedManifestList",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			m, descriptor, err := distribution.UnmarshalManifest(testCase.mediaType, []byte(testCase.content))
+			if (err != nil) != testCase.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err != nil {
+				return
+			}
+			if actual := fmt.Sprintf("%T", m); actual != testCase.expectedType {
+				t.Errorf("expected %s, got %s", testCase.expectedType, actual)
+			}
+
+			dgst, err := ContentDigestForManifest(m, digest.SHA256)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dgst != digest.FromString(testCase.content) || descriptor.Digest != dgst {
+				t.Errorf("expected the digest of the content, got %s and %s", dgst, descriptor.Digest)
+			}
+
+			subject, artifactType, err := ManifestSubject(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if artifactType != testCase.expectedArtifactType {
+				t.Errorf("expected artifact type %q, got %q", testCase.expectedArtifactType, artifactType)
+			}
+			switch {
+			case len(testCase.expectedSubject) == 0 && subject != nil:
+				t.Errorf("expected no subject, got %#v", subject)
+			case len(testCase.expectedSubject) > 0 && (subject == nil || subject.Digest != testCase.expectedSubject):
+				t.Errorf("expected subject %s, got %#v", testCase.expectedSubject, subject)
+			}
+		})
+	}
+}
+
+func TestContentDigestForManifestWithoutContent(t *testing.T) {
+	for _, m := range []distribution.Manifest{&DeserializedOCIManifest{}, &DeserializedArtifactManifest{}, &manifestlist.DeserializedManifestList{}} {
+		if _, err := ContentDigestForManifest(m, digest.SHA256); err == nil {
+			t.Errorf("%T: expected an error for a manifest without content", m)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package registryclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest/manifestlist"
+	"github.com/opencontainers/go-digest"
+
+	imagereference "github.com/openshift/library-go/pkg/image/reference"
+)
+
+// maxIndexDepth limits how many nested manifest lists are followed when resolving a platform.
+const maxIndexDepth = 3
+
+// ErrPlatformNotFound is returned when a manifest list has no manifest for the requested platform.
+type ErrPlatformNotFound struct {
+	Platform  imagereference.Platform
+	Available []imagereference.Platform
+}
+
+func (e *ErrPlatformNotFound) Error() string {
+	available := make([]string, 0, len(e.Available))
+	for _, platform := range e.Available {
+		available = append(available, platform.String())
+	}
+	return fmt.Sprintf("no manifest for platform %s, available platforms are: %s", e.Platform, strings.Join(available, ", "))
+}
+
+// ManifestPlatforms returns the platform of each manifest in a Docker manifest list or OCI image
+// index, in the order they are listed.
+func ManifestPlatforms(list *manifestlist.DeserializedManifestList) []imagereference.Platform {
+	platforms := make([]imagereference.Platform, 0, len(list.Manifests))
+	for _, descriptor := range list.Manifests {
+		platforms = append(platforms, imagereference.Platform{
+			OS:           descriptor.Platform.OS,
+			Architecture: descriptor.Platform.Architecture,
+			Variant:      descriptor.Platform.Variant,
+		})
+	}
+	return platforms
+}
+
+// ResolvePlatform returns the manifest for platform and its digest. If manifest is a Docker manifest
+// list or an OCI image index, the first listed manifest that runs on platform is retrieved from ms,
+// following nested indexes. Any other manifest is returned unchanged with dgst. Retrieved manifests
+// are checked against their digest.
+func ResolvePlatform(ctx context.Context, ms distribution.ManifestService, manifest distribution.Manifest, dgst digest.Digest, platform imagereference.Platform) (distribution.Manifest, digest.Digest, error) {
+	for depth := 0; ; depth++ {
+		list, ok := manifest.(*manifestlist.DeserializedManifestList)
+		if !ok {
+			return manifest, dgst, nil
+		}
+		if depth == maxIndexDepth {
+			return nil, "", fmt.Errorf("manifest lists are nested more than %d deep", maxIndexDepth)
+		}
+
+		platforms := ManifestPlatforms(list)
+		found := false
+		for i, descriptor := range list.Manifests {
+			if !platform.Matches(platforms[i]) {
+				continue
+			}
+			next, err := ms.Get(ctx, descriptor.Digest)
+			if err != nil {
+				return nil, "", fmt.Errorf("unable to retrieve the manifest for platform %s: %w", platform, err)
+			}
+			if err := VerifyManifestIntegrity(next, descriptor.Digest); err != nil {
+				return nil, "", err
+			}
+			manifest, dgst, found = next, descriptor.Digest, true
+			break
+		}
+		if !found {
+			return nil, "", &ErrPlatformNotFound{Platform: platform, Available: platforms}
+		}
+	}
+}
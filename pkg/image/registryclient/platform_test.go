@@ -0,0 +1,147 @@
+package registryclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest/manifestlist"
+	"github.com/distribution/distribution/v3/manifest/schema2"
+	"github.com/opencontainers/go-digest"
+
+	imagereference "github.com/openshift/library-go/pkg/image/reference"
+)
+
+// memoryManifestService serves manifests by digest.
+type memoryManifestService struct {
+	distribution.ManifestService
+	manifests map[digest.Digest]distribution.Manifest
+}
+
+func (s *memoryManifestService) Get(ctx context.Context, dgst digest.Digest, options ...distribution.ManifestServiceOption) (distribution.Manifest, error) {
+	m, ok := s.manifests[dgst]
+	if !ok {
+		return nil, distribution.ErrManifestUnknownRevision{Revision: dgst}
+	}
+	return m, nil
+}
+
+func (s *memoryManifestService) add(t *testing.T, m distribution.Manifest) distribution.Descriptor {
+	t.Helper()
+	mediaType, payload, err := m.Payload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dgst := digest.FromBytes(payload)
+	s.manifests[dgst] = m
+	return distribution.Descriptor{MediaType: mediaType, Digest: dgst, Size: int64(len(payload))}
+}
+
+func newImageManifest(t *testing.T, config string) distribution.Manifest {
+	t.Helper()
+	m, err := schema2.FromStruct(schema2.Manifest{
+		Versioned: schema2.SchemaVersion,
+		Config:    distribution.Descriptor{MediaType: schema2.MediaTypeImageConfig, Digest: digest.FromString(config), Size: int64(len(config))},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func newManifestList(t *testing.T, mediaType string, platforms map[string]distribution.Descriptor, order ...string) *manifestlist.DeserializedManifestList {
+	t.Helper()
+	var descriptors []manifestlist.ManifestDescriptor
+	for _, name := range order {
+		platform, err := imagereference.ParsePlatform(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		descriptors = append(descriptors, manifestlist.ManifestDescriptor{
+			Descriptor: platforms[name],
+			Platform:   manifestlist.PlatformSpec{OS: platform.OS, Architecture: platform.Architecture, Variant: platform.Variant},
+		})
+	}
+	list, err := manifestlist.FromDescriptorsWithMediaType(descriptors, mediaType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
+
+func TestResolvePlatform(t *testing.T) {
+	ctx := context.Background()
+	ms := &memoryManifestService{manifests: map[digest.Digest]distribution.Manifest{}}
+	amd64 := ms.add(t, newImageManifest(t, "amd64"))
+	arm64 := ms.add(t, newImageManifest(t, "arm64"))
+	armv6 := ms.add(t, newImageManifest(t, "armv6"))
+	armv7 := ms.add(t, newImageManifest(t, "armv7"))
+	single := newImageManifest(t, "single")
+
+	platforms := map[string]distribution.Descriptor{
+		"linux/amd64":    amd64,
+		"linux/arm64/v8": arm64,
+		"linux/arm/v6":   armv6,
+		"linux/arm/v7":   armv7,
+	}
+	dockerList := newManifestList(t, manifestlist.MediaTypeManifestList, platforms, "linux/amd64", "linux/arm64/v8", "linux/arm/v6", "linux/arm/v7")
+	ociIndex := newManifestList(t, MediaTypeImageIndex, platforms, "linux/arm/v6", "linux/arm/v7")
+	nested := newManifestList(t, MediaTypeImageIndex, map[string]distribution.Descriptor{"linux/arm": ms.add(t, ociIndex)}, "linux/arm")
+	corrupt := newManifestList(t, manifestlist.MediaTypeManifestList, map[string]distribution.Descriptor{
+		"linux/amd64": {MediaType: schema2.MediaTypeManifest, Digest: arm64.Digest},
+	}, "linux/amd64")
+	ms.manifests[arm64.Digest] = newImageManifest(t, "tampered")
+
+	testCases := []struct {
+		name     string
+		manifest distribution.Manifest
+		platform string
+		expected digest.Digest
+		notFound bool
+		err      bool
+	}{
+		{name: "docker manifest list", manifest: dockerList, platform: "linux/amd64", expected: amd64.Digest},
+		{name: "default variant", manifest: ociIndex, platform: "linux/arm", expected: armv7.Digest},
+		{name: "explicit variant", manifest: ociIndex, platform: "linux/arm/v6", expected: armv6.Digest},
+		{name: "nested index", manifest: nested, platform: "linux/arm/v7", expected: armv7.Digest},
+		{name: "missing platform", manifest: ociIndex, platform: "linux/s390x", notFound: true},
+		{name: "single manifest", manifest: single, platform: "linux/s390x", expected: "sha256:single"},
+		{name: "digest mismatch", manifest: corrupt, platform: "linux/amd64", err: true},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			platform, err := imagereference.ParsePlatform(testCase.platform)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, dgst, err := ResolvePlatform(ctx, ms, testCase.manifest, "sha256:single", platform)
+			notFound := &ErrPlatformNotFound{}
+			if errors.As(err, &notFound) != testCase.notFound {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if testCase.notFound {
+				if len(notFound.Available) != 2 {
+					t.Errorf("expected the available platforms in the error, got %v", err)
+				}
+				return
+			}
+			if (err != nil) != testCase.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err != nil {
+				return
+			}
+			if dgst != testCase.expected {
+				t.Errorf("expected %s, got %s", testCase.expected, dgst)
+			}
+			if testCase.expected == "sha256:single" {
+				if m != single {
+					t.Errorf("expected the manifest to be returned unchanged")
+				}
+			} else if ms.manifests[dgst] != m {
+				t.Errorf("unexpected manifest %#v", m)
+			}
+		})
+	}
+}
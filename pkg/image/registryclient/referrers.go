@@ -0,0 +1,200 @@
+package registryclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"golang.org/x/time/rate"
+
+	"k8s.io/klog/v2"
+
+	registryclient "github.com/distribution/distribution/v3/registry/client"
+	"github.com/opencontainers/go-digest"
+)
+
+// maxIndexSize is the largest image index that will be read from a registry, matching the
+// manifest size limit registries are expected to enforce.
+const maxIndexSize = 4 * 1024 * 1024
+
+// Referrer describes a manifest that has another manifest as its subject.
+type Referrer struct {
+	// MediaType is the media type of the referring manifest.
+	MediaType string `json:"mediaType"`
+	// ArtifactType is the artifact type of the referring manifest, if any.
+	ArtifactType string `json:"artifactType,omitempty"`
+	// Digest identifies the referring manifest.
+	Digest digest.Digest `json:"digest"`
+	// Size is the size in bytes of the referring manifest.
+	Size int64 `json:"size"`
+	// Annotations are the annotations of the referring manifest.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ReferrersService lists the manifests that refer to a manifest as their subject. Repositories
+// returned by Context implement this interface.
+type ReferrersService interface {
+	// Referrers returns the manifests whose subject is dgst. If artifactType is set only referrers
+	// of that type are returned. Registries without the OCI 1.1 referrers API are searched using
+	// the referrers tag schema, and a registry that has neither returns no referrers.
+	Referrers(ctx context.Context, dgst digest.Digest, artifactType string) ([]Referrer, error)
+}
+
+// referrersIndex is the image index returned by the referrers API and stored in the referrers tag.
+type referrersIndex struct {
+	SchemaVersion int        `json:"schemaVersion"`
+	MediaType     string     `json:"mediaType,omitempty"`
+	Manifests     []Referrer `json:"manifests"`
+}
+
+// httpRepository adds the requests the distribution client does not model, such as the referrers
+// API and resumable uploads, to a repository connected to a single registry.
+type httpRepository struct {
+	RepositoryWithLocation
+
+	client  *http.Client
+	baseURL *url.URL
+	name    string
+	limiter *rate.Limiter
+}
+
+var _ ReferrersService = &httpRepository{}
+
+// Referrers lists referrers with the referrers API, falling back to the referrers tag schema when
+// the registry does not implement the API.
+func (r *httpRepository) Referrers(ctx context.Context, dgst digest.Digest, artifactType string) ([]Referrer, error) {
+	if err := dgst.Validate(); err != nil {
+		return nil, err
+	}
+
+	target := *r.baseURL
+	target.Path = path.Join(target.Path, "v2", r.name, "referrers", dgst.String())
+	if len(artifactType) > 0 {
+		target.RawQuery = url.Values{"artifactType": []string{artifactType}}.Encode()
+	}
+
+	var referrers []Referrer
+	for next := &target; next != nil; {
+		index, resp, err := r.getIndex(ctx, next)
+		if err != nil {
+			return nil, err
+		}
+		if index == nil {
+			if len(referrers) > 0 {
+				return nil, fmt.Errorf("the referrers of %s in %s were not found on a subsequent page", dgst, r.Ref())
+			}
+			klog.V(5).Infof("The registry for %s does not support the referrers API, using the referrers tag", r.Ref())
+			return r.referrersFromTag(ctx, dgst, artifactType)
+		}
+		referrers = append(referrers, filterReferrers(index.Manifests, artifactType)...)
+		next = nextLink(next, resp.Header)
+	}
+	return referrers, nil
+}
+
+// referrersFromTag reads the image index the referrers tag schema stores under the tag <alg>-<ref>.
+func (r *httpRepository) referrersFromTag(ctx context.Context, dgst digest.Digest, artifactType string) ([]Referrer, error) {
+	target := *r.baseURL
+	target.Path = path.Join(target.Path, "v2", r.name, "manifests", fmt.Sprintf("%s-%s", dgst.Algorithm(), dgst.Encoded()))
+	index, _, err := r.getIndex(ctx, &target)
+	if err != nil || index == nil {
+		return nil, err
+	}
+	return filterReferrers(index.Manifests, artifactType), nil
+}
+
+// getIndex fetches an image index, returning nil if the server responds that it does not exist.
+func (r *httpRepository) getIndex(ctx context.Context, target *url.URL) (*referrersIndex, *http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", target.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", MediaTypeImageIndex)
+	resp, err := r.do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, resp, nil
+	case !registryclient.SuccessStatus(resp.StatusCode):
+		return nil, resp, registryclient.HandleErrorResponse(resp)
+	}
+	if contentType := resp.Header.Get("Content-Type"); len(contentType) > 0 && !strings.HasPrefix(contentType, MediaTypeImageIndex) {
+		return nil, resp, fmt.Errorf("expected an image index from %s, got %s", target.Redacted(), contentType)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxIndexSize+1))
+	if err != nil {
+		return nil, resp, err
+	}
+	if len(data) > maxIndexSize {
+		return nil, resp, fmt.Errorf("the image index from %s is larger than %d bytes", target.Redacted(), maxIndexSize)
+	}
+	index := &referrersIndex{}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, resp, fmt.Errorf("the image index from %s is not valid: %w", target.Redacted(), err)
+	}
+	return index, resp, nil
+}
+
+// filterReferrers returns the referrers with the given artifact type. Registries are not required to
+// apply the artifactType filter, so the result is always filtered on the client.
+func filterReferrers(referrers []Referrer, artifactType string) []Referrer {
+	if len(artifactType) == 0 {
+		return referrers
+	}
+	var filtered []Referrer
+	for _, referrer := range referrers {
+		if referrer.ArtifactType == artifactType {
+			filtered = append(filtered, referrer)
+		}
+	}
+	return filtered
+}
+
+// nextLink returns the next page of results from an RFC 5988 Link header, or nil if this is the last page.
+func nextLink(current *url.URL, header http.Header) *url.URL {
+	for _, link := range header.Values("Link") {
+		for _, value := range strings.Split(link, ",") {
+			parts := strings.Split(value, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				if strings.ReplaceAll(strings.TrimSpace(param), " ", "") != `rel="next"` {
+					continue
+				}
+				next, err := current.Parse(strings.Trim(target, "<>"))
+				if err != nil {
+					return nil
+				}
+				return next
+			}
+		}
+	}
+	return nil
+}
+
+// Referrers lists the referrers of dgst from the first repository that can serve them, honoring the
+// alternate blob source strategy since referrers are addressed by digest.
+func (r *blobMirroredRepository) Referrers(ctx context.Context, dgst digest.Digest, artifactType string) ([]Referrer, error) {
+	var referrers []Referrer
+	err := r.alternates(ctx, func(repo RepositoryWithLocation) error {
+		service, ok := repo.(ReferrersService)
+		if !ok {
+			return fmt.Errorf("the repository %s does not support listing referrers", repo.Ref())
+		}
+		var err error
+		referrers, err = service.Referrers(ctx, dgst, artifactType)
+		return err
+	})
+	return referrers, err
+}
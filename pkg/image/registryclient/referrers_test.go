@@ -0,0 +1,196 @@
+package registryclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/opencontainers/go-digest"
+
+	"k8s.io/client-go/rest"
+
+	imagereference "github.com/openshift/library-go/pkg/image/reference"
+)
+
+func TestReferrers(t *testing.T) {
+	subject := digest.FromString("subject")
+	signature := Referrer{MediaType: MediaTypeImageManifest, ArtifactType: "application/vnd.example.signature", Digest: digest.FromString("signature"), Size: 10}
+	sbom := Referrer{MediaType: MediaTypeImageManifest, ArtifactType: "application/vnd.example.sbom", Digest: digest.FromString("sbom"), Size: 20}
+	other := Referrer{MediaType: MediaTypeImageManifest, ArtifactType: "application/vnd.example.signature", Digest: digest.FromString("other"), Size: 30}
+
+	index := func(referrers ...Referrer) string {
+		items := ""
+		for i, referrer := range referrers {
+			if i > 0 {
+				items += ","
+			}
+			items += fmt.Sprintf(`{"mediaType":%q,"artifactType":%q,"digest":%q,"size":%d}`, referrer.MediaType, referrer.ArtifactType, referrer.Digest, referrer.Size)
+		}
+		return fmt.Sprintf(`{"schemaVersion":2,"mediaType":%q,"manifests":[%s]}`, MediaTypeImageIndex, items)
+	}
+
+	tests := []struct {
+		name         string
+		handler      http.HandlerFunc
+		artifactType string
+		expected     []Referrer
+		expectedErr  bool
+	}{
+		{
+			name: "referrers API with pages",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/v2/mirror/image/referrers/" + subject.String():
+					if r.URL.Query().Get("page") == "2" {
+						w.Header().Set("Content-Type", MediaTypeImageIndex)
+						w.Write([]byte(index(other)))
+						return
+					}
+					w.Header().Set("Content-Type", MediaTypeImageIndex)
+					w.Header().Set("Link", fmt.Sprintf(`</v2/mirror/image/referrers/%s?page=2>; rel="next"`, subject))
+					w.Write([]byte(index(signature, sbom)))
+				default:
+					http.Error(w, "not found", http.StatusNotFound)
+				}
+			},
+			artifactType: "application/vnd.example.signature",
+			expected:     []Referrer{signature, other},
+		},
+		{
+			name: "referrers tag schema",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/v2/mirror/image/manifests/sha256-" + subject.Encoded():
+					w.Header().Set("Content-Type", MediaTypeImageIndex)
+					w.Write([]byte(index(signature, sbom)))
+				default:
+					http.Error(w, "not found", http.StatusNotFound)
+				}
+			},
+			expected: []Referrer{signature, sbom},
+		},
+		{
+			name: "no referrers",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "not found", http.StatusNotFound)
+			},
+		},
+		{
+			name: "not an index",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.Write([]byte("<html></html>"))
+			},
+			expectedErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			insecureTransport, err := rest.TransportFor(&rest.Config{TLSClientConfig: rest.TLSClientConfig{Insecure: true}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sourceRegistry := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				t.Errorf("source registry: unexpected request to %s", r.URL.String())
+				http.Error(w, "not found", http.StatusNotFound)
+			}))
+			defer sourceRegistry.Close()
+			mirrorRegistry := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/v2/" {
+					return
+				}
+				test.handler(w, r)
+			}))
+			defer mirrorRegistry.Close()
+
+			originalRef, err := imagereference.Parse(fmt.Sprintf("%s/original/image", sourceRegistry.Listener.Addr().String()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			mirrorRef, err := imagereference.Parse(fmt.Sprintf("%s/mirror/image", mirrorRegistry.Listener.Addr().String()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := NewContext(http.DefaultTransport, insecureTransport).WithAlternateBlobSourceStrategy(&fakeAlternateBlobStrategy{
+				FirstAlternates: []imagereference.DockerImageReference{mirrorRef},
+			})
+			repo, err := c.Repository(ctx, originalRef.RegistryURL(), originalRef.RepositoryName(), true)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			referrers, err := repo.(ReferrersService).Referrers(ctx, subject, test.artifactType)
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(referrers, test.expected) {
+				t.Errorf("expected referrers %#v, got %#v", test.expected, referrers)
+			}
+		})
+	}
+}
+
+func TestOCIManifestGet(t *testing.T) {
+	ctx := context.Background()
+	manifest := fmt.Sprintf(`{"schemaVersion":2,"mediaType":%q,"artifactType":"application/vnd.example.signature","config":{"mediaType":"application/vnd.oci.empty.v1+json","digest":%q,"size":2},"layers":[{"mediaType":"application/vnd.example.payload","digest":%q,"size":5,"annotations":{"key":"value"}}],"subject":{"mediaType":%q,"digest":%q,"size":100}}`,
+		MediaTypeImageManifest, digest.FromString("{}"), digest.FromString("hello"), MediaTypeImageManifest, digest.FromString("subject"))
+	manifestDigest := digest.FromString(manifest)
+
+	registry := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/":
+		case "/v2/test/image/manifests/signed", "/v2/test/image/manifests/" + manifestDigest.String():
+			w.Header().Set("Content-Type", MediaTypeImageManifest)
+			w.Write([]byte(manifest))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer registry.Close()
+
+	insecureTransport, err := rest.TransportFor(&rest.Config{TLSClientConfig: rest.TLSClientConfig{Insecure: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := imagereference.Parse(fmt.Sprintf("%s/test/image", registry.Listener.Addr().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewContext(http.DefaultTransport, insecureTransport).Repository(ctx, ref.RegistryURL(), ref.RepositoryName(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms, err := repo.Manifests(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, get := range []func() (distribution.Manifest, error){
+		func() (distribution.Manifest, error) { return ms.Get(ctx, "", distribution.WithTag("signed")) },
+		func() (distribution.Manifest, error) { return ms.Get(ctx, manifestDigest) },
+	} {
+		m, err := get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ociManifest, ok := m.(*DeserializedOCIManifest)
+		if !ok {
+			t.Fatalf("expected an OCI manifest, got %T", m)
+		}
+		if ociManifest.ArtifactType != "application/vnd.example.signature" || ociManifest.Subject == nil || ociManifest.Subject.Digest != digest.FromString("subject") {
+			t.Errorf("unexpected manifest: %#v", ociManifest.OCIManifest)
+		}
+		if len(ociManifest.Layers) != 1 || ociManifest.Layers[0].Annotations["key"] != "value" {
+			t.Errorf("unexpected layers: %#v", ociManifest.Layers)
+		}
+		if err := VerifyManifestIntegrity(m, manifestDigest); err != nil {
+			t.Error(err)
+		}
+	}
+}
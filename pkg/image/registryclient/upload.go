@@ -0,0 +1,158 @@
+package registryclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+
+	"github.com/distribution/distribution/v3"
+	registryclient "github.com/distribution/distribution/v3/registry/client"
+	"github.com/opencontainers/go-digest"
+)
+
+// do sends a request to the registry, obeying the rate limit of the repository.
+func (r *httpRepository) do(req *http.Request) (*http.Response, error) {
+	if r.limiter != nil {
+		if err := r.limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+	return r.client.Do(req)
+}
+
+// location resolves the Location header of an upload response against the registry URL.
+func (r *httpRepository) location(resp *http.Response, current string) (string, error) {
+	location := resp.Header.Get("Location")
+	if len(location) == 0 {
+		return current, nil
+	}
+	u, err := r.baseURL.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("the registry returned an invalid upload location %q: %w", location, err)
+	}
+	return u.String(), nil
+}
+
+// startUpload begins a blob upload. If mountFrom is set the registry is asked to mount dgst from
+// that repository instead, and true is returned if it did.
+func (r *httpRepository) startUpload(ctx context.Context, dgst digest.Digest, mountFrom string) (string, bool, error) {
+	target := *r.baseURL
+	target.Path = path.Join(target.Path, "v2", r.name, "blobs", "uploads") + "/"
+	if len(mountFrom) > 0 {
+		target.RawQuery = url.Values{"mount": []string{dgst.String()}, "from": []string{mountFrom}}.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.String(), nil)
+	if err != nil {
+		return "", false, err
+	}
+	resp, err := r.do(req)
+	if err != nil {
+		return "", false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return "", true, nil
+	case http.StatusAccepted:
+		location, err := r.location(resp, "")
+		if err != nil {
+			return "", false, err
+		}
+		if len(location) == 0 {
+			return "", false, fmt.Errorf("the registry did not return an upload location for %s", dgst)
+		}
+		return location, false, nil
+	default:
+		return "", false, registryclient.HandleErrorResponse(resp)
+	}
+}
+
+// uploadChunk sends data to be stored at offset in the upload and returns the location to use
+// for the next request.
+func (r *httpRepository) uploadChunk(ctx context.Context, location string, offset int64, data []byte) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, location, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Range", fmt.Sprintf("%d-%d", offset, offset+int64(len(data))-1))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.ContentLength = int64(len(data))
+	resp, err := r.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", distribution.ErrBlobUploadUnknown
+	case !registryclient.SuccessStatus(resp.StatusCode):
+		return "", registryclient.HandleErrorResponse(resp)
+	}
+	return r.location(resp, location)
+}
+
+// uploadStatus returns how many bytes of the upload the registry has stored, and the location to
+// use for the next request.
+func (r *httpRepository) uploadStatus(ctx context.Context, location string) (string, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	resp, err := r.do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", 0, distribution.ErrBlobUploadUnknown
+	case !registryclient.SuccessStatus(resp.StatusCode):
+		return "", 0, registryclient.HandleErrorResponse(resp)
+	}
+	location, err = r.location(resp, location)
+	if err != nil {
+		return "", 0, err
+	}
+	rng := resp.Header.Get("Range")
+	if len(rng) == 0 {
+		return location, 0, nil
+	}
+	var start, end int64
+	if n, err := fmt.Sscanf(rng, "%d-%d", &start, &end); err != nil || n != 2 || start != 0 || end < 0 {
+		return "", 0, fmt.Errorf("the registry returned an invalid upload range %q", rng)
+	}
+	return location, end + 1, nil
+}
+
+// commitUpload completes the upload, which the registry must store as dgst.
+func (r *httpRepository) commitUpload(ctx context.Context, location string, dgst digest.Digest) error {
+	target, err := url.Parse(location)
+	if err != nil {
+		return err
+	}
+	query := target.Query()
+	query.Set("digest", dgst.String())
+	target.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Length", strconv.Itoa(0))
+	resp, err := r.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if !registryclient.SuccessStatus(resp.StatusCode) {
+		return registryclient.HandleErrorResponse(resp)
+	}
+	return nil
+}
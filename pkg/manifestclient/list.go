@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	apirequest "k8s.io/apiserver/pkg/endpoints/request"
@@ -33,7 +34,7 @@ func (mrt *manifestRoundTripper) listAll(requestInfo *apirequest.RequestInfo) ([
 		requestInfo.Resource == "namespaces" &&
 		len(requestInfo.Subresource) == 0 {
 
-		return mrt.listAllNamespaces()
+		return mrt.listAllNamespaces(requestInfo)
 	}
 
 	gvr := schema.GroupVersionResource{
@@ -79,6 +80,10 @@ func (mrt *manifestRoundTripper) listAll(requestInfo *apirequest.RequestInfo) ([
 		if err != nil {
 			return nil, fmt.Errorf("failed to filter by labelSelector %s: %w", requestInfo.LabelSelector, err)
 		}
+		retList, err = filterByFieldSelector(retList, requestInfo.FieldSelector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to filter by fieldSelector %s: %w", requestInfo.FieldSelector, err)
+		}
 		ret, err := serializeListObjToJSON(retList)
 		if err != nil {
 			return nil, fmt.Errorf("failed to serialize: %v", err)
@@ -118,6 +123,10 @@ func (mrt *manifestRoundTripper) listAll(requestInfo *apirequest.RequestInfo) ([
 		if err != nil {
 			return nil, fmt.Errorf("failed to filter by labelSelector %s: %w", requestInfo.LabelSelector, err)
 		}
+		retList, err = filterByFieldSelector(retList, requestInfo.FieldSelector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to filter by fieldSelector %s: %w", requestInfo.FieldSelector, err)
+		}
 		ret, err := serializeListObjToJSON(retList)
 		if err != nil {
 			return nil, fmt.Errorf("failed to serialize: %v", err)
@@ -135,7 +144,7 @@ func (mrt *manifestRoundTripper) listAll(requestInfo *apirequest.RequestInfo) ([
 	return []byte(ret), nil
 }
 
-func (mrt *manifestRoundTripper) listAllNamespaces() ([]byte, error) {
+func (mrt *manifestRoundTripper) listAllNamespaces(requestInfo *apirequest.RequestInfo) ([]byte, error) {
 	possibleNamespaceFiles, err := allPossibleNamespaceFiles(mrt.sourceFS)
 	switch {
 	case errors.Is(err, fs.ErrNotExist):
@@ -163,6 +172,10 @@ func (mrt *manifestRoundTripper) listAllNamespaces() ([]byte, error) {
 	}
 	retList.SetKind("NamespaceList")
 	retList.SetAPIVersion("v1")
+	retList, err = filterByFieldSelector(retList, requestInfo.FieldSelector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter by fieldSelector %s: %w", requestInfo.FieldSelector, err)
+	}
 
 	ret, err := serializeListObjToJSON(retList)
 	if err != nil {
@@ -305,3 +318,31 @@ func filterByLabelSelector(list *unstructured.UnstructuredList, labelSelector st
 		Items:  filteredItems,
 	}, nil
 }
+
+// filterByFieldSelector supports the fields every resource has: metadata.name and metadata.namespace.
+func filterByFieldSelector(list *unstructured.UnstructuredList, fieldSelector string) (*unstructured.UnstructuredList, error) {
+	if fieldSelector == "" {
+		return list, nil
+	}
+
+	parsedSelector, err := fields.ParseSelector(fieldSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	var filteredItems []unstructured.Unstructured
+	for _, item := range list.Items {
+		itemFields := fields.Set{
+			"metadata.name":      item.GetName(),
+			"metadata.namespace": item.GetNamespace(),
+		}
+		if parsedSelector.Matches(itemFields) {
+			filteredItems = append(filteredItems, item)
+		}
+	}
+
+	return &unstructured.UnstructuredList{
+		Object: list.Object,
+		Items:  filteredItems,
+	}, nil
+}
@@ -0,0 +1,280 @@
+package manifestclient
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// overlayFS is an in-memory, writable layer on top of a read-only fs.FS.
+// Files written to the overlay shadow files in the source, removed files are hidden from the source, and directories
+// are the union of both layers.  This allows mutations to a must-gather to be observed by later reads without touching
+// the original content.
+type overlayFS struct {
+	source fs.FS
+
+	lock    sync.RWMutex
+	files   map[string][]byte
+	removed sets.Set[string]
+}
+
+var (
+	_ fs.FS         = &overlayFS{}
+	_ fs.ReadFileFS = &overlayFS{}
+	_ fs.ReadDirFS  = &overlayFS{}
+	_ fs.StatFS     = &overlayFS{}
+)
+
+func newOverlayFS(source fs.FS) *overlayFS {
+	return &overlayFS{
+		source:  source,
+		files:   map[string][]byte{},
+		removed: sets.New[string](),
+	}
+}
+
+func cleanOverlayPath(name string) string {
+	return path.Clean(filepath.ToSlash(name))
+}
+
+func validOverlayPath(op, name string) (string, error) {
+	slashed := filepath.ToSlash(name)
+	if !fs.ValidPath(slashed) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return slashed, nil
+}
+
+// writeFile stores content at name, shadowing any file in the source.
+func (o *overlayFS) writeFile(name string, content []byte) {
+	name = cleanOverlayPath(name)
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.files[name] = bytes.Clone(content)
+	o.removed.Delete(name)
+}
+
+// removeFile hides name in both the overlay and the source.
+func (o *overlayFS) removeFile(name string) {
+	name = cleanOverlayPath(name)
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	delete(o.files, name)
+	o.removed.Insert(name)
+}
+
+func (o *overlayFS) ReadFile(name string) ([]byte, error) {
+	name, err := validOverlayPath("read", name)
+	if err != nil {
+		return nil, err
+	}
+
+	o.lock.RLock()
+	content, inOverlay := o.files[name]
+	isRemoved := o.removed.Has(name)
+	o.lock.RUnlock()
+
+	switch {
+	case inOverlay:
+		return bytes.Clone(content), nil
+	case isRemoved:
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	default:
+		return fs.ReadFile(o.source, name)
+	}
+}
+
+func (o *overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	name, err := validOverlayPath("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := map[string]fs.DirEntry{}
+	sourceEntries, sourceErr := fs.ReadDir(o.source, name)
+	switch {
+	case errors.Is(sourceErr, fs.ErrNotExist):
+	case sourceErr != nil:
+		return nil, sourceErr
+	}
+
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+
+	for _, curr := range sourceEntries {
+		if !curr.IsDir() && o.removed.Has(path.Join(name, curr.Name())) {
+			continue
+		}
+		entries[curr.Name()] = curr
+	}
+
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+	for filename, content := range o.files {
+		if !strings.HasPrefix(filename, prefix) {
+			continue
+		}
+		relative := strings.TrimPrefix(filename, prefix)
+		child, _, isNested := strings.Cut(relative, "/")
+		if isNested {
+			if _, exists := entries[child]; !exists {
+				entries[child] = fs.FileInfoToDirEntry(overlayFileInfo{name: child, dir: true})
+			}
+			continue
+		}
+		entries[child] = fs.FileInfoToDirEntry(overlayFileInfo{name: child, size: int64(len(content))})
+	}
+
+	if sourceErr != nil && len(entries) == 0 {
+		return nil, sourceErr
+	}
+
+	ret := make([]fs.DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		ret = append(ret, entry)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name() < ret[j].Name()
+	})
+	return ret, nil
+}
+
+func (o *overlayFS) Stat(name string) (fs.FileInfo, error) {
+	name, err := validOverlayPath("stat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	o.lock.RLock()
+	content, inOverlay := o.files[name]
+	isRemoved := o.removed.Has(name)
+	o.lock.RUnlock()
+
+	switch {
+	case inOverlay:
+		return overlayFileInfo{name: path.Base(name), size: int64(len(content))}, nil
+	case isRemoved:
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+
+	info, err := fs.Stat(o.source, name)
+	if err == nil {
+		return info, nil
+	}
+	if _, dirErr := o.ReadDir(name); dirErr == nil {
+		return overlayFileInfo{name: path.Base(name), dir: true}, nil
+	}
+	return nil, err
+}
+
+func (o *overlayFS) Open(name string) (fs.File, error) {
+	info, err := o.Stat(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if info.IsDir() {
+		entries, err := o.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		return &overlayDir{info: info, entries: entries}, nil
+	}
+	content, err := o.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return &overlayFile{info: info, Reader: bytes.NewReader(content)}, nil
+}
+
+// writeDirectory writes the merged content of the source and the overlay to outputDir.
+func (o *overlayFS) writeDirectory(outputDir string) error {
+	return fs.WalkDir(o, ".", func(currPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		outputPath := filepath.Join(outputDir, filepath.FromSlash(currPath))
+		if d.IsDir() {
+			if err := os.MkdirAll(outputPath, 0755); err != nil {
+				return fmt.Errorf("unable to create %q: %w", outputPath, err)
+			}
+			return nil
+		}
+		content, err := o.ReadFile(currPath)
+		if err != nil {
+			return fmt.Errorf("unable to read %q: %w", currPath, err)
+		}
+		if err := os.WriteFile(outputPath, content, 0644); err != nil {
+			return fmt.Errorf("unable to write %q: %w", outputPath, err)
+		}
+		return nil
+	})
+}
+
+type overlayFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (i overlayFileInfo) Name() string { return i.name }
+func (i overlayFileInfo) Size() int64  { return i.size }
+func (i overlayFileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
+func (i overlayFileInfo) ModTime() time.Time { return time.Time{} }
+func (i overlayFileInfo) IsDir() bool        { return i.dir }
+func (i overlayFileInfo) Sys() any           { return nil }
+
+type overlayFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (f *overlayFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *overlayFile) Close() error               { return nil }
+
+type overlayDir struct {
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *overlayDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *overlayDir) Close() error               { return nil }
+func (d *overlayDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.Name(), Err: fs.ErrInvalid}
+}
+
+func (d *overlayDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if count <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(remaining) {
+		count = len(remaining)
+	}
+	d.offset += count
+	return remaining[:count], nil
+}
@@ -0,0 +1,44 @@
+package manifestclient
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestOverlayFS(t *testing.T) {
+	source := fstest.MapFS{
+		"namespaces/ns/core/configmaps.yaml": {Data: []byte("source")},
+		"namespaces/ns/core/secrets.yaml":    {Data: []byte("source")},
+	}
+	overlay := newOverlayFS(source)
+	overlay.writeFile("namespaces/ns/core/configmaps.yaml", []byte("overlay"))
+	overlay.writeFile("namespaces/other/core/pods.yaml", []byte("overlay"))
+	overlay.removeFile("namespaces/ns/core/secrets.yaml")
+
+	if err := fstest.TestFS(overlay, "namespaces/ns/core/configmaps.yaml", "namespaces/other/core/pods.yaml"); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := fs.ReadFile(overlay, "namespaces/ns/core/configmaps.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "overlay" {
+		t.Errorf("expected overlay content, got %q", string(content))
+	}
+	if _, err := fs.ReadFile(overlay, "namespaces/ns/core/secrets.yaml"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected removed file to be missing, got %v", err)
+	}
+	namespaces, err := fs.ReadDir(overlay, "namespaces")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(namespaces) != 2 || namespaces[0].Name() != "ns" || namespaces[1].Name() != "other" || !namespaces[1].IsDir() {
+		t.Errorf("unexpected namespaces: %v", namespaces)
+	}
+	if source["namespaces/ns/core/configmaps.yaml"] == nil || string(source["namespaces/ns/core/configmaps.yaml"].Data) != "source" {
+		t.Errorf("source was modified")
+	}
+}
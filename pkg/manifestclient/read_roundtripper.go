@@ -6,7 +6,6 @@ import (
 	"io"
 	"io/fs"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
@@ -32,6 +31,9 @@ type manifestRoundTripper struct {
 	requestInfoResolver *apirequest.RequestInfoFactory
 
 	discoveryReader *discoveryReader
+
+	watchPollInterval     time.Duration
+	watchBookmarkInterval time.Duration
 }
 
 func newReadRoundTripper(content fs.FS, discoveryRoundTripper *discoveryReader) *manifestRoundTripper {
@@ -40,13 +42,14 @@ func newReadRoundTripper(content fs.FS, discoveryRoundTripper *discoveryReader)
 		requestInfoResolver: server.NewRequestInfoResolver(&server.Config{
 			LegacyAPIGroupPrefixes: sets.NewString(server.DefaultLegacyAPIPrefix),
 		}),
-		discoveryReader: discoveryRoundTripper,
+		discoveryReader:       discoveryRoundTripper,
+		watchPollInterval:     defaultWatchPollInterval,
+		watchBookmarkInterval: defaultWatchBookmarkInterval,
 	}
 }
 
 // RoundTrip will allow performing read requests very similar to a kube-apiserver against a must-gather style directory.
-// Only GETs.
-// no watches. (maybe add watches
+// Only GETs and watches.  Watches are produced by polling the directory, see watch.
 func (mrt *manifestRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	requestInfo, err := mrt.requestInfoResolver.NewRequestInfo(req)
 	if err != nil {
@@ -76,26 +79,14 @@ func (mrt *manifestRoundTripper) RoundTrip(req *http.Request) (*http.Response, e
 			returnBody, returnErr = mrt.get(requestInfo)
 		}
 	case "list":
-		// TODO handle label and field selectors
 		returnBody, returnErr = mrt.list(requestInfo)
 
 	case "watch":
-		// our watches do nothing.  We keep the connection alive (I  think), but nothing else.
-		timeoutSecondsString := req.URL.Query().Get("timeoutSeconds")
-		timeoutDuration := 10 * time.Minute
-		if len(timeoutSecondsString) > 0 {
-			currSeconds, err := strconv.ParseInt(timeoutSecondsString, 10, 32)
-			if err != nil {
-				returnErr = err
-				break
-			}
-			timeoutDuration = time.Duration(currSeconds) * time.Second
+		resp, err := mrt.watch(req, requestInfo)
+		if err == nil {
+			return resp, nil
 		}
-		resp := &http.Response{}
-		resp.StatusCode = http.StatusOK
-		resp.Status = http.StatusText(resp.StatusCode)
-		resp.Body = newDelayedNothingReader(timeoutDuration)
-		return resp, nil
+		returnErr = err
 
 	default:
 		return nil, fmt.Errorf("verb %v is not supported by this implementation", requestInfo.Verb)
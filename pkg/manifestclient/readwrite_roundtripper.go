@@ -53,6 +53,33 @@ func NewTestingHTTPClient(embedFS fs.FS) MutationTrackingClient {
 	}
 }
 
+// NewWriteThroughHTTPClient is like NewHTTPClient, but mutations are also applied to an in-memory overlay of the
+// must-gather directory.  Later reads observe earlier writes, so controllers can converge over multiple syncs.
+// The directory itself is never modified; use WriteMustGatherDirectory to write the result.
+func NewWriteThroughHTTPClient(mustGatherDir string) WriteThroughClient {
+	return newWriteThroughClient(os.DirFS(mustGatherDir))
+}
+
+// NewTestingWriteThroughHTTPClient is like NewWriteThroughHTTPClient, but reads from an fs.FS.
+func NewTestingWriteThroughHTTPClient(embedFS fs.FS) WriteThroughClient {
+	return newWriteThroughClient(embedFS)
+}
+
+func newWriteThroughClient(sourceFS fs.FS) *writeThroughClient {
+	overlay := newOverlayFS(sourceFS)
+	mutationTrackingRoundTripper := newReadWriteRoundTripper(overlay)
+	mutationTrackingRoundTripper.writeDelegate.replayer = newMutationReplayer(overlay)
+	return &writeThroughClient{
+		mutationTrackingClient: mutationTrackingClient{
+			httpClient: &http.Client{
+				Transport: mutationTrackingRoundTripper,
+			},
+			mutationTrackingRoundTripper: mutationTrackingRoundTripper,
+		},
+		overlay: overlay,
+	}
+}
+
 func NewTestingRoundTripper(embedFS fs.FS) *readWriteRoundTripper {
 	return newReadWriteRoundTripper(embedFS)
 }
@@ -98,6 +125,24 @@ type MutationTrackingClient interface {
 	GetMutations() *AllActionsTracker[TrackedSerializedRequest]
 }
 
+type writeThroughClient struct {
+	mutationTrackingClient
+
+	overlay *overlayFS
+}
+
+func (w *writeThroughClient) WriteMustGatherDirectory(outputDir string) error {
+	return w.overlay.writeDirectory(outputDir)
+}
+
+// WriteThroughClient is a MutationTrackingClient whose reads reflect its own writes.
+type WriteThroughClient interface {
+	MutationTrackingClient
+
+	// WriteMustGatherDirectory writes the source content with all successful mutations applied to outputDir.
+	WriteMustGatherDirectory(outputDir string) error
+}
+
 func (rt *readWriteRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	switch req.Method {
 	case "GET", "HEAD":
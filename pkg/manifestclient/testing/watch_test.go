@@ -0,0 +1,230 @@
+package testing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/manifestclient"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/ptr"
+)
+
+func nextWatchEvent(t *testing.T, w watch.Interface) watch.Event {
+	t.Helper()
+	select {
+	case event, ok := <-w.ResultChan():
+		if !ok {
+			t.Fatal("watch closed unexpectedly")
+		}
+		return event
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for watch event")
+	}
+	return watch.Event{}
+}
+
+func requireConfigMapEvent(t *testing.T, w watch.Interface, eventType watch.EventType, name string) *corev1.ConfigMap {
+	t.Helper()
+	event := nextWatchEvent(t, w)
+	if event.Type != eventType {
+		t.Fatalf("expected %v event, got %v: %#v", eventType, event.Type, event.Object)
+	}
+	configMap, ok := event.Object.(*corev1.ConfigMap)
+	if !ok {
+		t.Fatalf("expected a configmap, got %T", event.Object)
+	}
+	if configMap.Name != name {
+		t.Fatalf("expected %v event for %q, got %q", eventType, name, configMap.Name)
+	}
+	return configMap
+}
+
+func TestWatchReflectsWriteThroughMutations(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	_, client := setupWriteThroughClient(t)
+	configMaps := client.CoreV1().ConfigMaps("test-namespace")
+
+	w, err := configMaps.Watch(ctx, metav1.ListOptions{ResourceVersion: "0"})
+	if err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+	defer w.Stop()
+	requireConfigMapEvent(t, w, watch.Added, "audit")
+	requireConfigMapEvent(t, w, watch.Added, "audit-1")
+
+	if _, err := configMaps.Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "created", Namespace: "test-namespace"},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	requireConfigMapEvent(t, w, watch.Added, "created")
+
+	if err := configMaps.Delete(ctx, "audit", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	requireConfigMapEvent(t, w, watch.Deleted, "audit")
+}
+
+func TestWatchResumesFromResourceVersion(t *testing.T) {
+	testCases := []struct {
+		name            string
+		resourceVersion string
+		expectedReplay  []string
+	}{
+		{
+			name: "no resourceVersion starts from the current content",
+		},
+		{
+			name:            "resourceVersion 0 replays every object",
+			resourceVersion: "0",
+			expectedReplay:  []string{"audit", "audit-1"},
+		},
+		{
+			name:            "a resourceVersion replays the newer objects",
+			resourceVersion: "7000",
+			expectedReplay:  []string{"audit-1"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			_, client := setupWriteThroughClient(t)
+			configMaps := client.CoreV1().ConfigMaps("test-namespace")
+
+			w, err := configMaps.Watch(ctx, metav1.ListOptions{ResourceVersion: tc.resourceVersion})
+			if err != nil {
+				t.Fatalf("failed to watch: %v", err)
+			}
+			defer w.Stop()
+			for _, name := range tc.expectedReplay {
+				requireConfigMapEvent(t, w, watch.Added, name)
+			}
+
+			// the first change follows the replay immediately
+			if _, err := configMaps.Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "created", Namespace: "test-namespace"},
+			}, metav1.CreateOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			requireConfigMapEvent(t, w, watch.Added, "created")
+		})
+	}
+}
+
+func TestWatchSingleObjectAndBookmarks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	_, client := setupWriteThroughClient(t)
+
+	w, err := client.CoreV1().ConfigMaps("test-namespace").Watch(ctx, metav1.ListOptions{
+		FieldSelector:        "metadata.name=audit-1",
+		AllowWatchBookmarks:  true,
+		SendInitialEvents:    ptr.To(true),
+		ResourceVersionMatch: metav1.ResourceVersionMatchNotOlderThan,
+	})
+	if err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+	defer w.Stop()
+	requireConfigMapEvent(t, w, watch.Added, "audit-1")
+	bookmark := requireConfigMapEvent(t, w, watch.Bookmark, "")
+	if bookmark.Annotations[metav1.InitialEventsAnnotationKey] != "true" {
+		t.Errorf("expected the initial events bookmark, got %v", bookmark.Annotations)
+	}
+}
+
+func TestWatchReflectsChangesOnDisk(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	mustGatherDir := t.TempDir()
+	configMapsFile := filepath.Join("namespaces", "test-namespace", "core", "configmaps.yaml")
+	content, err := os.ReadFile(filepath.Join("test-data", "write-through-dir", configMapsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(filepath.Join(mustGatherDir, configMapsFile)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(mustGatherDir, configMapsFile), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := manifestclient.RecommendedKubernetesWithClient(manifestclient.NewHTTPClient(mustGatherDir).GetHTTPClient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := client.CoreV1().ConfigMaps("test-namespace").Watch(ctx, metav1.ListOptions{ResourceVersion: "0"})
+	if err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+	defer w.Stop()
+	requireConfigMapEvent(t, w, watch.Added, "audit")
+	requireConfigMapEvent(t, w, watch.Added, "audit-1")
+
+	updatedContent := strings.Replace(string(content), "key: audit-1", "key: changed-on-disk", 1)
+	if err := os.WriteFile(filepath.Join(mustGatherDir, configMapsFile), []byte(updatedContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modified := requireConfigMapEvent(t, w, watch.Modified, "audit-1")
+	if modified.Data["key"] != "changed-on-disk" {
+		t.Errorf("unexpected data: %v", modified.Data)
+	}
+}
+
+func TestSharedInformersObserveWriteThroughMutations(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	_, client := setupWriteThroughClient(t)
+
+	informerFactory := informers.NewSharedInformerFactoryWithOptions(client, 0, informers.WithNamespace("test-namespace"))
+	configMapInformer := informerFactory.Core().V1().ConfigMaps()
+	added := make(chan string, 10)
+	if _, err := configMapInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			added <- obj.(*corev1.ConfigMap).Name
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	informerFactory.Start(ctx.Done())
+	defer func() {
+		cancel()
+		informerFactory.Shutdown()
+	}()
+	if !cache.WaitForCacheSync(ctx.Done(), configMapInformer.Informer().HasSynced) {
+		t.Fatal("informer did not sync")
+	}
+
+	if _, err := client.CoreV1().ConfigMaps("test-namespace").Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "created", Namespace: "test-namespace"},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case name := <-added:
+			if name != "created" {
+				continue
+			}
+			if _, err := configMapInformer.Lister().ConfigMaps("test-namespace").Get("created"); err != nil {
+				t.Fatalf("created configmap is not in the lister: %v", err)
+			}
+			return
+		case <-timeout:
+			t.Fatal("timed out waiting for the informer to observe the created configmap")
+		}
+	}
+}
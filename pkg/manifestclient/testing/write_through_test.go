@@ -0,0 +1,172 @@
+package testing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/manifestclient"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	applyconfigurationscorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func setupWriteThroughClient(t *testing.T) (manifestclient.WriteThroughClient, *kubernetes.Clientset) {
+	t.Helper()
+	writeThroughClient := manifestclient.NewTestingWriteThroughHTTPClient(os.DirFS(filepath.Join("test-data", "write-through-dir")))
+	client, err := manifestclient.RecommendedKubernetesWithClient(writeThroughClient.GetHTTPClient())
+	if err != nil {
+		t.Fatalf("failure creating kubernetes client: %v", err)
+	}
+	return writeThroughClient, client
+}
+
+func TestWriteThroughReadsObserveWrites(t *testing.T) {
+	ctx := context.TODO()
+	_, client := setupWriteThroughClient(t)
+	configMaps := client.CoreV1().ConfigMaps("test-namespace")
+
+	created, err := configMaps.Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "created", Namespace: "test-namespace"},
+		Data:       map[string]string{"key": "value"},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("failed to create: %v", err)
+	}
+	if len(created.UID) == 0 || len(created.ResourceVersion) == 0 {
+		t.Errorf("expected uid and resourceVersion to be set, got %#v", created.ObjectMeta)
+	}
+	if _, err := configMaps.Create(ctx, created, metav1.CreateOptions{}); !apierrors.IsAlreadyExists(err) {
+		t.Errorf("expected already exists, got %v", err)
+	}
+
+	actual, err := configMaps.Get(ctx, "created", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get created configmap: %v", err)
+	}
+	if actual.Data["key"] != "value" {
+		t.Errorf("unexpected data: %v", actual.Data)
+	}
+
+	// audit is stored in a list file, make sure it can be updated in place.
+	audit, err := configMaps.Get(ctx, "audit", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get audit: %v", err)
+	}
+	stale := audit.DeepCopy()
+	audit.Labels = map[string]string{"updated": "true"}
+	if _, err := configMaps.Update(ctx, audit, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	if _, err := configMaps.Update(ctx, stale, metav1.UpdateOptions{}); !apierrors.IsConflict(err) {
+		t.Errorf("expected conflict for a stale resourceVersion, got %v", err)
+	}
+	audit, err = configMaps.Get(ctx, "audit", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get audit: %v", err)
+	}
+	if audit.Labels["updated"] != "true" {
+		t.Errorf("update not observed: %v", audit.Labels)
+	}
+
+	if _, err := configMaps.Patch(ctx, "created", types.MergePatchType, []byte(`{"data":{"other":"patched"}}`), metav1.PatchOptions{}); err != nil {
+		t.Fatalf("failed to patch: %v", err)
+	}
+	if _, err := configMaps.Apply(ctx, applyconfigurationscorev1.ConfigMap("applied", "test-namespace").WithData(map[string]string{"key": "applied"}), metav1.ApplyOptions{FieldManager: "test"}); err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+
+	list, err := configMaps.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	listed := map[string]corev1.ConfigMap{}
+	for _, item := range list.Items {
+		listed[item.Name] = item
+	}
+	if listed["created"].Data["other"] != "patched" {
+		t.Errorf("patch not observed in list: %v", listed["created"].Data)
+	}
+	if _, ok := listed["applied"]; !ok {
+		t.Errorf("apply not observed in list")
+	}
+
+	if err := configMaps.Delete(ctx, "created", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if _, err := configMaps.Get(ctx, "created", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected not found after delete, got %v", err)
+	}
+	if err := configMaps.Delete(ctx, "created", metav1.DeleteOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected not found for second delete, got %v", err)
+	}
+}
+
+func TestWriteThroughFailedRequestsAreNotTracked(t *testing.T) {
+	writeThroughClient, client := setupWriteThroughClient(t)
+
+	err := client.CoreV1().ConfigMaps("test-namespace").Delete(context.TODO(), "missing", metav1.DeleteOptions{})
+	if !apierrors.IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if mutations := writeThroughClient.GetMutations().AllRequests(); len(mutations) != 0 {
+		t.Errorf("expected no tracked mutations, got %d", len(mutations))
+	}
+}
+
+func TestWriteThroughWriteMustGatherDirectory(t *testing.T) {
+	ctx := context.TODO()
+	writeThroughClient, client := setupWriteThroughClient(t)
+
+	if _, err := client.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "new-namespace"}}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create namespace: %v", err)
+	}
+	if _, err := client.CoreV1().Secrets("new-namespace").Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "new-namespace"},
+		Data:       map[string][]byte{"key": []byte("value")},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create secret: %v", err)
+	}
+	if err := client.CoreV1().ConfigMaps("test-namespace").Delete(ctx, "audit", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+
+	outputDir := t.TempDir()
+	if err := writeThroughClient.WriteMustGatherDirectory(outputDir); err != nil {
+		t.Fatalf("failed to write must-gather: %v", err)
+	}
+
+	// the original directory must be untouched.
+	sourceClient, err := manifestclient.RecommendedKubernetesWithClient(manifestclient.NewHTTPClient(filepath.Join("test-data", "write-through-dir")).GetHTTPClient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sourceClient.CoreV1().ConfigMaps("test-namespace").Get(ctx, "audit", metav1.GetOptions{}); err != nil {
+		t.Errorf("source directory was modified: %v", err)
+	}
+
+	outputClient, err := manifestclient.RecommendedKubernetesWithClient(manifestclient.NewHTTPClient(outputDir).GetHTTPClient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := outputClient.CoreV1().ConfigMaps("test-namespace").Get(ctx, "audit", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected deleted configmap to be missing, got %v", err)
+	}
+	if _, err := outputClient.CoreV1().ConfigMaps("test-namespace").Get(ctx, "audit-1", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected existing configmap to be written: %v", err)
+	}
+	if _, err := outputClient.CoreV1().Namespaces().Get(ctx, "new-namespace", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected created namespace to be written: %v", err)
+	}
+	secret, err := outputClient.CoreV1().Secrets("new-namespace").Get(ctx, "secret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected created secret to be written: %v", err)
+	}
+	if string(secret.Data["key"]) != "value" {
+		t.Errorf("unexpected secret data: %v", secret.Data)
+	}
+}
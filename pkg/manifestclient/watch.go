@@ -0,0 +1,300 @@
+package manifestclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metainternalversionscheme "k8s.io/apimachinery/pkg/apis/meta/internalversion/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+)
+
+const (
+	defaultWatchTimeout          = 10 * time.Minute
+	defaultWatchPollInterval     = 1 * time.Second
+	defaultWatchBookmarkInterval = 1 * time.Minute
+)
+
+// watch produces a watch stream from the content of the directory.
+// The requested resourceVersion decides where the stream starts:
+//   - "0", or sendInitialEvents, starts with an ADDED event for every matching object.
+//   - "" starts from the current content without replaying it.
+//   - any other resourceVersion resumes from it: only the objects with a newer resourceVersion are sent, as ADDED events
+//     because the directory does not record whether they were created or updated since.
+//
+// After that the directory is re-listed every poll interval and the difference from the previous listing is sent as
+// ADDED, MODIFIED, and DELETED events.  This picks up changes made on disk as well as changes made through a
+// write-through client.
+func (mrt *manifestRoundTripper) watch(req *http.Request, requestInfo *apirequest.RequestInfo) (*http.Response, error) {
+	opts := &metav1.ListOptions{}
+	if err := metainternalversionscheme.ParameterCodec.DecodeParameters(req.URL.Query(), metav1.SchemeGroupVersion, opts); err != nil {
+		return nil, fmt.Errorf("unable to parse query parameters: %w", err)
+	}
+
+	timeout := defaultWatchTimeout
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+
+	listRequestInfo := *requestInfo
+	listRequestInfo.Verb = "list"
+	stream := &watchStream{
+		listFn: func() (*unstructured.UnstructuredList, error) {
+			return mrt.listForWatch(&listRequestInfo)
+		},
+		pollInterval:         mrt.watchPollInterval,
+		bookmarkInterval:     mrt.watchBookmarkInterval,
+		timeout:              timeout,
+		allowBookmarks:       opts.AllowWatchBookmarks,
+		sendInitialEventsEnd: opts.SendInitialEvents != nil && *opts.SendInitialEvents,
+	}
+	switch {
+	case stream.sendInitialEventsEnd || opts.ResourceVersion == "0":
+		stream.replayAll = true
+	case len(opts.ResourceVersion) > 0:
+		resumeFrom, err := strconv.ParseInt(opts.ResourceVersion, 10, 64)
+		if err != nil {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid resourceVersion %q: %v", opts.ResourceVersion, err))
+		}
+		stream.resumeFrom = &resumeFrom
+	}
+
+	// read the initial state before returning, so that invalid requests fail instead of producing an empty stream.
+	initialList, err := stream.listFn()
+	if err != nil {
+		return nil, err
+	}
+
+	reader, writer := io.Pipe()
+	body := &watchBody{PipeReader: reader, closed: make(chan struct{})}
+	ctx, cancel := context.WithCancel(req.Context())
+	go func() {
+		defer cancel()
+		stream.run(ctx, writer, initialList)
+	}()
+	go func() {
+		// stop the stream when the caller closes the body or goes away without closing it.
+		select {
+		case <-body.closed:
+		case <-ctx.Done():
+		}
+		cancel()
+		reader.CloseWithError(ctx.Err())
+	}()
+
+	resp := &http.Response{
+		Header: make(http.Header),
+	}
+	resp.StatusCode = http.StatusOK
+	resp.Status = http.StatusText(resp.StatusCode)
+	resp.Body = body
+	resp.Header.Set("Content-Type", "application/json")
+	return resp, nil
+}
+
+func (mrt *manifestRoundTripper) listForWatch(requestInfo *apirequest.RequestInfo) (*unstructured.UnstructuredList, error) {
+	listBytes, err := mrt.list(requestInfo)
+	if err != nil {
+		return nil, err
+	}
+	list, err := decodeListObj(listBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	itemKind := list.GroupVersionKind()
+	itemKind.Kind = strings.TrimSuffix(itemKind.Kind, "List")
+	for i := range list.Items {
+		if len(list.Items[i].GetKind()) == 0 {
+			list.Items[i].SetGroupVersionKind(itemKind)
+		}
+	}
+	return list, nil
+}
+
+// watchBody lets the stream stop as soon as the caller closes the body, instead of on the next write.
+type watchBody struct {
+	*io.PipeReader
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func (b *watchBody) Close() error {
+	b.closeOnce.Do(func() {
+		close(b.closed)
+	})
+	return b.PipeReader.Close()
+}
+
+type watchStream struct {
+	listFn func() (*unstructured.UnstructuredList, error)
+
+	pollInterval         time.Duration
+	bookmarkInterval     time.Duration
+	timeout              time.Duration
+	allowBookmarks       bool
+	sendInitialEventsEnd bool
+
+	// replayAll sends the initial listing as ADDED events.
+	replayAll bool
+	// resumeFrom sends the objects of the initial listing newer than the resourceVersion as ADDED events.
+	resumeFrom *int64
+}
+
+// replays returns true if the object of the initial listing is sent before the changes.
+func (w *watchStream) replays(obj *unstructured.Unstructured) bool {
+	if w.replayAll {
+		return true
+	}
+	if w.resumeFrom == nil {
+		return false
+	}
+	resourceVersion, err := strconv.ParseInt(obj.GetResourceVersion(), 10, 64)
+	return err == nil && resourceVersion > *w.resumeFrom
+}
+
+func (w *watchStream) run(ctx context.Context, writer *io.PipeWriter, initialList *unstructured.UnstructuredList) {
+	defer writer.Close()
+
+	itemKind := initialList.GroupVersionKind()
+	itemKind.Kind = strings.TrimSuffix(itemKind.Kind, "List")
+
+	previous := map[string]*unstructured.Unstructured{}
+	for i := range initialList.Items {
+		curr := &initialList.Items[i]
+		previous[watchKey(curr)] = curr
+		if !w.replays(curr) {
+			continue
+		}
+		if err := writeWatchEvent(writer, watch.Added, curr); err != nil {
+			return
+		}
+	}
+	if w.sendInitialEventsEnd {
+		bookmark := newBookmark(itemKind, initialList.GetResourceVersion())
+		bookmark.SetAnnotations(map[string]string{metav1.InitialEventsAnnotationKey: "true"})
+		if err := writeWatchEvent(writer, watch.Bookmark, bookmark); err != nil {
+			return
+		}
+	}
+
+	timeout := time.NewTimer(w.timeout)
+	defer timeout.Stop()
+	poll := time.NewTicker(w.pollInterval)
+	defer poll.Stop()
+	var bookmarkCh <-chan time.Time
+	if w.allowBookmarks {
+		bookmarkTicker := time.NewTicker(w.bookmarkInterval)
+		defer bookmarkTicker.Stop()
+		bookmarkCh = bookmarkTicker.C
+	}
+
+	resourceVersion := initialList.GetResourceVersion()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timeout.C:
+			return
+
+		case <-bookmarkCh:
+			if err := writeWatchEvent(writer, watch.Bookmark, newBookmark(itemKind, resourceVersion)); err != nil {
+				return
+			}
+
+		case <-poll.C:
+			currList, err := w.listFn()
+			if err != nil {
+				status := apierrors.NewInternalError(err).Status()
+				_ = writeWatchEvent(writer, watch.Error, &status)
+				return
+			}
+			if len(currList.GetResourceVersion()) > 0 {
+				resourceVersion = currList.GetResourceVersion()
+			}
+
+			current := map[string]*unstructured.Unstructured{}
+			for i := range currList.Items {
+				curr := &currList.Items[i]
+				key := watchKey(curr)
+				current[key] = curr
+
+				prev, existed := previous[key]
+				switch {
+				case !existed:
+					err = writeWatchEvent(writer, watch.Added, curr)
+				case !equality.Semantic.DeepEqual(prev.Object, curr.Object):
+					err = writeWatchEvent(writer, watch.Modified, curr)
+				}
+				if err != nil {
+					return
+				}
+			}
+			for key, prev := range previous {
+				if _, exists := current[key]; exists {
+					continue
+				}
+				if err := writeWatchEvent(writer, watch.Deleted, prev); err != nil {
+					return
+				}
+			}
+			previous = current
+		}
+	}
+}
+
+func watchKey(obj *unstructured.Unstructured) string {
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
+
+func newBookmark(kind schema.GroupVersionKind, resourceVersion string) *unstructured.Unstructured {
+	if len(resourceVersion) == 0 {
+		resourceVersion = "0"
+	}
+	bookmark := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	bookmark.SetGroupVersionKind(kind)
+	bookmark.SetResourceVersion(resourceVersion)
+	return bookmark
+}
+
+func writeWatchEvent(writer io.Writer, eventType watch.EventType, obj runtime.Object) error {
+	var objBytes []byte
+	var err error
+	switch t := obj.(type) {
+	case *unstructured.Unstructured:
+		objBytes, err = json.Marshal(t.Object)
+	case *metav1.Status:
+		t.Kind = "Status"
+		t.APIVersion = "v1"
+		objBytes, err = json.Marshal(t)
+	default:
+		err = fmt.Errorf("unsupported watch object %T", obj)
+	}
+	if err != nil {
+		return err
+	}
+
+	eventBytes, err := json.Marshal(&metav1.WatchEvent{
+		Type:   string(eventType),
+		Object: runtime.RawExtension{Raw: objBytes},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(append(eventBytes, '\n'))
+	return err
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metainternalversionscheme "k8s.io/apimachinery/pkg/apis/meta/internalversion/scheme"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -41,6 +42,10 @@ type writeTrackingRoundTripper struct {
 	lock              sync.RWMutex
 	nextRequestNumber int
 	actionTracker     *AllActionsTracker[TrackedSerializedRequest]
+
+	// replayer is optional.  When set, requests are applied to its overlay before being tracked and requests that fail
+	// are not tracked.
+	replayer *mutationReplayer
 }
 
 func newWriteRoundTripper(discoveryRoundTripper *discoveryReader) *writeTrackingRoundTripper {
@@ -58,6 +63,21 @@ func (mrt *writeTrackingRoundTripper) RoundTrip(req *http.Request) (*http.Respon
 	resp := &http.Response{}
 
 	retJSONBytes, err := mrt.roundTrip(req)
+	if statusErr, ok := err.(apierrors.APIStatus); ok && mrt.replayer != nil {
+		// write-through clients return real errors so callers can react to conflicts and missing objects.
+		status := statusErr.Status()
+		status.Kind = "Status"
+		status.APIVersion = "v1"
+		statusBytes, encodeErr := json.Marshal(status)
+		if encodeErr == nil {
+			resp.StatusCode = int(status.Code)
+			resp.Status = http.StatusText(resp.StatusCode)
+			resp.Body = io.NopCloser(bytes.NewReader(statusBytes))
+			resp.Header = make(http.Header)
+			resp.Header.Set("Content-Type", "application/json")
+			return resp, nil
+		}
+	}
 	if err != nil {
 		resp.StatusCode = http.StatusInternalServerError
 		resp.Status = http.StatusText(resp.StatusCode)
@@ -157,7 +177,8 @@ func (mrt *writeTrackingRoundTripper) roundTrip(req *http.Request) ([]byte, erro
 		if err != nil {
 			return nil, fmt.Errorf("unable to decode body: %w", err)
 		}
-		if requestInfo.Namespace != bodyObj.(*unstructured.Unstructured).GetNamespace() {
+		// delete bodies are DeleteOptions, they have no namespace or name.
+		if action != ActionDelete && requestInfo.Namespace != bodyObj.(*unstructured.Unstructured).GetNamespace() {
 			return nil, fmt.Errorf("request namespace %q does not equal body namespace %q", requestInfo.Namespace, bodyObj.(*unstructured.Unstructured).GetNamespace())
 		}
 		if action != ActionCreate && action != ActionDelete && requestInfo.Name != bodyObj.(*unstructured.Unstructured).GetName() {
@@ -226,6 +247,23 @@ func (mrt *writeTrackingRoundTripper) roundTrip(req *http.Request) ([]byte, erro
 	// this lock also protects the access to actionTracker
 	mrt.lock.Lock()
 	defer mrt.lock.Unlock()
+
+	var replayedObj *unstructured.Unstructured
+	if mrt.replayer != nil {
+		replayKind := kindType
+		if action == ActionPatch || action == ActionPatchStatus || action == ActionDelete {
+			kindForResource, err := mrt.discoveryReader.getKindForResource(gvr)
+			if err != nil {
+				return nil, err
+			}
+			replayKind = kindForResource.kind
+		}
+		replayedObj, err = mrt.replayer.replay(requestInfo, action, patchType, replayKind, bodyContent)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	trackedRequest := TrackedSerializedRequest{
 		RequestNumber:     mrt.nextRequestNumber,
 		SerializedRequest: serializedRequest,
@@ -234,6 +272,14 @@ func (mrt *writeTrackingRoundTripper) roundTrip(req *http.Request) ([]byte, erro
 
 	mrt.actionTracker.AddRequest(trackedRequest)
 
+	if replayedObj != nil {
+		retBytes, err := json.Marshal(replayedObj.Object)
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode body: %w", err)
+		}
+		return retBytes, nil
+	}
+
 	// returning a value that will probably not cause the wrapping client to fail, but isn't very useful.
 	// this keeps calling code from depending on the return value.
 	ret := &unstructured.Unstructured{Object: map[string]interface{}{}}
@@ -0,0 +1,370 @@
+package manifestclient
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/storage/names"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// mutationReplayer applies write requests to an overlayFS so that later reads observe them.
+// It behaves like a very small kube-apiserver:
+//  1. creates fail if the object exists, everything else fails if it does not.
+//  2. updates with a resourceVersion must match the stored resourceVersion.
+//  3. writes to the main resource keep the stored status and writes to the status subresource only change status.
+//  4. deletes of objects with finalizers only set the deletionTimestamp.
+//
+// Server-side apply is approximated with a JSON merge patch of the applied configuration; field ownership is not tracked.
+type mutationReplayer struct {
+	overlay *overlayFS
+
+	lock                sync.Mutex
+	nextResourceVersion int64
+}
+
+func newMutationReplayer(overlay *overlayFS) *mutationReplayer {
+	return &mutationReplayer{
+		overlay:             overlay,
+		nextResourceVersion: 1,
+	}
+}
+
+// objectLocation is the file holding an object.  Objects are either stored as individual files or as items in a list file.
+type objectLocation struct {
+	path   string
+	inList bool
+}
+
+// replay applies a single request and returns the resulting object.  For deletes, the deleted object is returned.
+// body is the raw request body; kind is the kind of the object the request is for.
+func (r *mutationReplayer) replay(requestInfo *apirequest.RequestInfo, action Action, patchType string, kind schema.GroupVersionKind, body []byte) (*unstructured.Unstructured, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var bodyObj *unstructured.Unstructured
+	if action != ActionPatch && action != ActionPatchStatus && action != ActionDelete {
+		obj, err := runtime.Decode(unstructured.UnstructuredJSONScheme, body)
+		if err != nil {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("unable to decode body: %v", err))
+		}
+		bodyObj = obj.(*unstructured.Unstructured)
+	}
+
+	// the subresource is part of the action, the object is stored in the same place regardless.
+	target := *requestInfo
+	target.Subresource = ""
+	if action == ActionCreate {
+		target.Name = bodyObj.GetName()
+		if len(target.Name) == 0 && len(bodyObj.GetGenerateName()) > 0 {
+			target.Name = names.SimpleNameGenerator.GenerateName(bodyObj.GetGenerateName())
+			bodyObj.SetName(target.Name)
+		}
+		if len(target.Name) == 0 {
+			return nil, apierrors.NewBadRequest("name or generateName is required")
+		}
+	}
+	if isNamespaceResource(&target) {
+		// namespaces list their own namespace in requestInfo.namespace
+		target.Namespace = target.Name
+	}
+	groupResource := schema.GroupResource{Group: target.APIGroup, Resource: target.Resource}
+
+	existing, location, err := r.find(&target)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *unstructured.Unstructured
+	switch action {
+	case ActionCreate:
+		if existing != nil {
+			return nil, apierrors.NewAlreadyExists(groupResource, target.Name)
+		}
+		result = bodyObj
+		result.SetUID(uuid.NewUUID())
+		result.SetCreationTimestamp(metav1.Now())
+
+	case ActionUpdate, ActionUpdateStatus:
+		if existing == nil {
+			return nil, apierrors.NewNotFound(groupResource, target.Name)
+		}
+		if rv := bodyObj.GetResourceVersion(); len(rv) > 0 && rv != existing.GetResourceVersion() {
+			return nil, apierrors.NewConflict(groupResource, target.Name,
+				fmt.Errorf("the object has been modified; please apply your changes to the latest version and try again"))
+		}
+		result = mergeSubresource(existing, bodyObj, action == ActionUpdateStatus)
+
+	case ActionApply, ActionApplyStatus:
+		if existing == nil && action == ActionApplyStatus {
+			return nil, apierrors.NewNotFound(groupResource, target.Name)
+		}
+		if existing == nil {
+			result = bodyObj
+			result.SetUID(uuid.NewUUID())
+			result.SetCreationTimestamp(metav1.Now())
+			break
+		}
+		applied, err := patchObject(existing, string(types.MergePatchType), body, kind)
+		if err != nil {
+			return nil, err
+		}
+		result = mergeSubresource(existing, applied, action == ActionApplyStatus)
+
+	case ActionPatch, ActionPatchStatus:
+		if existing == nil {
+			return nil, apierrors.NewNotFound(groupResource, target.Name)
+		}
+		patched, err := patchObject(existing, patchType, body, kind)
+		if err != nil {
+			return nil, err
+		}
+		result = mergeSubresource(existing, patched, action == ActionPatchStatus)
+
+	case ActionDelete:
+		if existing == nil {
+			return nil, apierrors.NewNotFound(groupResource, target.Name)
+		}
+		if len(existing.GetFinalizers()) == 0 {
+			if err := r.remove(location, kind, target.Name); err != nil {
+				return nil, err
+			}
+			return existing, nil
+		}
+		if existing.GetDeletionTimestamp() != nil {
+			return existing, nil
+		}
+		result = existing.DeepCopy()
+		now := metav1.Now()
+		result.SetDeletionTimestamp(&now)
+
+	default:
+		return nil, fmt.Errorf("action %v is not supported by this implementation", action)
+	}
+
+	if existing != nil {
+		result.SetUID(existing.GetUID())
+		result.SetCreationTimestamp(existing.GetCreationTimestamp())
+		if action != ActionDelete {
+			result.SetDeletionTimestamp(existing.GetDeletionTimestamp())
+		}
+		if generation := existing.GetGeneration(); generation > 0 {
+			result.SetGeneration(generation)
+			if specChanged(existing, result) {
+				result.SetGeneration(generation + 1)
+			}
+		}
+	}
+	result.SetNamespace(requestInfo.Namespace)
+	result.SetName(target.Name)
+	if isNamespaceResource(&target) {
+		result.SetNamespace("")
+	}
+	result.SetGroupVersionKind(kind)
+	result.SetResourceVersion(strconv.FormatInt(r.nextResourceVersion, 10))
+	r.nextResourceVersion++
+
+	// an update may remove the last finalizer of an object that is being deleted.
+	if result.GetDeletionTimestamp() != nil && len(result.GetFinalizers()) == 0 {
+		if err := r.remove(location, kind, target.Name); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+	if err := r.store(location, kind, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// find returns the current object and its location.  If the object does not exist, the location is where it should be
+// created: beside existing data for the resource if there is any, otherwise in a new list file.
+func (r *mutationReplayer) find(requestInfo *apirequest.RequestInfo) (*unstructured.Unstructured, objectLocation, error) {
+	individualFilePath := individualGetFileLocation(requestInfo)
+	individualObj, err := readIndividualFile(r.overlay, individualFilePath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, objectLocation{}, fmt.Errorf("unable to read file: %w", err)
+	default:
+		return individualObj, objectLocation{path: individualFilePath}, nil
+	}
+	if isNamespaceResource(requestInfo) {
+		return nil, objectLocation{path: individualFilePath}, nil
+	}
+
+	listFilePath := listGetFileLocation(requestInfo)
+	listObj, err := readListFile(r.overlay, listFilePath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, objectLocation{}, fmt.Errorf("unable to read file: %w", err)
+	default:
+		obj, _ := individualFromList(listObj, requestInfo.Name)
+		return obj, objectLocation{path: listFilePath, inList: true}, nil
+	}
+
+	if _, err := fs.ReadDir(r.overlay, filepath.Dir(individualFilePath)); err == nil {
+		return nil, objectLocation{path: individualFilePath}, nil
+	}
+	return nil, objectLocation{path: listFilePath, inList: true}, nil
+}
+
+func (r *mutationReplayer) store(location objectLocation, kind schema.GroupVersionKind, obj *unstructured.Unstructured) error {
+	if !location.inList {
+		content, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return fmt.Errorf("unable to encode %v: %w", location.path, err)
+		}
+		r.overlay.writeFile(location.path, content)
+		return nil
+	}
+
+	return r.updateList(location.path, kind, func(list *unstructured.UnstructuredList) {
+		for i := range list.Items {
+			if list.Items[i].GetName() == obj.GetName() {
+				list.Items[i] = *obj
+				return
+			}
+		}
+		list.Items = append(list.Items, *obj)
+	})
+}
+
+func (r *mutationReplayer) remove(location objectLocation, kind schema.GroupVersionKind, name string) error {
+	if !location.inList {
+		r.overlay.removeFile(location.path)
+		return nil
+	}
+
+	return r.updateList(location.path, kind, func(list *unstructured.UnstructuredList) {
+		items := []unstructured.Unstructured{}
+		for i := range list.Items {
+			if list.Items[i].GetName() != name {
+				items = append(items, list.Items[i])
+			}
+		}
+		list.Items = items
+	})
+}
+
+func (r *mutationReplayer) updateList(listFilePath string, kind schema.GroupVersionKind, mutateFn func(list *unstructured.UnstructuredList)) error {
+	list, err := readListFile(r.overlay, listFilePath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		list = &unstructured.UnstructuredList{Object: map[string]interface{}{}}
+		list.SetGroupVersionKind(kind.GroupVersion().WithKind(kind.Kind + "List"))
+	case err != nil:
+		return fmt.Errorf("unable to read file: %w", err)
+	}
+
+	mutateFn(list)
+
+	content, err := yaml.Marshal(list.UnstructuredContent())
+	if err != nil {
+		return fmt.Errorf("unable to encode %v: %w", listFilePath, err)
+	}
+	r.overlay.writeFile(listFilePath, content)
+	return nil
+}
+
+// mergeSubresource combines the stored object with the requested object.  Writes to the status subresource only change
+// status; writes to the main resource keep the stored status.
+func mergeSubresource(existing, requested *unstructured.Unstructured, statusOnly bool) *unstructured.Unstructured {
+	var ret *unstructured.Unstructured
+	var status interface{}
+	var hasStatus bool
+	if statusOnly {
+		ret = existing.DeepCopy()
+		status, hasStatus = requested.Object["status"]
+	} else {
+		ret = requested.DeepCopy()
+		status, hasStatus = existing.Object["status"]
+	}
+
+	if hasStatus {
+		ret.Object["status"] = runtime.DeepCopyJSONValue(status)
+	} else {
+		delete(ret.Object, "status")
+	}
+	return ret
+}
+
+func specChanged(existing, updated *unstructured.Unstructured) bool {
+	withoutMetadata := func(obj *unstructured.Unstructured) map[string]interface{} {
+		ret := map[string]interface{}{}
+		for k, v := range obj.Object {
+			if k == "metadata" || k == "status" {
+				continue
+			}
+			ret[k] = v
+		}
+		return ret
+	}
+	return !equality.Semantic.DeepEqual(withoutMetadata(existing), withoutMetadata(updated))
+}
+
+func patchObject(existing *unstructured.Unstructured, patchType string, patch []byte, kind schema.GroupVersionKind) (*unstructured.Unstructured, error) {
+	existingJSON, err := json.Marshal(existing.Object)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode existing object: %w", err)
+	}
+
+	var patchedJSON []byte
+	switch types.PatchType(patchType) {
+	case types.JSONPatchType:
+		jsonPatch, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("unable to decode JSONPatch: %v", err))
+		}
+		patchedJSON, err = jsonPatch.Apply(existingJSON)
+		if err != nil {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("unable to apply JSONPatch: %v", err))
+		}
+	case types.MergePatchType:
+		patchedJSON, err = jsonpatch.MergePatch(existingJSON, patch)
+		if err != nil {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("unable to apply merge patch: %v", err))
+		}
+	case types.StrategicMergePatchType:
+		dataStruct, err := clientgoscheme.Scheme.New(kind)
+		if err != nil {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("strategic merge patch is not supported for %v", kind))
+		}
+		patchedJSON, err = strategicpatch.StrategicMergePatch(existingJSON, patch, dataStruct)
+		if err != nil {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("unable to apply strategic merge patch: %v", err))
+		}
+	default:
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("patch type %q is not supported", patchType))
+	}
+
+	patched, err := runtime.Decode(unstructured.UnstructuredJSONScheme, patchedJSON)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode patched object: %w", err)
+	}
+	return patched.(*unstructured.Unstructured), nil
+}
+
+func isNamespaceResource(requestInfo *apirequest.RequestInfo) bool {
+	return len(requestInfo.APIGroup) == 0 &&
+		requestInfo.APIVersion == "v1" &&
+		requestInfo.Resource == "namespaces"
+}
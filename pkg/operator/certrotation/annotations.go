@@ -23,6 +23,10 @@ const (
 	CertificateAutoRegenerateAfterOfflineExpiryAnnotation string = "certificates.openshift.io/auto-regenerate-after-offline-expiry"
 	// CertificateRefreshPeriodAnnotation is the interval at which the certificate should be refreshed.
 	CertificateRefreshPeriodAnnotation string = "certificates.openshift.io/refresh-period"
+	// CertificateRevokedSerialsAnnotation contains the comma separated serial numbers of revoked certificates issued by a signer,
+	// each in the form <decimal serial number>@<revocation time in RFC3339 format>[@<expiry in RFC3339 format>].
+	// Serials are pruned once the certificate expired.
+	CertificateRevokedSerialsAnnotation string = "auth.openshift.io/certificate-revoked-serials"
 )
 
 type AdditionalAnnotations struct {
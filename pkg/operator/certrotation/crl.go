@@ -0,0 +1,169 @@
+package certrotation
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"math/big"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcehelper"
+)
+
+const (
+	// CRLConfigMapKey is the key of the PEM encoded certificate revocation list in the ConfigMap maintained by CRLConfigMap.
+	CRLConfigMapKey = "ca-bundle.crl"
+
+	// defaultCRLValidity is used when CRLConfigMap.Validity is not set.
+	defaultCRLValidity = 24 * time.Hour
+)
+
+// CRLConfigMap maintains a config map with the certificate revocation list of the certificates revoked via
+// RotatedSigningCASecret.RevokeCertificates, signed by the current signer. It is meant to live next to the
+// CA bundle config map of the same signer.
+type CRLConfigMap struct {
+	// Namespace is the namespace of the ConfigMap to maintain.
+	Namespace string
+	// Name is the name of the ConfigMap to maintain.
+	Name string
+	// Validity is the duration from time.Now() until the next update of the certificate revocation list is due.
+	// The list is re-signed when 80% of validity is reached. It defaults to 24h.
+	Validity time.Duration
+	// Owner is an optional reference to add to the config map that this rotator creates.
+	Owner *metav1.OwnerReference
+	// AdditionalAnnotations is a collection of annotations set for the config map
+	AdditionalAnnotations AdditionalAnnotations
+	// Plumbing:
+	Informer      corev1informers.ConfigMapInformer
+	Lister        corev1listers.ConfigMapLister
+	Client        corev1client.ConfigMapsGetter
+	EventRecorder events.Recorder
+}
+
+// EnsureConfigMapCRL makes sure the config map contains a current certificate revocation list with the given revoked
+// certificates, signed by the given signer. Signers predating the CRL sign key usage publish no list as long as there
+// is nothing to revoke. It returns the published list, or nil if there is none.
+func (c CRLConfigMap) EnsureConfigMapCRL(ctx context.Context, signingCertKeyPair *crypto.CA, revoked []x509.RevocationListEntry) (*x509.RevocationList, error) {
+	if !signingCertKeyPair.CanSignCRL() && len(revoked) == 0 {
+		return nil, nil
+	}
+
+	creationRequired := false
+	updateRequired := false
+
+	originalCRLConfigMap, err := c.Lister.ConfigMaps(c.Namespace).Get(c.Name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	crlConfigMap := originalCRLConfigMap.DeepCopy()
+	if apierrors.IsNotFound(err) {
+		// create an empty one
+		crlConfigMap = &corev1.ConfigMap{ObjectMeta: NewTLSArtifactObjectMeta(
+			c.Name,
+			c.Namespace,
+			c.AdditionalAnnotations,
+		)}
+		creationRequired = true
+	}
+
+	needsOwnerUpdate := false
+	if c.Owner != nil {
+		needsOwnerUpdate = ensureOwnerReference(&crlConfigMap.ObjectMeta, c.Owner)
+	}
+	needsMetadataUpdate := c.AdditionalAnnotations.EnsureTLSMetadataUpdate(&crlConfigMap.ObjectMeta)
+	updateRequired = needsOwnerUpdate || needsMetadataUpdate
+
+	currentCRL, _ := crypto.ParseCRLPEM([]byte(crlConfigMap.Data[CRLConfigMapKey]))
+	if reason := needNewCRL(currentCRL, signingCertKeyPair, revoked); len(reason) > 0 {
+		if creationRequired {
+			reason = "configmap doesn't exist"
+		}
+		c.EventRecorder.Eventf("CRLUpdateRequired", "%q in %q requires a new certificate revocation list: %s", c.Name, c.Namespace, reason)
+
+		number := big.NewInt(1)
+		if currentCRL != nil && currentCRL.Number != nil {
+			number = new(big.Int).Add(currentCRL.Number, big.NewInt(1))
+		}
+		validity := c.Validity
+		if validity == 0 {
+			validity = defaultCRLValidity
+		}
+		crlBytes, err := signingCertKeyPair.MakeCRLForDuration(revoked, number, validity)
+		if err != nil {
+			return nil, err
+		}
+		if crlConfigMap.Data == nil {
+			crlConfigMap.Data = map[string]string{}
+		}
+		crlConfigMap.Data[CRLConfigMapKey] = string(crlBytes)
+		LabelAsManagedConfigMap(crlConfigMap, CertificateTypeCRL)
+
+		updateRequired = true
+	}
+
+	if creationRequired {
+		actualCRLConfigMap, err := c.Client.ConfigMaps(c.Namespace).Create(ctx, crlConfigMap, metav1.CreateOptions{})
+		resourcehelper.ReportCreateEvent(c.EventRecorder, actualCRLConfigMap, err)
+		if err != nil {
+			return nil, err
+		}
+		klog.V(2).Infof("Created ca-bundle.crl configmap %s/%s with %d revoked certificates", crlConfigMap.Namespace, crlConfigMap.Name, len(revoked))
+		crlConfigMap = actualCRLConfigMap
+	} else if updateRequired {
+		actualCRLConfigMap, err := c.Client.ConfigMaps(c.Namespace).Update(ctx, crlConfigMap, metav1.UpdateOptions{})
+		if apierrors.IsConflict(err) {
+			// ignore error if its attempting to update outdated version of the configmap
+			return nil, nil
+		}
+		resourcehelper.ReportUpdateEvent(c.EventRecorder, actualCRLConfigMap, err)
+		if err != nil {
+			return nil, err
+		}
+		klog.V(2).Infof("Updated ca-bundle.crl configmap %s/%s with %d revoked certificates", crlConfigMap.Namespace, crlConfigMap.Name, len(revoked))
+		crlConfigMap = actualCRLConfigMap
+	}
+
+	crl, err := crypto.ParseCRLPEM([]byte(crlConfigMap.Data[CRLConfigMapKey]))
+	if err != nil {
+		return nil, fmt.Errorf("configmap/%s -n%s has an invalid %s: %v", crlConfigMap.Name, crlConfigMap.Namespace, CRLConfigMapKey, err)
+	}
+	return crl, nil
+}
+
+// needNewCRL returns a reason if the current certificate revocation list is missing, is not signed by the current
+// signer, does not list exactly the revoked certificates or is past 80% of its validity.
+func needNewCRL(currentCRL *x509.RevocationList, signingCertKeyPair *crypto.CA, revoked []x509.RevocationListEntry) string {
+	if currentCRL == nil {
+		return "missing or invalid certificate revocation list"
+	}
+	if err := currentCRL.CheckSignatureFrom(signingCertKeyPair.Config.Certs[0]); err != nil {
+		return fmt.Sprintf("not signed by the current signer %q", signingCertKeyPair.Config.Certs[0].Subject.CommonName)
+	}
+
+	if len(currentCRL.RevokedCertificateEntries) != len(revoked) {
+		return fmt.Sprintf("revoked certificates changed from %d to %d", len(currentCRL.RevokedCertificateEntries), len(revoked))
+	}
+	for _, entry := range revoked {
+		if !isSerialNumberRevoked(currentCRL.RevokedCertificateEntries, entry.SerialNumber) {
+			return fmt.Sprintf("certificate %s was revoked", entry.SerialNumber)
+		}
+	}
+
+	validity := currentCRL.NextUpdate.Sub(currentCRL.ThisUpdate)
+	at80Percent := currentCRL.NextUpdate.Add(-validity / 5)
+	if time.Now().After(at80Percent) {
+		return fmt.Sprintf("past refresh time (80%% of validity): %v", at80Percent)
+	}
+
+	return ""
+}
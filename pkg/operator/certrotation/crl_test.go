@@ -0,0 +1,223 @@
+package certrotation
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestEnsureConfigMapCRL(t *testing.T) {
+	signer, err := newTestSigner("signer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	previousSigner, err := newTestSigner("previous-signer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacySigner, err := newTestCACertificate(pkix.Name{CommonName: "signer-tests"}, int64(1), metav1.Duration{Duration: time.Hour * 24 * 60}, time.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revocationTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	revoked := []x509.RevocationListEntry{{SerialNumber: big.NewInt(1234), RevocationTime: revocationTime}}
+	moreRevoked := append([]x509.RevocationListEntry{{SerialNumber: big.NewInt(5678), RevocationTime: revocationTime}}, revoked...)
+
+	crlConfigMap := func(ca *crypto.CA, revoked []x509.RevocationListEntry, number int64) *corev1.ConfigMap {
+		crlBytes, err := ca.MakeCRLForDuration(revoked, big.NewInt(number), 24*time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "trust-crl", ResourceVersion: "10"},
+			Data:       map[string]string{CRLConfigMapKey: string(crlBytes)},
+		}
+	}
+
+	tests := []struct {
+		name string
+
+		initialConfigMap *corev1.ConfigMap
+		signer           *crypto.CA
+		revoked          []x509.RevocationListEntry
+
+		verifyActions   func(t *testing.T, client *kubefake.Clientset)
+		expectedRevoked []int64
+		expectedNumber  int64
+		expectedNoCRL   bool
+		expectedError   string
+	}{
+		{
+			name:    "initial create",
+			signer:  signer,
+			revoked: revoked,
+			verifyActions: func(t *testing.T, client *kubefake.Clientset) {
+				actions := client.Actions()
+				if len(actions) != 1 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("create", "configmaps") {
+					t.Error(actions[0])
+				}
+				actual := actions[0].(clienttesting.CreateAction).GetObject().(*corev1.ConfigMap)
+				if certType, _ := CertificateTypeFromObject(actual); certType != CertificateTypeCRL {
+					t.Errorf("expected certificate type 'crl', got: %v", certType)
+				}
+			},
+			expectedRevoked: []int64{1234},
+			expectedNumber:  1,
+		},
+		{
+			name:             "no work",
+			initialConfigMap: crlConfigMap(signer, revoked, 3),
+			signer:           signer,
+			revoked:          revoked,
+			verifyActions: func(t *testing.T, client *kubefake.Clientset) {
+				if actions := client.Actions(); len(actions) != 0 {
+					t.Fatal(spew.Sdump(actions))
+				}
+			},
+			expectedRevoked: []int64{1234},
+			expectedNumber:  3,
+		},
+		{
+			name:             "certificate revoked",
+			initialConfigMap: crlConfigMap(signer, revoked, 3),
+			signer:           signer,
+			revoked:          moreRevoked,
+			verifyActions: func(t *testing.T, client *kubefake.Clientset) {
+				actions := client.Actions()
+				if len(actions) != 1 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("update", "configmaps") {
+					t.Error(actions[0])
+				}
+			},
+			expectedRevoked: []int64{5678, 1234},
+			expectedNumber:  4,
+		},
+		{
+			name:             "signer rotated",
+			initialConfigMap: crlConfigMap(previousSigner, revoked, 3),
+			signer:           signer,
+			revoked:          revoked,
+			verifyActions: func(t *testing.T, client *kubefake.Clientset) {
+				actions := client.Actions()
+				if len(actions) != 1 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("update", "configmaps") {
+					t.Error(actions[0])
+				}
+			},
+			expectedRevoked: []int64{1234},
+			expectedNumber:  4,
+		},
+		{
+			name:   "legacy signer without revocations",
+			signer: legacySigner,
+			verifyActions: func(t *testing.T, client *kubefake.Clientset) {
+				if actions := client.Actions(); len(actions) != 0 {
+					t.Fatal(spew.Sdump(actions))
+				}
+			},
+			expectedNoCRL: true,
+		},
+		{
+			name:    "legacy signer with revocations",
+			signer:  legacySigner,
+			revoked: revoked,
+			verifyActions: func(t *testing.T, client *kubefake.Clientset) {
+				if actions := client.Actions(); len(actions) != 0 {
+					t.Fatal(spew.Sdump(actions))
+				}
+			},
+			expectedError: "is not allowed to sign certificate revocation lists",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			client := kubefake.NewSimpleClientset()
+			if test.initialConfigMap != nil {
+				indexer.Add(test.initialConfigMap)
+				client = kubefake.NewSimpleClientset(test.initialConfigMap)
+			}
+
+			c := &CRLConfigMap{
+				Namespace:     "ns",
+				Name:          "trust-crl",
+				Lister:        corev1listers.NewConfigMapLister(indexer),
+				Client:        client.CoreV1(),
+				EventRecorder: events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())),
+			}
+
+			crl, err := c.EnsureConfigMapCRL(context.TODO(), test.signer, test.revoked)
+			switch {
+			case err != nil && len(test.expectedError) == 0:
+				t.Error(err)
+			case err != nil && !strings.Contains(err.Error(), test.expectedError):
+				t.Error(err)
+			case err == nil && len(test.expectedError) != 0:
+				t.Errorf("missing %q", test.expectedError)
+			}
+
+			test.verifyActions(t, client)
+			if err != nil {
+				return
+			}
+			if test.expectedNoCRL {
+				if crl != nil {
+					t.Errorf("expected no certificate revocation list, got %s", spew.Sdump(crl))
+				}
+				return
+			}
+
+			if err := crypto.VerifyCRL(crl, test.signer.Config.Certs); err != nil {
+				t.Error(err)
+			}
+			if crl.Number.Int64() != test.expectedNumber {
+				t.Errorf("expected CRL number %d, got %d", test.expectedNumber, crl.Number.Int64())
+			}
+			if len(crl.RevokedCertificateEntries) != len(test.expectedRevoked) {
+				t.Fatalf("expected %d revoked certificates, got %s", len(test.expectedRevoked), spew.Sdump(crl.RevokedCertificateEntries))
+			}
+			for i, serial := range test.expectedRevoked {
+				if crl.RevokedCertificateEntries[i].SerialNumber.Int64() != serial {
+					t.Errorf("expected revoked serial number %d, got %d", serial, crl.RevokedCertificateEntries[i].SerialNumber.Int64())
+				}
+				if !crl.RevokedCertificateEntries[i].RevocationTime.Equal(revocationTime) {
+					t.Errorf("expected revocation time %v, got %v", revocationTime, crl.RevokedCertificateEntries[i].RevocationTime)
+				}
+			}
+		})
+	}
+}
+
+func newTestSigner(name string) (*crypto.CA, error) {
+	caConfig, err := crypto.MakeSelfSignedCAConfigForDuration(name, time.Hour*24*60)
+	if err != nil {
+		return nil, err
+	}
+	return &crypto.CA{Config: caConfig, SerialGenerator: &crypto.RandomSerialGenerator{}}, nil
+}
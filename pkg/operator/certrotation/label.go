@@ -19,6 +19,7 @@ var (
 	CertificateTypeCABundle CertificateType = "ca-bundle"
 	CertificateTypeSigner   CertificateType = "signer"
 	CertificateTypeTarget   CertificateType = "target"
+	CertificateTypeCRL      CertificateType = "crl"
 	CertificateTypeUnknown  CertificateType = "unknown"
 )
 
@@ -53,7 +54,7 @@ func CertificateTypeFromObject(obj runtime.Object) (CertificateType, error) {
 
 	t := CertificateType(actualLabels[ManagedCertificateTypeLabelName])
 	switch t {
-	case CertificateTypeCABundle, CertificateTypeSigner, CertificateTypeTarget:
+	case CertificateTypeCABundle, CertificateTypeSigner, CertificateTypeTarget, CertificateTypeCRL:
 		return t, nil
 	default:
 		return CertificateTypeUnknown, nil
@@ -0,0 +1,213 @@
+package certrotation
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/operator/resource/resourcehelper"
+)
+
+// revokedCertificate is a revocation recorded on a signer secret.
+type revokedCertificate struct {
+	x509.RevocationListEntry
+	// notAfter is the expiry of the revoked certificate, zero if unknown. Expired revocations are pruned.
+	notAfter time.Time
+}
+
+// RevokeCertificates records the given certificates as revoked on the signer secret. The revocations are kept
+// when the signer is rotated, and they are published as certificate revocation list by a CRLConfigMap until the
+// certificates expire. Certificates that are already revoked keep their original revocation time.
+func (c RotatedSigningCASecret) RevokeCertificates(ctx context.Context, certificates ...*x509.Certificate) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		signingCertKeyPairSecret, err := c.Client.Secrets(c.Namespace).Get(ctx, c.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		revoked, err := parseRevokedCertificates(signingCertKeyPairSecret)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		updated := false
+		for _, certificate := range certificates {
+			if isSerialNumberRevoked(toRevocationListEntries(revoked), certificate.SerialNumber) {
+				continue
+			}
+			revoked = append(revoked, revokedCertificate{
+				RevocationListEntry: x509.RevocationListEntry{SerialNumber: certificate.SerialNumber, RevocationTime: now},
+				notAfter:            certificate.NotAfter,
+			})
+			updated = true
+		}
+		if !updated {
+			return nil
+		}
+
+		signingCertKeyPairSecret = signingCertKeyPairSecret.DeepCopy()
+		setRevokedCertificates(signingCertKeyPairSecret, revoked)
+		actualSigningCertKeyPairSecret, err := c.Client.Secrets(c.Namespace).Update(ctx, signingCertKeyPairSecret, metav1.UpdateOptions{})
+		resourcehelper.ReportUpdateEvent(c.EventRecorder, actualSigningCertKeyPairSecret, err)
+		if err != nil {
+			return err
+		}
+		klog.V(2).Infof("Recorded %d revoked certificates on secret %s/%s", len(revoked), c.Namespace, c.Name)
+		return nil
+	})
+}
+
+// pruneExpiredRevocations removes the revocations of expired certificates from the signer secret.
+func (c RotatedSigningCASecret) pruneExpiredRevocations(ctx context.Context) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		signingCertKeyPairSecret, err := c.Client.Secrets(c.Namespace).Get(ctx, c.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		revoked, err := parseRevokedCertificates(signingCertKeyPairSecret)
+		if err != nil {
+			return err
+		}
+		unexpired := unexpiredRevocations(revoked, time.Now())
+		if len(unexpired) == len(revoked) {
+			return nil
+		}
+
+		signingCertKeyPairSecret = signingCertKeyPairSecret.DeepCopy()
+		setRevokedCertificates(signingCertKeyPairSecret, unexpired)
+		actualSigningCertKeyPairSecret, err := c.Client.Secrets(c.Namespace).Update(ctx, signingCertKeyPairSecret, metav1.UpdateOptions{})
+		resourcehelper.ReportUpdateEvent(c.EventRecorder, actualSigningCertKeyPairSecret, err)
+		if err != nil {
+			return err
+		}
+		klog.V(2).Infof("Pruned %d expired revoked certificates from secret %s/%s", len(revoked)-len(unexpired), c.Namespace, c.Name)
+		return nil
+	})
+}
+
+// RevokedCertificates returns the revoked certificates recorded on the given signer secret, ordered by revocation time.
+func RevokedCertificates(secret *corev1.Secret) ([]x509.RevocationListEntry, error) {
+	revoked, err := parseRevokedCertificates(secret)
+	if err != nil {
+		return nil, err
+	}
+	return toRevocationListEntries(revoked), nil
+}
+
+func parseRevokedCertificates(secret *corev1.Secret) ([]revokedCertificate, error) {
+	value := secret.Annotations[CertificateRevokedSerialsAnnotation]
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	revoked := []revokedCertificate{}
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.Split(entry, "@")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("bad revoked certificate %q in secret %s/%s: missing revocation time", entry, secret.Namespace, secret.Name)
+		}
+		if len(parts) > 3 {
+			return nil, fmt.Errorf("bad revoked certificate %q in secret %s/%s: too many fields", entry, secret.Namespace, secret.Name)
+		}
+		serialNumber, ok := new(big.Int).SetString(parts[0], 10)
+		if !ok {
+			return nil, fmt.Errorf("bad revoked certificate %q in secret %s/%s: invalid serial number", entry, secret.Namespace, secret.Name)
+		}
+		revocationTime, err := time.Parse(time.RFC3339, parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("bad revoked certificate %q in secret %s/%s: %v", entry, secret.Namespace, secret.Name, err)
+		}
+		var notAfter time.Time
+		if len(parts) == 3 {
+			notAfter, err = time.Parse(time.RFC3339, parts[2])
+			if err != nil {
+				return nil, fmt.Errorf("bad revoked certificate %q in secret %s/%s: %v", entry, secret.Namespace, secret.Name, err)
+			}
+		}
+		revoked = append(revoked, revokedCertificate{
+			RevocationListEntry: x509.RevocationListEntry{SerialNumber: serialNumber, RevocationTime: revocationTime},
+			notAfter:            notAfter,
+		})
+	}
+	return revoked, nil
+}
+
+func setRevokedCertificates(secret *corev1.Secret, revoked []revokedCertificate) {
+	sort.SliceStable(revoked, func(i, j int) bool {
+		return revoked[i].RevocationTime.Before(revoked[j].RevocationTime)
+	})
+	entries := make([]string, 0, len(revoked))
+	for _, entry := range revoked {
+		value := fmt.Sprintf("%s@%s", entry.SerialNumber.String(), entry.RevocationTime.UTC().Format(time.RFC3339))
+		if !entry.notAfter.IsZero() {
+			value += "@" + entry.notAfter.UTC().Format(time.RFC3339)
+		}
+		entries = append(entries, value)
+	}
+	if len(entries) == 0 {
+		delete(secret.Annotations, CertificateRevokedSerialsAnnotation)
+		return
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[CertificateRevokedSerialsAnnotation] = strings.Join(entries, ",")
+}
+
+// unexpiredRevocations returns the revocations of certificates which did not expire at the given time. Revocations
+// without known expiry never expire.
+func unexpiredRevocations(revoked []revokedCertificate, now time.Time) []revokedCertificate {
+	unexpired := []revokedCertificate{}
+	for _, entry := range revoked {
+		if entry.notAfter.IsZero() || now.Before(entry.notAfter) {
+			unexpired = append(unexpired, entry)
+		}
+	}
+	return unexpired
+}
+
+func toRevocationListEntries(revoked []revokedCertificate) []x509.RevocationListEntry {
+	if revoked == nil {
+		return nil
+	}
+	entries := make([]x509.RevocationListEntry, 0, len(revoked))
+	for _, entry := range revoked {
+		entries = append(entries, entry.RevocationListEntry)
+	}
+	return entries
+}
+
+func isSerialNumberRevoked(revoked []x509.RevocationListEntry, serialNumber *big.Int) bool {
+	for _, entry := range revoked {
+		if entry.SerialNumber.Cmp(serialNumber) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// crlSignerMissing returns a reason if certificates were revoked, but the signer in the secret predates the CRL sign
+// key usage and hence cannot sign the certificate revocation list.
+func crlSignerMissing(secret *corev1.Secret) string {
+	if len(secret.Annotations[CertificateRevokedSerialsAnnotation]) == 0 {
+		return ""
+	}
+	certificates, err := cert.ParseCertsPEM(secret.Data["tls.crt"])
+	if err != nil {
+		return fmt.Sprintf("unable to parse certificate: %v", err)
+	}
+	if certificates[0].KeyUsage&x509.KeyUsageCRLSign == 0 {
+		return "certificates were revoked, but the signer cannot sign certificate revocation lists"
+	}
+	return ""
+}
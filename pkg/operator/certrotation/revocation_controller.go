@@ -0,0 +1,113 @@
+package certrotation
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+// CertRevocationController publishes the certificates revoked on a signer secret (via RotatedSigningCASecret.RevokeCertificates)
+// as certificate revocation list signed by the current signer. The signer itself is managed by a CertRotationController.
+type CertRevocationController struct {
+	// controller name
+	Name string
+	// RotatedSigningCASecret is the signer whose revoked certificates are published.
+	RotatedSigningCASecret RotatedSigningCASecret
+	// CRLConfigMap maintains the config map with the certificate revocation list.
+	CRLConfigMap CRLConfigMap
+
+	// Plumbing:
+	StatusReporter StatusReporter
+}
+
+func NewCertRevocationController(
+	name string,
+	rotatedSigningCASecret RotatedSigningCASecret,
+	crlConfigMap CRLConfigMap,
+	recorder events.Recorder,
+	reporter StatusReporter,
+) factory.Controller {
+	c := &CertRevocationController{
+		Name:                   name,
+		RotatedSigningCASecret: rotatedSigningCASecret,
+		CRLConfigMap:           crlConfigMap,
+		StatusReporter:         reporter,
+	}
+	return factory.New().
+		ResyncEvery(time.Minute).
+		WithSync(c.Sync).
+		WithFilteredEventsInformers(
+			func(obj interface{}) bool {
+				if cm, ok := obj.(*corev1.ConfigMap); ok {
+					return cm.Namespace == crlConfigMap.Namespace && cm.Name == crlConfigMap.Name
+				}
+				if secret, ok := obj.(*corev1.Secret); ok {
+					return secret.Namespace == rotatedSigningCASecret.Namespace && secret.Name == rotatedSigningCASecret.Name
+				}
+				return true
+			},
+			rotatedSigningCASecret.Informer.Informer(),
+			crlConfigMap.Informer.Informer(),
+		).
+		ToController(
+			"CertRevocationController",
+			recorder.WithComponentSuffix("cert-revocation-controller").WithComponentSuffix(name),
+		)
+}
+
+func (c CertRevocationController) Sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	syncErr := c.SyncWorker(ctx)
+
+	// running this function with RunOnceContextKey value context will make this "run-once" without updating status.
+	isRunOnce, ok := ctx.Value(RunOnceContextKey).(bool)
+	if ok && isRunOnce {
+		return syncErr
+	}
+
+	updated, updateErr := c.StatusReporter.Report(ctx, c.Name, syncErr)
+	if updateErr != nil {
+		return updateErr
+	}
+	if updated && syncErr != nil {
+		syncCtx.Recorder().Warningf("RevocationError", syncErr.Error())
+	}
+
+	return syncErr
+}
+
+func (c CertRevocationController) SyncWorker(ctx context.Context) error {
+	signingCertKeyPairSecret, err := c.RotatedSigningCASecret.Lister.Secrets(c.RotatedSigningCASecret.Namespace).Get(c.RotatedSigningCASecret.Name)
+	if apierrors.IsNotFound(err) {
+		// the signer is created by the cert rotation controller, we are called again once it exists
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	signingCertKeyPair, err := crypto.GetCAFromBytes(signingCertKeyPairSecret.Data["tls.crt"], signingCertKeyPairSecret.Data["tls.key"])
+	if err != nil {
+		return err
+	}
+	revoked, err := parseRevokedCertificates(signingCertKeyPairSecret)
+	if err != nil {
+		return err
+	}
+
+	// expired certificates are rejected anyway, so they are dropped from the list and afterwards from the signer
+	unexpired := unexpiredRevocations(revoked, time.Now())
+	if _, err := c.CRLConfigMap.EnsureConfigMapCRL(ctx, signingCertKeyPair, toRevocationListEntries(unexpired)); err != nil {
+		return err
+	}
+	if len(unexpired) < len(revoked) {
+		return c.RotatedSigningCASecret.pruneExpiredRevocations(ctx)
+	}
+
+	return nil
+}
@@ -0,0 +1,304 @@
+package certrotation
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestRevokeCertificates(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "signer",
+			Annotations: map[string]string{
+				CertificateRevokedSerialsAnnotation: "1234@2020-01-01T00:00:00Z",
+			},
+		},
+		Type: corev1.SecretTypeTLS,
+	}
+	client := kubefake.NewSimpleClientset(secret)
+	c := &RotatedSigningCASecret{
+		Namespace:     "ns",
+		Name:          "signer",
+		Client:        client.CoreV1(),
+		EventRecorder: events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())),
+	}
+
+	notAfter := time.Now().Add(time.Hour).Truncate(time.Second)
+	certificate := func(serialNumber int64) *x509.Certificate {
+		return &x509.Certificate{SerialNumber: big.NewInt(serialNumber), NotAfter: notAfter}
+	}
+	if err := c.RevokeCertificates(context.TODO(), certificate(1234), certificate(5678)); err != nil {
+		t.Fatal(err)
+	}
+	actions := client.Actions()
+	if len(actions) != 2 || !actions[0].Matches("get", "secrets") || !actions[1].Matches("update", "secrets") {
+		t.Fatal(spew.Sdump(actions))
+	}
+	actual := actions[1].(clienttesting.UpdateAction).GetObject().(*corev1.Secret)
+	revoked, err := parseRevokedCertificates(actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revoked) != 2 {
+		t.Fatal(spew.Sdump(revoked))
+	}
+	// already revoked certificates keep their revocation time
+	if revoked[0].SerialNumber.Int64() != 1234 || !revoked[0].RevocationTime.Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)) || !revoked[0].notAfter.IsZero() {
+		t.Errorf("unexpected first revoked certificate: %s", spew.Sdump(revoked[0]))
+	}
+	if revoked[1].SerialNumber.Int64() != 5678 || time.Since(revoked[1].RevocationTime) > time.Minute || !revoked[1].notAfter.Equal(notAfter) {
+		t.Errorf("unexpected second revoked certificate: %s", spew.Sdump(revoked[1]))
+	}
+
+	// revoking again is a no-op
+	client.ClearActions()
+	if err := c.RevokeCertificates(context.TODO(), certificate(5678)); err != nil {
+		t.Fatal(err)
+	}
+	if actions := client.Actions(); len(actions) != 1 || !actions[0].Matches("get", "secrets") {
+		t.Fatal(spew.Sdump(actions))
+	}
+}
+
+func TestRevokedCertificates(t *testing.T) {
+	tests := []struct {
+		name          string
+		annotation    string
+		expected      int
+		expectedError bool
+	}{
+		{name: "none"},
+		{name: "one", annotation: "1234@2020-01-01T00:00:00Z", expected: 1},
+		{name: "two", annotation: "1234@2020-01-01T00:00:00Z,18446744073709551617@2020-01-02T00:00:00Z", expected: 2},
+		{name: "with expiry", annotation: "1234@2020-01-01T00:00:00Z@2021-01-01T00:00:00Z,5678@2020-01-02T00:00:00Z", expected: 2},
+		{name: "missing time", annotation: "1234", expectedError: true},
+		{name: "bad expiry", annotation: "1234@2020-01-01T00:00:00Z@never", expectedError: true},
+		{name: "too many fields", annotation: "1234@2020-01-01T00:00:00Z@2021-01-01T00:00:00Z@2022-01-01T00:00:00Z", expectedError: true},
+		{name: "bad serial", annotation: "abc@2020-01-01T00:00:00Z", expectedError: true},
+		{name: "bad time", annotation: "1234@yesterday", expectedError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{CertificateRevokedSerialsAnnotation: test.annotation},
+			}}
+			revoked, err := RevokedCertificates(secret)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error %v, got %v", test.expectedError, err)
+			}
+			if len(revoked) != test.expected {
+				t.Errorf("expected %d revoked certificates, got %s", test.expected, spew.Sdump(revoked))
+			}
+		})
+	}
+}
+
+func TestPruneExpiredRevocations(t *testing.T) {
+	expired := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	unexpired := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name               string
+		annotation         string
+		expectedUpdate     bool
+		expectedAnnotation string
+	}{
+		{
+			name:       "nothing revoked",
+			annotation: "",
+		},
+		{
+			name:       "nothing expired",
+			annotation: "1234@2020-01-01T00:00:00Z@" + unexpired + ",5678@2020-01-02T00:00:00Z",
+		},
+		{
+			name:               "expired certificates are pruned",
+			annotation:         "1234@2020-01-01T00:00:00Z@" + expired + ",5678@2020-01-02T00:00:00Z@" + unexpired,
+			expectedUpdate:     true,
+			expectedAnnotation: "5678@2020-01-02T00:00:00Z@" + unexpired,
+		},
+		{
+			name:           "all certificates expired",
+			annotation:     "1234@2020-01-01T00:00:00Z@" + expired,
+			expectedUpdate: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   "ns",
+					Name:        "signer",
+					Annotations: map[string]string{CertificateRevokedSerialsAnnotation: test.annotation},
+				},
+				Type: corev1.SecretTypeTLS,
+			}
+			client := kubefake.NewSimpleClientset(secret)
+			c := &RotatedSigningCASecret{
+				Namespace:     "ns",
+				Name:          "signer",
+				Client:        client.CoreV1(),
+				EventRecorder: events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())),
+			}
+
+			if err := c.pruneExpiredRevocations(context.TODO()); err != nil {
+				t.Fatal(err)
+			}
+			actions := client.Actions()
+			if !test.expectedUpdate {
+				if len(actions) != 1 || !actions[0].Matches("get", "secrets") {
+					t.Fatal(spew.Sdump(actions))
+				}
+				return
+			}
+			if len(actions) != 2 || !actions[1].Matches("update", "secrets") {
+				t.Fatal(spew.Sdump(actions))
+			}
+			actual := actions[1].(clienttesting.UpdateAction).GetObject().(*corev1.Secret)
+			value, found := actual.Annotations[CertificateRevokedSerialsAnnotation]
+			if found != (len(test.expectedAnnotation) > 0) || value != test.expectedAnnotation {
+				t.Errorf("expected revoked serials %q, got %q (found: %v)", test.expectedAnnotation, value, found)
+			}
+		})
+	}
+}
+
+func TestCertRevocationControllerPrunesExpiredCertificates(t *testing.T) {
+	signer, err := newTestSigner("signer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	certBytes, keyBytes := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := signer.Config.WriteCertConfig(certBytes, keyBytes); err != nil {
+		t.Fatal(err)
+	}
+	expired := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	unexpired := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "signer",
+			Annotations: map[string]string{
+				CertificateRevokedSerialsAnnotation: "1234@2020-01-01T00:00:00Z@" + expired + ",5678@2020-01-02T00:00:00Z@" + unexpired + ",9012@2020-01-03T00:00:00Z",
+			},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{"tls.crt": certBytes.Bytes(), "tls.key": keyBytes.Bytes()},
+	}
+	secretIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if err := secretIndexer.Add(secret); err != nil {
+		t.Fatal(err)
+	}
+	client := kubefake.NewSimpleClientset(secret)
+	recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+
+	c := CertRevocationController{
+		RotatedSigningCASecret: RotatedSigningCASecret{
+			Namespace:     "ns",
+			Name:          "signer",
+			Lister:        corev1listers.NewSecretLister(secretIndexer),
+			Client:        client.CoreV1(),
+			EventRecorder: recorder,
+		},
+		CRLConfigMap: CRLConfigMap{
+			Namespace:     "ns",
+			Name:          "trust-crl",
+			Lister:        corev1listers.NewConfigMapLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})),
+			Client:        client.CoreV1(),
+			EventRecorder: recorder,
+		},
+	}
+	if err := c.SyncWorker(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	var crl *x509.RevocationList
+	var updatedSecret *corev1.Secret
+	for _, action := range client.Actions() {
+		switch {
+		case action.Matches("create", "configmaps"):
+			cm := action.(clienttesting.CreateAction).GetObject().(*corev1.ConfigMap)
+			if crl, err = crypto.ParseCRLPEM([]byte(cm.Data[CRLConfigMapKey])); err != nil {
+				t.Fatal(err)
+			}
+		case action.Matches("update", "secrets"):
+			updatedSecret = action.(clienttesting.UpdateAction).GetObject().(*corev1.Secret)
+		}
+	}
+	if crl == nil || updatedSecret == nil {
+		t.Fatal(spew.Sdump(client.Actions()))
+	}
+	// the expired certificate is neither published nor kept on the signer
+	if len(crl.RevokedCertificateEntries) != 2 || crl.RevokedCertificateEntries[0].SerialNumber.Int64() != 5678 || crl.RevokedCertificateEntries[1].SerialNumber.Int64() != 9012 {
+		t.Errorf("unexpected revoked certificates in the CRL: %s", spew.Sdump(crl.RevokedCertificateEntries))
+	}
+	expectedAnnotation := "5678@2020-01-02T00:00:00Z@" + unexpired + ",9012@2020-01-03T00:00:00Z"
+	if actual := updatedSecret.Annotations[CertificateRevokedSerialsAnnotation]; actual != expectedAnnotation {
+		t.Errorf("expected revoked serials %q, got %q", expectedAnnotation, actual)
+	}
+}
+
+func TestNeedNewSigningCertKeyPairForRevocation(t *testing.T) {
+	legacySigner, err := newTestCACertificate(pkix.Name{CommonName: "signer-tests"}, int64(1), metav1.Duration{Duration: time.Hour * 24 * 60}, time.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacySignerSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+	certBytes, keyBytes := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := legacySigner.Config.WriteCertConfig(certBytes, keyBytes); err != nil {
+		t.Fatal(err)
+	}
+	legacySignerSecret.Data = map[string][]byte{"tls.crt": certBytes.Bytes(), "tls.key": keyBytes.Bytes()}
+	setTLSAnnotationsOnSigningCertKeyPairSecret(legacySignerSecret, legacySigner.Config, 24*time.Hour, AdditionalAnnotations{})
+
+	signerSecret := &corev1.Secret{}
+	if err := setSigningCertKeyPairSecretAndTLSAnnotations(signerSecret, 24*time.Hour, 24*time.Hour, "", AdditionalAnnotations{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name                   string
+		secret                 *corev1.Secret
+		revoked                bool
+		refreshOnlyWhenExpired bool
+		expected               bool
+	}{
+		{name: "legacy signer without revocations", secret: legacySignerSecret},
+		{name: "legacy signer with revocations", secret: legacySignerSecret, revoked: true, expected: true},
+		{name: "legacy signer with revocations and RefreshOnlyWhenExpired", secret: legacySignerSecret, revoked: true, refreshOnlyWhenExpired: true},
+		{name: "signer with revocations", secret: signerSecret, revoked: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secret := test.secret.DeepCopy()
+			if test.revoked {
+				secret.Annotations[CertificateRevokedSerialsAnnotation] = "1234@2020-01-01T00:00:00Z"
+			}
+			actual, reason := needNewSigningCertKeyPair(secret, 24*time.Hour, test.refreshOnlyWhenExpired, "")
+			if actual != test.expected {
+				t.Errorf("expected %v, got %v: %s", test.expected, actual, reason)
+			}
+		})
+	}
+}
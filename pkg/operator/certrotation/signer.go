@@ -15,12 +15,14 @@ import (
 	corev1informers "k8s.io/client-go/informers/core/v1"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/util/cert"
 	"k8s.io/klog/v2"
 )
 
 // RotatedSigningCASecret rotates a self-signed signing CA stored in a secret. It creates a new one when
 // - refresh duration is over
 // - or 80% of validity is over (if RefreshOnlyWhenExpired is false)
+// - or certificates were revoked, but the CA cannot sign revocation lists (if RefreshOnlyWhenExpired is false)
 // - or the CA is expired.
 type RotatedSigningCASecret struct {
 	// Namespace is the namespace of the Secret.
@@ -38,6 +40,10 @@ type RotatedSigningCASecret struct {
 	// rotation on expiration only, but not interfere with the ordinary rotation controller.
 	RefreshOnlyWhenExpired bool
 
+	// KeyAlgorithm is the algorithm of the signing CA key, crypto.DefaultKeyAlgorithm if unset.
+	// See keyAlgorithmMismatch for how a change of it is rotated.
+	KeyAlgorithm crypto.KeyAlgorithm
+
 	// Owner is an optional reference to add to the secret that this rotator creates. Use this when downstream
 	// consumers of the signer CA need to be aware of changes to the object.
 	// WARNING: be careful when using this option, as deletion of the owning object will cascade into deletion
@@ -87,12 +93,12 @@ func (c RotatedSigningCASecret) EnsureSigningCertKeyPair(ctx context.Context) (*
 
 	// run Update if signer content needs changing
 	signerUpdated := false
-	if needed, reason := needNewSigningCertKeyPair(signingCertKeyPairSecret, c.Refresh, c.RefreshOnlyWhenExpired); needed || creationRequired {
+	if needed, reason := needNewSigningCertKeyPair(signingCertKeyPairSecret, c.Refresh, c.RefreshOnlyWhenExpired, c.KeyAlgorithm); needed || creationRequired {
 		if creationRequired {
 			reason = "secret doesn't exist"
 		}
 		c.EventRecorder.Eventf("SignerUpdateRequired", "%q in %q requires a new signing cert/key pair: %v", c.Name, c.Namespace, reason)
-		if err = setSigningCertKeyPairSecretAndTLSAnnotations(signingCertKeyPairSecret, c.Validity, c.Refresh, c.AdditionalAnnotations); err != nil {
+		if err = setSigningCertKeyPairSecretAndTLSAnnotations(signingCertKeyPairSecret, c.Validity, c.Refresh, c.KeyAlgorithm, c.AdditionalAnnotations); err != nil {
 			return nil, false, err
 		}
 
@@ -149,7 +155,7 @@ func ensureOwnerReference(meta *metav1.ObjectMeta, owner *metav1.OwnerReference)
 	return false
 }
 
-func needNewSigningCertKeyPair(secret *corev1.Secret, refresh time.Duration, refreshOnlyWhenExpired bool) (bool, string) {
+func needNewSigningCertKeyPair(secret *corev1.Secret, refresh time.Duration, refreshOnlyWhenExpired bool, keyAlgorithm crypto.KeyAlgorithm) (bool, string) {
 	annotations := secret.Annotations
 	notBefore, notAfter, reason := getValidityFromAnnotations(annotations)
 	if len(reason) > 0 {
@@ -175,9 +181,39 @@ func needNewSigningCertKeyPair(secret *corev1.Secret, refresh time.Duration, ref
 		return true, fmt.Sprintf("past its refresh time %v", developerSpecifiedRefresh)
 	}
 
+	if reason := keyAlgorithmMismatch(secret, keyAlgorithm); len(reason) > 0 {
+		return true, reason
+	}
+
+	if reason := crlSignerMissing(secret); len(reason) > 0 {
+		return true, reason
+	}
+
 	return false, ""
 }
 
+// keyAlgorithmMismatch returns a reason if the certificate in the secret has a key of a different algorithm than
+// the given one. It is shared by the KeyAlgorithm of the signer and of all target rotations: if the KeyAlgorithm
+// is set, a certificate with a key of a different algorithm is rotated, unless RefreshOnlyWhenExpired is true.
+// An empty algorithm matches every key such that existing certificates are kept as they are.
+func keyAlgorithmMismatch(secret *corev1.Secret, keyAlgorithm crypto.KeyAlgorithm) string {
+	if len(keyAlgorithm) == 0 {
+		return ""
+	}
+	certificates, err := cert.ParseCertsPEM(secret.Data["tls.crt"])
+	if err != nil {
+		return fmt.Sprintf("unable to parse certificate: %v", err)
+	}
+	actualKeyAlgorithm, err := crypto.KeyAlgorithmOf(certificates[0].PublicKey)
+	if err != nil {
+		return fmt.Sprintf("unable to determine key algorithm: %v", err)
+	}
+	if actualKeyAlgorithm != keyAlgorithm {
+		return fmt.Sprintf("key algorithm changed from %s to %s", actualKeyAlgorithm, keyAlgorithm)
+	}
+	return ""
+}
+
 func getValidityFromAnnotations(annotations map[string]string) (notBefore time.Time, notAfter time.Time, reason string) {
 	notAfterString := annotations[CertificateNotAfterAnnotation]
 	if len(notAfterString) == 0 {
@@ -201,8 +237,8 @@ func getValidityFromAnnotations(annotations map[string]string) (notBefore time.T
 
 // setSigningCertKeyPairSecretAndTLSAnnotations generates a new signing certificate and key pair,
 // stores them in the specified secret, and adds predefined TLS annotations to that secret.
-func setSigningCertKeyPairSecretAndTLSAnnotations(signingCertKeyPairSecret *corev1.Secret, validity, refresh time.Duration, tlsAnnotations AdditionalAnnotations) error {
-	ca, err := setSigningCertKeyPairSecret(signingCertKeyPairSecret, validity)
+func setSigningCertKeyPairSecretAndTLSAnnotations(signingCertKeyPairSecret *corev1.Secret, validity, refresh time.Duration, keyAlgorithm crypto.KeyAlgorithm, tlsAnnotations AdditionalAnnotations) error {
+	ca, err := setSigningCertKeyPairSecret(signingCertKeyPairSecret, validity, keyAlgorithm)
 	if err != nil {
 		return err
 	}
@@ -212,9 +248,9 @@ func setSigningCertKeyPairSecretAndTLSAnnotations(signingCertKeyPairSecret *core
 }
 
 // setSigningCertKeyPairSecret creates a new signing cert/key pair and sets them in the secret
-func setSigningCertKeyPairSecret(signingCertKeyPairSecret *corev1.Secret, validity time.Duration) (*crypto.TLSCertificateConfig, error) {
+func setSigningCertKeyPairSecret(signingCertKeyPairSecret *corev1.Secret, validity time.Duration, keyAlgorithm crypto.KeyAlgorithm) (*crypto.TLSCertificateConfig, error) {
 	signerName := fmt.Sprintf("%s_%s@%d", signingCertKeyPairSecret.Namespace, signingCertKeyPairSecret.Name, time.Now().Unix())
-	ca, err := crypto.MakeSelfSignedCAConfigForDuration(signerName, validity)
+	ca, err := crypto.MakeSelfSignedCAConfigForDurationWithKeyAlgorithm(signerName, validity, keyAlgorithm)
 	if err != nil {
 		return nil, err
 	}
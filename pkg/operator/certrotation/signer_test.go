@@ -17,6 +17,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 
 	"github.com/openshift/api/annotations"
+	"github.com/openshift/library-go/pkg/crypto"
 	"github.com/openshift/library-go/pkg/operator/events"
 )
 
@@ -212,3 +213,100 @@ func TestEnsureSigningCertKeyPair(t *testing.T) {
 		})
 	}
 }
+
+func TestEnsureSigningCertKeyPairKeyAlgorithm(t *testing.T) {
+	tests := []struct {
+		name string
+
+		initialKeyAlgorithm    crypto.KeyAlgorithm
+		keyAlgorithm           crypto.KeyAlgorithm
+		RefreshOnlyWhenExpired bool
+
+		expectedUpdate       bool
+		expectedKeyAlgorithm crypto.KeyAlgorithm
+	}{
+		{
+			name:                 "unset algorithm keeps existing signer",
+			initialKeyAlgorithm:  crypto.ECDSAP256KeyAlgorithm,
+			expectedKeyAlgorithm: crypto.ECDSAP256KeyAlgorithm,
+		},
+		{
+			name:                 "same algorithm keeps existing signer",
+			initialKeyAlgorithm:  crypto.ECDSAP256KeyAlgorithm,
+			keyAlgorithm:         crypto.ECDSAP256KeyAlgorithm,
+			expectedKeyAlgorithm: crypto.ECDSAP256KeyAlgorithm,
+		},
+		{
+			name:                 "changed algorithm rotates signer",
+			initialKeyAlgorithm:  crypto.RSA2048KeyAlgorithm,
+			keyAlgorithm:         crypto.ECDSAP256KeyAlgorithm,
+			expectedUpdate:       true,
+			expectedKeyAlgorithm: crypto.ECDSAP256KeyAlgorithm,
+		},
+		{
+			name:                 "changed algorithm to ed25519 rotates signer",
+			initialKeyAlgorithm:  crypto.ECDSAP384KeyAlgorithm,
+			keyAlgorithm:         crypto.Ed25519KeyAlgorithm,
+			expectedUpdate:       true,
+			expectedKeyAlgorithm: crypto.Ed25519KeyAlgorithm,
+		},
+		{
+			name:                   "changed algorithm with RefreshOnlyWhenExpired set keeps existing signer",
+			initialKeyAlgorithm:    crypto.RSA2048KeyAlgorithm,
+			keyAlgorithm:           crypto.ECDSAP256KeyAlgorithm,
+			RefreshOnlyWhenExpired: true,
+			expectedKeyAlgorithm:   crypto.RSA2048KeyAlgorithm,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			newSigner := func(client *kubefake.Clientset, indexer cache.Indexer, keyAlgorithm crypto.KeyAlgorithm) *RotatedSigningCASecret {
+				return &RotatedSigningCASecret{
+					Namespace:     "ns",
+					Name:          "signer",
+					Validity:      24 * time.Hour,
+					Refresh:       12 * time.Hour,
+					Client:        client.CoreV1(),
+					Lister:        corev1listers.NewSecretLister(indexer),
+					EventRecorder: events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())),
+					AdditionalAnnotations: AdditionalAnnotations{
+						JiraComponent: "test",
+					},
+					Owner: &metav1.OwnerReference{
+						Name: "operator",
+					},
+					RefreshOnlyWhenExpired: test.RefreshOnlyWhenExpired,
+					KeyAlgorithm:           keyAlgorithm,
+				}
+			}
+
+			// create the initial signer with the initial algorithm
+			client := kubefake.NewSimpleClientset()
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			if _, _, err := newSigner(client, indexer, test.initialKeyAlgorithm).EnsureSigningCertKeyPair(context.TODO()); err != nil {
+				t.Fatal(err)
+			}
+			initialSecret := client.Actions()[0].(clienttesting.CreateAction).GetObject().(*corev1.Secret)
+
+			client = kubefake.NewSimpleClientset(initialSecret)
+			indexer = cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			indexer.Add(initialSecret)
+
+			signer, updated, err := newSigner(client, indexer, test.keyAlgorithm).EnsureSigningCertKeyPair(context.TODO())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if updated != test.expectedUpdate {
+				t.Errorf("expected updated=%v, got %v: %s", test.expectedUpdate, updated, spew.Sdump(client.Actions()))
+			}
+			actual, err := crypto.KeyAlgorithmOf(signer.Config.Certs[0].PublicKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != test.expectedKeyAlgorithm {
+				t.Errorf("expected signer with key algorithm %s, got %s", test.expectedKeyAlgorithm, actual)
+			}
+		})
+	}
+}
@@ -154,7 +154,7 @@ func (c RotatedSelfSignedCertKeySecret) EnsureTargetCertKeyPair(ctx context.Cont
 	return targetCertKeyPairSecret, nil
 }
 
-func needNewTargetCertKeyPair(secret *corev1.Secret, signer *crypto.CA, caBundleCerts []*x509.Certificate, refresh time.Duration, refreshOnlyWhenExpired, creationRequired bool) string {
+func needNewTargetCertKeyPair(secret *corev1.Secret, signer *crypto.CA, caBundleCerts []*x509.Certificate, refresh time.Duration, refreshOnlyWhenExpired, creationRequired bool, keyAlgorithm crypto.KeyAlgorithm) string {
 	if creationRequired {
 		return "secret doesn't exist"
 	}
@@ -169,13 +169,21 @@ func needNewTargetCertKeyPair(secret *corev1.Secret, signer *crypto.CA, caBundle
 	if len(signerCommonName) == 0 {
 		return "missing issuer name"
 	}
+	signerInBundle := false
 	for _, caCert := range caBundleCerts {
 		if signerCommonName == caCert.Subject.CommonName {
-			return ""
+			signerInBundle = true
+			break
 		}
 	}
+	if !signerInBundle {
+		return fmt.Sprintf("issuer %q, not in ca bundle:\n%s", signerCommonName, certs.CertificateBundleToString(caBundleCerts))
+	}
 
-	return fmt.Sprintf("issuer %q, not in ca bundle:\n%s", signerCommonName, certs.CertificateBundleToString(caBundleCerts))
+	if refreshOnlyWhenExpired {
+		return ""
+	}
+	return keyAlgorithmMismatch(secret, keyAlgorithm)
 }
 
 // needNewTargetCertKeyPairForTime returns true when
@@ -290,14 +298,17 @@ func setTLSAnnotationsOnTargetCertKeyPairSecret(targetCertKeyPairSecret *corev1.
 
 type ClientRotation struct {
 	UserInfo user.Info
+	// KeyAlgorithm is the algorithm of the client key, crypto.DefaultKeyAlgorithm if unset.
+	// See keyAlgorithmMismatch for how a change of it is rotated.
+	KeyAlgorithm crypto.KeyAlgorithm
 }
 
 func (r *ClientRotation) NewCertificate(signer *crypto.CA, validity time.Duration) (*crypto.TLSCertificateConfig, error) {
-	return signer.MakeClientCertificateForDuration(r.UserInfo, validity)
+	return signer.MakeClientCertificateForDurationWithKeyAlgorithm(r.UserInfo, validity, r.KeyAlgorithm)
 }
 
 func (r *ClientRotation) NeedNewTargetCertKeyPair(currentCertSecret *corev1.Secret, signer *crypto.CA, caBundleCerts []*x509.Certificate, refresh time.Duration, refreshOnlyWhenExpired, exists bool) string {
-	return needNewTargetCertKeyPair(currentCertSecret, signer, caBundleCerts, refresh, refreshOnlyWhenExpired, exists)
+	return needNewTargetCertKeyPair(currentCertSecret, signer, caBundleCerts, refresh, refreshOnlyWhenExpired, exists, r.KeyAlgorithm)
 }
 
 func (r *ClientRotation) SetAnnotations(cert *crypto.TLSCertificateConfig, annotations map[string]string) map[string]string {
@@ -308,13 +319,16 @@ type ServingRotation struct {
 	Hostnames              ServingHostnameFunc
 	CertificateExtensionFn []crypto.CertificateExtensionFunc
 	HostnamesChanged       <-chan struct{}
+	// KeyAlgorithm is the algorithm of the serving key, crypto.DefaultKeyAlgorithm if unset.
+	// See keyAlgorithmMismatch for how a change of it is rotated.
+	KeyAlgorithm crypto.KeyAlgorithm
 }
 
 func (r *ServingRotation) NewCertificate(signer *crypto.CA, validity time.Duration) (*crypto.TLSCertificateConfig, error) {
 	if len(r.Hostnames()) == 0 {
 		return nil, fmt.Errorf("no hostnames set")
 	}
-	return signer.MakeServerCertForDuration(sets.New(r.Hostnames()...), validity, r.CertificateExtensionFn...)
+	return signer.MakeServerCertForDurationWithKeyAlgorithm(sets.New(r.Hostnames()...), validity, r.KeyAlgorithm, r.CertificateExtensionFn...)
 }
 
 func (r *ServingRotation) RecheckChannel() <-chan struct{} {
@@ -322,7 +336,7 @@ func (r *ServingRotation) RecheckChannel() <-chan struct{} {
 }
 
 func (r *ServingRotation) NeedNewTargetCertKeyPair(currentCertSecret *corev1.Secret, signer *crypto.CA, caBundleCerts []*x509.Certificate, refresh time.Duration, refreshOnlyWhenExpired, creationRequired bool) string {
-	reason := needNewTargetCertKeyPair(currentCertSecret, signer, caBundleCerts, refresh, refreshOnlyWhenExpired, creationRequired)
+	reason := needNewTargetCertKeyPair(currentCertSecret, signer, caBundleCerts, refresh, refreshOnlyWhenExpired, creationRequired, r.KeyAlgorithm)
 	if len(reason) > 0 {
 		return reason
 	}
@@ -360,15 +374,18 @@ type ServingHostnameFunc func() []string
 
 type SignerRotation struct {
 	SignerName string
+	// KeyAlgorithm is the algorithm of the intermediate signer key, crypto.DefaultKeyAlgorithm if unset.
+	// See keyAlgorithmMismatch for how a change of it is rotated.
+	KeyAlgorithm crypto.KeyAlgorithm
 }
 
 func (r *SignerRotation) NewCertificate(signer *crypto.CA, validity time.Duration) (*crypto.TLSCertificateConfig, error) {
 	signerName := fmt.Sprintf("%s_@%d", r.SignerName, time.Now().Unix())
-	return crypto.MakeCAConfigForDuration(signerName, validity, signer)
+	return crypto.MakeCAConfigForDurationWithKeyAlgorithm(signerName, validity, signer, r.KeyAlgorithm)
 }
 
 func (r *SignerRotation) NeedNewTargetCertKeyPair(currentCertSecret *corev1.Secret, signer *crypto.CA, caBundleCerts []*x509.Certificate, refresh time.Duration, refreshOnlyWhenExpired, exists bool) string {
-	return needNewTargetCertKeyPair(currentCertSecret, signer, caBundleCerts, refresh, refreshOnlyWhenExpired, exists)
+	return needNewTargetCertKeyPair(currentCertSecret, signer, caBundleCerts, refresh, refreshOnlyWhenExpired, exists, r.KeyAlgorithm)
 }
 
 func (r *SignerRotation) SetAnnotations(cert *crypto.TLSCertificateConfig, annotations map[string]string) map[string]string {
@@ -19,6 +19,13 @@ type Provider interface {
 	ShouldRunEncryptionControllers() (bool, error)
 }
 
+// KMSProvider is implemented by providers of operators that support the kms encryption mode.
+type KMSProvider interface {
+	// KMSPluginEndpoint returns the gRPC endpoint of the KMSv2 plugin used by the operand,
+	// for example unix:///var/run/kmsplugin/kms.sock.
+	KMSPluginEndpoint() string
+}
+
 func shouldRunEncryptionController(operatorClient operatorv1helpers.OperatorClient, preconditionsFulfilledFn preconditionsFulfilled, shouldRunFn func() (bool, error)) (bool, error) {
 	if shouldRun, err := shouldRunFn(); !shouldRun || err != nil {
 		return false, err
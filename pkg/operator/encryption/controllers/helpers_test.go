@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"encoding/base64"
 	"fmt"
 	"testing"
 
@@ -9,6 +10,7 @@ import (
 	apiserverconfigv1 "k8s.io/apiserver/pkg/apis/apiserver/v1"
 
 	"github.com/openshift/library-go/pkg/operator/encryption/encryptionconfig"
+	encryptiontesting "github.com/openshift/library-go/pkg/operator/encryption/testing"
 )
 
 func createEncryptionCfgSecret(t *testing.T, targetNs string, revision string, encryptionCfg *apiserverconfigv1.EncryptionConfiguration) *corev1.Secret {
@@ -38,3 +40,30 @@ func (p *testProvider) EncryptedGRs() []schema.GroupResource {
 func (p *testProvider) ShouldRunEncryptionControllers() (bool, error) {
 	return true, nil
 }
+
+type testKMSProvider struct {
+	testProvider
+	endpoint string
+}
+
+func newTestKMSProvider(encryptedGRs []schema.GroupResource, endpoint string) Provider {
+	return &testKMSProvider{testProvider: testProvider{encryptedGRs: encryptedGRs}, endpoint: endpoint}
+}
+
+func (p *testKMSProvider) KMSPluginEndpoint() string {
+	return p.endpoint
+}
+
+// newScenarioProvider returns the provider of a table test scenario. It supports the kms mode with the fake plugin,
+// if one is set.
+func newScenarioProvider(encryptedGRs []schema.GroupResource, kmsPlugin *encryptiontesting.FakeKMSPlugin) Provider {
+	if kmsPlugin == nil {
+		return newTestProvider(encryptedGRs)
+	}
+	return newTestKMSProvider(encryptedGRs, kmsPlugin.Endpoint())
+}
+
+// kmsKeySecret returns the secret of the keys of the fake plugin in encryption configs, i.e. the encoded endpoint.
+func kmsKeySecret(kmsPlugin *encryptiontesting.FakeKMSPlugin) string {
+	return base64.StdEncoding.EncodeToString([]byte(kmsPlugin.Endpoint()))
+}
@@ -20,6 +20,7 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/utils/ptr"
 
+	configv1 "github.com/openshift/api/config/v1"
 	operatorv1 "github.com/openshift/api/operator/v1"
 	configv1client "github.com/openshift/client-go/config/clientset/versioned/typed/config/v1"
 	configv1informers "github.com/openshift/client-go/config/informers/externalversions/config/v1"
@@ -27,6 +28,7 @@ import (
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/encryption/crypto"
+	"github.com/openshift/library-go/pkg/operator/encryption/kms"
 	"github.com/openshift/library-go/pkg/operator/encryption/secrets"
 	"github.com/openshift/library-go/pkg/operator/encryption/state"
 	"github.com/openshift/library-go/pkg/operator/encryption/statemachine"
@@ -50,7 +52,8 @@ const encryptionSecretMigrationInterval = time.Hour * 24 * 7 // one week
 //   - encryption is being enabled via the API or
 //   - a new to-be-encrypted resource shows up or
 //   - the EncryptionType in the API does not match with the newest existing key or
-//   - based on time (once a week is the proposed rotation interval) or
+//   - the KMS plugin reports a different key ID than the newest existing key of the kms mode or
+//   - based on time (once a week is the proposed rotation interval, not for the kms mode) or
 //   - an external reason given as a string in .encryption.reason of UnsupportedConfigOverrides.
 //     It then creates it.
 //
@@ -179,6 +182,18 @@ func (c *keyController) checkAndCreateKeys(ctx context.Context, syncContext fact
 		return nil
 	}
 
+	var kmsEndpoint, kmsKeyID string
+	if currentMode == state.KMS {
+		kmsProvider, ok := c.provider.(KMSProvider)
+		if !ok {
+			return fmt.Errorf("encryption mode %s is not supported by this operator", currentMode)
+		}
+		kmsEndpoint = kmsProvider.KMSPluginEndpoint()
+		if kmsKeyID, err = kms.GetKeyID(ctx, kmsEndpoint); err != nil {
+			return err
+		}
+	}
+
 	var (
 		newKeyRequired bool
 		newKeyID       uint64
@@ -191,7 +206,7 @@ func (c *keyController) checkAndCreateKeys(ctx context.Context, syncContext fact
 
 	var commonReason *string
 	for gr, grKeys := range desiredEncryptionState {
-		latestKeyID, internalReason, needed := needsNewKey(grKeys, currentMode, externalReason, encryptedGRs)
+		latestKeyID, internalReason, needed := needsNewKey(grKeys, currentMode, externalReason, kmsKeyID, encryptedGRs)
 		if !needed {
 			continue
 		}
@@ -218,7 +233,7 @@ func (c *keyController) checkAndCreateKeys(ctx context.Context, syncContext fact
 
 	sort.Sort(sort.StringSlice(reasons))
 	internalReason := strings.Join(reasons, ", ")
-	keySecret, err := c.generateKeySecret(newKeyID, currentMode, internalReason, externalReason)
+	keySecret, err := c.generateKeySecret(newKeyID, currentMode, internalReason, externalReason, kmsEndpoint, kmsKeyID)
 	if err != nil {
 		return fmt.Errorf("failed to create key: %v", err)
 	}
@@ -255,8 +270,14 @@ func (c *keyController) validateExistingSecret(ctx context.Context, keySecret *c
 	return nil // we made this key earlier
 }
 
-func (c *keyController) generateKeySecret(keyID uint64, currentMode state.Mode, internalReason, externalReason string) (*corev1.Secret, error) {
-	bs := crypto.ModeToNewKeyFunc[currentMode]()
+func (c *keyController) generateKeySecret(keyID uint64, currentMode state.Mode, internalReason, externalReason, kmsEndpoint, kmsKeyID string) (*corev1.Secret, error) {
+	var bs []byte
+	if currentMode == state.KMS {
+		// the key material never leaves the KMS, we only record where to find the plugin
+		bs = []byte(kmsEndpoint)
+	} else {
+		bs = crypto.ModeToNewKeyFunc[currentMode]()
+	}
 	ks := state.KeyState{
 		Key: apiserverv1.Key{
 			Name:   fmt.Sprintf("%d", keyID),
@@ -265,6 +286,7 @@ func (c *keyController) generateKeySecret(keyID uint64, currentMode state.Mode,
 		Mode:           currentMode,
 		InternalReason: internalReason,
 		ExternalReason: externalReason,
+		KMSKeyID:       kmsKeyID,
 	}
 	return secrets.FromKeyState(c.instanceName, ks)
 }
@@ -289,6 +311,8 @@ func (c *keyController) getCurrentModeAndExternalReason(ctx context.Context) (st
 	switch currentMode := state.Mode(apiServer.Spec.Encryption.Type); currentMode {
 	case state.AESCBC, state.AESGCM, state.Identity: // secretbox is disabled for now
 		return currentMode, reason, nil
+	case state.Mode(configv1.EncryptionTypeKMS):
+		return state.KMS, reason, nil
 	case "": // unspecified means use the default (which can change over time)
 		return state.DefaultMode, reason, nil
 	default:
@@ -297,8 +321,8 @@ func (c *keyController) getCurrentModeAndExternalReason(ctx context.Context) (st
 }
 
 // needsNewKey checks whether a new key must be created for the given resource. If true, it also returns the latest
-// used key ID and a reason string.
-func needsNewKey(grKeys state.GroupResourceState, currentMode state.Mode, externalReason string, encryptedGRs []schema.GroupResource) (uint64, string, bool) {
+// used key ID and a reason string. kmsKeyID is the key ID currently reported by the KMS plugin in the kms mode.
+func needsNewKey(grKeys state.GroupResourceState, currentMode state.Mode, externalReason, kmsKeyID string, encryptedGRs []schema.GroupResource) (uint64, string, bool) {
 	// we always need to have some encryption keys unless we are turned off
 	if len(grKeys.ReadKeys) == 0 {
 		return 0, "key-does-not-exist", currentMode != state.Identity
@@ -346,6 +370,11 @@ func needsNewKey(grKeys state.GroupResourceState, currentMode state.Mode, extern
 		return latestKeyID, "external-reason-changed", true
 	}
 
+	// the KMS owns the rotation of the key encryption key. We follow by rewriting all data once the plugin reports a new key ID.
+	if currentMode == state.KMS {
+		return latestKeyID, "kms-key-id-changed", latestKey.KMSKeyID != kmsKeyID
+	}
+
 	// we check for encryptionSecretMigratedTimestamp set by migration controller to determine when migration completed
 	// this also generates back pressure for key rotation when migration takes a long time or was recently completed
 	return latestKeyID, "rotation-interval-has-passed", time.Since(latestKey.Migrated.Timestamp) > encryptionSecretMigrationInterval
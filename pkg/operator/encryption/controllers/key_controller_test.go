@@ -41,6 +41,11 @@ func TestKeyController(t *testing.T) {
 	apiServerWithAESGCM := simpleAPIServer.DeepCopy()
 	apiServerWithAESGCM.Spec.Encryption = configv1.APIServerEncryption{Type: "aesgcm"}
 
+	apiServerWithKMS := simpleAPIServer.DeepCopy()
+	apiServerWithKMS.Spec.Encryption = configv1.APIServerEncryption{Type: "KMS"}
+
+	kmsPlugin := encryptiontesting.NewFakeKMSPlugin(t, "kms-key-2")
+
 	scenarios := []struct {
 		name                     string
 		initialObjects           []runtime.Object
@@ -48,6 +53,7 @@ func TestKeyController(t *testing.T) {
 		encryptionSecretSelector metav1.ListOptions
 		targetNamespace          string
 		targetGRs                []schema.GroupResource
+		kmsPlugin                *encryptiontesting.FakeKMSPlugin
 		// expectedActions holds actions to be verified in the form of "verb:resource:namespace"
 		expectedActions            []string
 		validateFunc               func(ts *testing.T, actions []clientgotesting.Action, targetNamespace string, targetGRs []schema.GroupResource)
@@ -324,6 +330,132 @@ func TestKeyController(t *testing.T) {
 				}
 			},
 		},
+
+		{
+			name: "checks if a secret of the kms mode with the plugin key ID is created",
+			targetGRs: []schema.GroupResource{
+				{Group: "", Resource: "secrets"},
+			},
+			initialObjects: []runtime.Object{
+				encryptiontesting.CreateDummyKubeAPIPod("kube-apiserver-1", "kms", "node-1"),
+			},
+			apiServerObjects: []runtime.Object{apiServerWithKMS},
+			kmsPlugin:        kmsPlugin,
+			targetNamespace:  "kms",
+			expectedActions:  []string{"list:pods:kms", "get:secrets:kms", "list:secrets:openshift-config-managed", "create:secrets:openshift-config-managed", "create:events:kms"},
+			validateFunc: func(ts *testing.T, actions []clientgotesting.Action, targetNamespace string, targetGRs []schema.GroupResource) {
+				wasSecretValidated := false
+				for _, action := range actions {
+					if action.Matches("create", "secrets") {
+						createAction := action.(clientgotesting.CreateAction)
+						actualSecret := createAction.GetObject().(*corev1.Secret)
+						expectedSecret := encryptiontesting.CreateKMSEncryptionKeySecret(targetNamespace, []schema.GroupResource{}, 1, kmsPlugin.Endpoint(), "kms-key-2")
+						expectedSecret.Annotations["encryption.apiserver.operator.openshift.io/internal-reason"] = "secrets-key-does-not-exist"
+						if !equality.Semantic.DeepEqual(actualSecret, expectedSecret) {
+							ts.Errorf("%s", diff.ObjectDiff(expectedSecret, actualSecret))
+						}
+						wasSecretValidated = true
+						break
+					}
+				}
+				if !wasSecretValidated {
+					ts.Errorf("the secret wasn't created and validated")
+				}
+			},
+		},
+
+		{
+			name: "no-op when the kms plugin key ID did not change even though the rotation interval has passed",
+			targetGRs: []schema.GroupResource{
+				{Group: "", Resource: "secrets"},
+			},
+			initialObjects: []runtime.Object{
+				encryptiontesting.CreateDummyKubeAPIPod("kube-apiserver-1", "kms", "node-1"),
+				encryptiontesting.CreateMigratedKMSEncryptionKeySecret("kms", []schema.GroupResource{{Group: "", Resource: "secrets"}}, 3, kmsPlugin.Endpoint(), "kms-key-2", time.Now().Add(-(time.Hour*24*7 + time.Hour))),
+			},
+			apiServerObjects: []runtime.Object{apiServerWithKMS},
+			kmsPlugin:        kmsPlugin,
+			targetNamespace:  "kms",
+			expectedActions:  []string{"list:pods:kms", "get:secrets:kms", "list:secrets:openshift-config-managed"},
+		},
+
+		{
+			name: "no-op when the kms plugin key ID changed, but the latest key is not migrated",
+			targetGRs: []schema.GroupResource{
+				{Group: "", Resource: "secrets"},
+			},
+			initialObjects: []runtime.Object{
+				encryptiontesting.CreateDummyKubeAPIPod("kube-apiserver-1", "kms", "node-1"),
+				encryptiontesting.CreateKMSEncryptionKeySecret("kms", nil, 3, kmsPlugin.Endpoint(), "kms-key-1"),
+			},
+			apiServerObjects: []runtime.Object{apiServerWithKMS},
+			kmsPlugin:        kmsPlugin,
+			targetNamespace:  "kms",
+			expectedActions:  []string{"list:pods:kms", "get:secrets:kms", "list:secrets:openshift-config-managed"},
+		},
+
+		{
+			name: "creates a new write key because the kms plugin key ID changed",
+			targetGRs: []schema.GroupResource{
+				{Group: "", Resource: "secrets"},
+			},
+			initialObjects: []runtime.Object{
+				encryptiontesting.CreateDummyKubeAPIPod("kube-apiserver-1", "kms", "node-1"),
+				encryptiontesting.CreateMigratedKMSEncryptionKeySecret("kms", []schema.GroupResource{{Group: "", Resource: "secrets"}}, 3, kmsPlugin.Endpoint(), "kms-key-1", time.Now()),
+			},
+			apiServerObjects: []runtime.Object{apiServerWithKMS},
+			kmsPlugin:        kmsPlugin,
+			targetNamespace:  "kms",
+			expectedActions:  []string{"list:pods:kms", "get:secrets:kms", "list:secrets:openshift-config-managed", "create:secrets:openshift-config-managed", "create:events:kms"},
+			validateFunc: func(ts *testing.T, actions []clientgotesting.Action, targetNamespace string, targetGRs []schema.GroupResource) {
+				wasSecretValidated := false
+				for _, action := range actions {
+					if action.Matches("create", "secrets") {
+						createAction := action.(clientgotesting.CreateAction)
+						actualSecret := createAction.GetObject().(*corev1.Secret)
+						expectedSecret := encryptiontesting.CreateKMSEncryptionKeySecret(targetNamespace, []schema.GroupResource{}, 4, kmsPlugin.Endpoint(), "kms-key-2")
+						expectedSecret.Annotations["encryption.apiserver.operator.openshift.io/internal-reason"] = "secrets-kms-key-id-changed"
+						if !equality.Semantic.DeepEqual(actualSecret, expectedSecret) {
+							ts.Errorf("%s", diff.ObjectDiff(expectedSecret, actualSecret))
+						}
+						wasSecretValidated = true
+						break
+					}
+				}
+				if !wasSecretValidated {
+					ts.Errorf("the secret wasn't created and validated")
+				}
+			},
+		},
+
+		{
+			name: "creates a new write key of the kms mode because the encryption mode changed",
+			targetGRs: []schema.GroupResource{
+				{Group: "", Resource: "secrets"},
+			},
+			initialObjects: []runtime.Object{
+				encryptiontesting.CreateDummyKubeAPIPod("kube-apiserver-1", "kms", "node-1"),
+				encryptiontesting.CreateEncryptionKeySecretWithRawKeyWithMode("kms", []schema.GroupResource{{Group: "", Resource: "secrets"}}, 5, []byte("61def964fb967f5d7c44a2af8dab6865"), "aescbc"),
+			},
+			apiServerObjects: []runtime.Object{apiServerWithKMS},
+			kmsPlugin:        kmsPlugin,
+			targetNamespace:  "kms",
+			expectedActions:  []string{"list:pods:kms", "get:secrets:kms", "list:secrets:openshift-config-managed", "create:secrets:openshift-config-managed", "create:events:kms"},
+		},
+
+		{
+			name: "degraded when the kms mode is not supported by the operator",
+			targetGRs: []schema.GroupResource{
+				{Group: "", Resource: "secrets"},
+			},
+			initialObjects: []runtime.Object{
+				encryptiontesting.CreateDummyKubeAPIPod("kube-apiserver-1", "kms", "node-1"),
+			},
+			apiServerObjects: []runtime.Object{apiServerWithKMS},
+			targetNamespace:  "kms",
+			expectedActions:  []string{"list:pods:kms", "get:secrets:kms", "list:secrets:openshift-config-managed"},
+			expectedError:    errors.New("encryption mode kms is not supported by this operator"),
+		},
 	}
 
 	for _, scenario := range scenarios {
@@ -371,7 +503,7 @@ func TestKeyController(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			provider := newTestProvider(scenario.targetGRs)
+			provider := newScenarioProvider(scenario.targetGRs, scenario.kmsPlugin)
 
 			target := NewKeyController(scenario.targetNamespace, nil, provider, deployer, alwaysFulfilledPreconditions, fakeOperatorClient, fakeApiServerClient, fakeApiServerInformer, kubeInformers, fakeSecretClient, scenario.encryptionSecretSelector, eventRecorder)
 
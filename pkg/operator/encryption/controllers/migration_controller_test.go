@@ -34,6 +34,9 @@ import (
 )
 
 func TestMigrationController(t *testing.T) {
+	kmsPlugin := encryptiontesting.NewFakeKMSPlugin(t, "kms-key-1")
+	kmsEndpointSecret := kmsKeySecret(kmsPlugin)
+
 	scenarios := []struct {
 		name                     string
 		initialResources         []runtime.Object
@@ -42,6 +45,7 @@ func TestMigrationController(t *testing.T) {
 		targetNamespace          string
 		targetGRs                []schema.GroupResource
 		targetAPIResources       []metav1.APIResource
+		kmsPlugin                *encryptiontesting.FakeKMSPlugin
 		// expectedActions holds actions to be verified in the form of "verb:resource:namespace"
 		expectedActions []string
 
@@ -566,6 +570,77 @@ func TestMigrationController(t *testing.T) {
 			},
 		},
 
+		{
+			name:            "secrets are migrated to a kms write key",
+			targetNamespace: "kms",
+			targetGRs: []schema.GroupResource{
+				{Group: "", Resource: "secrets"},
+			},
+			targetAPIResources: []metav1.APIResource{
+				{
+					Name:       "secrets",
+					Namespaced: true,
+					Group:      "",
+					Version:    "v1",
+				},
+			},
+			kmsPlugin: kmsPlugin,
+			initialResources: []runtime.Object{
+				encryptiontesting.CreateDummyKubeAPIPod("kube-apiserver-1", "kms", "node-1"),
+			},
+			initialSecrets: []*corev1.Secret{
+				func() *corev1.Secret {
+					s := encryptiontesting.CreateKMSEncryptionKeySecret("kms", nil, 1, kmsPlugin.Endpoint(), "kms-key-1")
+					s.Kind = "Secret"
+					s.APIVersion = corev1.SchemeGroupVersion.String()
+					return s
+				}(),
+				func() *corev1.Secret {
+					ec := encryptiontesting.CreateEncryptionCfgWithWriteKey([]encryptiontesting.EncryptionKeysResourceTuple{{
+						Resource: "secrets",
+						Keys:     []apiserverconfigv1.Key{{Name: "1", Secret: kmsEndpointSecret}},
+						Modes:    []string{"kms"},
+					}})
+					ecs := createEncryptionCfgSecret(t, "kms", "1", ec)
+					ecs.APIVersion = corev1.SchemeGroupVersion.String()
+
+					return ecs
+				}(),
+			},
+			migratorEnsureReplies: map[schema.GroupResource]map[string]finishedResultErr{
+				{Group: "", Resource: "secrets"}: {"1": {finished: true}},
+			},
+			expectedActions: []string{
+				"list:pods:kms",
+				"get:secrets:kms",
+				"list:secrets:openshift-config-managed",
+				"list:secrets:openshift-config-managed",
+				"get:secrets:openshift-config-managed",
+				"get:secrets:openshift-config-managed",
+				"update:secrets:openshift-config-managed",
+				"create:events:operator",
+			},
+			expectedMigratorCalls: []string{
+				"ensure:secrets:1",
+			},
+			validateFunc: func(ts *testing.T, actionsKube []clientgotesting.Action, initialSecrets []*corev1.Secret, targetGRs []schema.GroupResource, unstructuredObjs []runtime.Object) {
+				validateSecretsWereAnnotated(ts, []schema.GroupResource{{Group: "", Resource: "secrets"}}, actionsKube, []*corev1.Secret{initialSecrets[0]}, nil)
+			},
+			validateOperatorClientFunc: func(ts *testing.T, operatorClient v1helpers.OperatorClient) {
+				expectedConditions := []operatorv1.OperatorCondition{
+					{
+						Type:   "EncryptionMigrationControllerDegraded",
+						Status: "False",
+					},
+					{
+						Type:   "EncryptionMigrationControllerProgressing",
+						Status: "False",
+					},
+				}
+				encryptiontesting.ValidateOperatorClientConditions(ts, operatorClient, expectedConditions)
+			},
+		},
+
 		// TODO: add more tests for not so happy paths
 	}
 
@@ -646,7 +721,7 @@ func TestMigrationController(t *testing.T) {
 				ensureReplies: scenario.migratorEnsureReplies,
 				pruneReplies:  scenario.migratorPruneReplies,
 			}
-			provider := newTestProvider(scenario.targetGRs)
+			provider := newScenarioProvider(scenario.targetGRs, scenario.kmsPlugin)
 
 			// act
 			target := NewMigrationController(
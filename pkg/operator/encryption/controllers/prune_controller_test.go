@@ -31,12 +31,16 @@ import (
 )
 
 func TestPruneController(t *testing.T) {
+	kmsPlugin := encryptiontesting.NewFakeKMSPlugin(t, "kms-key-1")
+
 	scenarios := []struct {
 		name                     string
 		initialSecrets           []*corev1.Secret
 		encryptionSecretSelector metav1.ListOptions
 		targetNamespace          string
 		targetGRs                []schema.GroupResource
+		// the write key and all keys in the encryption config are of the kms mode with a kmsPlugin
+		kmsPlugin *encryptiontesting.FakeKMSPlugin
 		// expectedActions holds actions to be verified in the form of "verb:resource:namespace"
 		expectedActions       []string
 		expectedEncryptionCfg *apiserverconfigv1.EncryptionConfiguration
@@ -100,6 +104,32 @@ func TestPruneController(t *testing.T) {
 				"list:secrets:openshift-config-managed",
 			},
 		},
+
+		{
+			name:            "15 kms keys were migrated, 2 of them are used, 10 are kept, the 3 most oldest are pruned",
+			targetNamespace: "kms",
+			targetGRs: []schema.GroupResource{
+				{Group: "", Resource: "secrets"},
+			},
+			kmsPlugin:      kmsPlugin,
+			initialSecrets: createMigratedKMSEncryptionKeySecrets(15, "kms", "secrets", kmsPlugin.Endpoint()),
+			expectedActions: []string{
+				"list:pods:kms",
+				"get:secrets:kms",
+				"list:secrets:openshift-config-managed",
+				"list:secrets:openshift-config-managed",
+				"update:secrets:openshift-config-managed",
+				"delete:secrets:openshift-config-managed",
+				"update:secrets:openshift-config-managed",
+				"delete:secrets:openshift-config-managed",
+				"update:secrets:openshift-config-managed",
+				"delete:secrets:openshift-config-managed",
+				"create:events:kms",
+			},
+			validateFunc: func(ts *testing.T, actions []clientgotesting.Action, initialSecrets []*corev1.Secret) {
+				validateSecretsWerePruned(ts, actions, initialSecrets[:3])
+			},
+		},
 	}
 
 	for _, scenario := range scenarios {
@@ -138,6 +168,10 @@ func TestPruneController(t *testing.T) {
 			writeKeyRaw := []byte("71ea7c91419a68fd1224f88d50316b4e") // NzFlYTdjOTE0MTlhNjhmZDEyMjRmODhkNTAzMTZiNGU=
 			writeKeyID := uint64(len(scenario.initialSecrets) + 1)
 			writeKeySecret := encryptiontesting.CreateEncryptionKeySecretWithRawKey(scenario.targetNamespace, nil, writeKeyID, writeKeyRaw)
+			if scenario.kmsPlugin != nil {
+				writeKeyRaw = []byte(scenario.kmsPlugin.Endpoint())
+				writeKeySecret = encryptiontesting.CreateKMSEncryptionKeySecret(scenario.targetNamespace, nil, writeKeyID, scenario.kmsPlugin.Endpoint(), "kms-key-1")
+			}
 
 			initialKeys := []state.KeyState{}
 			for _, s := range scenario.initialSecrets {
@@ -157,14 +191,22 @@ func TestPruneController(t *testing.T) {
 						Secret: rk.Key.Secret,
 					})
 				}
+				keys := append([]apiserverconfigv1.Key{
+					{
+						Name:   fmt.Sprintf("%d", writeKeyID),
+						Secret: base64.StdEncoding.EncodeToString(writeKeyRaw),
+					},
+				}, additionaConfigReadKeys...)
+				var modes []string
+				if scenario.kmsPlugin != nil {
+					for range keys {
+						modes = append(modes, "kms")
+					}
+				}
 				ec := encryptiontesting.CreateEncryptionCfgWithWriteKey([]encryptiontesting.EncryptionKeysResourceTuple{{
 					Resource: "secrets",
-					Keys: append([]apiserverconfigv1.Key{
-						{
-							Name:   fmt.Sprintf("%d", writeKeyID),
-							Secret: base64.StdEncoding.EncodeToString(writeKeyRaw),
-						},
-					}, additionaConfigReadKeys...),
+					Keys:     keys,
+					Modes:    modes,
 				}})
 				ec.APIVersion = corev1.SchemeGroupVersion.String()
 				return createEncryptionCfgSecret(t, "kms", "1", ec)
@@ -183,7 +225,7 @@ func TestPruneController(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			provider := newTestProvider(scenario.targetGRs)
+			provider := newScenarioProvider(scenario.targetGRs, scenario.kmsPlugin)
 
 			target := NewPruneController(
 				"EncryptionPruneController",
@@ -265,3 +307,12 @@ func createMigratedEncryptionKeySecretsWithRndKey(ts *testing.T, count int, name
 	}
 	return ret
 }
+
+func createMigratedKMSEncryptionKeySecrets(count int, namespace, resource, endpoint string) []*corev1.Secret {
+	ret := []*corev1.Secret{}
+	for i := 1; i <= count; i++ {
+		s := encryptiontesting.CreateMigratedKMSEncryptionKeySecret(namespace, []schema.GroupResource{{Group: "", Resource: resource}}, uint64(i), endpoint, "kms-key-1", time.Now())
+		ret = append(ret, s)
+	}
+	return ret
+}
@@ -33,12 +33,16 @@ import (
 )
 
 func TestStateController(t *testing.T) {
+	kmsPlugin := encryptiontesting.NewFakeKMSPlugin(t, "kms-key-1")
+	kmsEndpointSecret := kmsKeySecret(kmsPlugin)
+
 	scenarios := []struct {
 		name                     string
 		initialResources         []runtime.Object
 		encryptionSecretSelector metav1.ListOptions
 		targetNamespace          string
 		targetGRs                []schema.GroupResource
+		kmsPlugin                *encryptiontesting.FakeKMSPlugin
 		// expectedActions holds actions to be verified in the form of "verb:resource:namespace"
 		expectedActions            []string
 		expectedEncryptionCfg      *apiserverconfigv1.EncryptionConfiguration
@@ -707,6 +711,121 @@ func TestStateController(t *testing.T) {
 				encryptiontesting.ValidateOperatorClientConditions(ts, operatorClient, []operatorv1.OperatorCondition{expectedCondition})
 			},
 		},
+
+		// scenario 12
+		{
+			name:            "secret with EncryptionConfig is created and it contains a single kms write key",
+			targetNamespace: "kms",
+			targetGRs: []schema.GroupResource{
+				{Group: "", Resource: "secrets"},
+			},
+			kmsPlugin: kmsPlugin,
+			initialResources: []runtime.Object{
+				encryptiontesting.CreateDummyKubeAPIPod("kube-apiserver-1", "kms", "node-1"),
+				encryptiontesting.CreateKMSEncryptionKeySecret("kms", []schema.GroupResource{{Group: "", Resource: "secrets"}}, 1, kmsPlugin.Endpoint(), "kms-key-1"),
+				func() *corev1.Secret {
+					ec := encryptiontesting.CreateEncryptionCfgNoWriteKeyMultipleReadKeys([]encryptiontesting.EncryptionKeysResourceTuple{{
+						Resource: "secrets",
+						Keys:     []apiserverconfigv1.Key{{Name: "1", Secret: kmsEndpointSecret}},
+						Modes:    []string{"kms"},
+					}})
+					return createEncryptionCfgSecret(t, "kms", "1", ec)
+				}(),
+			},
+			expectedEncryptionCfg: encryptiontesting.CreateEncryptionCfgWithWriteKey([]encryptiontesting.EncryptionKeysResourceTuple{{
+				Resource: "secrets",
+				Keys:     []apiserverconfigv1.Key{{Name: "1", Secret: kmsEndpointSecret}},
+				Modes:    []string{"kms"},
+			}}),
+			expectedActions: []string{
+				"list:pods:kms",
+				"get:secrets:kms",
+				"list:secrets:openshift-config-managed",
+				"get:secrets:openshift-config-managed",
+				"create:secrets:openshift-config-managed",
+				"create:events:kms",
+				"create:events:kms",
+			},
+			validateFunc: func(ts *testing.T, actions []clientgotesting.Action, destName string, expectedEncryptionCfg *apiserverconfigv1.EncryptionConfiguration) {
+				wasSecretValidated := false
+				for _, action := range actions {
+					if action.Matches("create", "secrets") {
+						createAction := action.(clientgotesting.CreateAction)
+						actualSecret := createAction.GetObject().(*corev1.Secret)
+						err := validateSecretWithEncryptionConfig(actualSecret, expectedEncryptionCfg, destName)
+						if err != nil {
+							ts.Fatalf("failed to verfy the encryption config, due to %v", err)
+						}
+						wasSecretValidated = true
+						break
+					}
+				}
+				if !wasSecretValidated {
+					ts.Errorf("the secret wasn't created and validated")
+				}
+			},
+		},
+
+		// scenario 13
+		{
+			name:            "the kms key with ID=34 is transitioning from an aescbc key (observed as a read key) so it is used as a write key in the EncryptionConfig",
+			targetNamespace: "kms",
+			targetGRs: []schema.GroupResource{
+				{Group: "", Resource: "secrets"},
+			},
+			kmsPlugin: kmsPlugin,
+			initialResources: []runtime.Object{
+				encryptiontesting.CreateDummyKubeAPIPod("kube-apiserver-1", "kms", "node-1"),
+				encryptiontesting.CreateExpiredMigratedEncryptionKeySecretWithRawKey("kms", []schema.GroupResource{{Group: "", Resource: "secrets"}}, 33, []byte("171582a0fcd6c5fdb65cbf5a3e9249d7")),
+				encryptiontesting.CreateKMSEncryptionKeySecret("kms", []schema.GroupResource{{Group: "", Resource: "secrets"}}, 34, kmsPlugin.Endpoint(), "kms-key-1"),
+				func() *corev1.Secret { // encryption config in kms namespace
+					ec := encryptiontesting.CreateEncryptionCfgWithWriteKey([]encryptiontesting.EncryptionKeysResourceTuple{{
+						Resource: "secrets",
+						Keys: []apiserverconfigv1.Key{
+							{Name: "33", Secret: "MTcxNTgyYTBmY2Q2YzVmZGI2NWNiZjVhM2U5MjQ5ZDc="},
+							{Name: "34", Secret: kmsEndpointSecret},
+						},
+						Modes: []string{"aescbc", "kms"},
+					}})
+					return createEncryptionCfgSecret(t, "kms", "1", ec)
+				}(),
+			},
+			expectedEncryptionCfg: encryptiontesting.CreateEncryptionCfgWithWriteKey([]encryptiontesting.EncryptionKeysResourceTuple{{
+				Resource: "secrets",
+				Keys: []apiserverconfigv1.Key{
+					{Name: "34", Secret: kmsEndpointSecret},
+					{Name: "33", Secret: "MTcxNTgyYTBmY2Q2YzVmZGI2NWNiZjVhM2U5MjQ5ZDc="},
+				},
+				Modes: []string{"kms", "aescbc"},
+			}}),
+			expectedActions: []string{
+				"list:pods:kms",
+				"get:secrets:kms",
+				"list:secrets:openshift-config-managed",
+				"get:secrets:openshift-config-managed",
+				"create:secrets:openshift-config-managed",
+				"create:events:kms",
+				"create:events:kms",
+			},
+			validateFunc: func(ts *testing.T, actions []clientgotesting.Action, destName string, expectedEncryptionCfg *apiserverconfigv1.EncryptionConfiguration) {
+				wasSecretValidated := false
+				for _, action := range actions {
+					if action.Matches("create", "secrets") {
+						createAction := action.(clientgotesting.CreateAction)
+						actualSecret := createAction.GetObject().(*corev1.Secret)
+						err := validateSecretWithEncryptionConfig(actualSecret, expectedEncryptionCfg, destName)
+						if err != nil {
+							ts.Fatalf("failed to verfy the encryption config, due to %v", err)
+						}
+						wasSecretValidated = true
+						break
+					}
+				}
+				if !wasSecretValidated {
+					ts.Errorf("the secret wasn't created and validated")
+				}
+			},
+		},
 	}
 
 	for _, scenario := range scenarios {
@@ -753,7 +872,7 @@ func TestStateController(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			provider := newTestProvider(scenario.targetGRs)
+			provider := newScenarioProvider(scenario.targetGRs, scenario.kmsPlugin)
 
 			target := NewStateController(
 				scenario.targetNamespace,
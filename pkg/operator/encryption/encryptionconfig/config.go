@@ -2,14 +2,18 @@ package encryptionconfig
 
 import (
 	"encoding/base64"
+	"fmt"
 	"sort"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	apiserverconfigv1 "k8s.io/apiserver/pkg/apis/apiserver/v1"
 	"k8s.io/klog/v2"
 
 	"github.com/openshift/library-go/pkg/operator/encryption/crypto"
+	"github.com/openshift/library-go/pkg/operator/encryption/kms"
 	"github.com/openshift/library-go/pkg/operator/encryption/secrets"
 	"github.com/openshift/library-go/pkg/operator/encryption/state"
 )
@@ -25,7 +29,7 @@ func FromEncryptionState(encryptionState map[schema.GroupResource]state.GroupRes
 	for gr, grKeys := range encryptionState {
 		resourceConfigs = append(resourceConfigs, apiserverconfigv1.ResourceConfiguration{
 			Resources: []string{gr.String()}, // we are forced to lose data here because this API is broken
-			Providers: stateToProviders(grKeys),
+			Providers: stateToProviders(gr, grKeys),
 		})
 	}
 
@@ -48,6 +52,7 @@ func FromEncryptionState(encryptionState map[schema.GroupResource]state.GroupRes
 //   - each resource has a distinct configuration with zero or more key based providers and the identity provider.
 //   - the last providers might be of type aesgcm. Then it carries the names of identity keys, recent first.
 //     We never use aesgcm as a real key because it is unsafe.
+//   - kms providers are named <keyID>_<group resource> and carry the plugin endpoint instead of a key.
 func ToEncryptionState(encryptionConfig *apiserverconfigv1.EncryptionConfiguration, keySecrets []*corev1.Secret) (map[schema.GroupResource]state.GroupResourceState, []state.KeyState) {
 	backedKeys := make([]state.KeyState, 0, len(keySecrets))
 	for _, s := range keySecrets {
@@ -91,6 +96,20 @@ func ToEncryptionState(encryptionConfig *apiserverconfigv1.EncryptionConfigurati
 					Mode: state.SecretBox,
 				}
 
+			case provider.KMS != nil && provider.KMS.APIVersion == kms.APIVersion:
+				keyID, ok := kmsProviderNameToKeyID(provider.KMS.Name)
+				if !ok {
+					klog.Infof("skipping kms provider index %d with invalid name %q for resource %s", i, provider.KMS.Name, resourceConfig.Resources[0])
+					continue // should never happen
+				}
+				ks = state.KeyState{
+					Key: apiserverconfigv1.Key{
+						Name:   keyID,
+						Secret: base64.StdEncoding.EncodeToString([]byte(provider.KMS.Endpoint)),
+					},
+					Mode: state.KMS,
+				}
+
 			case provider.Identity != nil:
 				// skip fake provider. If this is write-key, wait for first aesgcm provider providing the write key.
 				continue
@@ -139,7 +158,9 @@ func ToEncryptionState(encryptionConfig *apiserverconfigv1.EncryptionConfigurati
 // it primarily handles the conversion of KeyState to the appropriate provider config.
 // the identity mode is transformed into a custom aesgcm provider that simply exists to
 // curry the associated null key secret through the encryption state machine.
-func stateToProviders(desired state.GroupResourceState) []apiserverconfigv1.ProviderConfiguration {
+// the kms mode is transformed into a KMSv2 provider named after the key ID and the group resource
+// because the apiserver requires kms provider names to be unique across all resources.
+func stateToProviders(gr schema.GroupResource, desired state.GroupResourceState) []apiserverconfigv1.ProviderConfiguration {
 	allKeys := desired.ReadKeys
 
 	providers := make([]apiserverconfigv1.ProviderConfiguration, 0, len(allKeys)+1) // one extra for identity
@@ -181,6 +202,21 @@ func stateToProviders(desired state.GroupResourceState) []apiserverconfigv1.Prov
 					Keys: []apiserverconfigv1.Key{key.Key},
 				},
 			})
+		case state.KMS:
+			endpoint, err := base64.StdEncoding.DecodeString(key.Key.Secret)
+			if err != nil {
+				// this should never happen because our input should always be valid
+				klog.Infof("skipping key %s as it has an invalid kms endpoint: %v", key.Key.Name, err)
+				continue
+			}
+			providers = append(providers, apiserverconfigv1.ProviderConfiguration{
+				KMS: &apiserverconfigv1.KMSConfiguration{
+					APIVersion: kms.APIVersion,
+					Name:       kmsProviderName(key.Key.Name, gr),
+					Endpoint:   string(endpoint),
+					Timeout:    &metav1.Duration{Duration: kms.DefaultTimeout},
+				},
+			})
 		case state.Identity:
 			if i == 0 {
 				providers = append(providers, apiserverconfigv1.ProviderConfiguration{
@@ -210,3 +246,21 @@ func stateToProviders(desired state.GroupResourceState) []apiserverconfigv1.Prov
 
 	return providers
 }
+
+// kmsProviderName returns the name of the kms provider for the given key ID and group resource.
+// The name is part of the prefix of every value stored in etcd, hence it is kept short.
+func kmsProviderName(keyID string, gr schema.GroupResource) string {
+	return fmt.Sprintf("%s_%s", keyID, gr.String())
+}
+
+// kmsProviderNameToKeyID extracts the key ID from a kms provider name generated by kmsProviderName.
+func kmsProviderNameToKeyID(name string) (string, bool) {
+	keyID, _, found := strings.Cut(name, "_")
+	if !found {
+		return "", false
+	}
+	if _, valid := state.NameToKeyID(keyID); !valid {
+		return "", false
+	}
+	return keyID, true
+}
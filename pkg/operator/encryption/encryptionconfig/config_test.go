@@ -4,10 +4,12 @@ import (
 	"encoding/base64"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	apiserverconfigv1 "k8s.io/apiserver/pkg/apis/apiserver/v1"
 
@@ -361,6 +363,40 @@ func TestToEncryptionState(t *testing.T) {
 				},
 			},
 		},
+
+		// scenario 11
+		{
+			name: "kms write key and aescbc read key",
+			input: func() *apiserverconfigv1.EncryptionConfiguration {
+				keysRes := encryptiontesting.EncryptionKeysResourceTuple{
+					Resource: "secrets",
+					Keys: []apiserverconfigv1.Key{
+						{
+							Name:   "35",
+							Secret: base64.StdEncoding.EncodeToString([]byte("unix:///var/run/kmsplugin/kms.sock")),
+						},
+						{
+							Name:   "34",
+							Secret: "MTcxNTgyYTBmY2Q2YzVmZGI2NWNiZjVhM2U5MjQ5ZDc=",
+						},
+					},
+					Modes: []string{"kms", "aescbc"},
+				}
+				ec := encryptiontesting.CreateEncryptionCfgWithWriteKey([]encryptiontesting.EncryptionKeysResourceTuple{keysRes})
+				return ec
+			}(),
+			output: map[schema.GroupResource]state.GroupResourceState{
+				{Group: "", Resource: "secrets"}: {
+					WriteKey: state.KeyState{
+						Key: apiserverconfigv1.Key{Name: "35", Secret: base64.StdEncoding.EncodeToString([]byte("unix:///var/run/kmsplugin/kms.sock"))}, Mode: "kms",
+					},
+					ReadKeys: []state.KeyState{
+						{Key: apiserverconfigv1.Key{Name: "35", Secret: base64.StdEncoding.EncodeToString([]byte("unix:///var/run/kmsplugin/kms.sock"))}, Mode: "kms"},
+						{Key: apiserverconfigv1.Key{Name: "34", Secret: "MTcxNTgyYTBmY2Q2YzVmZGI2NWNiZjVhM2U5MjQ5ZDc="}, Mode: "aescbc"},
+					},
+				},
+			},
+		},
 	}
 
 	for _, scenario := range scenarios {
@@ -522,6 +558,44 @@ func TestFromEncryptionState(t *testing.T) {
 
 		// scenario 6
 		// TODO: encryption on after being off
+
+		// scenario 7
+		{
+			name:       "kms keys get a provider per resource named after the key ID",
+			grs:        []schema.GroupResource{{Group: "", Resource: "secrets"}, {Group: "route.openshift.io", Resource: "routes"}},
+			targetNs:   "kms",
+			writeKeyIn: encryptiontesting.CreateKMSEncryptionKeySecret("kms", nil, 3, "unix:///var/run/kmsplugin/kms.sock", "kms-key-2"),
+			readKeysIn: []*corev1.Secret{
+				encryptiontesting.CreateKMSEncryptionKeySecret("kms", []schema.GroupResource{{Group: "", Resource: "secrets"}, {Group: "route.openshift.io", Resource: "routes"}}, 2, "unix:///var/run/kmsplugin/kms.sock", "kms-key-1"),
+			},
+			makeOutput: func(writeKey *corev1.Secret, readKeys []*corev1.Secret) []apiserverconfigv1.ResourceConfiguration {
+				kmsConfig := func(name string) *apiserverconfigv1.KMSConfiguration {
+					return &apiserverconfigv1.KMSConfiguration{
+						APIVersion: "v2",
+						Name:       name,
+						Endpoint:   "unix:///var/run/kmsplugin/kms.sock",
+						Timeout:    &metav1.Duration{Duration: 10 * time.Second},
+					}
+				}
+
+				rr := apiserverconfigv1.ResourceConfiguration{}
+				rr.Resources = []string{"routes.route.openshift.io"}
+				rr.Providers = []apiserverconfigv1.ProviderConfiguration{
+					{KMS: kmsConfig("3_routes.route.openshift.io")},
+					{KMS: kmsConfig("2_routes.route.openshift.io")},
+					{Identity: &apiserverconfigv1.IdentityConfiguration{}},
+				}
+
+				rs := apiserverconfigv1.ResourceConfiguration{}
+				rs.Resources = []string{"secrets"}
+				rs.Providers = []apiserverconfigv1.ProviderConfiguration{
+					{KMS: kmsConfig("3_secrets")},
+					{KMS: kmsConfig("2_secrets")},
+					{Identity: &apiserverconfigv1.IdentityConfiguration{}},
+				}
+				return []apiserverconfigv1.ResourceConfiguration{rr, rs}
+			},
+		},
 	}
 
 	for _, scenario := range scenarios {
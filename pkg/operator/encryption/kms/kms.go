@@ -0,0 +1,51 @@
+package kms
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	kmsapi "k8s.io/kms/apis/v2"
+)
+
+const (
+	// APIVersion is the KMS API version the encryption controllers configure for the kms mode.
+	APIVersion = "v2"
+
+	// DefaultTimeout is the timeout for gRPC calls to the KMS plugin, both by the apiserver and by the key controller.
+	DefaultTimeout = 10 * time.Second
+
+	// healthzOK is the healthz value a healthy KMSv2 plugin reports in its status response.
+	healthzOK = "ok"
+)
+
+// GetKeyID asks the KMSv2 plugin listening on the given endpoint (e.g. unix:///var/run/kmsplugin/kms.sock)
+// for its current key ID. A change of the returned key ID means the plugin rotated its key encryption key.
+func GetKeyID(ctx context.Context, endpoint string) (string, error) {
+	conn, err := grpc.NewClient(endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to KMS plugin at %q: %w", endpoint, err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	status, err := kmsapi.NewKeyManagementServiceClient(conn).Status(ctx, &kmsapi.StatusRequest{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get status of KMS plugin at %q: %w", endpoint, err)
+	}
+	if status.Version != APIVersion {
+		return "", fmt.Errorf("KMS plugin at %q reports unsupported version %q, expected %q", endpoint, status.Version, APIVersion)
+	}
+	if status.Healthz != healthzOK {
+		return "", fmt.Errorf("KMS plugin at %q is unhealthy: %s", endpoint, status.Healthz)
+	}
+	if len(status.KeyId) == 0 {
+		return "", fmt.Errorf("KMS plugin at %q returned an empty key ID", endpoint)
+	}
+
+	return status.KeyId, nil
+}
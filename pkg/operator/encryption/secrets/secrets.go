@@ -60,7 +60,7 @@ func ToKeyState(s *corev1.Secret) (state.KeyState, error) {
 
 	keyMode := state.Mode(s.Annotations[encryptionSecretMode])
 	switch keyMode {
-	case state.AESCBC, state.AESGCM, state.SecretBox, state.Identity:
+	case state.AESCBC, state.AESGCM, state.SecretBox, state.Identity, state.KMS:
 		key.Mode = keyMode
 	default:
 		return state.KeyState{}, fmt.Errorf("secret %s/%s has invalid mode: %s", s.Namespace, s.Name, keyMode)
@@ -68,6 +68,12 @@ func ToKeyState(s *corev1.Secret) (state.KeyState, error) {
 	if keyMode != state.Identity && len(data) == 0 {
 		return state.KeyState{}, fmt.Errorf("secret %s/%s of mode %q must have non-empty key", s.Namespace, s.Name, keyMode)
 	}
+	if keyMode == state.KMS {
+		key.KMSKeyID = s.Annotations[encryptionSecretKMSKeyID]
+		if len(key.KMSKeyID) == 0 {
+			return state.KeyState{}, fmt.Errorf("secret %s/%s of mode %q must have non-empty %s annotation", s.Namespace, s.Name, keyMode, encryptionSecretKMSKeyID)
+		}
+	}
 
 	return key, nil
 }
@@ -101,6 +107,9 @@ func FromKeyState(component string, ks state.KeyState) (*corev1.Secret, error) {
 		Type: corev1.SecretTypeOpaque,
 	}
 
+	if ks.Mode == state.KMS {
+		s.Annotations[encryptionSecretKMSKeyID] = ks.KMSKeyID
+	}
 	if !ks.Migrated.Timestamp.IsZero() {
 		s.Annotations[EncryptionSecretMigratedTimestamp] = ks.Migrated.Timestamp.Format(time.RFC3339)
 	}
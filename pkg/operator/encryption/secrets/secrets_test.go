@@ -111,6 +111,26 @@ func TestRoundtrip(t *testing.T) {
 				ExternalReason: "external",
 			},
 		},
+		{
+			name:      "kms",
+			component: "kms",
+			ks: state.KeyState{
+				Key: v1.Key{
+					Name:   "54",
+					Secret: base64.StdEncoding.EncodeToString([]byte("unix:///var/run/kmsplugin/kms.sock")),
+				},
+				Backed: true, // this will be set by ToKeyState()
+				Mode:   "kms",
+				Migrated: state.MigrationState{
+					Timestamp: now,
+					Resources: []schema.GroupResource{
+						{Resource: "secrets"},
+					},
+				},
+				InternalReason: "internal",
+				KMSKeyID:       "arn:aws:kms:us-east-1:123456789012:key/abcd-1234",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
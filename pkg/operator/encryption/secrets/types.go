@@ -41,8 +41,14 @@ const (
 	// determine if a new key should be created even if encryptionSecretMigrationInterval has not been reached.
 	encryptionSecretExternalReason = "encryption.apiserver.operator.openshift.io/external-reason"
 
+	// encryptionSecretKMSKeyID is the annotation that holds the key ID the KMS plugin reported when a key of
+	// the kms mode was created. The key minting controller creates a new key when the plugin reports a different
+	// key ID, i.e. when the key encryption key was rotated inside of the KMS.
+	encryptionSecretKMSKeyID = "encryption.apiserver.operator.openshift.io/kms-key-id"
+
 	// In the data field of the secret API object, this (map) key is used to hold the actual encryption key
 	// (i.e. for AES-CBC mode the value associated with this map key is 32 bytes of random noise).
+	// For the kms mode it holds the endpoint of the KMS plugin because the key material never leaves the KMS.
 	EncryptionSecretKeyDataKey = "encryption.apiserver.operator.openshift.io-key"
 
 	// encryptionSecretFinalizer is a finalizer attached to all secrets generated
@@ -40,6 +40,10 @@ type KeyState struct {
 	InternalReason string
 	// the user via unsupportConfigOverrides.encryption.reason triggered this key.
 	ExternalReason string
+
+	// the key ID reported by the KMS plugin when this key was created. Only set for the kms mode.
+	// For the kms mode Key.Secret does not hold key material but the base64 encoded plugin endpoint.
+	KMSKeyID string
 }
 
 type MigrationState struct {
@@ -60,6 +64,7 @@ const (
 	AESGCM    Mode = "aesgcm"
 	SecretBox Mode = "secretbox" // available from the first release, see defaultMode below
 	Identity  Mode = "identity"  // available from the first release, see defaultMode below
+	KMS       Mode = "kms"       // envelope encryption through an external KMSv2 plugin
 
 	// Changing this value requires caution to not break downgrades.
 	// Specifically, if some new Mode is released in version X, that new Mode cannot
@@ -1,6 +1,7 @@
 package testing
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -186,7 +187,7 @@ func CreateEncryptionCfgNoWriteKeyMultipleReadKeys(keysResources []EncryptionKey
 			if len(keysResource.Modes) == len(keysResource.Keys) {
 				desiredMode = keysResource.Modes[i]
 			}
-			rc.Providers = append(rc.Providers, *createProviderCfg(desiredMode, key))
+			rc.Providers = append(rc.Providers, *createProviderCfg(desiredMode, key, keysResource.Resource))
 		}
 		ec.Resources = append(ec.Resources, rc)
 	}
@@ -204,7 +205,7 @@ func CreateEncryptionCfgWithWriteKey(keysResources []EncryptionKeysResourceTuple
 			if len(keysResource.Modes) == len(keysResource.Keys) {
 				desiredMode = keysResource.Modes[i]
 			}
-			providers = append(providers, *createProviderCfg(desiredMode, key))
+			providers = append(providers, *createProviderCfg(desiredMode, key, keysResource.Resource))
 		}
 		providers = append(providers, apiserverconfigv1.ProviderConfiguration{
 			Identity: &apiserverconfigv1.IdentityConfiguration{},
@@ -225,8 +226,22 @@ func CreateEncryptionCfgWithWriteKey(keysResources []EncryptionKeysResourceTuple
 	}
 }
 
-func createProviderCfg(mode string, key apiserverconfigv1.Key) *apiserverconfigv1.ProviderConfiguration {
+func createProviderCfg(mode string, key apiserverconfigv1.Key, resource string) *apiserverconfigv1.ProviderConfiguration {
 	switch mode {
+	case "kms":
+		// for the kms mode the key secret carries the plugin endpoint
+		endpoint, err := base64.StdEncoding.DecodeString(key.Secret)
+		if err != nil {
+			panic(err)
+		}
+		return &apiserverconfigv1.ProviderConfiguration{
+			KMS: &apiserverconfigv1.KMSConfiguration{
+				APIVersion: "v2",
+				Name:       fmt.Sprintf("%s_%s", key.Name, resource),
+				Endpoint:   string(endpoint),
+				Timeout:    &metav1.Duration{Duration: 10 * time.Second},
+			},
+		}
 	case "aesgcm":
 		return &apiserverconfigv1.ProviderConfiguration{
 			AESGCM: &apiserverconfigv1.AESConfiguration{
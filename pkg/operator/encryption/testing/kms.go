@@ -0,0 +1,131 @@
+package testing
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kmsapi "k8s.io/kms/apis/v2"
+)
+
+// FakeKMSPlugin is a KMSv2 plugin serving on a unix socket for unit tests.
+// It "encrypts" by prefixing the plaintext with the current key ID and can
+// decrypt everything encrypted with any key ID it ever had.
+type FakeKMSPlugin struct {
+	kmsapi.UnimplementedKeyManagementServiceServer
+
+	endpoint string
+	server   *grpc.Server
+
+	lock    sync.Mutex
+	keyID   string
+	keyIDs  map[string]bool
+	healthz string
+}
+
+var _ kmsapi.KeyManagementServiceServer = &FakeKMSPlugin{}
+
+// NewFakeKMSPlugin starts a fake KMSv2 plugin reporting the given key ID. It is stopped when the test finishes.
+func NewFakeKMSPlugin(t testing.TB, keyID string) *FakeKMSPlugin {
+	t.Helper()
+
+	// t.TempDir() easily exceeds the maximum unix socket path length, hence a short directory in /tmp
+	dir, err := os.MkdirTemp("", "kms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	socket := filepath.Join(dir, "kms.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &FakeKMSPlugin{
+		endpoint: "unix://" + socket,
+		server:   grpc.NewServer(),
+		keyID:    keyID,
+		keyIDs:   map[string]bool{keyID: true},
+		healthz:  "ok",
+	}
+	kmsapi.RegisterKeyManagementServiceServer(p.server, p)
+	go p.server.Serve(listener)
+
+	t.Cleanup(func() {
+		p.server.Stop()
+		os.RemoveAll(dir)
+	})
+
+	return p
+}
+
+// Endpoint returns the endpoint of the plugin in the form unix:///path/to/kms.sock.
+func (p *FakeKMSPlugin) Endpoint() string {
+	return p.endpoint
+}
+
+// RotateKeyID makes the plugin report the given key ID. Data encrypted with previous key IDs can still be decrypted.
+func (p *FakeKMSPlugin) RotateKeyID(keyID string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.keyID = keyID
+	p.keyIDs[keyID] = true
+}
+
+// SetHealthz sets the healthz value reported in the status response. Anything but "ok" means unhealthy.
+func (p *FakeKMSPlugin) SetHealthz(healthz string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.healthz = healthz
+}
+
+func (p *FakeKMSPlugin) Status(_ context.Context, _ *kmsapi.StatusRequest) (*kmsapi.StatusResponse, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return &kmsapi.StatusResponse{Version: "v2", Healthz: p.healthz, KeyId: p.keyID}, nil
+}
+
+func (p *FakeKMSPlugin) Encrypt(_ context.Context, req *kmsapi.EncryptRequest) (*kmsapi.EncryptResponse, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return &kmsapi.EncryptResponse{
+		Ciphertext: append([]byte(p.keyID+":"), req.Plaintext...),
+		KeyId:      p.keyID,
+	}, nil
+}
+
+func (p *FakeKMSPlugin) Decrypt(_ context.Context, req *kmsapi.DecryptRequest) (*kmsapi.DecryptResponse, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if !p.keyIDs[req.KeyId] {
+		return nil, status.Errorf(codes.NotFound, "unknown key ID %q", req.KeyId)
+	}
+	prefix := []byte(req.KeyId + ":")
+	if !bytes.HasPrefix(req.Ciphertext, prefix) {
+		return nil, status.Errorf(codes.InvalidArgument, "ciphertext was not encrypted with key ID %q", req.KeyId)
+	}
+	return &kmsapi.DecryptResponse{Plaintext: bytes.TrimPrefix(req.Ciphertext, prefix)}, nil
+}
+
+// CreateKMSEncryptionKeySecret creates a key secret of the kms mode pointing to the given plugin endpoint.
+func CreateKMSEncryptionKeySecret(targetNS string, grs []schema.GroupResource, keyID uint64, endpoint, kmsKeyID string) *corev1.Secret {
+	secret := CreateEncryptionKeySecretWithRawKeyWithMode(targetNS, grs, keyID, []byte(endpoint), "kms")
+	secret.Annotations["encryption.apiserver.operator.openshift.io/kms-key-id"] = kmsKeyID
+	return secret
+}
+
+// CreateMigratedKMSEncryptionKeySecret creates a key secret of the kms mode that was migrated at the given time.
+func CreateMigratedKMSEncryptionKeySecret(targetNS string, grs []schema.GroupResource, keyID uint64, endpoint, kmsKeyID string, ts time.Time) *corev1.Secret {
+	secret := CreateKMSEncryptionKeySecret(targetNS, grs, keyID, endpoint, kmsKeyID)
+	secret.Annotations[encryptionSecretMigratedTimestampForTest] = ts.Format(time.RFC3339)
+	return secret
+}
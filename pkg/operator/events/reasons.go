@@ -0,0 +1,119 @@
+package events
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// maxReasonLength and maxActionLength are the limits the events.k8s.io API validates.
+const (
+	maxReasonLength = 128
+	maxActionLength = 128
+)
+
+var reasonPattern = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+
+// Reason is a registered reason of the structured events of a controller.
+type Reason struct {
+	// Reason is the UpperCamelCase reason of the event, e.g. "DeploymentUpdated".
+	Reason string
+	// Type is corev1.EventTypeNormal or corev1.EventTypeWarning.
+	Type string
+	// Action is what the controller did, or failed to do, to the regarding object, e.g. "Update".
+	Action string
+}
+
+// ReasonRegistry is the set of reasons a controller records structured events with. Structured
+// recorders refuse unregistered reasons, so the events of a controller are listed in one place and
+// tools can rely on them.
+type ReasonRegistry struct {
+	controller string
+
+	lock    sync.RWMutex
+	reasons map[string]Reason
+}
+
+// NewReasonRegistry returns an empty registry for a controller. The controller name is recorded as
+// the reporting controller of the events, e.g. "openshift.io/cluster-kube-apiserver-operator".
+func NewReasonRegistry(controller string) *ReasonRegistry {
+	return &ReasonRegistry{
+		controller: controller,
+		reasons:    make(map[string]Reason),
+	}
+}
+
+// Controller returns the name of the controller.
+func (r *ReasonRegistry) Controller() string {
+	return r.controller
+}
+
+// Register adds a reason to the registry and returns it. A reason can only be registered again
+// with the same type and action.
+func (r *ReasonRegistry) Register(reason Reason) (Reason, error) {
+	if err := validateReason(reason); err != nil {
+		return Reason{}, err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if existing, ok := r.reasons[reason.Reason]; ok && existing != reason {
+		return Reason{}, fmt.Errorf("reason %q of controller %s is already registered as %+v", reason.Reason, r.controller, existing)
+	}
+	r.reasons[reason.Reason] = reason
+	return reason, nil
+}
+
+// MustRegister is like Register but panics if the reason is invalid. It is meant for package level
+// declarations of reasons.
+func (r *ReasonRegistry) MustRegister(reason Reason) Reason {
+	registered, err := r.Register(reason)
+	if err != nil {
+		panic(err)
+	}
+	return registered
+}
+
+// Reasons returns the registered reasons sorted by name.
+func (r *ReasonRegistry) Reasons() []Reason {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	reasons := make([]Reason, 0, len(r.reasons))
+	for _, reason := range r.reasons {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool { return reasons[i].Reason < reasons[j].Reason })
+	return reasons
+}
+
+// Validate returns an error if the reason is not registered exactly as given.
+func (r *ReasonRegistry) Validate(reason Reason) error {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	registered, ok := r.reasons[reason.Reason]
+	if !ok {
+		return fmt.Errorf("reason %q is not registered for controller %s", reason.Reason, r.controller)
+	}
+	if registered != reason {
+		return fmt.Errorf("reason %q of controller %s is registered as %+v, not %+v", reason.Reason, r.controller, registered, reason)
+	}
+	return nil
+}
+
+func validateReason(reason Reason) error {
+	if len(reason.Reason) > maxReasonLength || !reasonPattern.MatchString(reason.Reason) {
+		return fmt.Errorf("reason %q must be UpperCamelCase and at most %d characters", reason.Reason, maxReasonLength)
+	}
+	if reason.Type != corev1.EventTypeNormal && reason.Type != corev1.EventTypeWarning {
+		return fmt.Errorf("reason %q has type %q, expected %s or %s", reason.Reason, reason.Type, corev1.EventTypeNormal, corev1.EventTypeWarning)
+	}
+	if len(reason.Action) == 0 || len(reason.Action) > maxActionLength {
+		return fmt.Errorf("reason %q must have an action of at most %d characters", reason.Reason, maxActionLength)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package events
+
+import (
+	"context"
+	"fmt"
+)
+
+// NewFanOutRecorder provides event recorder that records every event to all the given recorders,
+// e.g. an API recorder and a logging recorder. The component name is the one of the first recorder.
+func NewFanOutRecorder(recorders ...Recorder) Recorder {
+	return &fanOutRecorder{recorders: recorders}
+}
+
+type fanOutRecorder struct {
+	recorders []Recorder
+}
+
+func (r *fanOutRecorder) ComponentName() string {
+	if len(r.recorders) == 0 {
+		return ""
+	}
+	return r.recorders[0].ComponentName()
+}
+
+func (r *fanOutRecorder) ForComponent(componentName string) Recorder {
+	recorders := make([]Recorder, 0, len(r.recorders))
+	for _, recorder := range r.recorders {
+		recorders = append(recorders, recorder.ForComponent(componentName))
+	}
+	return &fanOutRecorder{recorders: recorders}
+}
+
+func (r *fanOutRecorder) WithComponentSuffix(suffix string) Recorder {
+	return r.ForComponent(fmt.Sprintf("%s-%s", r.ComponentName(), suffix))
+}
+
+func (r *fanOutRecorder) WithContext(ctx context.Context) Recorder {
+	recorders := make([]Recorder, 0, len(r.recorders))
+	for _, recorder := range r.recorders {
+		recorders = append(recorders, recorder.WithContext(ctx))
+	}
+	return &fanOutRecorder{recorders: recorders}
+}
+
+func (r *fanOutRecorder) Shutdown() {
+	for _, recorder := range r.recorders {
+		recorder.Shutdown()
+	}
+}
+
+func (r *fanOutRecorder) Event(reason, message string) {
+	for _, recorder := range r.recorders {
+		recorder.Event(reason, message)
+	}
+}
+
+func (r *fanOutRecorder) Eventf(reason, messageFmt string, args ...interface{}) {
+	r.Event(reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *fanOutRecorder) Warning(reason, message string) {
+	for _, recorder := range r.recorders {
+		recorder.Warning(reason, message)
+	}
+}
+
+func (r *fanOutRecorder) Warningf(reason, messageFmt string, args ...interface{}) {
+	r.Warning(reason, fmt.Sprintf(messageFmt, args...))
+}
@@ -0,0 +1,33 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestFanOutRecorder(t *testing.T) {
+	clock := clocktesting.NewFakePassiveClock(time.Now())
+	first := NewInMemoryRecorder("first", clock)
+	second := NewInMemoryRecorder("second", clock)
+	r := NewFanOutRecorder(first, second)
+
+	if r.ComponentName() != "first" {
+		t.Errorf("expected the component of the first recorder, got %s", r.ComponentName())
+	}
+	r.Eventf("Normal", "event %d", 1)
+	r.Warning("Failed", "warning")
+	r = r.WithComponentSuffix("sub")
+	r.Event("Sub", "event")
+
+	for _, recorder := range []InMemoryRecorder{first, second} {
+		events := recorder.Events()
+		if len(events) != 3 {
+			t.Fatalf("%s: expected 3 events, got %d", recorder.ComponentName(), len(events))
+		}
+		if events[0].Message != "event 1" || events[1].Type != "Warning" || events[2].Source.Component != "first-sub" {
+			t.Errorf("%s: unexpected events %v", recorder.ComponentName(), events)
+		}
+	}
+}
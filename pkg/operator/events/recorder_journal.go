@@ -0,0 +1,347 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+
+	"github.com/openshift/library-go/pkg/operator/resource/retry"
+)
+
+const (
+	defaultJournalMaxEvents     = 1000
+	defaultJournalFlushInterval = 10 * time.Second
+	journalMaxFlushBackoff      = 5 * time.Minute
+	journalFileSuffix           = ".json"
+)
+
+// JournalOptions configures a JournalRecorder.
+type JournalOptions struct {
+	// Dir is the directory that holds the journal. It is created if it does not exist.
+	Dir string
+	// MaxEvents is the number of events the journal holds, 1000 if unset. The oldest events are
+	// dropped when the journal is full.
+	MaxEvents int
+	// FlushInterval is how often Run replays the journal, 10 seconds if unset. Failed replays are
+	// retried with exponential backoff, up to 5 minutes apart.
+	FlushInterval time.Duration
+}
+
+// JournalRecorder is an event recorder that spools the events it cannot create to a journal on
+// disk, e.g. while the API server is down, and creates them once the API server is reachable.
+// Events are created in the order they were recorded: while the journal is not empty, new events
+// are appended to it and created when Run or Flush replays the journal. The journal survives restarts, so a process
+// using the same directory flushes the events a previous process could not create.
+type JournalRecorder struct {
+	client            corev1client.EventInterface
+	component         string
+	involvedObjectRef *corev1.ObjectReference
+	clock             clock.PassiveClock
+	flushInterval     time.Duration
+
+	// journal is shared by the recorders of all components
+	journal *eventJournal
+
+	ctx context.Context
+}
+
+var _ Recorder = &JournalRecorder{}
+
+// NewJournalRecorder returns new event recorder that spools events to the journal in options.Dir.
+func NewJournalRecorder(client corev1client.EventInterface, options JournalOptions, sourceComponentName string, involvedObjectRef *corev1.ObjectReference, clock clock.PassiveClock) (*JournalRecorder, error) {
+	if options.MaxEvents <= 0 {
+		options.MaxEvents = defaultJournalMaxEvents
+	}
+	if options.FlushInterval <= 0 {
+		options.FlushInterval = defaultJournalFlushInterval
+	}
+	journal, err := openEventJournal(options.Dir, options.MaxEvents)
+	if err != nil {
+		return nil, err
+	}
+	return &JournalRecorder{
+		client:            client,
+		component:         sourceComponentName,
+		involvedObjectRef: involvedObjectRef,
+		clock:             clock,
+		flushInterval:     options.FlushInterval,
+		journal:           journal,
+	}, nil
+}
+
+// Run replays the journal periodically until the context is done, backing off while the API
+// server is unreachable.
+func (r *JournalRecorder) Run(ctx context.Context) {
+	backoff := r.flushBackoff()
+	for {
+		delay := r.flushInterval
+		if err := r.Flush(ctx); err != nil {
+			delay = backoff.Step()
+			klog.V(2).Infof("Unable to flush the event journal, retrying in %v: %v", delay, err)
+		} else {
+			backoff = r.flushBackoff()
+		}
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (r *JournalRecorder) flushBackoff() wait.Backoff {
+	return wait.Backoff{
+		Duration: r.flushInterval,
+		Factor:   2,
+		Jitter:   0.1,
+		Steps:    math.MaxInt32,
+		Cap:      journalMaxFlushBackoff,
+	}
+}
+
+// Flush creates the events of the journal in order. It stops at the first event that cannot be
+// created because the API server is unreachable, and returns the error.
+func (r *JournalRecorder) Flush(ctx context.Context) error {
+	return r.journal.flush(ctx, r.client)
+}
+
+// Pending returns the number of events in the journal.
+func (r *JournalRecorder) Pending() int {
+	names, err := r.journal.list()
+	if err != nil {
+		klog.Warningf("Unable to list the event journal: %v", err)
+		return 0
+	}
+	return len(names)
+}
+
+func (r *JournalRecorder) ComponentName() string {
+	return r.component
+}
+
+func (r *JournalRecorder) ForComponent(componentName string) Recorder {
+	newRecorderForComponent := *r
+	newRecorderForComponent.component = componentName
+	return &newRecorderForComponent
+}
+
+func (r *JournalRecorder) WithComponentSuffix(suffix string) Recorder {
+	return r.ForComponent(fmt.Sprintf("%s-%s", r.ComponentName(), suffix))
+}
+
+func (r *JournalRecorder) WithContext(ctx context.Context) Recorder {
+	r.ctx = ctx
+	return r
+}
+
+// Shutdown makes a last attempt to flush the journal. Events that are still not created are kept
+// for the next process.
+func (r *JournalRecorder) Shutdown() {
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+	if err := r.Flush(ctx); err != nil {
+		klog.Warningf("Unable to flush the event journal, %d events are kept in %s: %v", r.Pending(), r.journal.dir, err)
+	}
+}
+
+func (r *JournalRecorder) Eventf(reason, messageFmt string, args ...interface{}) {
+	r.Event(reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *JournalRecorder) Warningf(reason, messageFmt string, args ...interface{}) {
+	r.Warning(reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *JournalRecorder) Event(reason, message string) {
+	r.record(makeEvent(r.clock, r.involvedObjectRef, r.component, corev1.EventTypeNormal, reason, message))
+}
+
+func (r *JournalRecorder) Warning(reason, message string) {
+	r.record(makeEvent(r.clock, r.involvedObjectRef, r.component, corev1.EventTypeWarning, reason, message))
+}
+
+func (r *JournalRecorder) context() context.Context {
+	if r.ctx != nil {
+		return r.ctx
+	}
+	return context.Background()
+}
+
+// record creates the event, or spools it if the API server is unreachable. Events are spooled
+// without trying the API server while the journal is not empty, so they are created in order and
+// recording does not wait on an API server that is down.
+func (r *JournalRecorder) record(event *corev1.Event) {
+	if err := r.journal.recordOrAppend(r.context(), r.client, event); err != nil {
+		klog.Warningf("Error recording event %+v: %v", event, err)
+	}
+}
+
+// eventJournal is a ring buffer of events stored as one file per event, named by a sequence number.
+type eventJournal struct {
+	dir       string
+	maxEvents int
+
+	// lock serializes the writes and flushes of the journal
+	lock sync.Mutex
+	next uint64
+}
+
+func openEventJournal(dir string, maxEvents int) (*eventJournal, error) {
+	if len(dir) == 0 {
+		return nil, fmt.Errorf("the event journal directory is required")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("unable to create the event journal: %w", err)
+	}
+	j := &eventJournal{dir: dir, maxEvents: maxEvents}
+	names, err := j.list()
+	if err != nil {
+		return nil, err
+	}
+	if len(names) > 0 {
+		last, _ := strconv.ParseUint(strings.TrimSuffix(names[len(names)-1], journalFileSuffix), 10, 64)
+		j.next = last + 1
+	}
+	return j, nil
+}
+
+// list returns the names of the journal files, oldest first.
+func (j *eventJournal) list() ([]string, error) {
+	entries, err := os.ReadDir(j.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, journalFileSuffix) {
+			continue
+		}
+		if _, err := strconv.ParseUint(strings.TrimSuffix(name, journalFileSuffix), 10, 64); err != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	// the names are zero padded, so they sort by sequence number
+	sort.Strings(names)
+	return names, nil
+}
+
+func (j *eventJournal) recordOrAppend(ctx context.Context, client corev1client.EventInterface, event *corev1.Event) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	names, err := j.list()
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		err := createJournaledEvent(ctx, client, event)
+		if err == nil || !isRetriableEventError(err) {
+			return err
+		}
+		klog.V(2).Infof("Spooling event %s to the journal: %v", event.Reason, err)
+		return j.append(event)
+	}
+
+	return j.append(event)
+}
+
+// append writes an event to the journal and drops the oldest events over the limit.
+func (j *eventJournal) append(event *corev1.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%020d%s", j.next, journalFileSuffix)
+	tmp := filepath.Join(j.dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(j.dir, name)); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	j.next++
+
+	names, err := j.list()
+	if err != nil {
+		return err
+	}
+	for len(names) > j.maxEvents {
+		klog.Warningf("Event journal %s is full, dropping the oldest event %s", j.dir, names[0])
+		if err := os.Remove(filepath.Join(j.dir, names[0])); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+func (j *eventJournal) flush(ctx context.Context, client corev1client.EventInterface) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.flushLocked(ctx, client)
+}
+
+func (j *eventJournal) flushLocked(ctx context.Context, client corev1client.EventInterface) error {
+	names, err := j.list()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		path := filepath.Join(j.dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		event := &corev1.Event{}
+		if err := json.Unmarshal(data, event); err != nil {
+			klog.Warningf("Dropping corrupt event %s from the journal: %v", path, err)
+		} else if err := createJournaledEvent(ctx, client, event); err != nil {
+			if isRetriableEventError(err) {
+				return err
+			}
+			klog.Warningf("Dropping event %+v from the journal: %v", event, err)
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// createJournaledEvent creates an event, treating an event that already exists as created since a
+// previous flush may have been interrupted after the event was created.
+func createJournaledEvent(ctx context.Context, client corev1client.EventInterface, event *corev1.Event) error {
+	_, err := client.Create(ctx, event, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+// isRetriableEventError returns true unless the API server rejected the event, in which case
+// retrying would not help.
+func isRetriableEventError(err error) bool {
+	return !retry.IsHTTPClientError(err) || errors.IsTooManyRequests(err)
+}
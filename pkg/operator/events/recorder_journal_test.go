@@ -0,0 +1,148 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	clientgotesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+// apiServerDown fails the creation of events while it is set.
+func apiServerDown(client *fake.Clientset, down *bool, createErr error) {
+	client.PrependReactor("create", "events", func(action clientgotesting.Action) (bool, runtime.Object, error) {
+		if *down {
+			return true, nil, createErr
+		}
+		return false, nil, nil
+	})
+}
+
+func createdEventMessages(client *fake.Clientset) []string {
+	var messages []string
+	for _, action := range client.Actions() {
+		if action.Matches("create", "events") {
+			messages = append(messages, action.(clientgotesting.CreateAction).GetObject().(*corev1.Event).Message)
+		}
+	}
+	return messages
+}
+
+func TestJournalRecorder(t *testing.T) {
+	dir := t.TempDir()
+	client := fake.NewSimpleClientset()
+	down := false
+	apiServerDown(client, &down, fmt.Errorf("connection refused"))
+	clock := clocktesting.NewFakePassiveClock(time.Now())
+
+	r, err := NewJournalRecorder(client.CoreV1().Events("test"), JournalOptions{Dir: dir, MaxEvents: 3}, "test", fakeControllerRef(t), clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Event("Created", "first")
+	if r.Pending() != 0 {
+		t.Fatalf("expected the event to be created, got %d pending", r.Pending())
+	}
+
+	down = true
+	for i := 0; i < 4; i++ {
+		clock.SetTime(clock.Now().Add(time.Second))
+		r.Warningf("Spooled", "spooled %d", i)
+	}
+	if r.Pending() != 3 {
+		t.Fatalf("expected the journal to hold 3 events, got %d", r.Pending())
+	}
+	if err := r.Flush(context.TODO()); err == nil {
+		t.Errorf("expected the flush to fail while the API server is down")
+	}
+
+	// a new recorder using the same journal flushes the events of the previous one
+	down = false
+	client.ClearActions()
+	r, err = NewJournalRecorder(client.CoreV1().Events("test"), JournalOptions{Dir: dir, MaxEvents: 3}, "test", fakeControllerRef(t), clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Flush(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	messages := createdEventMessages(client)
+	expected := []string{"spooled 1", "spooled 2", "spooled 3"}
+	if fmt.Sprint(messages) != fmt.Sprint(expected) {
+		t.Errorf("expected the oldest event to be dropped and the others created in order, got %v", messages)
+	}
+	if r.Pending() != 0 {
+		t.Errorf("expected the journal to be empty, got %d pending", r.Pending())
+	}
+}
+
+func TestJournalRecorderOrder(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	down := true
+	apiServerDown(client, &down, errors.NewServiceUnavailable("unavailable"))
+	r, err := NewJournalRecorder(client.CoreV1().Events("test"), JournalOptions{Dir: t.TempDir(), FlushInterval: 10 * time.Millisecond}, "test", fakeControllerRef(t), clocktesting.NewFakePassiveClock(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Event("Spooled", "first")
+	r.Event("Spooled", "second")
+	// events recorded while the journal is not empty are spooled without trying the API server
+	if messages := createdEventMessages(client); fmt.Sprint(messages) != fmt.Sprint([]string{"first"}) {
+		t.Errorf("expected a single attempt to create an event, got %v", messages)
+	}
+	down = false
+	r.ForComponent("other").Event("Created", "third")
+	if r.Pending() != 3 {
+		t.Fatalf("expected the journal to hold 3 events, got %d", r.Pending())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Run(ctx)
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		return r.Pending() == 0, nil
+	}); err != nil {
+		t.Fatalf("expected Run to flush the journal, got %d pending", r.Pending())
+	}
+	if messages := createdEventMessages(client); fmt.Sprint(messages) != fmt.Sprint([]string{"first", "first", "second", "third"}) {
+		t.Errorf("expected the spooled events to be created in order, got %v", messages)
+	}
+}
+
+func TestJournalRecorderDropsRejectedEvents(t *testing.T) {
+	dir := t.TempDir()
+	client := fake.NewSimpleClientset()
+	down := true
+	apiServerDown(client, &down, errors.NewInvalid(schema.GroupKind{Kind: "Event"}, "event", nil))
+	r, err := NewJournalRecorder(client.CoreV1().Events("test"), JournalOptions{Dir: dir}, "test", fakeControllerRef(t), clocktesting.NewFakePassiveClock(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Event("Invalid", "rejected")
+	if r.Pending() != 0 {
+		t.Errorf("expected the rejected event not to be spooled, got %d pending", r.Pending())
+	}
+
+	// corrupt entries are dropped
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000007.json"), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Flush(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if r.Pending() != 0 {
+		t.Errorf("expected the corrupt event to be dropped, got %d pending", r.Pending())
+	}
+}
@@ -0,0 +1,162 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+// SamplingOptions configures the deduplication and sampling of events.
+type SamplingOptions struct {
+	// DuplicateWindow drops an event with the same component, type, reason and message as an event
+	// recorded within the window. Zero disables deduplication.
+	DuplicateWindow time.Duration
+
+	// Interval is the period the limits apply to, one minute if unset.
+	Interval time.Duration
+	// ReasonLimits is the number of events of a reason recorded per interval.
+	ReasonLimits map[string]int
+	// DefaultLimit is the number of events of other reasons recorded per interval. Zero is unlimited.
+	DefaultLimit int
+}
+
+// NewSamplingRecorder provides event recorder that drops duplicate events and events of a reason
+// over its limit before recording them to the delegate. The next event of a reason recorded after
+// events were dropped mentions how many were dropped.
+func NewSamplingRecorder(delegate Recorder, options SamplingOptions, clock clock.PassiveClock) Recorder {
+	if options.Interval <= 0 {
+		options.Interval = time.Minute
+	}
+	return &samplingRecorder{
+		delegate: delegate,
+		sampler: &eventSampler{
+			options:    options,
+			clock:      clock,
+			lastSeen:   make(map[string]time.Time),
+			reasons:    make(map[string]*reasonSample),
+			lastPruned: clock.Now(),
+		},
+	}
+}
+
+type samplingRecorder struct {
+	delegate Recorder
+	// sampler is shared by the recorders of all components
+	sampler *eventSampler
+}
+
+// eventSampler holds the events seen recently.
+type eventSampler struct {
+	options SamplingOptions
+	clock   clock.PassiveClock
+
+	lock       sync.Mutex
+	lastSeen   map[string]time.Time
+	reasons    map[string]*reasonSample
+	lastPruned time.Time
+}
+
+// reasonSample counts the events of a reason in the current interval.
+type reasonSample struct {
+	start    time.Time
+	recorded int
+	dropped  int
+}
+
+// sample returns the message to record, or false if the event is dropped.
+func (s *eventSampler) sample(component, eventType, reason, message string) (string, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := s.clock.Now()
+
+	if s.options.DuplicateWindow > 0 {
+		if now.Sub(s.lastPruned) > s.options.DuplicateWindow {
+			for key, seen := range s.lastSeen {
+				if now.Sub(seen) >= s.options.DuplicateWindow {
+					delete(s.lastSeen, key)
+				}
+			}
+			s.lastPruned = now
+		}
+		key := strings.Join([]string{component, eventType, reason, message}, "\x00")
+		if seen, ok := s.lastSeen[key]; ok && now.Sub(seen) < s.options.DuplicateWindow {
+			klog.V(4).Infof("Dropping duplicate event %s: %s", reason, message)
+			return "", false
+		}
+		s.lastSeen[key] = now
+	}
+
+	limit, ok := s.options.ReasonLimits[reason]
+	if !ok {
+		limit = s.options.DefaultLimit
+	}
+	if limit <= 0 {
+		return message, true
+	}
+	sample, ok := s.reasons[reason]
+	if !ok || now.Sub(sample.start) >= s.options.Interval {
+		dropped := 0
+		if ok {
+			dropped = sample.dropped
+		}
+		sample = &reasonSample{start: now, dropped: dropped}
+		s.reasons[reason] = sample
+	}
+	if sample.recorded >= limit {
+		sample.dropped++
+		klog.V(4).Infof("Dropping event %s over the limit of %d per %v: %s", reason, limit, s.options.Interval, message)
+		return "", false
+	}
+	sample.recorded++
+	if sample.dropped > 0 {
+		message = fmt.Sprintf("%s (%d similar events were dropped)", message, sample.dropped)
+		sample.dropped = 0
+	}
+	return message, true
+}
+
+func (r *samplingRecorder) ComponentName() string {
+	return r.delegate.ComponentName()
+}
+
+func (r *samplingRecorder) ForComponent(componentName string) Recorder {
+	return &samplingRecorder{delegate: r.delegate.ForComponent(componentName), sampler: r.sampler}
+}
+
+func (r *samplingRecorder) WithComponentSuffix(suffix string) Recorder {
+	return r.ForComponent(fmt.Sprintf("%s-%s", r.ComponentName(), suffix))
+}
+
+func (r *samplingRecorder) WithContext(ctx context.Context) Recorder {
+	return &samplingRecorder{delegate: r.delegate.WithContext(ctx), sampler: r.sampler}
+}
+
+func (r *samplingRecorder) Shutdown() {
+	r.delegate.Shutdown()
+}
+
+func (r *samplingRecorder) Event(reason, message string) {
+	if message, ok := r.sampler.sample(r.ComponentName(), corev1.EventTypeNormal, reason, message); ok {
+		r.delegate.Event(reason, message)
+	}
+}
+
+func (r *samplingRecorder) Eventf(reason, messageFmt string, args ...interface{}) {
+	r.Event(reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *samplingRecorder) Warning(reason, message string) {
+	if message, ok := r.sampler.sample(r.ComponentName(), corev1.EventTypeWarning, reason, message); ok {
+		r.delegate.Warning(reason, message)
+	}
+}
+
+func (r *samplingRecorder) Warningf(reason, messageFmt string, args ...interface{}) {
+	r.Warning(reason, fmt.Sprintf(messageFmt, args...))
+}
@@ -0,0 +1,178 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestSamplingRecorder(t *testing.T) {
+	clock := clocktesting.NewFakeClock(time.Now())
+	delegate := NewInMemoryRecorder("test", clock)
+	r := NewSamplingRecorder(delegate, SamplingOptions{
+		DuplicateWindow: time.Minute,
+		Interval:        time.Minute,
+		ReasonLimits:    map[string]int{"Limited": 2},
+	}, clock)
+
+	// duplicates are dropped within the window
+	r.Event("Duplicate", "same")
+	r.Event("Duplicate", "same")
+	r.Warning("Duplicate", "same")
+	r.Event("Duplicate", "other")
+	r.ForComponent("other").Event("Duplicate", "same")
+
+	// reasons are limited per interval
+	for i := 0; i < 5; i++ {
+		r.Eventf("Limited", "event %d", i)
+	}
+	// reasons without a limit are not limited
+	for i := 0; i < 5; i++ {
+		r.Eventf("Unlimited", "event %d", i)
+	}
+
+	clock.Step(time.Minute)
+	r.Event("Duplicate", "same")
+	r.Eventf("Limited", "event %d", 5)
+
+	var messages []string
+	for _, event := range delegate.Events() {
+		messages = append(messages, event.Reason+": "+event.Message)
+	}
+	expected := []string{
+		"Duplicate: same",
+		"Duplicate: same",
+		"Duplicate: other",
+		"Duplicate: same",
+		"Limited: event 0",
+		"Limited: event 1",
+		"Unlimited: event 0",
+		"Unlimited: event 1",
+		"Unlimited: event 2",
+		"Unlimited: event 3",
+		"Unlimited: event 4",
+		"Duplicate: same",
+		"Limited: event 5 (3 similar events were dropped)",
+	}
+	if len(messages) != len(expected) {
+		t.Fatalf("expected events:\n%v\ngot:\n%v", expected, messages)
+	}
+	for i := range expected {
+		if messages[i] != expected[i] {
+			t.Errorf("event %d: expected %q, got %q", i, expected[i], messages[i])
+		}
+	}
+}
+
+func TestEventSampler(t *testing.T) {
+	type sample struct {
+		// after is the time since the previous event
+		after     time.Duration
+		component string
+		reason    string
+		message   string
+
+		expected        string
+		expectedDropped bool
+	}
+	testCases := []struct {
+		name    string
+		options SamplingOptions
+		samples []sample
+	}{
+		{
+			name:    "duplicates are dropped within the window",
+			options: SamplingOptions{DuplicateWindow: time.Minute},
+			samples: []sample{
+				{reason: "Reason", message: "message", expected: "message"},
+				{after: 59 * time.Second, reason: "Reason", message: "message", expectedDropped: true},
+				{after: 59 * time.Second, reason: "Reason", message: "message", expected: "message"},
+				{after: time.Minute, reason: "Reason", message: "message", expected: "message"},
+			},
+		},
+		{
+			name:    "events of other components, reasons or messages are not duplicates",
+			options: SamplingOptions{DuplicateWindow: time.Minute},
+			samples: []sample{
+				{reason: "Reason", message: "message", expected: "message"},
+				{component: "other", reason: "Reason", message: "message", expected: "message"},
+				{reason: "Other", message: "message", expected: "message"},
+				{reason: "Reason", message: "other", expected: "other"},
+			},
+		},
+		{
+			name:    "reasons are limited per interval",
+			options: SamplingOptions{Interval: time.Minute, ReasonLimits: map[string]int{"Limited": 2}},
+			samples: []sample{
+				{reason: "Limited", message: "1", expected: "1"},
+				{after: 10 * time.Second, reason: "Limited", message: "2", expected: "2"},
+				{after: 10 * time.Second, reason: "Limited", message: "3", expectedDropped: true},
+				{after: 39 * time.Second, reason: "Limited", message: "4", expectedDropped: true},
+				// the interval started with the first event
+				{after: time.Second, reason: "Limited", message: "5", expected: "5 (2 similar events were dropped)"},
+				{reason: "Limited", message: "6", expected: "6"},
+				{reason: "Limited", message: "7", expectedDropped: true},
+				{reason: "Unlimited", message: "8", expected: "8"},
+			},
+		},
+		{
+			name:    "the default limit applies to reasons without a limit",
+			options: SamplingOptions{Interval: time.Minute, ReasonLimits: map[string]int{"Unlimited": 0}, DefaultLimit: 1},
+			samples: []sample{
+				{reason: "Reason", message: "1", expected: "1"},
+				{reason: "Reason", message: "2", expectedDropped: true},
+				{reason: "Other", message: "3", expected: "3"},
+				{reason: "Unlimited", message: "4", expected: "4"},
+				{reason: "Unlimited", message: "5", expected: "5"},
+			},
+		},
+		{
+			name:    "dropped duplicates do not count against the limit",
+			options: SamplingOptions{DuplicateWindow: time.Minute, Interval: time.Minute, DefaultLimit: 2},
+			samples: []sample{
+				{reason: "Reason", message: "1", expected: "1"},
+				{reason: "Reason", message: "1", expectedDropped: true},
+				{reason: "Reason", message: "2", expected: "2"},
+				{reason: "Reason", message: "3", expectedDropped: true},
+				{after: time.Minute, reason: "Reason", message: "1", expected: "1 (1 similar events were dropped)"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clock := clocktesting.NewFakePassiveClock(time.Now())
+			sampler := NewSamplingRecorder(NewInMemoryRecorder("test", clock), tc.options, clock).(*samplingRecorder).sampler
+			for i, s := range tc.samples {
+				clock.SetTime(clock.Now().Add(s.after))
+				component := s.component
+				if len(component) == 0 {
+					component = "test"
+				}
+				message, ok := sampler.sample(component, corev1.EventTypeNormal, s.reason, s.message)
+				if ok == s.expectedDropped {
+					t.Fatalf("event %d: expected dropped %v, got %v", i, s.expectedDropped, !ok)
+				}
+				if ok && message != s.expected {
+					t.Errorf("event %d: expected %q, got %q", i, s.expected, message)
+				}
+			}
+		})
+	}
+}
+
+func TestEventSamplerForgetsExpiredEvents(t *testing.T) {
+	clock := clocktesting.NewFakePassiveClock(time.Now())
+	sampler := NewSamplingRecorder(NewInMemoryRecorder("test", clock), SamplingOptions{DuplicateWindow: time.Minute}, clock).(*samplingRecorder).sampler
+
+	sampler.sample("test", corev1.EventTypeNormal, "Reason", "old")
+	clock.SetTime(clock.Now().Add(30 * time.Second))
+	sampler.sample("test", corev1.EventTypeNormal, "Reason", "recent")
+	clock.SetTime(clock.Now().Add(31 * time.Second))
+	sampler.sample("test", corev1.EventTypeNormal, "Reason", "new")
+
+	if len(sampler.lastSeen) != 2 {
+		t.Errorf("expected the event older than the window to be forgotten, got %v", sampler.lastSeen)
+	}
+}
@@ -0,0 +1,189 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	eventsv1client "k8s.io/client-go/kubernetes/typed/events/v1"
+	"k8s.io/client-go/tools/reference"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+// maxNoteLength is the limit of the note the events.k8s.io API validates.
+const maxNoteLength = 1024
+
+// StructuredRecorder records events.k8s.io/v1 events about the objects a controller reconciles,
+// rather than about the operator itself.
+type StructuredRecorder interface {
+	// Record records an event of a registered reason about the regarding object, e.g. the
+	// Deployment that was updated, and optionally a related object, e.g. the Secret that caused
+	// the update. The note is a human readable description formatted with args. An error is
+	// returned if the reason is not registered or the objects cannot be referenced.
+	Record(regarding, related runtime.Object, reason Reason, noteFmt string, args ...interface{}) error
+
+	// Registry returns the reasons the recorder accepts.
+	Registry() *ReasonRegistry
+
+	// WithContext allows to set a context for event create API calls.
+	WithContext(ctx context.Context) StructuredRecorder
+}
+
+// NewStructuredRecorder returns a recorder that creates events.k8s.io/v1 events reported by the
+// controller of the registry. The reporting instance identifies the process, usually the pod name.
+// Objects are referenced using the scheme, or the client-go scheme if it is nil; objects of other
+// types must have their TypeMeta set.
+func NewStructuredRecorder(client eventsv1client.EventsGetter, objectScheme *runtime.Scheme, registry *ReasonRegistry, reportingInstance string, clock clock.PassiveClock) StructuredRecorder {
+	if objectScheme == nil {
+		objectScheme = scheme.Scheme
+	}
+	return &structuredRecorder{
+		client:            client,
+		scheme:            objectScheme,
+		registry:          registry,
+		reportingInstance: reportingInstance,
+		clock:             clock,
+	}
+}
+
+// structuredRecorder is an implementation of StructuredRecorder interface.
+type structuredRecorder struct {
+	client            eventsv1client.EventsGetter
+	scheme            *runtime.Scheme
+	registry          *ReasonRegistry
+	reportingInstance string
+	clock             clock.PassiveClock
+
+	ctx context.Context
+}
+
+func (r *structuredRecorder) Registry() *ReasonRegistry {
+	return r.registry
+}
+
+func (r *structuredRecorder) WithContext(ctx context.Context) StructuredRecorder {
+	newRecorder := *r
+	newRecorder.ctx = ctx
+	return &newRecorder
+}
+
+func (r *structuredRecorder) Record(regarding, related runtime.Object, reason Reason, noteFmt string, args ...interface{}) error {
+	event, err := makeStructuredEvent(r.scheme, r.clock, r.registry, r.reportingInstance, regarding, related, reason, fmt.Sprintf(noteFmt, args...))
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if r.ctx != nil {
+		ctx = r.ctx
+	}
+	eventsCounterMetric.WithLabelValues(event.Type).Inc()
+	if _, err := r.client.Events(event.Namespace).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+		klog.Warningf("Error creating event %+v: %v", event, err)
+	}
+	return nil
+}
+
+// makeStructuredEvent builds the event of a registered reason about the regarding object.
+func makeStructuredEvent(objectScheme *runtime.Scheme, clock clock.PassiveClock, registry *ReasonRegistry, reportingInstance string, regarding, related runtime.Object, reason Reason, note string) (*eventsv1.Event, error) {
+	if err := registry.Validate(reason); err != nil {
+		return nil, err
+	}
+	regardingRef, err := reference.GetReference(objectScheme, regarding)
+	if err != nil {
+		return nil, fmt.Errorf("unable to reference the regarding object of event %s: %w", reason.Reason, err)
+	}
+	var relatedRef *corev1.ObjectReference
+	if related != nil {
+		relatedRef, err = reference.GetReference(objectScheme, related)
+		if err != nil {
+			return nil, fmt.Errorf("unable to reference the related object of event %s: %w", reason.Reason, err)
+		}
+	}
+	if len(note) > maxNoteLength {
+		// truncate on a rune boundary so the note stays valid UTF-8
+		n := maxNoteLength - 3
+		for n > 0 && !utf8.RuneStart(note[n]) {
+			n--
+		}
+		note = note[:n] + "..."
+	}
+
+	// events of cluster scoped objects are created in the default namespace
+	namespace := regardingRef.Namespace
+	if len(namespace) == 0 {
+		namespace = metav1.NamespaceDefault
+	}
+	eventTime := metav1.MicroTime{Time: clock.Now()}
+	return &eventsv1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%v.%x.%s", regardingRef.Name, eventTime.UnixNano(), hashForEventNameSuffix(reason.Type, reason.Reason, note)),
+			Namespace: namespace,
+		},
+		EventTime:           eventTime,
+		ReportingController: registry.Controller(),
+		ReportingInstance:   reportingInstance,
+		Action:              reason.Action,
+		Reason:              reason.Reason,
+		Regarding:           *regardingRef,
+		Related:             relatedRef,
+		Note:                note,
+		Type:                reason.Type,
+	}, nil
+}
+
+// InMemoryStructuredRecorder is a StructuredRecorder that keeps the events it records.
+type InMemoryStructuredRecorder interface {
+	Events() []*eventsv1.Event
+	StructuredRecorder
+}
+
+// NewInMemoryStructuredRecorder provides a structured event recorder that stores all events
+// recorded in memory and allow to replay them using the Events() method.
+// This recorder should be only used in unit tests.
+func NewInMemoryStructuredRecorder(registry *ReasonRegistry, clock clock.PassiveClock) InMemoryStructuredRecorder {
+	return &inMemoryStructuredRecorder{
+		registry: registry,
+		clock:    clock,
+	}
+}
+
+type inMemoryStructuredRecorder struct {
+	registry *ReasonRegistry
+	clock    clock.PassiveClock
+
+	lock   sync.Mutex
+	events []*eventsv1.Event
+}
+
+func (r *inMemoryStructuredRecorder) Registry() *ReasonRegistry {
+	return r.registry
+}
+
+func (r *inMemoryStructuredRecorder) WithContext(ctx context.Context) StructuredRecorder {
+	return r
+}
+
+// Events returns list of recorded events
+func (r *inMemoryStructuredRecorder) Events() []*eventsv1.Event {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]*eventsv1.Event{}, r.events...)
+}
+
+func (r *inMemoryStructuredRecorder) Record(regarding, related runtime.Object, reason Reason, noteFmt string, args ...interface{}) error {
+	event, err := makeStructuredEvent(scheme.Scheme, r.clock, r.registry, "in-memory", regarding, related, reason, fmt.Sprintf(noteFmt, args...))
+	if err != nil {
+		return err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
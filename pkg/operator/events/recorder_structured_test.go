@@ -0,0 +1,144 @@
+package events
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/fake"
+	clientgotesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+var (
+	testRegistry       = NewReasonRegistry("openshift.io/test-operator")
+	reasonDeployUpdate = testRegistry.MustRegister(Reason{Reason: "DeploymentUpdated", Type: corev1.EventTypeNormal, Action: "Update"})
+	reasonSecretFailed = testRegistry.MustRegister(Reason{Reason: "SecretSyncFailed", Type: corev1.EventTypeWarning, Action: "Sync"})
+)
+
+func TestReasonRegistry(t *testing.T) {
+	registry := NewReasonRegistry("test")
+	invalid := []Reason{
+		{Reason: "lowerCase", Type: corev1.EventTypeNormal, Action: "Update"},
+		{Reason: "With Space", Type: corev1.EventTypeNormal, Action: "Update"},
+		{Reason: "Valid", Type: "Error", Action: "Update"},
+		{Reason: "Valid", Type: corev1.EventTypeNormal},
+		{Reason: "V" + strings.Repeat("a", maxReasonLength), Type: corev1.EventTypeNormal, Action: "Update"},
+	}
+	for _, reason := range invalid {
+		if _, err := registry.Register(reason); err == nil {
+			t.Errorf("expected %+v to be invalid", reason)
+		}
+	}
+
+	reason := Reason{Reason: "Updated", Type: corev1.EventTypeNormal, Action: "Update"}
+	if _, err := registry.Register(reason); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := registry.Register(reason); err != nil {
+		t.Errorf("expected an identical registration to succeed: %v", err)
+	}
+	if _, err := registry.Register(Reason{Reason: "Updated", Type: corev1.EventTypeWarning, Action: "Update"}); err == nil {
+		t.Errorf("expected a conflicting registration to fail")
+	}
+	registry.MustRegister(Reason{Reason: "Applied", Type: corev1.EventTypeNormal, Action: "Apply"})
+
+	reasons := registry.Reasons()
+	if len(reasons) != 2 || reasons[0].Reason != "Applied" || reasons[1].Reason != "Updated" {
+		t.Errorf("unexpected reasons %v", reasons)
+	}
+	if err := registry.Validate(Reason{Reason: "Updated", Type: corev1.EventTypeNormal, Action: "Delete"}); err == nil {
+		t.Errorf("expected a reason with another action to be rejected")
+	}
+	if err := registry.Validate(reasonDeployUpdate); err == nil {
+		t.Errorf("expected a reason of another registry to be rejected")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected MustRegister to panic")
+		}
+	}()
+	registry.MustRegister(Reason{Reason: "bad"})
+}
+
+func TestStructuredRecorder(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	client := fake.NewSimpleClientset()
+	r := NewStructuredRecorder(client.EventsV1(), nil, testRegistry, "test-pod", clocktesting.NewFakePassiveClock(now))
+
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "apiserver", Namespace: "openshift-apiserver", UID: "1"}}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "serving-cert", Namespace: "openshift-apiserver"}}
+	if err := r.Record(deployment, secret, reasonDeployUpdate, "updated for %s", "serving-cert"); err != nil {
+		t.Fatal(err)
+	}
+
+	var event *eventsv1.Event
+	for _, action := range client.Actions() {
+		if action.Matches("create", "events") && action.GetResource().Group == "events.k8s.io" {
+			event = action.(clientgotesting.CreateAction).GetObject().(*eventsv1.Event)
+		}
+	}
+	if event == nil {
+		t.Fatalf("expected an event to be created, got %v", client.Actions())
+	}
+	if event.Namespace != "openshift-apiserver" || !strings.HasPrefix(event.Name, "apiserver.") {
+		t.Errorf("unexpected event name %s/%s", event.Namespace, event.Name)
+	}
+	if event.Regarding.Kind != "Deployment" || event.Regarding.APIVersion != "apps/v1" || event.Regarding.Name != "apiserver" || event.Regarding.UID != "1" {
+		t.Errorf("unexpected regarding object %+v", event.Regarding)
+	}
+	if event.Related == nil || event.Related.Kind != "Secret" || event.Related.Name != "serving-cert" {
+		t.Errorf("unexpected related object %+v", event.Related)
+	}
+	if event.Reason != "DeploymentUpdated" || event.Action != "Update" || event.Type != corev1.EventTypeNormal || event.Note != "updated for serving-cert" {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if event.ReportingController != "openshift.io/test-operator" || event.ReportingInstance != "test-pod" || !event.EventTime.Time.Equal(now) {
+		t.Errorf("unexpected reporter %s/%s at %v", event.ReportingController, event.ReportingInstance, event.EventTime)
+	}
+
+	unregistered := Reason{Reason: "Unregistered", Type: corev1.EventTypeNormal, Action: "Update"}
+	if err := r.Record(deployment, nil, unregistered, "note"); err == nil {
+		t.Errorf("expected an unregistered reason to be rejected")
+	}
+	if err := r.Record(nil, nil, reasonDeployUpdate, "note"); err == nil {
+		t.Errorf("expected an event without regarding object to be rejected")
+	}
+}
+
+func TestInMemoryStructuredRecorder(t *testing.T) {
+	r := NewInMemoryStructuredRecorder(testRegistry, clocktesting.NewFakePassiveClock(time.Now()))
+
+	// objects of types that are not in the scheme are referenced by their TypeMeta
+	cr := &unstructured.Unstructured{}
+	cr.SetAPIVersion("operator.openshift.io/v1")
+	cr.SetKind("KubeAPIServer")
+	cr.SetName("cluster")
+	if err := r.Record(cr, nil, reasonSecretFailed, "%s", strings.Repeat("x", 2*maxNoteLength)); err != nil {
+		t.Fatal(err)
+	}
+
+	events := r.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected one event, got %d", len(events))
+	}
+	event := events[0]
+	if event.Namespace != metav1.NamespaceDefault {
+		t.Errorf("expected the event of a cluster scoped object in the default namespace, got %q", event.Namespace)
+	}
+	if event.Regarding.Kind != "KubeAPIServer" || event.Regarding.APIVersion != "operator.openshift.io/v1" {
+		t.Errorf("unexpected regarding object %+v", event.Regarding)
+	}
+	if event.Type != corev1.EventTypeWarning || event.Action != "Sync" {
+		t.Errorf("unexpected event %s %s", event.Type, event.Action)
+	}
+	if len(event.Note) != maxNoteLength || !strings.HasSuffix(event.Note, "...") {
+		t.Errorf("expected the note to be truncated to %d characters, got %d", maxNoteLength, len(event.Note))
+	}
+}
@@ -34,7 +34,7 @@ func ApplyMutatingWebhookConfigurationImproved(ctx context.Context, client admis
 	if apierrors.IsNotFound(err) {
 		required := requiredOriginal.DeepCopy()
 		actual, err := client.MutatingWebhookConfigurations().Create(
-			ctx, resourcemerge.WithCleanLabelsAndAnnotations(required).(*admissionregistrationv1.MutatingWebhookConfiguration), metav1.CreateOptions{})
+			ctx, resourcemerge.WithCleanLabelsAndAnnotations(required).(*admissionregistrationv1.MutatingWebhookConfiguration), createOptions(ctx))
 		resourcehelper.ReportCreateEvent(recorder, required, err)
 		if err != nil {
 			return nil, false, err
@@ -68,7 +68,7 @@ func ApplyMutatingWebhookConfigurationImproved(ctx context.Context, client admis
 
 	klog.V(2).Infof("MutatingWebhookConfiguration %q changes: %v", required.GetNamespace()+"/"+required.GetName(), JSONPatchNoError(existing, toWrite))
 
-	actual, err := client.MutatingWebhookConfigurations().Update(ctx, toWrite, metav1.UpdateOptions{})
+	actual, err := client.MutatingWebhookConfigurations().Update(ctx, toWrite, updateOptions(ctx))
 	resourcehelper.ReportUpdateEvent(recorder, required, err)
 	if err != nil {
 		return nil, false, err
@@ -109,7 +109,7 @@ func ApplyValidatingWebhookConfigurationImproved(ctx context.Context, client adm
 	if apierrors.IsNotFound(err) {
 		required := requiredOriginal.DeepCopy()
 		actual, err := client.ValidatingWebhookConfigurations().Create(
-			ctx, resourcemerge.WithCleanLabelsAndAnnotations(required).(*admissionregistrationv1.ValidatingWebhookConfiguration), metav1.CreateOptions{})
+			ctx, resourcemerge.WithCleanLabelsAndAnnotations(required).(*admissionregistrationv1.ValidatingWebhookConfiguration), createOptions(ctx))
 		resourcehelper.ReportCreateEvent(recorder, required, err)
 		if err != nil {
 			return nil, false, err
@@ -143,7 +143,7 @@ func ApplyValidatingWebhookConfigurationImproved(ctx context.Context, client adm
 
 	klog.V(2).Infof("ValidatingWebhookConfiguration %q changes: %v", required.GetNamespace()+"/"+required.GetName(), JSONPatchNoError(existing, toWrite))
 
-	actual, err := client.ValidatingWebhookConfigurations().Update(ctx, toWrite, metav1.UpdateOptions{})
+	actual, err := client.ValidatingWebhookConfigurations().Update(ctx, toWrite, updateOptions(ctx))
 	resourcehelper.ReportUpdateEvent(recorder, required, err)
 	if err != nil {
 		return nil, false, err
@@ -154,7 +154,7 @@ func ApplyValidatingWebhookConfigurationImproved(ctx context.Context, client adm
 }
 
 func DeleteValidatingWebhookConfiguration(ctx context.Context, client admissionregistrationclientv1.ValidatingWebhookConfigurationsGetter, recorder events.Recorder, required *admissionregistrationv1.ValidatingWebhookConfiguration) (*admissionregistrationv1.ValidatingWebhookConfiguration, bool, error) {
-	err := client.ValidatingWebhookConfigurations().Delete(ctx, required.Name, metav1.DeleteOptions{})
+	err := client.ValidatingWebhookConfigurations().Delete(ctx, required.Name, deleteOptions(ctx))
 	if err != nil && apierrors.IsNotFound(err) {
 		return nil, false, nil
 	}
@@ -196,7 +196,7 @@ func ApplyValidatingAdmissionPolicyV1beta1(ctx context.Context, client admission
 	if apierrors.IsNotFound(err) {
 		required := requiredOriginal.DeepCopy()
 		actual, err := client.ValidatingAdmissionPolicies().Create(
-			ctx, resourcemerge.WithCleanLabelsAndAnnotations(required).(*admissionregistrationv1beta1.ValidatingAdmissionPolicy), metav1.CreateOptions{})
+			ctx, resourcemerge.WithCleanLabelsAndAnnotations(required).(*admissionregistrationv1beta1.ValidatingAdmissionPolicy), createOptions(ctx))
 		resourcehelper.ReportCreateEvent(recorder, required, err)
 		if err != nil {
 			return nil, false, err
@@ -229,7 +229,7 @@ func ApplyValidatingAdmissionPolicyV1beta1(ctx context.Context, client admission
 
 	klog.V(2).Infof("ValidatingAdmissionPolicyConfigurationV1beta1 %q changes: %v", required.GetNamespace()+"/"+required.GetName(), JSONPatchNoError(existing, toWrite))
 
-	actual, err := client.ValidatingAdmissionPolicies().Update(ctx, toWrite, metav1.UpdateOptions{})
+	actual, err := client.ValidatingAdmissionPolicies().Update(ctx, toWrite, updateOptions(ctx))
 	resourcehelper.ReportUpdateEvent(recorder, required, err)
 	if err != nil {
 		return nil, false, err
@@ -255,7 +255,7 @@ func ApplyValidatingAdmissionPolicyV1(ctx context.Context, client admissionregis
 	if apierrors.IsNotFound(err) {
 		required := requiredOriginal.DeepCopy()
 		actual, err := client.ValidatingAdmissionPolicies().Create(
-			ctx, resourcemerge.WithCleanLabelsAndAnnotations(required).(*admissionregistrationv1.ValidatingAdmissionPolicy), metav1.CreateOptions{})
+			ctx, resourcemerge.WithCleanLabelsAndAnnotations(required).(*admissionregistrationv1.ValidatingAdmissionPolicy), createOptions(ctx))
 		resourcehelper.ReportCreateEvent(recorder, required, err)
 		if err != nil {
 			return nil, false, err
@@ -288,7 +288,7 @@ func ApplyValidatingAdmissionPolicyV1(ctx context.Context, client admissionregis
 
 	klog.V(2).Infof("ValidatingAdmissionPolicyConfigurationV1 %q changes: %v", required.GetNamespace()+"/"+required.GetName(), JSONPatchNoError(existing, toWrite))
 
-	actual, err := client.ValidatingAdmissionPolicies().Update(ctx, toWrite, metav1.UpdateOptions{})
+	actual, err := client.ValidatingAdmissionPolicies().Update(ctx, toWrite, updateOptions(ctx))
 	resourcehelper.ReportUpdateEvent(recorder, required, err)
 	if err != nil {
 		return nil, false, err
@@ -314,7 +314,7 @@ func ApplyValidatingAdmissionPolicyBindingV1beta1(ctx context.Context, client ad
 	if apierrors.IsNotFound(err) {
 		required := requiredOriginal.DeepCopy()
 		actual, err := client.ValidatingAdmissionPolicyBindings().Create(
-			ctx, resourcemerge.WithCleanLabelsAndAnnotations(required).(*admissionregistrationv1beta1.ValidatingAdmissionPolicyBinding), metav1.CreateOptions{})
+			ctx, resourcemerge.WithCleanLabelsAndAnnotations(required).(*admissionregistrationv1beta1.ValidatingAdmissionPolicyBinding), createOptions(ctx))
 		resourcehelper.ReportCreateEvent(recorder, required, err)
 		if err != nil {
 			return nil, false, err
@@ -347,7 +347,7 @@ func ApplyValidatingAdmissionPolicyBindingV1beta1(ctx context.Context, client ad
 
 	klog.V(2).Infof("ValidatingAdmissionPolicyBindingConfigurationV1beta1 %q changes: %v", required.GetNamespace()+"/"+required.GetName(), JSONPatchNoError(existing, toWrite))
 
-	actual, err := client.ValidatingAdmissionPolicyBindings().Update(ctx, toWrite, metav1.UpdateOptions{})
+	actual, err := client.ValidatingAdmissionPolicyBindings().Update(ctx, toWrite, updateOptions(ctx))
 	resourcehelper.ReportUpdateEvent(recorder, required, err)
 	if err != nil {
 		return nil, false, err
@@ -373,7 +373,7 @@ func ApplyValidatingAdmissionPolicyBindingV1(ctx context.Context, client admissi
 	if apierrors.IsNotFound(err) {
 		required := requiredOriginal.DeepCopy()
 		actual, err := client.ValidatingAdmissionPolicyBindings().Create(
-			ctx, resourcemerge.WithCleanLabelsAndAnnotations(required).(*admissionregistrationv1.ValidatingAdmissionPolicyBinding), metav1.CreateOptions{})
+			ctx, resourcemerge.WithCleanLabelsAndAnnotations(required).(*admissionregistrationv1.ValidatingAdmissionPolicyBinding), createOptions(ctx))
 		resourcehelper.ReportCreateEvent(recorder, required, err)
 		if err != nil {
 			return nil, false, err
@@ -406,7 +406,7 @@ func ApplyValidatingAdmissionPolicyBindingV1(ctx context.Context, client admissi
 
 	klog.V(2).Infof("ValidatingAdmissionPolicyBindingConfigurationV1 %q changes: %v", required.GetNamespace()+"/"+required.GetName(), JSONPatchNoError(existing, toWrite))
 
-	actual, err := client.ValidatingAdmissionPolicyBindings().Update(ctx, toWrite, metav1.UpdateOptions{})
+	actual, err := client.ValidatingAdmissionPolicyBindings().Update(ctx, toWrite, updateOptions(ctx))
 	resourcehelper.ReportUpdateEvent(recorder, required, err)
 	if err != nil {
 		return nil, false, err
@@ -19,7 +19,7 @@ func ApplyCustomResourceDefinitionV1(ctx context.Context, client apiextclientv1.
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
 		actual, err := client.CustomResourceDefinitions().Create(
-			ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*apiextensionsv1.CustomResourceDefinition), metav1.CreateOptions{})
+			ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*apiextensionsv1.CustomResourceDefinition), createOptions(ctx))
 		resourcehelper.ReportCreateEvent(recorder, required, err)
 		return actual, true, err
 	}
@@ -38,14 +38,14 @@ func ApplyCustomResourceDefinitionV1(ctx context.Context, client apiextclientv1.
 		klog.Infof("CustomResourceDefinition %q changes: %s", existing.Name, JSONPatchNoError(existing, existingCopy))
 	}
 
-	actual, err := client.CustomResourceDefinitions().Update(ctx, existingCopy, metav1.UpdateOptions{})
+	actual, err := client.CustomResourceDefinitions().Update(ctx, existingCopy, updateOptions(ctx))
 	resourcehelper.ReportUpdateEvent(recorder, required, err)
 
 	return actual, true, err
 }
 
 func DeleteCustomResourceDefinitionV1(ctx context.Context, client apiextclientv1.CustomResourceDefinitionsGetter, recorder events.Recorder, required *apiextensionsv1.CustomResourceDefinition) (*apiextensionsv1.CustomResourceDefinition, bool, error) {
-	err := client.CustomResourceDefinitions().Delete(ctx, required.Name, metav1.DeleteOptions{})
+	err := client.CustomResourceDefinitions().Delete(ctx, required.Name, deleteOptions(ctx))
 	if err != nil && apierrors.IsNotFound(err) {
 		return nil, false, nil
 	}
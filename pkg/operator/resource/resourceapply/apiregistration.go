@@ -21,7 +21,7 @@ func ApplyAPIService(ctx context.Context, client apiregistrationv1client.APIServ
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
 		actual, err := client.APIServices().Create(
-			ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*apiregistrationv1.APIService), metav1.CreateOptions{})
+			ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*apiregistrationv1.APIService), createOptions(ctx))
 		resourcehelper.ReportCreateEvent(recorder, required, err)
 		return actual, true, err
 	}
@@ -46,7 +46,7 @@ func ApplyAPIService(ctx context.Context, client apiregistrationv1client.APIServ
 	if klog.V(2).Enabled() {
 		klog.Infof("APIService %q changes: %s", existing.Name, JSONPatchNoError(existing, existingCopy))
 	}
-	actual, err := client.APIServices().Update(ctx, existingCopy, metav1.UpdateOptions{})
+	actual, err := client.APIServices().Update(ctx, existingCopy, updateOptions(ctx))
 	resourcehelper.ReportUpdateEvent(recorder, required, err)
 	return actual, true, err
 }
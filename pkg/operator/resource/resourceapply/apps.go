@@ -118,7 +118,7 @@ func ApplyDeploymentWithForce(ctx context.Context, client appsclientv1.Deploymen
 	}
 	existing, err := client.Deployments(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
-		actual, err := client.Deployments(required.Namespace).Create(ctx, required, metav1.CreateOptions{})
+		actual, err := client.Deployments(required.Namespace).Create(ctx, required, createOptions(ctx))
 		resourcehelper.ReportCreateEvent(recorder, required, err)
 		return actual, true, err
 	}
@@ -155,7 +155,7 @@ func ApplyDeploymentWithForce(ctx context.Context, client appsclientv1.Deploymen
 		klog.Infof("Deployment %q changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, toWrite))
 	}
 
-	actual, err := client.Deployments(required.Namespace).Update(ctx, toWrite, metav1.UpdateOptions{})
+	actual, err := client.Deployments(required.Namespace).Update(ctx, toWrite, updateOptions(ctx))
 	resourcehelper.ReportUpdateEvent(recorder, required, err)
 	return actual, true, err
 }
@@ -205,7 +205,7 @@ func ApplyDaemonSetWithForce(ctx context.Context, client appsclientv1.DaemonSets
 	}
 	existing, err := client.DaemonSets(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
-		actual, err := client.DaemonSets(required.Namespace).Create(ctx, required, metav1.CreateOptions{})
+		actual, err := client.DaemonSets(required.Namespace).Create(ctx, required, createOptions(ctx))
 		resourcehelper.ReportCreateEvent(recorder, required, err)
 		return actual, true, err
 	}
@@ -241,13 +241,13 @@ func ApplyDaemonSetWithForce(ctx context.Context, client appsclientv1.DaemonSets
 	if klog.V(2).Enabled() {
 		klog.Infof("DaemonSet %q changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, toWrite))
 	}
-	actual, err := client.DaemonSets(required.Namespace).Update(ctx, toWrite, metav1.UpdateOptions{})
+	actual, err := client.DaemonSets(required.Namespace).Update(ctx, toWrite, updateOptions(ctx))
 	resourcehelper.ReportUpdateEvent(recorder, required, err)
 	return actual, true, err
 }
 
 func DeleteDeployment(ctx context.Context, client appsclientv1.DeploymentsGetter, recorder events.Recorder, required *appsv1.Deployment) (*appsv1.Deployment, bool, error) {
-	err := client.Deployments(required.Namespace).Delete(ctx, required.Name, metav1.DeleteOptions{})
+	err := client.Deployments(required.Namespace).Delete(ctx, required.Name, deleteOptions(ctx))
 	if err != nil && apierrors.IsNotFound(err) {
 		return nil, false, nil
 	}
@@ -259,7 +259,7 @@ func DeleteDeployment(ctx context.Context, client appsclientv1.DeploymentsGetter
 }
 
 func DeleteDaemonSet(ctx context.Context, client appsclientv1.DaemonSetsGetter, recorder events.Recorder, required *appsv1.DaemonSet) (*appsv1.DaemonSet, bool, error) {
-	err := client.DaemonSets(required.Namespace).Delete(ctx, required.Name, metav1.DeleteOptions{})
+	err := client.DaemonSets(required.Namespace).Delete(ctx, required.Name, deleteOptions(ctx))
 	if err != nil && apierrors.IsNotFound(err) {
 		return nil, false, nil
 	}
@@ -95,7 +95,7 @@ func ApplyNamespaceImproved(ctx context.Context, client coreclientv1.NamespacesG
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
 		actual, err := client.Namespaces().
-			Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.Namespace), metav1.CreateOptions{})
+			Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.Namespace), createOptions(ctx))
 		resourcehelper.ReportCreateEvent(recorder, requiredCopy, err)
 		cache.UpdateCachedResourceMetadata(required, actual)
 		return actual, true, err
@@ -121,7 +121,7 @@ func ApplyNamespaceImproved(ctx context.Context, client coreclientv1.NamespacesG
 		klog.Infof("Namespace %q changes: %v", required.Name, JSONPatchNoError(existing, existingCopy))
 	}
 
-	actual, err := client.Namespaces().Update(ctx, existingCopy, metav1.UpdateOptions{})
+	actual, err := client.Namespaces().Update(ctx, existingCopy, updateOptions(ctx))
 	resourcehelper.ReportUpdateEvent(recorder, required, err)
 	cache.UpdateCachedResourceMetadata(required, actual)
 	return actual, true, err
@@ -142,7 +142,7 @@ func ApplyServiceImproved(ctx context.Context, client coreclientv1.ServicesGette
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
 		actual, err := client.Services(requiredCopy.Namespace).
-			Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.Service), metav1.CreateOptions{})
+			Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.Service), createOptions(ctx))
 		resourcehelper.ReportCreateEvent(recorder, requiredCopy, err)
 		cache.UpdateCachedResourceMetadata(required, actual)
 		return actual, true, err
@@ -182,7 +182,7 @@ func ApplyServiceImproved(ctx context.Context, client coreclientv1.ServicesGette
 		klog.Infof("Service %q changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, required))
 	}
 
-	actual, err := client.Services(required.Namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
+	actual, err := client.Services(required.Namespace).Update(ctx, existingCopy, updateOptions(ctx))
 	resourcehelper.ReportUpdateEvent(recorder, required, err)
 	cache.UpdateCachedResourceMetadata(required, actual)
 	return actual, true, err
@@ -194,7 +194,7 @@ func ApplyPodImproved(ctx context.Context, client coreclientv1.PodsGetter, recor
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
 		actual, err := client.Pods(requiredCopy.Namespace).
-			Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.Pod), metav1.CreateOptions{})
+			Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.Pod), createOptions(ctx))
 		resourcehelper.ReportCreateEvent(recorder, requiredCopy, err)
 		cache.UpdateCachedResourceMetadata(required, actual)
 		return actual, true, err
@@ -220,7 +220,7 @@ func ApplyPodImproved(ctx context.Context, client coreclientv1.PodsGetter, recor
 		klog.Infof("Pod %q changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, required))
 	}
 
-	actual, err := client.Pods(required.Namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
+	actual, err := client.Pods(required.Namespace).Update(ctx, existingCopy, updateOptions(ctx))
 	resourcehelper.ReportUpdateEvent(recorder, required, err)
 	cache.UpdateCachedResourceMetadata(required, actual)
 	return actual, true, err
@@ -232,7 +232,7 @@ func ApplyServiceAccountImproved(ctx context.Context, client coreclientv1.Servic
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
 		actual, err := client.ServiceAccounts(requiredCopy.Namespace).
-			Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.ServiceAccount), metav1.CreateOptions{})
+			Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.ServiceAccount), createOptions(ctx))
 		resourcehelper.ReportCreateEvent(recorder, requiredCopy, err)
 		cache.UpdateCachedResourceMetadata(required, actual)
 		return actual, true, err
@@ -256,7 +256,7 @@ func ApplyServiceAccountImproved(ctx context.Context, client coreclientv1.Servic
 	if klog.V(2).Enabled() {
 		klog.Infof("ServiceAccount %q changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, required))
 	}
-	actual, err := client.ServiceAccounts(required.Namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
+	actual, err := client.ServiceAccounts(required.Namespace).Update(ctx, existingCopy, updateOptions(ctx))
 	resourcehelper.ReportUpdateEvent(recorder, required, err)
 	cache.UpdateCachedResourceMetadata(required, actual)
 	return actual, true, err
@@ -268,7 +268,7 @@ func ApplyConfigMapImproved(ctx context.Context, client coreclientv1.ConfigMapsG
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
 		actual, err := client.ConfigMaps(requiredCopy.Namespace).
-			Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.ConfigMap), metav1.CreateOptions{})
+			Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.ConfigMap), createOptions(ctx))
 		resourcehelper.ReportCreateEvent(recorder, requiredCopy, err)
 		cache.UpdateCachedResourceMetadata(required, actual)
 		return actual, true, err
@@ -341,7 +341,7 @@ func ApplyConfigMapImproved(ctx context.Context, client coreclientv1.ConfigMapsG
 		existingCopy.Data["ca-bundle.crt"] = existingCABundle
 	}
 
-	actual, err := client.ConfigMaps(required.Namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
+	actual, err := client.ConfigMaps(required.Namespace).Update(ctx, existingCopy, updateOptions(ctx))
 
 	var details string
 	if !dataSame {
@@ -386,7 +386,7 @@ func ApplySecretImproved(ctx context.Context, client coreclientv1.SecretsGetter,
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
 		actual, err := client.Secrets(requiredCopy.Namespace).
-			Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.Secret), metav1.CreateOptions{})
+			Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.Secret), createOptions(ctx))
 		resourcehelper.ReportCreateEvent(recorder, requiredCopy, err)
 		cache.UpdateCachedResourceMetadata(requiredInput, actual)
 		return actual, true, err
@@ -438,7 +438,7 @@ func ApplySecretImproved(ctx context.Context, client coreclientv1.SecretsGetter,
 	 * We need to explicitly opt for delete+create in that case.
 	 */
 	if existingCopy.Type == existing.Type {
-		actual, err = client.Secrets(required.Namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
+		actual, err = client.Secrets(required.Namespace).Update(ctx, existingCopy, updateOptions(ctx))
 		resourcehelper.ReportUpdateEvent(recorder, existingCopy, err)
 
 		if err == nil {
@@ -449,13 +449,19 @@ func ApplySecretImproved(ctx context.Context, client coreclientv1.SecretsGetter,
 		}
 	}
 
+	// a dry-run delete leaves the secret in place, so the create would always fail. Return the secret that would be created.
+	if isDryRun(ctx) {
+		existingCopy.ResourceVersion = ""
+		return existingCopy, true, nil
+	}
+
 	// if the field was immutable on a secret, we're going to be stuck until we delete it.  Try to delete and then create
-	deleteErr := client.Secrets(required.Namespace).Delete(ctx, existingCopy.Name, metav1.DeleteOptions{})
+	deleteErr := client.Secrets(required.Namespace).Delete(ctx, existingCopy.Name, deleteOptions(ctx))
 	resourcehelper.ReportDeleteEvent(recorder, existingCopy, deleteErr)
 
 	// clear the RV and track the original actual and error for the return like our create value.
 	existingCopy.ResourceVersion = ""
-	actual, err = client.Secrets(required.Namespace).Create(ctx, existingCopy, metav1.CreateOptions{})
+	actual, err = client.Secrets(required.Namespace).Create(ctx, existingCopy, createOptions(ctx))
 	resourcehelper.ReportCreateEvent(recorder, existingCopy, err)
 	cache.UpdateCachedResourceMetadata(requiredInput, actual)
 	return actual, true, err
@@ -526,7 +532,7 @@ func deleteConfigMapSyncTarget(ctx context.Context, client coreclientv1.ConfigMa
 	if apierrors.IsNotFound(err) {
 		return false, nil
 	}
-	err = client.ConfigMaps(targetNamespace).Delete(ctx, targetName, metav1.DeleteOptions{})
+	err = client.ConfigMaps(targetNamespace).Delete(ctx, targetName, deleteOptions(ctx))
 	if apierrors.IsNotFound(err) {
 		return false, nil
 	}
@@ -614,7 +620,7 @@ func syncPartialSecret(ctx context.Context, client coreclientv1.SecretsGetter, r
 }
 
 func deleteSecretSyncTarget(ctx context.Context, client coreclientv1.SecretsGetter, recorder events.Recorder, targetNamespace, targetName string) (bool, error) {
-	err := client.Secrets(targetNamespace).Delete(ctx, targetName, metav1.DeleteOptions{})
+	err := client.Secrets(targetNamespace).Delete(ctx, targetName, deleteOptions(ctx))
 	if apierrors.IsNotFound(err) {
 		return false, nil
 	}
@@ -626,7 +632,7 @@ func deleteSecretSyncTarget(ctx context.Context, client coreclientv1.SecretsGett
 }
 
 func DeleteNamespace(ctx context.Context, client coreclientv1.NamespacesGetter, recorder events.Recorder, required *corev1.Namespace) (*corev1.Namespace, bool, error) {
-	err := client.Namespaces().Delete(ctx, required.Name, metav1.DeleteOptions{})
+	err := client.Namespaces().Delete(ctx, required.Name, deleteOptions(ctx))
 	if err != nil && apierrors.IsNotFound(err) {
 		return nil, false, nil
 	}
@@ -638,7 +644,7 @@ func DeleteNamespace(ctx context.Context, client coreclientv1.NamespacesGetter,
 }
 
 func DeleteService(ctx context.Context, client coreclientv1.ServicesGetter, recorder events.Recorder, required *corev1.Service) (*corev1.Service, bool, error) {
-	err := client.Services(required.Namespace).Delete(ctx, required.Name, metav1.DeleteOptions{})
+	err := client.Services(required.Namespace).Delete(ctx, required.Name, deleteOptions(ctx))
 	if err != nil && apierrors.IsNotFound(err) {
 		return nil, false, nil
 	}
@@ -650,7 +656,7 @@ func DeleteService(ctx context.Context, client coreclientv1.ServicesGetter, reco
 }
 
 func DeletePod(ctx context.Context, client coreclientv1.PodsGetter, recorder events.Recorder, required *corev1.Pod) (*corev1.Pod, bool, error) {
-	err := client.Pods(required.Namespace).Delete(ctx, required.Name, metav1.DeleteOptions{})
+	err := client.Pods(required.Namespace).Delete(ctx, required.Name, deleteOptions(ctx))
 	if err != nil && apierrors.IsNotFound(err) {
 		return nil, false, nil
 	}
@@ -662,7 +668,7 @@ func DeletePod(ctx context.Context, client coreclientv1.PodsGetter, recorder eve
 }
 
 func DeleteServiceAccount(ctx context.Context, client coreclientv1.ServiceAccountsGetter, recorder events.Recorder, required *corev1.ServiceAccount) (*corev1.ServiceAccount, bool, error) {
-	err := client.ServiceAccounts(required.Namespace).Delete(ctx, required.Name, metav1.DeleteOptions{})
+	err := client.ServiceAccounts(required.Namespace).Delete(ctx, required.Name, deleteOptions(ctx))
 	if err != nil && apierrors.IsNotFound(err) {
 		return nil, false, nil
 	}
@@ -674,7 +680,7 @@ func DeleteServiceAccount(ctx context.Context, client coreclientv1.ServiceAccoun
 }
 
 func DeleteConfigMap(ctx context.Context, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, required *corev1.ConfigMap) (*corev1.ConfigMap, bool, error) {
-	err := client.ConfigMaps(required.Namespace).Delete(ctx, required.Name, metav1.DeleteOptions{})
+	err := client.ConfigMaps(required.Namespace).Delete(ctx, required.Name, deleteOptions(ctx))
 	if err != nil && apierrors.IsNotFound(err) {
 		return nil, false, nil
 	}
@@ -686,7 +692,7 @@ func DeleteConfigMap(ctx context.Context, client coreclientv1.ConfigMapsGetter,
 }
 
 func DeleteSecret(ctx context.Context, client coreclientv1.SecretsGetter, recorder events.Recorder, required *corev1.Secret) (*corev1.Secret, bool, error) {
-	err := client.Secrets(required.Namespace).Delete(ctx, required.Name, metav1.DeleteOptions{})
+	err := client.Secrets(required.Namespace).Delete(ctx, required.Name, deleteOptions(ctx))
 	if err != nil && apierrors.IsNotFound(err) {
 		return nil, false, nil
 	}
@@ -559,6 +559,49 @@ func TestApplySecret(t *testing.T) {
 	}
 }
 
+func TestApplySecretDryRunTypeChange(t *testing.T) {
+	existing := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", ResourceVersion: "1"},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{"foo": []byte("aaa")},
+	}
+	required := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{"foo": []byte("bar")},
+	}
+
+	client := fake.NewSimpleClientset(existing)
+	// the fake clientset ignores dryRun, so the reactor keeps the secret like a server-side dry-run delete would
+	client.PrependReactor("delete", "secrets", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, nil
+	})
+
+	got, changed, err := ApplySecret(withDryRun(context.TODO()), client.CoreV1(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), required)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !changed {
+		t.Errorf("expected changed")
+	}
+	if got.Type != corev1.SecretTypeOpaque || string(got.Data["foo"]) != "bar" || len(got.ResourceVersion) != 0 {
+		t.Errorf("unexpected secret: %s", spew.Sdump(got))
+	}
+	for _, action := range client.Actions() {
+		if action.GetVerb() != "get" {
+			t.Errorf("unexpected action in dry-run: %s", spew.Sdump(action))
+		}
+	}
+
+	actual, err := client.CoreV1().Secrets("default").Get(context.TODO(), "test", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equality.Semantic.DeepEqual(existing, actual) {
+		t.Errorf("dry-run changed the secret: %s", cmp.Diff(existing, actual))
+	}
+}
+
 func TestApplyNamespace(t *testing.T) {
 	tests := []struct {
 		name     string
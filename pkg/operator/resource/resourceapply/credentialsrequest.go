@@ -61,7 +61,7 @@ func ApplyCredentialsRequest(
 	crClient := client.Resource(credentialsRequestResourceGVR).Namespace(required.GetNamespace())
 	existing, err := crClient.Get(ctx, required.GetName(), metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
-		actual, err := crClient.Create(ctx, required, metav1.CreateOptions{})
+		actual, err := crClient.Create(ctx, required, createOptions(ctx))
 		if err == nil {
 			recorder.Eventf(
 				fmt.Sprintf("%sCreated", required.GetKind()),
@@ -98,7 +98,7 @@ func ApplyCredentialsRequest(
 
 	requiredCopy := required.DeepCopy()
 	existing.Object["spec"] = requiredCopy.Object["spec"]
-	actual, err := crClient.Update(ctx, existing, metav1.UpdateOptions{})
+	actual, err := crClient.Update(ctx, existing, updateOptions(ctx))
 	if err != nil {
 		return nil, false, err
 	}
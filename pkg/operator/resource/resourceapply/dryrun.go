@@ -0,0 +1,274 @@
+package resourceapply
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	migrationv1alpha1 "sigs.k8s.io/kube-storage-version-migrator/pkg/apis/migration/v1alpha1"
+)
+
+type dryRunKey struct{}
+
+// withDryRun returns a context which makes all requests of the apply and delete functions server-side dry-run requests.
+func withDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+func isDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
+
+func dryRunOption(ctx context.Context) []string {
+	if isDryRun(ctx) {
+		return []string{metav1.DryRunAll}
+	}
+	return nil
+}
+
+func createOptions(ctx context.Context) metav1.CreateOptions {
+	return metav1.CreateOptions{DryRun: dryRunOption(ctx)}
+}
+
+func updateOptions(ctx context.Context) metav1.UpdateOptions {
+	return metav1.UpdateOptions{DryRun: dryRunOption(ctx)}
+}
+
+func deleteOptions(ctx context.Context) metav1.DeleteOptions {
+	return metav1.DeleteOptions{DryRun: dryRunOption(ctx)}
+}
+
+func patchOptions(ctx context.Context) metav1.PatchOptions {
+	return metav1.PatchOptions{DryRun: dryRunOption(ctx)}
+}
+
+// FieldDiff is a single field that is changed by an apply. Old is nil for fields that are added, New is nil for
+// fields that are removed. Both values are in their JSON representation, i.e. as decoded by encoding/json.
+type FieldDiff struct {
+	Path string
+	Old  interface{}
+	New  interface{}
+}
+
+func (d FieldDiff) String() string {
+	return fmt.Sprintf("%s: %s -> %s", d.Path, diffValueString(d.Old), diffValueString(d.New))
+}
+
+func diffValueString(v interface{}) string {
+	if v == nil {
+		return "<none>"
+	}
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(bytes)
+}
+
+// ignoredDiffFields are set by the server and would show up in every diff.
+var ignoredDiffFields = map[string]bool{
+	"apiVersion":                 true,
+	"kind":                       true,
+	"status":                     true,
+	"metadata.resourceVersion":   true,
+	"metadata.generation":        true,
+	"metadata.managedFields":     true,
+	"metadata.uid":               true,
+	"metadata.creationTimestamp": true,
+	"metadata.selfLink":          true,
+}
+
+// dryRunDiff returns the fields the dry-run apply of required changes on the server, given the resulting object.
+func (c *ClientHolder) dryRunDiff(ctx context.Context, required, actual runtime.Object) ([]FieldDiff, error) {
+	existing, err := c.getExisting(ctx, required)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get existing object: %w", err)
+	}
+	return diffObjects(existing, actual)
+}
+
+// diffObjects returns the fields that differ between existing and actual, ordered by path. A nil existing
+// object is treated as empty, i.e. all fields of actual are reported as added.
+func diffObjects(existing, actual runtime.Object) ([]FieldDiff, error) {
+	existingFields, err := toUnstructuredContent(existing)
+	if err != nil {
+		return nil, err
+	}
+	actualFields, err := toUnstructuredContent(actual)
+	if err != nil {
+		return nil, err
+	}
+	diffs := diffValues("", existingFields, actualFields, nil)
+	sort.SliceStable(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs, nil
+}
+
+func toUnstructuredContent(obj runtime.Object) (map[string]interface{}, error) {
+	if obj == nil || reflect.ValueOf(obj).IsNil() {
+		return map[string]interface{}{}, nil
+	}
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.UnstructuredContent(), nil
+	}
+	return runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+}
+
+func diffValues(path string, old, new interface{}, diffs []FieldDiff) []FieldDiff {
+	if ignoredDiffFields[path] {
+		return diffs
+	}
+	if isEmptyValue(old) && isEmptyValue(new) {
+		return diffs
+	}
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	// added and removed structs are reported field by field, such that server-managed fields are skipped
+	if oldIsMap && new == nil || old == nil && newIsMap || oldIsMap && newIsMap {
+		keys := map[string]bool{}
+		for k := range oldMap {
+			keys[k] = true
+		}
+		for k := range newMap {
+			keys[k] = true
+		}
+		for k := range keys {
+			diffs = diffValues(fieldPath(path, k), oldMap[k], newMap[k], diffs)
+		}
+		return diffs
+	}
+	oldList, oldIsList := old.([]interface{})
+	newList, newIsList := new.([]interface{})
+	if oldIsList && newIsList && len(oldList) == len(newList) {
+		for i := range oldList {
+			diffs = diffValues(fmt.Sprintf("%s[%d]", path, i), oldList[i], newList[i], diffs)
+		}
+		return diffs
+	}
+	if reflect.DeepEqual(old, new) {
+		return diffs
+	}
+	return append(diffs, FieldDiff{Path: path, Old: old, New: new})
+}
+
+// isEmptyValue returns true for nil and empty lists and maps, which are equivalent in API objects.
+func isEmptyValue(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(t) == 0
+	case []interface{}:
+		return len(t) == 0
+	}
+	return false
+}
+
+var simpleFieldName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// fieldPath appends a field to the path, e.g. "spec.replicas" or "metadata.labels[app.kubernetes.io/name]".
+func fieldPath(path, field string) string {
+	switch {
+	case !simpleFieldName.MatchString(field):
+		return fmt.Sprintf("%s[%s]", path, field)
+	case len(path) == 0:
+		return field
+	default:
+		return path + "." + field
+	}
+}
+
+// getExisting returns the current version of the required object on the server, or nil if it does not exist.
+// It supports the types handled by ApplyDirectly.
+func (c *ClientHolder) getExisting(ctx context.Context, required runtime.Object) (runtime.Object, error) {
+	var existing runtime.Object
+	var err error
+
+	switch t := required.(type) {
+	case *corev1.Namespace:
+		existing, err = c.kubeClient.CoreV1().Namespaces().Get(ctx, t.Name, metav1.GetOptions{})
+	case *corev1.Service:
+		existing, err = c.kubeClient.CoreV1().Services(t.Namespace).Get(ctx, t.Name, metav1.GetOptions{})
+	case *corev1.Pod:
+		existing, err = c.kubeClient.CoreV1().Pods(t.Namespace).Get(ctx, t.Name, metav1.GetOptions{})
+	case *corev1.ServiceAccount:
+		existing, err = c.kubeClient.CoreV1().ServiceAccounts(t.Namespace).Get(ctx, t.Name, metav1.GetOptions{})
+	case *corev1.ConfigMap:
+		existing, err = c.configMapsGetter().ConfigMaps(t.Namespace).Get(ctx, t.Name, metav1.GetOptions{})
+	case *corev1.Secret:
+		existing, err = c.secretsGetter().Secrets(t.Namespace).Get(ctx, t.Name, metav1.GetOptions{})
+	case *networkingv1.NetworkPolicy:
+		existing, err = c.kubeClient.NetworkingV1().NetworkPolicies(t.Namespace).Get(ctx, t.Name, metav1.GetOptions{})
+	case *rbacv1.ClusterRole:
+		existing, err = c.kubeClient.RbacV1().ClusterRoles().Get(ctx, t.Name, metav1.GetOptions{})
+	case *rbacv1.ClusterRoleBinding:
+		existing, err = c.kubeClient.RbacV1().ClusterRoleBindings().Get(ctx, t.Name, metav1.GetOptions{})
+	case *rbacv1.Role:
+		existing, err = c.kubeClient.RbacV1().Roles(t.Namespace).Get(ctx, t.Name, metav1.GetOptions{})
+	case *rbacv1.RoleBinding:
+		existing, err = c.kubeClient.RbacV1().RoleBindings(t.Namespace).Get(ctx, t.Name, metav1.GetOptions{})
+	case *policyv1.PodDisruptionBudget:
+		existing, err = c.kubeClient.PolicyV1().PodDisruptionBudgets(t.Namespace).Get(ctx, t.Name, metav1.GetOptions{})
+	case *apiextensionsv1.CustomResourceDefinition:
+		existing, err = c.apiExtensionsClient.ApiextensionsV1().CustomResourceDefinitions().Get(ctx, t.Name, metav1.GetOptions{})
+	case *storagev1.StorageClass:
+		existing, err = c.kubeClient.StorageV1().StorageClasses().Get(ctx, t.Name, metav1.GetOptions{})
+	case *storagev1.CSIDriver:
+		existing, err = c.kubeClient.StorageV1().CSIDrivers().Get(ctx, t.Name, metav1.GetOptions{})
+	case *admissionregistrationv1.ValidatingWebhookConfiguration:
+		existing, err = c.kubeClient.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(ctx, t.Name, metav1.GetOptions{})
+	case *admissionregistrationv1.MutatingWebhookConfiguration:
+		existing, err = c.kubeClient.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(ctx, t.Name, metav1.GetOptions{})
+	case *admissionregistrationv1beta1.ValidatingAdmissionPolicy:
+		existing, err = c.kubeClient.AdmissionregistrationV1beta1().ValidatingAdmissionPolicies().Get(ctx, t.Name, metav1.GetOptions{})
+	case *admissionregistrationv1beta1.ValidatingAdmissionPolicyBinding:
+		existing, err = c.kubeClient.AdmissionregistrationV1beta1().ValidatingAdmissionPolicyBindings().Get(ctx, t.Name, metav1.GetOptions{})
+	case *admissionregistrationv1.ValidatingAdmissionPolicy:
+		existing, err = c.kubeClient.AdmissionregistrationV1().ValidatingAdmissionPolicies().Get(ctx, t.Name, metav1.GetOptions{})
+	case *admissionregistrationv1.ValidatingAdmissionPolicyBinding:
+		existing, err = c.kubeClient.AdmissionregistrationV1().ValidatingAdmissionPolicyBindings().Get(ctx, t.Name, metav1.GetOptions{})
+	case *migrationv1alpha1.StorageVersionMigration:
+		existing, err = c.migrationClient.MigrationV1alpha1().StorageVersionMigrations().Get(ctx, t.Name, metav1.GetOptions{})
+	case *unstructured.Unstructured:
+		gvr, ok := knownUnstructuredResources[t.GroupVersionKind().GroupKind()]
+		if !ok {
+			return nil, fmt.Errorf("unsupported object type: %s", t.GetKind())
+		}
+		existing, err = c.dynamicClient.Resource(gvr).Namespace(t.GetNamespace()).Get(ctx, t.GetName(), metav1.GetOptions{})
+	default:
+		return nil, fmt.Errorf("unhandled type %T", required)
+	}
+
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// knownUnstructuredResources are the resources of the types handled by ApplyKnownUnstructured.
+var knownUnstructuredResources = map[schema.GroupKind]schema.GroupVersionResource{
+	{Group: "monitoring.coreos.com", Kind: "ServiceMonitor"}:        serviceMonitorGVR,
+	{Group: "monitoring.coreos.com", Kind: "PrometheusRule"}:        prometheusRuleGVR,
+	{Group: "snapshot.storage.k8s.io", Kind: "VolumeSnapshotClass"}: volumeSnapshotClassResourceGVR,
+	{Group: "monitoring.coreos.com", Kind: "Alertmanager"}:          alertmanagerGVR,
+	{Group: "monitoring.coreos.com", Kind: "Prometheus"}:            prometheusGVR,
+}
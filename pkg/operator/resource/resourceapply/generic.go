@@ -20,6 +20,7 @@ import (
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/utils/clock"
 	migrationv1alpha1 "sigs.k8s.io/kube-storage-version-migrator/pkg/apis/migration/v1alpha1"
 	migrationclient "sigs.k8s.io/kube-storage-version-migrator/pkg/clients/clientset"
 
@@ -36,6 +37,10 @@ type ApplyResult struct {
 	Result  runtime.Object
 	Changed bool
 	Error   error
+
+	// Diff lists the fields the apply changes. It is only computed by ApplyDirectly in dry-run mode,
+	// see ClientHolder.WithDryRun.
+	Diff []FieldDiff
 }
 
 // ConditionalFunction provides needed dependency for a resource on another condition instead of blindly creating
@@ -48,6 +53,7 @@ type ClientHolder struct {
 	kubeInformers       v1helpers.KubeInformersForNamespaces
 	dynamicClient       dynamic.Interface
 	migrationClient     migrationclient.Interface
+	dryRun              bool
 }
 
 func NewClientHolder() *ClientHolder {
@@ -83,9 +89,26 @@ func (c *ClientHolder) WithMigrationClient(client migrationclient.Interface) *Cl
 	return c
 }
 
+// WithDryRun makes ApplyDirectly and DeleteAll send all requests with dryRun=All, so nothing is persisted.
+// The results of ApplyDirectly then carry the diff of the fields the apply would change. No events are
+// emitted and the resource cache is neither used nor updated in dry-run mode.
+func (c *ClientHolder) WithDryRun() *ClientHolder {
+	c.dryRun = true
+	return c
+}
+
+// dryRunContext returns the context, recorder and cache to use for the requests of ApplyDirectly and DeleteAll.
+func (c *ClientHolder) dryRunContext(ctx context.Context, recorder events.Recorder, cache ResourceCache) (context.Context, events.Recorder, ResourceCache) {
+	if !c.dryRun {
+		return ctx, recorder, cache
+	}
+	return withDryRun(ctx), events.NewInMemoryRecorder(recorder.ComponentName(), clock.RealClock{}), noCache
+}
+
 // ApplyDirectly applies the given manifest files to API server.
 func ApplyDirectly(ctx context.Context, clients *ClientHolder, recorder events.Recorder, cache ResourceCache, manifests AssetFunc, files ...string) []ApplyResult {
 	ret := []ApplyResult{}
+	ctx, recorder, cache = clients.dryRunContext(ctx, recorder, cache)
 
 	for _, file := range files {
 		result := ApplyResult{File: file}
@@ -249,6 +272,11 @@ func ApplyDirectly(ctx context.Context, clients *ClientHolder, recorder events.R
 			result.Error = fmt.Errorf("unhandled type %T", requiredObj)
 		}
 
+		if clients.dryRun && result.Error == nil && result.Changed {
+			// nothing was persisted, so the object on the server is still the one before the apply
+			result.Diff, result.Error = clients.dryRunDiff(ctx, requiredObj, result.Result)
+		}
+
 		ret = append(ret, result)
 	}
 
@@ -258,6 +286,7 @@ func ApplyDirectly(ctx context.Context, clients *ClientHolder, recorder events.R
 func DeleteAll(ctx context.Context, clients *ClientHolder, recorder events.Recorder, manifests AssetFunc,
 	files ...string) []ApplyResult {
 	ret := []ApplyResult{}
+	ctx, recorder, _ = clients.dryRunContext(ctx, recorder, nil)
 
 	for _, file := range files {
 		result := ApplyResult{File: file}
@@ -3,10 +3,17 @@ package resourceapply
 import (
 	"context"
 	clocktesting "k8s.io/utils/clock/testing"
+	"reflect"
 	"testing"
 	"time"
 
+	"github.com/davecgh/go-spew/spew"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
 
 	"github.com/openshift/library-go/pkg/operator/events"
 )
@@ -30,3 +37,114 @@ metadata:
 		t.Fatal(ret[0].Error)
 	}
 }
+
+func TestApplyDirectlyDryRun(t *testing.T) {
+	manifests := map[string]string{
+		"configmap": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: existing
+  namespace: ns
+  labels:
+    app.kubernetes.io/name: foo
+data:
+  foo: new
+  bar: added
+`,
+		"unchanged": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unchanged
+  namespace: ns
+data:
+  foo: bar
+`,
+		"sa": `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: sa
+  namespace: ns
+`,
+	}
+	content := func(name string) ([]byte, error) {
+		return []byte(manifests[name]), nil
+	}
+
+	fakeClient := fake.NewSimpleClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "existing", ResourceVersion: "1"},
+			Data:       map[string]string{"foo": "old", "removed": "value"},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "unchanged"},
+			Data:       map[string]string{"foo": "bar"},
+		},
+	)
+	// the fake clientset ignores dryRun, so the reactor makes sure nothing is persisted
+	dryRunReactor := func(action clienttesting.Action) (bool, runtime.Object, error) {
+		var dryRun []string
+		var obj runtime.Object
+		switch a := action.(type) {
+		case clienttesting.CreateActionImpl:
+			dryRun, obj = a.GetCreateOptions().DryRun, a.GetObject()
+		case clienttesting.UpdateActionImpl:
+			dryRun, obj = a.GetUpdateOptions().DryRun, a.GetObject()
+		}
+		if !reflect.DeepEqual(dryRun, []string{metav1.DryRunAll}) {
+			t.Errorf("expected dry-run request, got %s", spew.Sdump(action))
+		}
+		return true, obj, nil
+	}
+	fakeClient.PrependReactor("create", "*", dryRunReactor)
+	fakeClient.PrependReactor("update", "*", dryRunReactor)
+
+	recorder := events.NewInMemoryRecorder("", clocktesting.NewFakePassiveClock(time.Now()))
+	ret := ApplyDirectly(context.TODO(), NewKubeClientHolder(fakeClient).WithDryRun(), recorder, NewResourceCache(), content, "configmap", "unchanged", "sa")
+
+	for _, result := range ret {
+		if result.Error != nil {
+			t.Fatalf("%s: %v", result.File, result.Error)
+		}
+	}
+	expected := []FieldDiff{
+		{Path: "data.bar", New: "added"},
+		{Path: "data.foo", Old: "old", New: "new"},
+		{Path: "data.removed", Old: "value"},
+		{Path: "metadata.labels[app.kubernetes.io/name]", New: "foo"},
+	}
+	if !ret[0].Changed || !reflect.DeepEqual(ret[0].Diff, expected) {
+		t.Errorf("unexpected diff for changed configmap: %s", spew.Sdump(ret[0]))
+	}
+	if ret[1].Changed || len(ret[1].Diff) != 0 {
+		t.Errorf("unexpected diff for unchanged configmap: %s", spew.Sdump(ret[1]))
+	}
+	expected = []FieldDiff{
+		{Path: "metadata.name", New: "sa"},
+		{Path: "metadata.namespace", New: "ns"},
+	}
+	if !ret[2].Changed || !reflect.DeepEqual(ret[2].Diff, expected) {
+		t.Errorf("unexpected diff for created service account: %s", spew.Sdump(ret[2]))
+	}
+	if len(recorder.Events()) != 0 {
+		t.Errorf("expected no events in dry-run mode, got %s", spew.Sdump(recorder.Events()))
+	}
+
+	existing, err := fakeClient.CoreV1().ConfigMaps("ns").Get(context.TODO(), "existing", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if existing.Data["foo"] != "old" {
+		t.Errorf("expected configmap not to be updated, got %s", spew.Sdump(existing))
+	}
+}
+
+func TestFieldDiffString(t *testing.T) {
+	diff := FieldDiff{Path: "spec.replicas", Old: int64(1), New: int64(3)}
+	if actual := diff.String(); actual != "spec.replicas: 1 -> 3" {
+		t.Errorf("unexpected string %q", actual)
+	}
+	diff = FieldDiff{Path: "metadata.labels[app.kubernetes.io/name]", New: "foo"}
+	if actual := diff.String(); actual != `metadata.labels[app.kubernetes.io/name]: <none> -> "foo"` {
+		t.Errorf("unexpected string %q", actual)
+	}
+}
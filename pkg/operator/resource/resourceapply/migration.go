@@ -21,7 +21,7 @@ func ApplyStorageVersionMigration(ctx context.Context, client migrationclientv1a
 	existing, err := clientInterface.Get(ctx, required.Name, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
-		actual, err := clientInterface.Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*v1alpha1.StorageVersionMigration), metav1.CreateOptions{})
+		actual, err := clientInterface.Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*v1alpha1.StorageVersionMigration), createOptions(ctx))
 		resourcehelper.ReportCreateEvent(recorder, requiredCopy, err)
 		return actual, true, err
 	}
@@ -41,14 +41,14 @@ func ApplyStorageVersionMigration(ctx context.Context, client migrationclientv1a
 	}
 
 	required.Spec.Resource.DeepCopyInto(&existingCopy.Spec.Resource)
-	actual, err := clientInterface.Update(ctx, existingCopy, metav1.UpdateOptions{})
+	actual, err := clientInterface.Update(ctx, existingCopy, updateOptions(ctx))
 	resourcehelper.ReportUpdateEvent(recorder, required, err)
 	return actual, true, err
 }
 
 func DeleteStorageVersionMigration(ctx context.Context, client migrationclientv1alpha1.Interface, recorder events.Recorder, required *migrationv1alpha1.StorageVersionMigration) (*migrationv1alpha1.StorageVersionMigration, bool, error) {
 	clientInterface := client.MigrationV1alpha1().StorageVersionMigrations()
-	err := clientInterface.Delete(ctx, required.Name, metav1.DeleteOptions{})
+	err := clientInterface.Delete(ctx, required.Name, deleteOptions(ctx))
 	if err != nil && apierrors.IsNotFound(err) {
 		return nil, false, nil
 	}
@@ -82,7 +82,7 @@ func ApplyUnstructuredResourceImproved(
 	}
 	existing, err := client.Resource(resourceGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
 	if errors.IsNotFound(err) {
-		want, errCreate := client.Resource(resourceGVR).Namespace(namespace).Create(ctx, required, metav1.CreateOptions{})
+		want, errCreate := client.Resource(resourceGVR).Namespace(namespace).Create(ctx, required, createOptions(ctx))
 		resourcehelper.ReportCreateEvent(recorder, required, errCreate)
 		cache.UpdateCachedResourceMetadata(required, want)
 		return want, true, errCreate
@@ -128,7 +128,7 @@ func ApplyUnstructuredResourceImproved(
 	if klog.V(4).Enabled() {
 		klog.Infof("%s %q changes: %v", resourceGVR.String(), namespace+"/"+name, JSONPatchNoError(existing, existingCopy))
 	}
-	actual, errUpdate := client.Resource(resourceGVR).Namespace(namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
+	actual, errUpdate := client.Resource(resourceGVR).Namespace(namespace).Update(ctx, existingCopy, updateOptions(ctx))
 	resourcehelper.ReportUpdateEvent(recorder, existingCopy, errUpdate)
 	cache.UpdateCachedResourceMetadata(existingCopy, actual)
 	return actual, true, errUpdate
@@ -136,7 +136,7 @@ func ApplyUnstructuredResourceImproved(
 
 // DeleteUnstructuredResource deletes the unstructured resource.
 func DeleteUnstructuredResource(ctx context.Context, client dynamic.Interface, recorder events.Recorder, required *unstructured.Unstructured, resourceGVR schema.GroupVersionResource) (*unstructured.Unstructured, bool, error) {
-	err := client.Resource(resourceGVR).Namespace(required.GetNamespace()).Delete(ctx, required.GetName(), metav1.DeleteOptions{})
+	err := client.Resource(resourceGVR).Namespace(required.GetNamespace()).Delete(ctx, required.GetName(), deleteOptions(ctx))
 	if err != nil && errors.IsNotFound(err) {
 		return nil, false, nil
 	}
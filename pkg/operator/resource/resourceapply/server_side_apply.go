@@ -0,0 +1,408 @@
+package resourceapply
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsclientv1 "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/typed/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/scheme"
+	admissionregistrationclientv1 "k8s.io/client-go/kubernetes/typed/admissionregistration/v1"
+	admissionregistrationclientv1beta1 "k8s.io/client-go/kubernetes/typed/admissionregistration/v1beta1"
+	appsclientv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+	coreclientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	networkingclientv1 "k8s.io/client-go/kubernetes/typed/networking/v1"
+	policyclientv1 "k8s.io/client-go/kubernetes/typed/policy/v1"
+	rbacclientv1 "k8s.io/client-go/kubernetes/typed/rbac/v1"
+	storageclientv1 "k8s.io/client-go/kubernetes/typed/storage/v1"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	apiregistrationv1client "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset/typed/apiregistration/v1"
+	"k8s.io/utils/ptr"
+	migrationv1alpha1 "sigs.k8s.io/kube-storage-version-migrator/pkg/apis/migration/v1alpha1"
+	migrationclientv1alpha1 "sigs.k8s.io/kube-storage-version-migrator/pkg/clients/clientset"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcehelper"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
+)
+
+// The Apply<type>ServerSide methods in this file are the server-side apply variants of the Apply<type> methods.
+// Instead of merging the required object into the existing one on the client, the required object is sent as
+// apply patch and the API server merges it, owning exactly the fields set in required for the given field
+// manager. Fields that were set by a previous apply but are not set in required anymore are removed.
+//
+// The field manager should be unique per controller and usage, i.e. it should be created with
+// factory.ControllerFieldManager, e.g.
+//
+//   factory.ControllerFieldManager("my-operator-static-resources", "apply")
+//
+// Conflicts with other field managers are forced, i.e. the operator takes the ownership of the fields it requires.
+// Resources created or updated by the Apply<type> methods before should be migrated with
+// MigrateToServerSideApply first, otherwise the old field manager keeps owning all fields and fields removed from
+// required are never removed from the resource.
+
+// serverSideApplyScheme knows the kinds of the types with Apply<type>ServerSide methods.
+var serverSideApplyScheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(scheme.AddToScheme(serverSideApplyScheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(serverSideApplyScheme))
+	utilruntime.Must(apiregistrationv1.AddToScheme(serverSideApplyScheme))
+	utilruntime.Must(migrationv1alpha1.AddToScheme(serverSideApplyScheme))
+}
+
+// serverSideApplyObject is an API object of one of the types with Apply<type>ServerSide methods.
+type serverSideApplyObject interface {
+	runtime.Object
+	metav1.Object
+}
+
+// ServerSideApplyClient is the subset of the typed and dynamic resource clients needed for server-side apply.
+type ServerSideApplyClient[T serverSideApplyObject] interface {
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (T, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (T, error)
+}
+
+// ApplyNamespaceServerSide applies the namespace with server-side apply.
+func ApplyNamespaceServerSide(ctx context.Context, client coreclientv1.NamespacesGetter, recorder events.Recorder, required *corev1.Namespace, fieldManager string) (*corev1.Namespace, bool, error) {
+	return applyServerSide[*corev1.Namespace](ctx, client.Namespaces(), recorder, required, fieldManager)
+}
+
+// ApplyServiceServerSide applies the service with server-side apply.
+func ApplyServiceServerSide(ctx context.Context, client coreclientv1.ServicesGetter, recorder events.Recorder, required *corev1.Service, fieldManager string) (*corev1.Service, bool, error) {
+	return applyServerSide[*corev1.Service](ctx, client.Services(required.Namespace), recorder, required, fieldManager)
+}
+
+// ApplyPodServerSide applies the pod with server-side apply.
+func ApplyPodServerSide(ctx context.Context, client coreclientv1.PodsGetter, recorder events.Recorder, required *corev1.Pod, fieldManager string) (*corev1.Pod, bool, error) {
+	return applyServerSide[*corev1.Pod](ctx, client.Pods(required.Namespace), recorder, required, fieldManager)
+}
+
+// ApplyServiceAccountServerSide applies the service account with server-side apply.
+func ApplyServiceAccountServerSide(ctx context.Context, client coreclientv1.ServiceAccountsGetter, recorder events.Recorder, required *corev1.ServiceAccount, fieldManager string) (*corev1.ServiceAccount, bool, error) {
+	return applyServerSide[*corev1.ServiceAccount](ctx, client.ServiceAccounts(required.Namespace), recorder, required, fieldManager)
+}
+
+// ApplyConfigMapServerSide applies the config map with server-side apply.
+func ApplyConfigMapServerSide(ctx context.Context, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, required *corev1.ConfigMap, fieldManager string) (*corev1.ConfigMap, bool, error) {
+	return applyServerSide[*corev1.ConfigMap](ctx, client.ConfigMaps(required.Namespace), recorder, required, fieldManager)
+}
+
+// ApplySecretServerSide applies the secret with server-side apply. Unlike ApplySecret, it does not recreate secrets
+// whose type changed, the type of a secret is immutable.
+func ApplySecretServerSide(ctx context.Context, client coreclientv1.SecretsGetter, recorder events.Recorder, required *corev1.Secret, fieldManager string) (*corev1.Secret, bool, error) {
+	return applyServerSide[*corev1.Secret](ctx, client.Secrets(required.Namespace), recorder, required, fieldManager)
+}
+
+// ApplyDeploymentServerSide applies the deployment with server-side apply. The spec hash and generation
+// annotations used by ApplyDeployment are not needed, the API server detects spec changes.
+func ApplyDeploymentServerSide(ctx context.Context, client appsclientv1.DeploymentsGetter, recorder events.Recorder, required *appsv1.Deployment, fieldManager string) (*appsv1.Deployment, bool, error) {
+	return applyServerSide[*appsv1.Deployment](ctx, client.Deployments(required.Namespace), recorder, required, fieldManager)
+}
+
+// ApplyDaemonSetServerSide applies the daemonset with server-side apply. The spec hash and generation
+// annotations used by ApplyDaemonSet are not needed, the API server detects spec changes.
+func ApplyDaemonSetServerSide(ctx context.Context, client appsclientv1.DaemonSetsGetter, recorder events.Recorder, required *appsv1.DaemonSet, fieldManager string) (*appsv1.DaemonSet, bool, error) {
+	return applyServerSide[*appsv1.DaemonSet](ctx, client.DaemonSets(required.Namespace), recorder, required, fieldManager)
+}
+
+// ApplyNetworkPolicyServerSide applies the network policy with server-side apply.
+func ApplyNetworkPolicyServerSide(ctx context.Context, client networkingclientv1.NetworkPoliciesGetter, recorder events.Recorder, required *networkingv1.NetworkPolicy, fieldManager string) (*networkingv1.NetworkPolicy, bool, error) {
+	return applyServerSide[*networkingv1.NetworkPolicy](ctx, client.NetworkPolicies(required.Namespace), recorder, required, fieldManager)
+}
+
+// ApplyPodDisruptionBudgetServerSide applies the pod disruption budget with server-side apply.
+func ApplyPodDisruptionBudgetServerSide(ctx context.Context, client policyclientv1.PodDisruptionBudgetsGetter, recorder events.Recorder, required *policyv1.PodDisruptionBudget, fieldManager string) (*policyv1.PodDisruptionBudget, bool, error) {
+	return applyServerSide[*policyv1.PodDisruptionBudget](ctx, client.PodDisruptionBudgets(required.Namespace), recorder, required, fieldManager)
+}
+
+// ApplyClusterRoleServerSide applies the cluster role with server-side apply.
+func ApplyClusterRoleServerSide(ctx context.Context, client rbacclientv1.ClusterRolesGetter, recorder events.Recorder, required *rbacv1.ClusterRole, fieldManager string) (*rbacv1.ClusterRole, bool, error) {
+	return applyServerSide[*rbacv1.ClusterRole](ctx, client.ClusterRoles(), recorder, required, fieldManager)
+}
+
+// ApplyClusterRoleBindingServerSide applies the cluster role binding with server-side apply.
+func ApplyClusterRoleBindingServerSide(ctx context.Context, client rbacclientv1.ClusterRoleBindingsGetter, recorder events.Recorder, required *rbacv1.ClusterRoleBinding, fieldManager string) (*rbacv1.ClusterRoleBinding, bool, error) {
+	return applyServerSide[*rbacv1.ClusterRoleBinding](ctx, client.ClusterRoleBindings(), recorder, required, fieldManager)
+}
+
+// ApplyRoleServerSide applies the role with server-side apply.
+func ApplyRoleServerSide(ctx context.Context, client rbacclientv1.RolesGetter, recorder events.Recorder, required *rbacv1.Role, fieldManager string) (*rbacv1.Role, bool, error) {
+	return applyServerSide[*rbacv1.Role](ctx, client.Roles(required.Namespace), recorder, required, fieldManager)
+}
+
+// ApplyRoleBindingServerSide applies the role binding with server-side apply.
+func ApplyRoleBindingServerSide(ctx context.Context, client rbacclientv1.RoleBindingsGetter, recorder events.Recorder, required *rbacv1.RoleBinding, fieldManager string) (*rbacv1.RoleBinding, bool, error) {
+	return applyServerSide[*rbacv1.RoleBinding](ctx, client.RoleBindings(required.Namespace), recorder, required, fieldManager)
+}
+
+// ApplyStorageClassServerSide applies the storage class with server-side apply.
+func ApplyStorageClassServerSide(ctx context.Context, client storageclientv1.StorageClassesGetter, recorder events.Recorder, required *storagev1.StorageClass, fieldManager string) (*storagev1.StorageClass, bool, error) {
+	return applyServerSide[*storagev1.StorageClass](ctx, client.StorageClasses(), recorder, required, fieldManager)
+}
+
+// ApplyCSIDriverServerSide applies the CSI driver with server-side apply.
+func ApplyCSIDriverServerSide(ctx context.Context, client storageclientv1.CSIDriversGetter, recorder events.Recorder, required *storagev1.CSIDriver, fieldManager string) (*storagev1.CSIDriver, bool, error) {
+	return applyServerSide[*storagev1.CSIDriver](ctx, client.CSIDrivers(), recorder, required, fieldManager)
+}
+
+// ApplyCustomResourceDefinitionV1ServerSide applies the custom resource definition with server-side apply.
+func ApplyCustomResourceDefinitionV1ServerSide(ctx context.Context, client apiextensionsclientv1.CustomResourceDefinitionsGetter, recorder events.Recorder, required *apiextensionsv1.CustomResourceDefinition, fieldManager string) (*apiextensionsv1.CustomResourceDefinition, bool, error) {
+	return applyServerSide[*apiextensionsv1.CustomResourceDefinition](ctx, client.CustomResourceDefinitions(), recorder, required, fieldManager)
+}
+
+// ApplyAPIServiceServerSide applies the API service with server-side apply. The CA bundle is not required,
+// so it stays owned by the service CA controller.
+func ApplyAPIServiceServerSide(ctx context.Context, client apiregistrationv1client.APIServicesGetter, recorder events.Recorder, required *apiregistrationv1.APIService, fieldManager string) (*apiregistrationv1.APIService, bool, error) {
+	return applyServerSide[*apiregistrationv1.APIService](ctx, client.APIServices(), recorder, required, fieldManager)
+}
+
+// ApplyStorageVersionMigrationServerSide applies the storage version migration with server-side apply.
+func ApplyStorageVersionMigrationServerSide(ctx context.Context, client migrationclientv1alpha1.Interface, recorder events.Recorder, required *migrationv1alpha1.StorageVersionMigration, fieldManager string) (*migrationv1alpha1.StorageVersionMigration, bool, error) {
+	return applyServerSide[*migrationv1alpha1.StorageVersionMigration](ctx, client.MigrationV1alpha1().StorageVersionMigrations(), recorder, required, fieldManager)
+}
+
+// ApplyMutatingWebhookConfigurationServerSide applies the mutating webhook configuration with server-side apply.
+// The CA bundles of the webhooks should not be set in required, they stay owned by the service CA controller.
+func ApplyMutatingWebhookConfigurationServerSide(ctx context.Context, client admissionregistrationclientv1.MutatingWebhookConfigurationsGetter, recorder events.Recorder, required *admissionregistrationv1.MutatingWebhookConfiguration, fieldManager string) (*admissionregistrationv1.MutatingWebhookConfiguration, bool, error) {
+	return applyServerSide[*admissionregistrationv1.MutatingWebhookConfiguration](ctx, client.MutatingWebhookConfigurations(), recorder, required, fieldManager)
+}
+
+// ApplyValidatingWebhookConfigurationServerSide applies the validating webhook configuration with server-side apply.
+// The CA bundles of the webhooks should not be set in required, they stay owned by the service CA controller.
+func ApplyValidatingWebhookConfigurationServerSide(ctx context.Context, client admissionregistrationclientv1.ValidatingWebhookConfigurationsGetter, recorder events.Recorder, required *admissionregistrationv1.ValidatingWebhookConfiguration, fieldManager string) (*admissionregistrationv1.ValidatingWebhookConfiguration, bool, error) {
+	return applyServerSide[*admissionregistrationv1.ValidatingWebhookConfiguration](ctx, client.ValidatingWebhookConfigurations(), recorder, required, fieldManager)
+}
+
+// ApplyValidatingAdmissionPolicyV1ServerSide applies the validating admission policy with server-side apply.
+func ApplyValidatingAdmissionPolicyV1ServerSide(ctx context.Context, client admissionregistrationclientv1.ValidatingAdmissionPoliciesGetter, recorder events.Recorder, required *admissionregistrationv1.ValidatingAdmissionPolicy, fieldManager string) (*admissionregistrationv1.ValidatingAdmissionPolicy, bool, error) {
+	return applyServerSide[*admissionregistrationv1.ValidatingAdmissionPolicy](ctx, client.ValidatingAdmissionPolicies(), recorder, required, fieldManager)
+}
+
+// ApplyValidatingAdmissionPolicyBindingV1ServerSide applies the validating admission policy binding with server-side apply.
+func ApplyValidatingAdmissionPolicyBindingV1ServerSide(ctx context.Context, client admissionregistrationclientv1.ValidatingAdmissionPolicyBindingsGetter, recorder events.Recorder, required *admissionregistrationv1.ValidatingAdmissionPolicyBinding, fieldManager string) (*admissionregistrationv1.ValidatingAdmissionPolicyBinding, bool, error) {
+	return applyServerSide[*admissionregistrationv1.ValidatingAdmissionPolicyBinding](ctx, client.ValidatingAdmissionPolicyBindings(), recorder, required, fieldManager)
+}
+
+// ApplyValidatingAdmissionPolicyV1beta1ServerSide applies the validating admission policy with server-side apply.
+func ApplyValidatingAdmissionPolicyV1beta1ServerSide(ctx context.Context, client admissionregistrationclientv1beta1.ValidatingAdmissionPoliciesGetter, recorder events.Recorder, required *admissionregistrationv1beta1.ValidatingAdmissionPolicy, fieldManager string) (*admissionregistrationv1beta1.ValidatingAdmissionPolicy, bool, error) {
+	return applyServerSide[*admissionregistrationv1beta1.ValidatingAdmissionPolicy](ctx, client.ValidatingAdmissionPolicies(), recorder, required, fieldManager)
+}
+
+// ApplyValidatingAdmissionPolicyBindingV1beta1ServerSide applies the validating admission policy binding with server-side apply.
+func ApplyValidatingAdmissionPolicyBindingV1beta1ServerSide(ctx context.Context, client admissionregistrationclientv1beta1.ValidatingAdmissionPolicyBindingsGetter, recorder events.Recorder, required *admissionregistrationv1beta1.ValidatingAdmissionPolicyBinding, fieldManager string) (*admissionregistrationv1beta1.ValidatingAdmissionPolicyBinding, bool, error) {
+	return applyServerSide[*admissionregistrationv1beta1.ValidatingAdmissionPolicyBinding](ctx, client.ValidatingAdmissionPolicyBindings(), recorder, required, fieldManager)
+}
+
+// ApplyUnstructuredResourceServerSide applies the unstructured resource with server-side apply.
+func ApplyUnstructuredResourceServerSide(ctx context.Context, client dynamic.Interface, recorder events.Recorder, required *unstructured.Unstructured, resourceGVR schema.GroupVersionResource, fieldManager string) (*unstructured.Unstructured, bool, error) {
+	return applyServerSide[*unstructured.Unstructured](ctx, DynamicServerSideApplyClient(client.Resource(resourceGVR).Namespace(required.GetNamespace())), recorder, required, fieldManager)
+}
+
+type dynamicServerSideApplyClient struct {
+	client dynamic.ResourceInterface
+}
+
+// DynamicServerSideApplyClient adapts a dynamic resource client for MigrateToServerSideApply.
+func DynamicServerSideApplyClient(client dynamic.ResourceInterface) ServerSideApplyClient[*unstructured.Unstructured] {
+	return &dynamicServerSideApplyClient{client: client}
+}
+
+func (c *dynamicServerSideApplyClient) Get(ctx context.Context, name string, opts metav1.GetOptions) (*unstructured.Unstructured, error) {
+	return c.client.Get(ctx, name, opts)
+}
+
+func (c *dynamicServerSideApplyClient) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	return c.client.Patch(ctx, name, pt, data, opts, subresources...)
+}
+
+// ApplyKnownUnstructuredServerSide applies the unstructured types supported by ApplyKnownUnstructured with server-side apply.
+func ApplyKnownUnstructuredServerSide(ctx context.Context, client dynamic.Interface, recorder events.Recorder, required *unstructured.Unstructured, fieldManager string) (*unstructured.Unstructured, bool, error) {
+	resourceGVR, ok := knownUnstructuredResources[required.GroupVersionKind().GroupKind()]
+	if !ok {
+		return nil, false, fmt.Errorf("unsupported object type: %s", required.GetKind())
+	}
+	return ApplyUnstructuredResourceServerSide(ctx, client, recorder, required, resourceGVR, fieldManager)
+}
+
+// ApplyCredentialsRequestServerSide applies the credentials request with server-side apply.
+func ApplyCredentialsRequestServerSide(ctx context.Context, client dynamic.Interface, recorder events.Recorder, required *unstructured.Unstructured, fieldManager string) (*unstructured.Unstructured, bool, error) {
+	return ApplyUnstructuredResourceServerSide(ctx, client, recorder, required, credentialsRequestResourceGVR, fieldManager)
+}
+
+func applyServerSide[T serverSideApplyObject](ctx context.Context, client ServerSideApplyClient[T], recorder events.Recorder, required T, fieldManager string) (T, bool, error) {
+	var zero T
+	if len(fieldManager) == 0 {
+		return zero, false, fmt.Errorf("missing field manager for server-side apply of %s", resourcehelper.FormatResourceForCLIWithNamespace(required))
+	}
+
+	existing, err := client.Get(ctx, required.GetName(), metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return zero, false, err
+	}
+	exists := err == nil
+
+	patch, err := serverSideApplyPatch(required)
+	if err != nil {
+		return zero, false, err
+	}
+	opts := patchOptions(ctx)
+	opts.FieldManager = fieldManager
+	opts.Force = ptr.To(true)
+	actual, err := client.Patch(ctx, required.GetName(), types.ApplyPatchType, patch, opts)
+	if !exists {
+		resourcehelper.ReportCreateEvent(recorder, required, err)
+		return actual, true, err
+	}
+	if err != nil {
+		resourcehelper.ReportUpdateEvent(recorder, required, err)
+		return actual, false, err
+	}
+	// the API server does not write anything if the apply does not change the object
+	if equality.Semantic.DeepEqual(existing, actual) {
+		return actual, false, nil
+	}
+	resourcehelper.ReportUpdateEvent(recorder, required, nil)
+	return actual, true, nil
+}
+
+// serverSideApplyPatch returns the apply patch for the required object, i.e. the object without the fields which
+// are set by the server.
+func serverSideApplyPatch(required serverSideApplyObject) ([]byte, error) {
+	var content map[string]interface{}
+	if u, ok := required.(*unstructured.Unstructured); ok {
+		content = runtime.DeepCopyJSON(u.UnstructuredContent())
+	} else {
+		requiredCopy := required.DeepCopyObject().(serverSideApplyObject)
+		var err error
+		content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy))
+		if err != nil {
+			return nil, err
+		}
+		kinds, _, err := serverSideApplyScheme.ObjectKinds(required)
+		if err != nil {
+			return nil, err
+		}
+		apiVersion, kind := kinds[0].ToAPIVersionAndKind()
+		content["apiVersion"], content["kind"] = apiVersion, kind
+	}
+
+	delete(content, "status")
+	for _, field := range []string{"creationTimestamp", "resourceVersion", "generation", "managedFields", "uid", "selfLink"} {
+		unstructured.RemoveNestedField(content, "metadata", field)
+	}
+	return json.Marshal(content)
+}
+
+// MigrateToServerSideApply moves the ownership of the fields managed by client-side updates of the csaManagers to
+// the server-side apply fieldManager. Without the migration the client-side managers keep owning all fields they ever
+// set, so fields removed from the required object of the next server-side apply are not removed from the resource.
+//
+// The client-side managers are the field managers of the update requests of the Apply<type> methods, which is the
+// user agent of the client unless a field manager was set. Updates of fieldManager itself are always migrated.
+// It returns true if the managed fields were changed. The migration is done with a resource version precondition,
+// a conflict error is returned if the resource was changed concurrently.
+func MigrateToServerSideApply[T serverSideApplyObject](ctx context.Context, client ServerSideApplyClient[T], name string, csaManagers sets.Set[string], fieldManager string) (bool, error) {
+	existing, err := client.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	patch, err := serverSideApplyMigrationPatch(existing, csaManagers, fieldManager)
+	if err != nil || patch == nil {
+		return false, err
+	}
+	_, err = client.Patch(ctx, name, types.JSONPatchType, patch, patchOptions(ctx))
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// serverSideApplyMigrationPatch returns a JSON patch which merges the fields of the update managed fields entries of
+// the csaManagers and fieldManager into the apply entry of fieldManager. It returns nil if there is nothing to migrate.
+func serverSideApplyMigrationPatch(obj metav1.Object, csaManagers sets.Set[string], fieldManager string) ([]byte, error) {
+	var applyEntry *metav1.ManagedFieldsEntry
+	owned := &fieldpath.Set{}
+	migrated := []metav1.ManagedFieldsEntry{}
+	kept := []metav1.ManagedFieldsEntry{}
+	for _, entry := range obj.GetManagedFields() {
+		var err error
+		switch {
+		case len(entry.Subresource) != 0:
+			kept = append(kept, entry)
+		case entry.Manager == fieldManager && entry.Operation == metav1.ManagedFieldsOperationApply:
+			applyEntry = entry.DeepCopy()
+			owned, err = unionManagedFields(owned, entry)
+		case entry.Operation == metav1.ManagedFieldsOperationUpdate && (entry.Manager == fieldManager || csaManagers.Has(entry.Manager)):
+			migrated = append(migrated, entry)
+			owned, err = unionManagedFields(owned, entry)
+		default:
+			kept = append(kept, entry)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(migrated) == 0 {
+		return nil, nil
+	}
+
+	if applyEntry == nil {
+		// keep the API version and time of the latest update
+		applyEntry = migrated[len(migrated)-1].DeepCopy()
+		applyEntry.Manager = fieldManager
+		applyEntry.Operation = metav1.ManagedFieldsOperationApply
+	}
+	ownedJSON, err := owned.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+	applyEntry.FieldsType = "FieldsV1"
+	applyEntry.FieldsV1 = &metav1.FieldsV1{Raw: ownedJSON}
+
+	patch := []map[string]interface{}{
+		{"op": "replace", "path": "/metadata/managedFields", "value": append(kept, *applyEntry)},
+	}
+	if len(obj.GetResourceVersion()) > 0 {
+		patch = append([]map[string]interface{}{
+			{"op": "test", "path": "/metadata/resourceVersion", "value": obj.GetResourceVersion()},
+		}, patch...)
+	}
+	return json.Marshal(patch)
+}
+
+func unionManagedFields(fields *fieldpath.Set, entry metav1.ManagedFieldsEntry) (*fieldpath.Set, error) {
+	if entry.FieldsV1 == nil {
+		return fields, nil
+	}
+	entryFields := &fieldpath.Set{}
+	if err := entryFields.FromJSON(bytes.NewReader(entry.FieldsV1.Raw)); err != nil {
+		return nil, fmt.Errorf("failed to parse managed fields of %q: %w", entry.Manager, err)
+	}
+	return fields.Union(entryFields), nil
+}
@@ -0,0 +1,178 @@
+package resourceapply
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/fake"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+const testFieldManager = "test-controller-apply"
+
+func TestApplyConfigMapServerSide(t *testing.T) {
+	client := fake.NewClientset()
+	recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+	required := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "foo", Labels: map[string]string{"app": "foo"}},
+		Data:       map[string]string{"a": "1", "b": "2"},
+	}
+
+	actual, changed, err := ApplyConfigMapServerSide(context.TODO(), client.CoreV1(), recorder, required, testFieldManager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || !reflect.DeepEqual(actual.Data, required.Data) {
+		t.Fatalf("expected created config map, got changed=%v: %s", changed, spew.Sdump(actual))
+	}
+	if len(actual.ManagedFields) != 1 || actual.ManagedFields[0].Manager != testFieldManager || actual.ManagedFields[0].Operation != metav1.ManagedFieldsOperationApply {
+		t.Errorf("unexpected managed fields: %s", spew.Sdump(actual.ManagedFields))
+	}
+
+	// no change
+	_, changed, err = ApplyConfigMapServerSide(context.TODO(), client.CoreV1(), recorder, required, testFieldManager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed {
+		t.Error("expected no change")
+	}
+
+	// fields no longer required are removed
+	required.Data = map[string]string{"a": "3"}
+	actual, changed, err = ApplyConfigMapServerSide(context.TODO(), client.CoreV1(), recorder, required, testFieldManager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || !reflect.DeepEqual(actual.Data, required.Data) {
+		t.Errorf("expected updated config map, got changed=%v: %s", changed, spew.Sdump(actual))
+	}
+
+	if _, _, err := ApplyConfigMapServerSide(context.TODO(), client.CoreV1(), recorder, required, ""); err == nil {
+		t.Error("expected error for missing field manager")
+	}
+
+	var reasons []string
+	for _, event := range recorder.Events() {
+		reasons = append(reasons, event.Reason)
+	}
+	if expected := []string{"ConfigMapCreated", "ConfigMapUpdated"}; !reflect.DeepEqual(reasons, expected) {
+		t.Errorf("expected events %v, got %v", expected, reasons)
+	}
+}
+
+func TestServerSideApplyPatch(t *testing.T) {
+	required := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "ns",
+			Name:        "foo",
+			Annotations: map[string]string{"keep": "true", "remove-": ""},
+		},
+		Status: appsv1.DeploymentStatus{Replicas: 3},
+	}
+	patch, err := serverSideApplyPatch(required)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := map[string]interface{}{}
+	if err := json.Unmarshal(patch, &actual); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"namespace":   "ns",
+			"name":        "foo",
+			"annotations": map[string]interface{}{"keep": "true"},
+		},
+		"spec": map[string]interface{}{
+			"selector": nil,
+			"strategy": map[string]interface{}{},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"creationTimestamp": nil},
+				"spec":     map[string]interface{}{"containers": nil},
+			},
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected patch: %s", patch)
+	}
+	if _, ok := required.Annotations["remove-"]; !ok {
+		t.Error("required object must not be mutated")
+	}
+}
+
+func TestMigrateToServerSideApply(t *testing.T) {
+	client := fake.NewClientset()
+	recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+
+	// created by a client-side apply, with a field set by another actor
+	existing := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "foo"},
+		Data:       map[string]string{"a": "1", "b": "2"},
+	}
+	if _, err := client.CoreV1().ConfigMaps("ns").Create(context.TODO(), existing, metav1.CreateOptions{FieldManager: "test-operator"}); err != nil {
+		t.Fatal(err)
+	}
+	existing, err := client.CoreV1().ConfigMaps("ns").Get(context.TODO(), "foo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing.Data["c"] = "user"
+	if _, err := client.CoreV1().ConfigMaps("ns").Update(context.TODO(), existing, metav1.UpdateOptions{FieldManager: "user"}); err != nil {
+		t.Fatal(err)
+	}
+
+	migrated, err := MigrateToServerSideApply[*corev1.ConfigMap](context.TODO(), client.CoreV1().ConfigMaps("ns"), "foo", sets.New("test-operator"), testFieldManager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !migrated {
+		t.Fatal("expected managed fields to be migrated")
+	}
+	actual, err := client.CoreV1().ConfigMaps("ns").Get(context.TODO(), "foo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	managers := map[string]metav1.ManagedFieldsOperationType{}
+	for _, entry := range actual.ManagedFields {
+		managers[entry.Manager] = entry.Operation
+	}
+	if expected := map[string]metav1.ManagedFieldsOperationType{testFieldManager: "Apply", "user": "Update"}; !reflect.DeepEqual(managers, expected) {
+		t.Fatalf("unexpected managed fields: %s", spew.Sdump(actual.ManagedFields))
+	}
+
+	// nothing left to migrate
+	migrated, err = MigrateToServerSideApply[*corev1.ConfigMap](context.TODO(), client.CoreV1().ConfigMaps("ns"), "foo", sets.New("test-operator"), testFieldManager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated {
+		t.Error("expected no migration")
+	}
+
+	// the apply now owns the fields of the client-side apply and removes them, but not the fields of other actors
+	required := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "foo"},
+		Data:       map[string]string{"a": "1"},
+	}
+	actual, _, err = ApplyConfigMapServerSide(context.TODO(), client.CoreV1(), recorder, required, testFieldManager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := map[string]string{"a": "1", "c": "user"}; !reflect.DeepEqual(actual.Data, expected) {
+		t.Errorf("expected data %v, got %v", expected, actual.Data)
+	}
+}
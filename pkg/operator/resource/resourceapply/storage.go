@@ -37,7 +37,7 @@ func ApplyStorageClass(ctx context.Context, client storageclientv1.StorageClasse
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
 		actual, err := client.StorageClasses().Create(
-			ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*storagev1.StorageClass), metav1.CreateOptions{})
+			ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*storagev1.StorageClass), createOptions(ctx))
 		resourcehelper.ReportCreateEvent(recorder, required, err)
 		return actual, true, err
 	}
@@ -84,12 +84,12 @@ func ApplyStorageClass(ctx context.Context, client storageclientv1.StorageClasse
 
 	if storageClassNeedsRecreate(existingCopy, requiredCopy) {
 		requiredCopy.ObjectMeta.ResourceVersion = ""
-		err = client.StorageClasses().Delete(ctx, existingCopy.Name, metav1.DeleteOptions{})
+		err = client.StorageClasses().Delete(ctx, existingCopy.Name, deleteOptions(ctx))
 		resourcehelper.ReportDeleteEvent(recorder, requiredCopy, err, "Deleting StorageClass to re-create it with updated parameters")
 		if err != nil && !apierrors.IsNotFound(err) {
 			return existing, false, err
 		}
-		actual, err := client.StorageClasses().Create(ctx, requiredCopy, metav1.CreateOptions{})
+		actual, err := client.StorageClasses().Create(ctx, requiredCopy, createOptions(ctx))
 		if err != nil && apierrors.IsAlreadyExists(err) {
 			// Delete() few lines above did not really delete the object,
 			// the API server is probably waiting for a finalizer removal or so.
@@ -105,7 +105,7 @@ func ApplyStorageClass(ctx context.Context, client storageclientv1.StorageClasse
 	}
 
 	// Only mutable fields need a change
-	actual, err := client.StorageClasses().Update(ctx, requiredCopy, metav1.UpdateOptions{})
+	actual, err := client.StorageClasses().Update(ctx, requiredCopy, updateOptions(ctx))
 	resourcehelper.ReportUpdateEvent(recorder, required, err)
 	return actual, true, err
 }
@@ -156,7 +156,7 @@ func ApplyCSIDriver(ctx context.Context, client storageclientv1.CSIDriversGetter
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
 		actual, err := client.CSIDrivers().Create(
-			ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*storagev1.CSIDriver), metav1.CreateOptions{})
+			ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*storagev1.CSIDriver), createOptions(ctx))
 		resourcehelper.ReportCreateEvent(recorder, required, err)
 		return actual, true, err
 	}
@@ -190,7 +190,7 @@ func ApplyCSIDriver(ctx context.Context, client storageclientv1.CSIDriversGetter
 
 	if sameSpec {
 		// Update metadata by a simple Update call
-		actual, err := client.CSIDrivers().Update(ctx, existingCopy, metav1.UpdateOptions{})
+		actual, err := client.CSIDrivers().Update(ctx, existingCopy, updateOptions(ctx))
 		resourcehelper.ReportUpdateEvent(recorder, required, err)
 		return actual, true, err
 	}
@@ -198,12 +198,12 @@ func ApplyCSIDriver(ctx context.Context, client storageclientv1.CSIDriversGetter
 	existingCopy.Spec = required.Spec
 	existingCopy.ObjectMeta.ResourceVersion = ""
 	// Spec is read-only after creation. Delete and re-create the object
-	err = client.CSIDrivers().Delete(ctx, existingCopy.Name, metav1.DeleteOptions{})
+	err = client.CSIDrivers().Delete(ctx, existingCopy.Name, deleteOptions(ctx))
 	resourcehelper.ReportDeleteEvent(recorder, existingCopy, err, "Deleting CSIDriver to re-create it with updated parameters")
 	if err != nil && !apierrors.IsNotFound(err) {
 		return existing, false, err
 	}
-	actual, err := client.CSIDrivers().Create(ctx, existingCopy, metav1.CreateOptions{})
+	actual, err := client.CSIDrivers().Create(ctx, existingCopy, createOptions(ctx))
 	if err != nil && apierrors.IsAlreadyExists(err) {
 		// Delete() few lines above did not really delete the object,
 		// the API server is probably waiting for a finalizer removal or so.
@@ -239,7 +239,7 @@ func validateRequiredCSIDriverLabels(required *storagev1.CSIDriver) error {
 
 func DeleteStorageClass(ctx context.Context, client storageclientv1.StorageClassesGetter, recorder events.Recorder, required *storagev1.StorageClass) (*storagev1.StorageClass, bool,
 	error) {
-	err := client.StorageClasses().Delete(ctx, required.Name, metav1.DeleteOptions{})
+	err := client.StorageClasses().Delete(ctx, required.Name, deleteOptions(ctx))
 	if err != nil && apierrors.IsNotFound(err) {
 		return nil, false, nil
 	}
@@ -251,7 +251,7 @@ func DeleteStorageClass(ctx context.Context, client storageclientv1.StorageClass
 }
 
 func DeleteCSIDriver(ctx context.Context, client storageclientv1.CSIDriversGetter, recorder events.Recorder, required *storagev1.CSIDriver) (*storagev1.CSIDriver, bool, error) {
-	err := client.CSIDrivers().Delete(ctx, required.Name, metav1.DeleteOptions{})
+	err := client.CSIDrivers().Delete(ctx, required.Name, deleteOptions(ctx))
 	if err != nil && apierrors.IsNotFound(err) {
 		return nil, false, nil
 	}
@@ -113,6 +113,14 @@ type InstallerController struct {
 	installerBackOff func(count int) time.Duration
 	fallbackBackOff  func(count int) time.Duration
 
+	// rolloutStrategy configures canary and partitioned rollouts, nil to roll out to one node at a time
+	rolloutStrategy *RolloutStrategy
+	// rolloutHealthTargets and rolloutNodeTargetFn gate the rollout of a new revision on the health of the operand
+	rolloutHealthTargets HealthTargets
+	rolloutNodeTargetFn  func(nodeName string) string
+	// rolloutProgress is the progress of the rollout reported by the last manageInstallationPods
+	rolloutProgress *rolloutProgress
+
 	// track StaticPodOperatorStatus apply requests to perform live read during
 	// the next controller sync
 	podOperatorStatusApplied bool
@@ -482,6 +490,19 @@ func (c *InstallerController) manageInstallationPods(ctx context.Context, operat
 		return true, requeueAfter, nil, nil, nil
 	}
 
+	rollout, err := c.newRolloutPlan(ctx, operatorStatus, startNode)
+	if err != nil {
+		return true, 0, nil, nil, err
+	}
+	if rollout != nil {
+		c.rolloutProgress = &rollout.progress
+		startNodeState := &operatorStatus.NodeStatuses[startNode]
+		if rollout.canary >= 0 && startNodeState.TargetRevision <= startNodeState.CurrentRevision {
+			startNode = rollout.canary
+			nodeChoiceReason = fmt.Sprintf("node %s is the canary", operatorStatus.NodeStatuses[startNode].NodeName)
+		}
+	}
+
 	for l := 0; l < len(operatorStatus.NodeStatuses); l++ {
 		i := (startNode + l) % len(operatorStatus.NodeStatuses)
 
@@ -548,12 +569,25 @@ func (c *InstallerController) manageInstallationPods(ctx context.Context, operat
 			}
 
 			klog.V(2).Infof("%q is in transition to %d, but has not made progress because %s", currNodeState.NodeName, currNodeState.TargetRevision, reasonWithBlame(reason))
+			if rollout.startsMore() {
+				// the rollout strategy allows more than one node to install the latest revision at the same time
+				continue
+			}
 			return false, 0, nil, nil, nil
 		}
 
 		// here we are not in transition, i.e. there is no install pod running
 
 		revisionToStart := c.getRevisionToStart(currNodeState, prevNodeState, operatorStatus)
+		latest := operatorStatus.LatestAvailableRevision
+		if revisionToStart == 0 && rollout.startsMore() && currNodeState.CurrentRevision != latest && currNodeState.LastFailedRevision != latest {
+			// the previous node is still installing the latest revision, but the rollout strategy allows this one to start too
+			revisionToStart = latest
+		}
+		if revisionToStart != 0 && !rollout.allowsStart(i) {
+			klog.V(4).Infof("%s, but the rollout of revision %d does not allow node %s to start: %s", nodeChoiceReason, revisionToStart, currNodeState.NodeName, rollout.progress.message)
+			continue
+		}
 		if revisionToStart == 0 {
 			klog.V(4).Infof("%s, but node %s does not need update", nodeChoiceReason, currNodeState.NodeName)
 			continue
@@ -1169,6 +1203,7 @@ func (c *InstallerController) Sync(ctx context.Context, syncCtx factory.SyncCont
 	// Only manage installation pods when all required certs are present.
 	var updatedNode *operatorv1.NodeStatus
 	var updatedNodeReportOnSuccessfulUpdateFn func()
+	c.rolloutProgress = nil
 	if err == nil {
 		var requeue bool
 		var after time.Duration
@@ -1185,6 +1220,7 @@ func (c *InstallerController) Sync(ctx context.Context, syncCtx factory.SyncCont
 	// If required certs are missing, this will report degraded as we can't create installer pods because of this pre-condition.
 	nodeStatusApplyConfigurations := prepareNodeStatusApplyConfigurationFor(originalOperatorStatus.NodeStatuses, updatedNode)
 	operatorConditionApplyConfigurations := prepareNodeInstallerConditionApplyConfiguration(nodeStatusApplyConfigurations, originalOperatorStatus.LatestAvailableRevision)
+	operatorConditionApplyConfigurations = withRolloutProgress(operatorConditionApplyConfigurations, c.rolloutProgress)
 	operatorConditionApplyConfigurations = append(operatorConditionApplyConfigurations, prepareInstallerDegradedConditionApplyConfigurationFor(err))
 	status := applyoperatorv1.StaticPodOperatorStatus().
 		WithConditions(operatorConditionApplyConfigurations...).
@@ -1197,6 +1233,10 @@ func (c *InstallerController) Sync(ctx context.Context, syncCtx factory.SyncCont
 	} else if updatedNodeReportOnSuccessfulUpdateFn != nil {
 		updatedNodeReportOnSuccessfulUpdateFn()
 	}
+	if c.rolloutProgress != nil && c.rolloutProgress.requeueAfter > 0 {
+		// check again when the canary finished soaking
+		syncCtx.Queue().AddAfter(syncCtx.QueueKey(), c.rolloutProgress.requeueAfter)
+	}
 	return err
 }
 
@@ -0,0 +1,266 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	applyoperatorv1 "github.com/openshift/client-go/operator/applyconfigurations/operator/v1"
+	"github.com/openshift/library-go/pkg/monitor/health"
+	"github.com/openshift/library-go/pkg/operator/condition"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/ptr"
+)
+
+// RolloutStrategy configures how a new revision is rolled out to the nodes, see WithRolloutStrategy.
+// Without a rollout strategy, a new revision is rolled out to one node at a time.
+type RolloutStrategy struct {
+	// CanaryNode is the node a new revision is installed on first. If empty while SoakDuration is set, the canary is the
+	// node the installer would update first.
+	CanaryNode string
+	// SoakDuration is how long the canary must be ready and healthy before the revision is rolled out to the other nodes.
+	SoakDuration time.Duration
+	// MaxUnavailable is the number or the percentage of nodes that may be updating or unavailable at the same time
+	// after the canary, 1 if unset. Percentages are rounded down, to at least one node.
+	MaxUnavailable *intstr.IntOrString
+}
+
+func (s *RolloutStrategy) hasCanary() bool {
+	return len(s.CanaryNode) > 0 || s.SoakDuration > 0
+}
+
+// WithRolloutStrategy installs a new revision on a canary node first and rolls it out to several nodes at a time.
+func (c *InstallerController) WithRolloutStrategy(strategy RolloutStrategy) *InstallerController {
+	c.rolloutStrategy = &strategy
+	return c
+}
+
+// HealthTargets reports the health of the probed targets, like a health.Prober probing the operand of every node.
+type HealthTargets interface {
+	Targets() (healthy []string, unhealthy []string)
+}
+
+var _ HealthTargets = &health.Prober{}
+
+// WithRolloutHealthChecks gates the rollout of a new revision on the health of the operand. The revision is not
+// installed on the next node before the nodes at the new revision are healthy, and the rollout stops when one of them
+// is unhealthy. nodeTargetFn returns the target probed for the operand of a node.
+func (c *InstallerController) WithRolloutHealthChecks(healthTargets HealthTargets, nodeTargetFn func(nodeName string) string) *InstallerController {
+	c.rolloutHealthTargets = healthTargets
+	c.rolloutNodeTargetFn = nodeTargetFn
+	return c
+}
+
+// rolloutProgress is the state of the rollout reported on the NodeInstallerProgressing condition.
+type rolloutProgress struct {
+	reason  string
+	message string
+	// requeueAfter is when the canary finishes soaking
+	requeueAfter time.Duration
+}
+
+// rolloutPlan decides which nodes may start installing the latest revision.
+type rolloutPlan struct {
+	// canary is the index of the node that must install the latest revision first, -1 once the rollout started
+	canary int
+	// blocked is true while the canary soaks or when the rollout is halted
+	blocked bool
+	// halted is true when a node failed to install the latest revision or is unhealthy at it. Only the nodes which
+	// failed to install it may retry.
+	halted  bool
+	retries sets.Set[int]
+	// slots is the number of nodes that may start installing the latest revision
+	slots int
+	// unavailable are the indexes of the nodes that are updating, not ready or not healthy
+	unavailable sets.Set[int]
+	progress    rolloutProgress
+}
+
+// startsMore returns true if more nodes may start installing the latest revision.
+func (p *rolloutPlan) startsMore() bool {
+	return p != nil && !p.blocked && p.slots > 0
+}
+
+// allowsStart returns true if the node at the given index may start installing a new revision.
+func (p *rolloutPlan) allowsStart(i int) bool {
+	if p == nil {
+		return true
+	}
+	if p.halted {
+		// the installer retries the failed nodes with backoff
+		return p.retries.Has(i)
+	}
+	if p.blocked || (p.canary >= 0 && p.canary != i) {
+		return false
+	}
+	// replacing an unavailable node does not make fewer nodes available
+	return p.slots > 0 || p.unavailable.Has(i)
+}
+
+func (p *rolloutPlan) halt(messageFmt string, args ...interface{}) {
+	if p.halted {
+		return
+	}
+	p.halted = true
+	p.blocked = true
+	p.progress = rolloutProgress{reason: "RolloutHalted", message: fmt.Sprintf(messageFmt, args...)}
+}
+
+// newRolloutPlan returns the plan of the rollout of the latest revision, nil without a rollout strategy and health checks.
+func (c *InstallerController) newRolloutPlan(ctx context.Context, operatorStatus *operatorv1.StaticPodOperatorStatus, startNode int) (*rolloutPlan, error) {
+	if c.rolloutStrategy == nil && c.rolloutHealthTargets == nil {
+		return nil, nil
+	}
+	strategy := &RolloutStrategy{}
+	if c.rolloutStrategy != nil {
+		strategy = c.rolloutStrategy
+	}
+
+	nodes := operatorStatus.NodeStatuses
+	latest := operatorStatus.LatestAvailableRevision
+	canary := startNode
+	if len(strategy.CanaryNode) > 0 {
+		canary = -1
+		for i := range nodes {
+			if nodes[i].NodeName == strategy.CanaryNode {
+				canary = i
+			}
+		}
+		if canary < 0 {
+			return nil, fmt.Errorf("invalid rollout strategy: canary node %q does not exist", strategy.CanaryNode)
+		}
+	}
+	maxUnavailable := 1
+	if strategy.MaxUnavailable != nil {
+		var err error
+		maxUnavailable, err = intstr.GetScaledValueFromIntOrPercent(strategy.MaxUnavailable, len(nodes), false)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rollout strategy maxUnavailable: %w", err)
+		}
+		if maxUnavailable < 1 {
+			maxUnavailable = 1
+		}
+	}
+
+	healthy, unhealthy := sets.New[string](), sets.New[string]()
+	if c.rolloutHealthTargets != nil {
+		healthyTargets, unhealthyTargets := c.rolloutHealthTargets.Targets()
+		healthy.Insert(healthyTargets...)
+		unhealthy.Insert(unhealthyTargets...)
+	}
+
+	plan := &rolloutPlan{canary: -1, unavailable: sets.New[int](), retries: sets.New[int]()}
+	// started are the nodes at or installing the latest revision
+	var started []int
+	// readySince is when the nodes at the latest revision became ready and healthy
+	readySince := map[int]time.Time{}
+	for i := range nodes {
+		node := &nodes[i]
+		if node.CurrentRevision == latest || node.TargetRevision == latest {
+			started = append(started, i)
+		}
+		if node.LastFailedRevision == latest && node.CurrentRevision != latest {
+			plan.retries.Insert(i)
+			plan.halt("node %s failed to install revision %d", node.NodeName, latest)
+		}
+		if node.TargetRevision > node.CurrentRevision {
+			plan.unavailable.Insert(i)
+			continue
+		}
+
+		state, revision, _, _, ts, err := c.getStaticPodState(ctx, node.NodeName)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		if err != nil || state != staticPodStateReady {
+			plan.unavailable.Insert(i)
+			continue
+		}
+		if c.rolloutHealthTargets != nil && revision == strconv.Itoa(int(latest)) {
+			switch target := c.rolloutNodeTargetFn(node.NodeName); {
+			case unhealthy.Has(target):
+				plan.halt("node %s at revision %d is unhealthy", node.NodeName, latest)
+				plan.unavailable.Insert(i)
+				continue
+			case !healthy.Has(target):
+				plan.unavailable.Insert(i)
+				continue
+			}
+		}
+		if revision == strconv.Itoa(int(latest)) {
+			readySince[i] = ts
+		}
+	}
+	if plan.blocked {
+		return plan, nil
+	}
+
+	if strategy.hasCanary() && len(nodes) > 1 {
+		switch len(started) {
+		case 0:
+			plan.canary = canary
+			plan.slots = 1
+			plan.progress = rolloutProgress{
+				reason:  "CanaryRollout",
+				message: fmt.Sprintf("revision %d is installed on canary node %s first", latest, nodes[plan.canary].NodeName),
+			}
+			return plan, nil
+
+		case 1:
+			canary := &nodes[started[0]]
+			if canary.CurrentRevision != latest {
+				plan.progress = rolloutProgress{
+					reason:  "CanaryRollout",
+					message: fmt.Sprintf("revision %d is being installed on canary node %s", latest, canary.NodeName),
+				}
+				return plan, nil
+			}
+			since, ready := readySince[started[0]]
+			if !ready {
+				plan.blocked = true
+				plan.progress = rolloutProgress{
+					reason:  "CanaryNotReady",
+					message: fmt.Sprintf("waiting for canary node %s at revision %d to be ready", canary.NodeName, latest),
+				}
+				return plan, nil
+			}
+			if soaking := strategy.SoakDuration - c.clock.Since(since); soaking > 0 {
+				plan.blocked = true
+				plan.progress = rolloutProgress{
+					reason:       "CanarySoaking",
+					message:      fmt.Sprintf("canary node %s at revision %d is soaking for %s", canary.NodeName, latest, soaking.Round(time.Second)),
+					requeueAfter: soaking,
+				}
+				return plan, nil
+			}
+		}
+	}
+
+	plan.slots = maxUnavailable - plan.unavailable.Len()
+	if maxUnavailable > 1 {
+		plan.progress = rolloutProgress{
+			reason:  "PartitionedRollout",
+			message: fmt.Sprintf("revision %d is installed on up to %d nodes at a time", latest, maxUnavailable),
+		}
+	}
+	return plan, nil
+}
+
+// withRolloutProgress reports the progress of the rollout on the NodeInstallerProgressing condition.
+func withRolloutProgress(conditions []*applyoperatorv1.OperatorConditionApplyConfiguration, progress *rolloutProgress) []*applyoperatorv1.OperatorConditionApplyConfiguration {
+	if progress == nil || len(progress.reason) == 0 {
+		return conditions
+	}
+	for _, c := range conditions {
+		if ptr.Deref(c.Type, "") != condition.NodeInstallerProgressingConditionType || ptr.Deref(c.Status, "") != operatorv1.ConditionTrue {
+			continue
+		}
+		c.WithReason(progress.reason).WithMessage(fmt.Sprintf("%s; %s", ptr.Deref(c.Message, ""), progress.message))
+	}
+	return conditions
+}
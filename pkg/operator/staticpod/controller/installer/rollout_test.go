@@ -0,0 +1,263 @@
+package installer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	applyoperatorv1 "github.com/openshift/client-go/operator/applyconfigurations/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/condition"
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	"github.com/openshift/library-go/pkg/operator/staticpod/controller/revision"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+)
+
+type fakeHealthTargets struct {
+	healthy, unhealthy []string
+}
+
+func (f *fakeHealthTargets) Targets() ([]string, []string) {
+	return f.healthy, f.unhealthy
+}
+
+func TestRollout(t *testing.T) {
+	now := time.Now()
+	nodesAtRevision1 := func() []operatorv1.NodeStatus {
+		return []operatorv1.NodeStatus{
+			{NodeName: "test-node-0", CurrentRevision: 1},
+			{NodeName: "test-node-1", CurrentRevision: 1},
+			{NodeName: "test-node-2", CurrentRevision: 1},
+		}
+	}
+	readyPods := func(revisions ...int) []runtime.Object {
+		var pods []runtime.Object
+		for i, node := range []string{"test-node-0", "test-node-1", "test-node-2"} {
+			pods = append(pods, newStaticPodWithReadyTime(mirrorPodNameForNode("test-pod", node), revisions[i], corev1.PodRunning, true, now.Add(-time.Hour)))
+		}
+		return pods
+	}
+	canaryAtRevision2 := func(readyTime time.Time) []runtime.Object {
+		pods := readyPods(1, 1, 2)
+		pods[2] = newStaticPodWithReadyTime(mirrorPodNameForNode("test-pod", "test-node-2"), 2, corev1.PodRunning, true, readyTime)
+		return pods
+	}
+	withNode := func(nodes []operatorv1.NodeStatus, i int, mutate func(*operatorv1.NodeStatus)) []operatorv1.NodeStatus {
+		mutate(&nodes[i])
+		return nodes
+	}
+	canary := &RolloutStrategy{CanaryNode: "test-node-2", SoakDuration: 10 * time.Minute}
+	partitioned := func(maxUnavailable intstr.IntOrString) *RolloutStrategy {
+		return &RolloutStrategy{MaxUnavailable: &maxUnavailable}
+	}
+
+	testCases := []struct {
+		name           string
+		strategy       *RolloutStrategy
+		health         *fakeHealthTargets
+		nodes          []operatorv1.NodeStatus
+		pods           []runtime.Object
+		expectedNode   string
+		expectedReason string
+		expectedSoak   time.Duration
+	}{
+		{
+			name:         "without a strategy the rollout is unchanged",
+			nodes:        nodesAtRevision1(),
+			pods:         readyPods(1, 1, 1),
+			expectedNode: "test-node-0",
+		},
+		{
+			name:           "the canary node is updated first",
+			strategy:       canary,
+			nodes:          nodesAtRevision1(),
+			pods:           readyPods(1, 1, 1),
+			expectedNode:   "test-node-2",
+			expectedReason: "CanaryRollout",
+		},
+		{
+			name:           "the other nodes wait for the canary to soak",
+			strategy:       canary,
+			nodes:          withNode(nodesAtRevision1(), 2, func(n *operatorv1.NodeStatus) { n.CurrentRevision = 2 }),
+			pods:           canaryAtRevision2(now.Add(-time.Minute)),
+			expectedReason: "CanarySoaking",
+			expectedSoak:   9 * time.Minute,
+		},
+		{
+			name:           "the other nodes wait for the canary to be ready",
+			strategy:       canary,
+			nodes:          withNode(nodesAtRevision1(), 2, func(n *operatorv1.NodeStatus) { n.CurrentRevision = 2 }),
+			pods:           append(readyPods(1, 1, 1)[:2], newStaticPod(mirrorPodNameForNode("test-pod", "test-node-2"), 2, corev1.PodRunning, false)),
+			expectedReason: "CanaryNotReady",
+		},
+		{
+			name:         "the rollout continues after the canary soaked",
+			strategy:     canary,
+			nodes:        withNode(nodesAtRevision1(), 2, func(n *operatorv1.NodeStatus) { n.CurrentRevision = 2 }),
+			pods:         canaryAtRevision2(now.Add(-20 * time.Minute)),
+			expectedNode: "test-node-0",
+		},
+		{
+			name:           "an unhealthy canary halts the rollout",
+			strategy:       canary,
+			health:         &fakeHealthTargets{healthy: []string{"test-node-0", "test-node-1"}, unhealthy: []string{"test-node-2"}},
+			nodes:          withNode(nodesAtRevision1(), 2, func(n *operatorv1.NodeStatus) { n.CurrentRevision = 2 }),
+			pods:           canaryAtRevision2(now.Add(-20 * time.Minute)),
+			expectedReason: "RolloutHalted",
+		},
+		{
+			name:           "the canary is soaked only once healthy",
+			strategy:       canary,
+			health:         &fakeHealthTargets{healthy: []string{"test-node-0", "test-node-1"}},
+			nodes:          withNode(nodesAtRevision1(), 2, func(n *operatorv1.NodeStatus) { n.CurrentRevision = 2 }),
+			pods:           canaryAtRevision2(now.Add(-20 * time.Minute)),
+			expectedReason: "CanaryNotReady",
+		},
+		{
+			name:     "a failed revision halts the rollout",
+			strategy: partitioned(intstr.FromInt32(2)),
+			nodes: withNode(withNode(nodesAtRevision1(), 1, func(n *operatorv1.NodeStatus) {
+				n.TargetRevision = 2
+				n.LastFailedRevision = 2
+				n.LastFailedCount = 1
+				n.LastFailedTime = &metav1.Time{Time: now.Add(-time.Second)}
+			}), 2, func(n *operatorv1.NodeStatus) { n.CurrentRevision = 2 }),
+			pods:           readyPods(1, 1, 2),
+			expectedReason: "RolloutHalted",
+		},
+		{
+			name:     "the failed node is retried while the rollout is halted",
+			strategy: partitioned(intstr.FromInt32(2)),
+			nodes: withNode(nodesAtRevision1(), 1, func(n *operatorv1.NodeStatus) {
+				n.LastFailedRevision = 2
+				n.LastFailedTime = &metav1.Time{Time: now.Add(-time.Hour)}
+			}),
+			pods:           readyPods(1, 1, 1),
+			expectedNode:   "test-node-1",
+			expectedReason: "RolloutHalted",
+		},
+		{
+			name:           "a partitioned rollout updates another node while one is updating",
+			strategy:       partitioned(intstr.FromInt32(2)),
+			nodes:          withNode(nodesAtRevision1(), 0, func(n *operatorv1.NodeStatus) { n.TargetRevision = 2 }),
+			pods:           readyPods(1, 1, 1),
+			expectedNode:   "test-node-1",
+			expectedReason: "PartitionedRollout",
+		},
+		{
+			name:     "a partitioned rollout does not exceed the unavailable nodes",
+			strategy: partitioned(intstr.FromString("50%")),
+			nodes:    withNode(nodesAtRevision1(), 0, func(n *operatorv1.NodeStatus) { n.TargetRevision = 2 }),
+			pods:     readyPods(1, 1, 1),
+		},
+		{
+			name:   "health checks wait for the updated node to be healthy",
+			health: &fakeHealthTargets{healthy: []string{"test-node-1", "test-node-2"}},
+			nodes:  withNode(nodesAtRevision1(), 0, func(n *operatorv1.NodeStatus) { n.CurrentRevision = 2 }),
+			pods:   readyPods(2, 1, 1),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset(tc.pods...)
+			fakeClock := clocktesting.NewFakeClock(now)
+			c := &InstallerController{
+				targetNamespace:       "test",
+				staticPodName:         "test-pod",
+				configMaps:            []revision.RevisionResource{{Name: "test-config"}},
+				podsGetter:            kubeClient.CoreV1(),
+				eventRecorder:         eventstesting.NewTestingEventRecorder(t),
+				installerPodImageFn:   func() string { return "docker.io/foo/bar" },
+				ownerRefsFn:           func(ctx context.Context, revision int32) ([]metav1.OwnerReference, error) { return nil, nil },
+				startupMonitorEnabled: func() (bool, error) { return false, nil },
+				clock:                 fakeClock,
+				now:                   fakeClock.Now,
+				installerBackOff:      backOffDuration(10*time.Second, 1.5, 10*time.Minute),
+				fallbackBackOff:       backOffDuration(10*time.Second, 1.5, 10*time.Minute),
+			}
+			if tc.strategy != nil {
+				c.WithRolloutStrategy(*tc.strategy)
+			}
+			if tc.health != nil {
+				c.WithRolloutHealthChecks(tc.health, func(nodeName string) string { return nodeName })
+			}
+
+			_, _, updatedNode, _, err := c.manageInstallationPods(context.TODO(), &operatorv1.StaticPodOperatorSpec{}, &operatorv1.StaticPodOperatorStatus{
+				OperatorStatus: operatorv1.OperatorStatus{LatestAvailableRevision: 2},
+				NodeStatuses:   tc.nodes,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch {
+			case updatedNode == nil && len(tc.expectedNode) > 0:
+				t.Errorf("expected node %s to be updated, got none", tc.expectedNode)
+			case updatedNode != nil && updatedNode.NodeName != tc.expectedNode:
+				t.Errorf("expected node %q to be updated, got %s", tc.expectedNode, updatedNode.NodeName)
+			case updatedNode != nil && updatedNode.TargetRevision != 2:
+				t.Errorf("expected node %s to be updated to revision 2, got %d", updatedNode.NodeName, updatedNode.TargetRevision)
+			}
+
+			var progress rolloutProgress
+			if c.rolloutProgress != nil {
+				progress = *c.rolloutProgress
+			}
+			if progress.reason != tc.expectedReason {
+				t.Errorf("expected rollout reason %q, got %q: %s", tc.expectedReason, progress.reason, progress.message)
+			}
+			if progress.requeueAfter != tc.expectedSoak {
+				t.Errorf("expected a requeue after %s, got %s", tc.expectedSoak, progress.requeueAfter)
+			}
+		})
+	}
+}
+
+func TestRolloutWithUnknownCanaryNode(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(newStaticPod(mirrorPodNameForNode("test-pod", "test-node-0"), 1, corev1.PodRunning, true))
+	c := (&InstallerController{
+		targetNamespace: "test",
+		staticPodName:   "test-pod",
+		podsGetter:      kubeClient.CoreV1(),
+		clock:           clocktesting.NewFakeClock(time.Now()),
+	}).WithRolloutStrategy(RolloutStrategy{CanaryNode: "test-node-1"})
+
+	_, _, updatedNode, _, err := c.manageInstallationPods(context.TODO(), &operatorv1.StaticPodOperatorSpec{}, &operatorv1.StaticPodOperatorStatus{
+		OperatorStatus: operatorv1.OperatorStatus{LatestAvailableRevision: 2},
+		NodeStatuses:   []operatorv1.NodeStatus{{NodeName: "test-node-0", CurrentRevision: 1}},
+	})
+	if err == nil || !strings.Contains(err.Error(), `canary node "test-node-1" does not exist`) {
+		t.Errorf("expected an unknown canary node to fail, got %v", err)
+	}
+	if updatedNode != nil {
+		t.Errorf("expected no node to be updated, got %s", updatedNode.NodeName)
+	}
+}
+
+func TestWithRolloutProgress(t *testing.T) {
+	conditions := prepareNodeInstallerConditionApplyConfiguration([]*applyoperatorv1.NodeStatusApplyConfiguration{
+		applyoperatorv1.NodeStatus().WithNodeName("test-node-0").WithCurrentRevision(2),
+		applyoperatorv1.NodeStatus().WithNodeName("test-node-1").WithCurrentRevision(1),
+	}, 2)
+	conditions = withRolloutProgress(conditions, &rolloutProgress{reason: "CanarySoaking", message: "canary node test-node-0 at revision 2 is soaking for 5m0s"})
+
+	for _, c := range conditions {
+		if ptr.Deref(c.Type, "") != condition.NodeInstallerProgressingConditionType {
+			if ptr.Deref(c.Reason, "") == "CanarySoaking" {
+				t.Errorf("expected only the progressing condition to report the rollout, got %s", ptr.Deref(c.Type, ""))
+			}
+			continue
+		}
+		if ptr.Deref(c.Reason, "") != "CanarySoaking" || !strings.HasSuffix(ptr.Deref(c.Message, ""), "; canary node test-node-0 at revision 2 is soaking for 5m0s") {
+			t.Errorf("unexpected progressing condition %s: %s", ptr.Deref(c.Reason, ""), ptr.Deref(c.Message, ""))
+		}
+		return
+	}
+	t.Errorf("expected a progressing condition")
+}
@@ -68,6 +68,10 @@ type InstallOptions struct {
 
 	PodMutationFns []PodMutationFunc
 
+	// EventJournalDir is an optional directory where events are spooled while the API server is unreachable.
+	// The events are created once the API server is reachable, by this or a later installer using the same directory.
+	EventJournalDir string
+
 	KubeletVersion string
 }
 
@@ -148,6 +152,7 @@ func (o *InstallOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringSliceVar(&o.OptionalCertSecretNamePrefixes, "optional-cert-secrets", o.OptionalCertSecretNamePrefixes, "list of optional secret names to be included")
 	fs.StringSliceVar(&o.OptionalCertConfigMapNamePrefixes, "optional-cert-configmaps", o.OptionalCertConfigMapNamePrefixes, "list of optional configmaps to be included")
 	fs.StringVar(&o.CertDir, "cert-dir", o.CertDir, "directory for all certs")
+	fs.StringVar(&o.EventJournalDir, "event-journal-dir", o.EventJournalDir, "directory to spool events to while the API server is unreachable, events are not spooled if empty")
 }
 
 func (o *InstallOptions) Complete() error {
@@ -444,7 +449,8 @@ func (o *InstallOptions) Run(ctx context.Context) error {
 		klog.Infof("Got kubelet version %s on target node %s", o.KubeletVersion, o.NodeName)
 	}
 
-	recorder := events.NewRecorder(o.KubeClient.CoreV1().Events(o.Namespace), "static-pod-installer", eventTarget, o.Clock)
+	recorder := o.newEventRecorder(eventTarget)
+	defer recorder.Shutdown()
 	if err := o.copyContent(ctx); err != nil {
 		recorder.Warningf("StaticPodInstallerFailed", "Installing revision %s: %v", o.Revision, err)
 		return fmt.Errorf("failed to copy: %v", err)
@@ -454,6 +460,20 @@ func (o *InstallOptions) Run(ctx context.Context) error {
 	return nil
 }
 
+// newEventRecorder returns a recorder for the installer events, spooling them to the event journal if one is configured.
+func (o *InstallOptions) newEventRecorder(eventTarget *corev1.ObjectReference) events.Recorder {
+	client := o.KubeClient.CoreV1().Events(o.Namespace)
+	if len(o.EventJournalDir) == 0 {
+		return events.NewRecorder(client, "static-pod-installer", eventTarget, o.Clock)
+	}
+	recorder, err := events.NewJournalRecorder(client, events.JournalOptions{Dir: o.EventJournalDir}, "static-pod-installer", eventTarget, o.Clock)
+	if err != nil {
+		klog.Warningf("unable to open the event journal (events will not be spooled): %v", err)
+		return events.NewRecorder(client, "static-pod-installer", eventTarget, o.Clock)
+	}
+	return recorder
+}
+
 func (o *InstallOptions) waitForOtherInstallerRevisionsToSettle(ctx context.Context) error {
 	currRevision64, err := strconv.ParseInt(o.Revision, 10, 32)
 	if err != nil {
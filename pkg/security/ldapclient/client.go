@@ -106,29 +106,33 @@ func ldapDialTLS(network, addr string, config *tls.Config) (*ldap.Conn, error) {
 // be made (or successfully upgraded to TLS). If no error is returned, the caller is responsible for
 // closing the connection
 func (l *ldapClientConfig) Connect() (ldap.Client, error) {
-	tlsConfig := l.tlsConfig
+	return connect(l.scheme, l.host, l.insecure, l.tlsConfig)
+}
 
+// connect establishes a connection to the LDAP server at host. Unless insecure is set, ldap://
+// connections are upgraded to TLS with StartTLS.
+func connect(scheme ldaputil.Scheme, host string, insecure bool, tlsConfig *tls.Config) (ldap.Client, error) {
 	// Ensure tlsConfig specifies the server we're connecting to
 	if tlsConfig != nil && !tlsConfig.InsecureSkipVerify && len(tlsConfig.ServerName) == 0 {
 		// Add to a copy of the tlsConfig to avoid mutating the original
 		c := tlsConfig.Clone()
-		if host, _, err := net.SplitHostPort(l.host); err == nil {
-			c.ServerName = host
+		if serverName, _, err := net.SplitHostPort(host); err == nil {
+			c.ServerName = serverName
 		} else {
-			c.ServerName = l.host
+			c.ServerName = host
 		}
 		tlsConfig = c
 	}
 
-	switch l.scheme {
+	switch scheme {
 	case ldaputil.SchemeLDAP:
-		con, err := ldapDial("tcp", l.host)
+		con, err := ldapDial("tcp", host)
 		if err != nil {
 			return nil, err
 		}
 
 		// If an insecure connection is desired, we're done
-		if l.insecure {
+		if insecure {
 			return con, nil
 		}
 
@@ -143,10 +147,10 @@ func (l *ldapClientConfig) Connect() (ldap.Client, error) {
 		return con, nil
 
 	case ldaputil.SchemeLDAPS:
-		return ldapDialTLS("tcp", l.host, tlsConfig)
+		return ldapDialTLS("tcp", host, tlsConfig)
 
 	default:
-		return nil, fmt.Errorf("unsupported scheme %q", l.scheme)
+		return nil, fmt.Errorf("unsupported scheme %q", scheme)
 	}
 }
 
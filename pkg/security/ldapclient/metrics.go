@@ -0,0 +1,100 @@
+package ldapclient
+
+import (
+	"time"
+
+	k8smetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	metricsNamespace = "openshift"
+	metricsSubsystem = "ldap_client"
+)
+
+// metrics provides access to all pooled LDAP client metrics.
+var metrics *poolMetrics
+
+func init() {
+	metrics = newPoolMetrics(legacyregistry.Register)
+}
+
+// poolMetrics instruments pooled LDAP clients with prometheus metrics, labeled with the LDAP server.
+type poolMetrics struct {
+	requestDuration *k8smetrics.HistogramVec
+	requestErrors   *k8smetrics.CounterVec
+	dials           *k8smetrics.CounterVec
+	serverUp        *k8smetrics.GaugeVec
+}
+
+func newPoolMetrics(registerFunc func(k8smetrics.Registerable) error) *poolMetrics {
+	requestDuration := k8smetrics.NewHistogramVec(
+		&k8smetrics.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "request_duration_seconds",
+			Help:      "How long an LDAP operation takes in seconds, labeled with the server URL and the operation",
+			Buckets:   k8smetrics.ExponentialBuckets(0.001, 2, 15),
+		}, []string{"server", "operation"})
+	registerFunc(requestDuration)
+
+	requestErrors := k8smetrics.NewCounterVec(
+		&k8smetrics.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "request_errors_total",
+			Help:      "The total number of failed LDAP operations, labeled with the server URL and the operation",
+		}, []string{"server", "operation"})
+	registerFunc(requestErrors)
+
+	dials := k8smetrics.NewCounterVec(
+		&k8smetrics.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "dials_total",
+			Help:      "The total number of connections opened to an LDAP server, labeled with the server URL and the result",
+		}, []string{"server", "result"})
+	registerFunc(dials)
+
+	serverUp := k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "server_up",
+			Help:      "Whether an LDAP server is considered healthy (1) or is backed off after failures (0), labeled with the server URL",
+		}, []string{"server"})
+	registerFunc(serverUp)
+
+	return &poolMetrics{
+		requestDuration: requestDuration,
+		requestErrors:   requestErrors,
+		dials:           dials,
+		serverUp:        serverUp,
+	}
+}
+
+// observeRequest records the duration and result of an operation which started at the given time.
+func (m *poolMetrics) observeRequest(server, operation string, start time.Time, err error) {
+	m.requestDuration.WithLabelValues(server, operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.requestErrors.WithLabelValues(server, operation).Inc()
+	}
+}
+
+// observeDial records the result of opening a connection to a server.
+func (m *poolMetrics) observeDial(server string, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	m.dials.WithLabelValues(server, result).Inc()
+}
+
+// observeHealth records whether a server is healthy.
+func (m *poolMetrics) observeHealth(server string, healthy bool) {
+	up := 0.0
+	if healthy {
+		up = 1
+	}
+	m.serverUp.WithLabelValues(server).Set(up)
+}
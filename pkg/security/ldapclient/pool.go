@@ -0,0 +1,491 @@
+package ldapclient
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/security/ldaputil"
+)
+
+const (
+	defaultMaxIdleConnections = 2
+	defaultMinBackoff         = time.Second
+	defaultMaxBackoff         = 5 * time.Minute
+)
+
+// PooledConfigOptions configures a pooled LDAP client config.
+type PooledConfigOptions struct {
+	// URLs are the LDAP servers in order of preference. Connections are made to the first healthy server.
+	URLs []string
+
+	// BindDN and BindPassword are optional credentials that connections are bound with.
+	BindDN       string
+	BindPassword string
+	// BindCredentialsFunc optionally returns the current bind credentials, overriding BindDN and
+	// BindPassword, so that rotated credentials are used without recreating the config.
+	BindCredentialsFunc func() (bindDN, bindPassword string, err error)
+
+	// CA is an optional file holding the CA bundle that verifies the servers.
+	CA string
+	// Insecure keeps ldap:// connections in plain text. Otherwise they are upgraded with StartTLS.
+	Insecure bool
+
+	// MaxIdleConnections is the number of idle connections kept per server, 2 if unset.
+	MaxIdleConnections int
+	// MinBackoff and MaxBackoff bound how long a failing server is skipped, doubling with every
+	// consecutive failure. They default to one second and five minutes.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// PooledConfig is a Config whose connections are reused and fail over between several LDAP servers.
+// Closing a client returned by Connect returns its connection to the pool, unless the connection
+// failed or was bound with other credentials than those of the config.
+type PooledConfig struct {
+	servers             []*pooledServer
+	bindDN              string
+	bindPassword        string
+	bindCredentialsFunc func() (string, string, error)
+	maxIdle             int
+	minBackoff          time.Duration
+	maxBackoff          time.Duration
+
+	// dial and now are replaced in tests
+	dial func(server *pooledServer) (ldap.Client, error)
+	now  func() time.Time
+
+	// lock guards the state of the servers and the pool
+	lock   sync.Mutex
+	closed bool
+}
+
+// pooledServer is an LDAP server of a pool.
+type pooledServer struct {
+	// url is scheme://host, it identifies the server in logs and metrics
+	url       string
+	scheme    ldaputil.Scheme
+	host      string
+	insecure  bool
+	tlsConfig *tls.Config
+
+	// failures is the number of consecutive failures of the server
+	failures int
+	// retryAfter is when the server is tried again after failures
+	retryAfter time.Time
+	// idle holds connections ready to be reused
+	idle []*pooledConn
+}
+
+// pooledConn is a connection with the credentials it is bound with.
+type pooledConn struct {
+	ldap.Client
+	bindDN       string
+	bindPassword string
+}
+
+var _ Config = &PooledConfig{}
+
+// NewPooledLDAPClientConfig returns a pooled LDAP client config for the given servers.
+func NewPooledLDAPClientConfig(options PooledConfigOptions) (*PooledConfig, error) {
+	if len(options.URLs) == 0 {
+		return nil, fmt.Errorf("at least one LDAP server URL is required")
+	}
+
+	tlsConfig := &tls.Config{}
+	if len(options.CA) > 0 {
+		roots, err := cert.NewPool(options.CA)
+		if err != nil {
+			return nil, fmt.Errorf("error loading cert pool from ca file %s: %v", options.CA, err)
+		}
+		tlsConfig.RootCAs = roots
+	}
+
+	config := &PooledConfig{
+		bindDN:              options.BindDN,
+		bindPassword:        options.BindPassword,
+		bindCredentialsFunc: options.BindCredentialsFunc,
+		maxIdle:             options.MaxIdleConnections,
+		minBackoff:          options.MinBackoff,
+		maxBackoff:          options.MaxBackoff,
+		dial:                dialServer,
+		now:                 time.Now,
+	}
+	if config.maxIdle <= 0 {
+		config.maxIdle = defaultMaxIdleConnections
+	}
+	if config.minBackoff <= 0 {
+		config.minBackoff = defaultMinBackoff
+	}
+	if config.maxBackoff < config.minBackoff {
+		config.maxBackoff = defaultMaxBackoff
+	}
+	for _, rawURL := range options.URLs {
+		url, err := ldaputil.ParseURL(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing URL %q: %v", rawURL, err)
+		}
+		server := &pooledServer{
+			url:       fmt.Sprintf("%s://%s", url.Scheme, url.Host),
+			scheme:    url.Scheme,
+			host:      url.Host,
+			insecure:  options.Insecure,
+			tlsConfig: tlsConfig,
+		}
+		config.servers = append(config.servers, server)
+		metrics.observeHealth(server.url, true)
+	}
+	return config, nil
+}
+
+func dialServer(server *pooledServer) (ldap.Client, error) {
+	return connect(server.scheme, server.host, server.insecure, server.tlsConfig)
+}
+
+// Connect returns a client for the first healthy server, reusing an idle connection if possible.
+// The connection is already bound with the bind credentials. Servers that fail to connect are
+// backed off and the next server is tried.
+func (p *PooledConfig) Connect() (ldap.Client, error) {
+	bindDN, bindPassword := p.GetBindCredentials()
+
+	var errs []error
+	for _, server := range p.candidates() {
+		conn, failover, err := p.connect(server, bindDN, bindPassword)
+		if err == nil {
+			return &pooledClient{pool: p, server: server, conn: conn}, nil
+		}
+		if !failover {
+			return nil, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", server.url, err))
+	}
+	return nil, fmt.Errorf("no LDAP server is available: %w", utilerrors.NewAggregate(errs))
+}
+
+// connect returns a connection to the server bound with the given credentials. It returns whether
+// the error is a failure of the server, so another server should be tried.
+func (p *PooledConfig) connect(server *pooledServer, bindDN, bindPassword string) (*pooledConn, bool, error) {
+	if conn := p.takeIdle(server, bindDN, bindPassword); conn != nil {
+		return conn, false, nil
+	}
+
+	client, err := p.dial(server)
+	metrics.observeDial(server.url, err)
+	if err != nil {
+		p.markFailed(server, err)
+		return nil, true, err
+	}
+	conn := &pooledConn{Client: client}
+	if len(bindDN) > 0 {
+		start := time.Now()
+		err := client.Bind(bindDN, bindPassword)
+		metrics.observeRequest(server.url, "bind", start, err)
+		if err != nil {
+			client.Close()
+			if isServerError(err) {
+				p.markFailed(server, err)
+				return nil, true, err
+			}
+			return nil, false, fmt.Errorf("could not bind to the LDAP server %s: %w", server.url, err)
+		}
+		conn.bindDN, conn.bindPassword = bindDN, bindPassword
+	}
+	p.markHealthy(server)
+	return conn, false, nil
+}
+
+// candidates returns the servers in the order they should be tried: the healthy servers in order
+// of preference, then the backed off servers by the time they are retried.
+func (p *PooledConfig) candidates() []*pooledServer {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := p.now()
+	var healthy, backedOff []*pooledServer
+	for _, server := range p.servers {
+		if server.retryAfter.After(now) {
+			backedOff = append(backedOff, server)
+		} else {
+			healthy = append(healthy, server)
+		}
+	}
+	// the backed off servers are still tried as a last resort
+	sort.SliceStable(backedOff, func(i, j int) bool {
+		return backedOff[i].retryAfter.Before(backedOff[j].retryAfter)
+	})
+	return append(healthy, backedOff...)
+}
+
+// takeIdle returns an idle connection to the server bound with the given credentials. Idle
+// connections that were closed or are bound with other credentials are discarded.
+func (p *PooledConfig) takeIdle(server *pooledServer, bindDN, bindPassword string) *pooledConn {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for len(server.idle) > 0 {
+		conn := server.idle[len(server.idle)-1]
+		server.idle = server.idle[:len(server.idle)-1]
+		if conn.IsClosing() || conn.bindDN != bindDN || conn.bindPassword != bindPassword {
+			conn.Close()
+			continue
+		}
+		return conn
+	}
+	return nil
+}
+
+// release returns a connection to the idle connections of the server, or closes it.
+func (p *PooledConfig) release(server *pooledServer, conn *pooledConn, reusable bool) {
+	bindDN, bindPassword := p.GetBindCredentials()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if !reusable || p.closed || conn.IsClosing() || len(server.idle) >= p.maxIdle ||
+		conn.bindDN != bindDN || conn.bindPassword != bindPassword {
+		conn.Close()
+		return
+	}
+	server.idle = append(server.idle, conn)
+}
+
+// markFailed backs off a server and closes its idle connections.
+func (p *PooledConfig) markFailed(server *pooledServer, err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	server.failures++
+	backoff := p.minBackoff
+	for i := 1; i < server.failures && backoff < p.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.maxBackoff {
+		backoff = p.maxBackoff
+	}
+	server.retryAfter = p.now().Add(backoff)
+	for _, conn := range server.idle {
+		conn.Close()
+	}
+	server.idle = nil
+
+	klog.V(2).Infof("LDAP server %s failed %d times, retrying in %v: %v", server.url, server.failures, backoff, err)
+	metrics.observeHealth(server.url, false)
+}
+
+// markHealthy resets the failures of a server.
+func (p *PooledConfig) markHealthy(server *pooledServer) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if server.failures > 0 {
+		klog.V(2).Infof("LDAP server %s recovered after %d failures", server.url, server.failures)
+	}
+	server.failures = 0
+	server.retryAfter = time.Time{}
+	metrics.observeHealth(server.url, true)
+}
+
+// GetBindCredentials returns the current bind credentials. If they cannot be refreshed, the last
+// credentials are returned.
+func (p *PooledConfig) GetBindCredentials() (string, string) {
+	if p.bindCredentialsFunc == nil {
+		return p.bindDN, p.bindPassword
+	}
+	bindDN, bindPassword, err := p.bindCredentialsFunc()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if err != nil {
+		klog.Warningf("Unable to refresh the LDAP bind credentials, using the previous credentials: %v", err)
+		return p.bindDN, p.bindPassword
+	}
+	p.bindDN, p.bindPassword = bindDN, bindPassword
+	return bindDN, bindPassword
+}
+
+// Host returns the host:port of the preferred server, which identifies the pool.
+func (p *PooledConfig) Host() string {
+	return p.servers[0].host
+}
+
+// Close closes the idle connections. Connections in use are closed when their clients are closed.
+func (p *PooledConfig) Close() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.closed = true
+	for _, server := range p.servers {
+		for _, conn := range server.idle {
+			conn.Close()
+		}
+		server.idle = nil
+	}
+}
+
+// String implements Stringer for debugging purposes
+func (p *PooledConfig) String() string {
+	urls := make([]string, 0, len(p.servers))
+	for _, server := range p.servers {
+		urls = append(urls, server.url)
+	}
+	bindDN, bindPassword := p.GetBindCredentials()
+	return fmt.Sprintf("{URLs: %v BindDN: %v len(BindPassword): %v Insecure: %v}", strings.Join(urls, ","), bindDN, len(bindPassword), p.servers[0].insecure)
+}
+
+// isServerError returns true if an error means the server is unreachable or unable to serve requests.
+func isServerError(err error) bool {
+	return ldap.IsErrorWithCode(err, ldap.ErrorNetwork) ||
+		ldap.IsErrorWithCode(err, ldap.LDAPResultUnavailable) ||
+		ldap.IsErrorWithCode(err, ldap.LDAPResultBusy)
+}
+
+// pooledClient is a connection borrowed from a pool. Operations are recorded in the metrics of
+// the server, and server failures back off the server.
+type pooledClient struct {
+	pool   *PooledConfig
+	server *pooledServer
+	conn   *pooledConn
+
+	// broken is set when the connection failed or its bind identity is unknown, so it is not reused
+	broken    atomic.Bool
+	closeOnce sync.Once
+}
+
+var _ ldap.Client = &pooledClient{}
+
+func (c *pooledClient) observe(operation string, start time.Time, err error) {
+	metrics.observeRequest(c.server.url, operation, start, err)
+	if err != nil && isServerError(err) {
+		c.broken.Store(true)
+		c.pool.markFailed(c.server, err)
+	}
+}
+
+// Close returns the connection to the pool.
+func (c *pooledClient) Close() {
+	c.closeOnce.Do(func() {
+		c.pool.release(c.server, c.conn, !c.broken.Load())
+	})
+}
+
+func (c *pooledClient) Start()                     { c.conn.Start() }
+func (c *pooledClient) IsClosing() bool            { return c.conn.IsClosing() }
+func (c *pooledClient) SetTimeout(d time.Duration) { c.conn.SetTimeout(d) }
+
+// StartTLS is not supported, pooled connections are upgraded when they are established.
+func (c *pooledClient) StartTLS(*tls.Config) error {
+	return ldap.NewError(ldap.ErrorNetwork, fmt.Errorf("StartTLS is not supported on pooled connections"))
+}
+
+// Bind binds the connection, skipping the request if it is already bound with the credentials.
+func (c *pooledClient) Bind(username, password string) error {
+	if username == c.conn.bindDN && password == c.conn.bindPassword && len(username) > 0 {
+		return nil
+	}
+	start := time.Now()
+	err := c.conn.Bind(username, password)
+	c.observe("bind", start, err)
+	if err != nil {
+		// a failed bind leaves the connection anonymous
+		c.conn.bindDN, c.conn.bindPassword = "", ""
+		return err
+	}
+	c.conn.bindDN, c.conn.bindPassword = username, password
+	return nil
+}
+
+func (c *pooledClient) UnauthenticatedBind(username string) error {
+	c.broken.Store(true)
+	start := time.Now()
+	err := c.conn.UnauthenticatedBind(username)
+	c.observe("bind", start, err)
+	return err
+}
+
+func (c *pooledClient) SimpleBind(request *ldap.SimpleBindRequest) (*ldap.SimpleBindResult, error) {
+	c.broken.Store(true)
+	start := time.Now()
+	result, err := c.conn.SimpleBind(request)
+	c.observe("bind", start, err)
+	return result, err
+}
+
+func (c *pooledClient) ExternalBind() error {
+	c.broken.Store(true)
+	start := time.Now()
+	err := c.conn.ExternalBind()
+	c.observe("bind", start, err)
+	return err
+}
+
+func (c *pooledClient) Add(request *ldap.AddRequest) error {
+	start := time.Now()
+	err := c.conn.Add(request)
+	c.observe("add", start, err)
+	return err
+}
+
+func (c *pooledClient) Del(request *ldap.DelRequest) error {
+	start := time.Now()
+	err := c.conn.Del(request)
+	c.observe("delete", start, err)
+	return err
+}
+
+func (c *pooledClient) Modify(request *ldap.ModifyRequest) error {
+	start := time.Now()
+	err := c.conn.Modify(request)
+	c.observe("modify", start, err)
+	return err
+}
+
+func (c *pooledClient) ModifyDN(request *ldap.ModifyDNRequest) error {
+	start := time.Now()
+	err := c.conn.ModifyDN(request)
+	c.observe("modify", start, err)
+	return err
+}
+
+func (c *pooledClient) ModifyWithResult(request *ldap.ModifyRequest) (*ldap.ModifyResult, error) {
+	start := time.Now()
+	result, err := c.conn.ModifyWithResult(request)
+	c.observe("modify", start, err)
+	return result, err
+}
+
+func (c *pooledClient) Compare(dn, attribute, value string) (bool, error) {
+	start := time.Now()
+	matches, err := c.conn.Compare(dn, attribute, value)
+	c.observe("compare", start, err)
+	return matches, err
+}
+
+func (c *pooledClient) PasswordModify(request *ldap.PasswordModifyRequest) (*ldap.PasswordModifyResult, error) {
+	start := time.Now()
+	result, err := c.conn.PasswordModify(request)
+	c.observe("password_modify", start, err)
+	return result, err
+}
+
+func (c *pooledClient) Search(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	start := time.Now()
+	result, err := c.conn.Search(request)
+	c.observe("search", start, err)
+	return result, err
+}
+
+func (c *pooledClient) SearchWithPaging(request *ldap.SearchRequest, pagingSize uint32) (*ldap.SearchResult, error) {
+	start := time.Now()
+	result, err := c.conn.SearchWithPaging(request, pagingSize)
+	c.observe("search", start, err)
+	return result, err
+}
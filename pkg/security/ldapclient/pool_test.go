@@ -0,0 +1,251 @@
+package ldapclient
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// fakeConn is a connection to a fake LDAP server.
+type fakeConn struct {
+	ldap.Client
+	server *fakeServer
+	closed bool
+}
+
+func (c *fakeConn) Close()          { c.closed = true }
+func (c *fakeConn) IsClosing() bool { return c.closed || c.server.down }
+
+func (c *fakeConn) Bind(username, password string) error {
+	c.server.binds++
+	if c.server.down {
+		return ldap.NewError(ldap.ErrorNetwork, fmt.Errorf("connection closed"))
+	}
+	if password != c.server.passwords[username] {
+		return ldap.NewError(ldap.LDAPResultInvalidCredentials, fmt.Errorf("invalid credentials"))
+	}
+	return nil
+}
+
+func (c *fakeConn) Search(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	c.server.searches++
+	if c.server.down {
+		return nil, ldap.NewError(ldap.ErrorNetwork, fmt.Errorf("connection closed"))
+	}
+	return &ldap.SearchResult{}, nil
+}
+
+// fakeServer counts the connections and requests it receives.
+type fakeServer struct {
+	down      bool
+	passwords map[string]string
+	dials     int
+	binds     int
+	searches  int
+}
+
+func newTestPool(t *testing.T, options PooledConfigOptions, servers map[string]*fakeServer) (*PooledConfig, *time.Time) {
+	pool, err := NewPooledLDAPClientConfig(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	pool.now = func() time.Time { return now }
+	pool.dial = func(s *pooledServer) (ldap.Client, error) {
+		server := servers[s.host]
+		server.dials++
+		if server.down {
+			return nil, ldap.NewError(ldap.ErrorNetwork, fmt.Errorf("connection refused"))
+		}
+		return &fakeConn{server: server}, nil
+	}
+	return pool, &now
+}
+
+func newFakeServers() (map[string]*fakeServer, *fakeServer, *fakeServer) {
+	passwords := map[string]string{"cn=sync": "secret", "cn=alice": "alice"}
+	a := &fakeServer{passwords: passwords}
+	b := &fakeServer{passwords: passwords}
+	return map[string]*fakeServer{"a.example.com:389": a, "b.example.com:636": b}, a, b
+}
+
+var testPoolOptions = PooledConfigOptions{
+	URLs:         []string{"ldap://a.example.com", "ldaps://b.example.com"},
+	BindDN:       "cn=sync",
+	BindPassword: "secret",
+}
+
+func TestPooledConfigReuse(t *testing.T) {
+	servers, a, b := newFakeServers()
+	pool, _ := newTestPool(t, testPoolOptions, servers)
+
+	for i := 0; i < 3; i++ {
+		client, err := ConnectMaybeBind(pool)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Search(&ldap.SearchRequest{}); err != nil {
+			t.Fatal(err)
+		}
+		client.Close()
+	}
+	if a.dials != 1 || a.binds != 1 || a.searches != 3 {
+		t.Errorf("expected one bound connection to be reused for three searches, got %d dials, %d binds and %d searches", a.dials, a.binds, a.searches)
+	}
+	if b.dials != 0 {
+		t.Errorf("expected the second server to be unused, got %d dials", b.dials)
+	}
+	if pool.Host() != "a.example.com:389" {
+		t.Errorf("expected the host of the preferred server, got %s", pool.Host())
+	}
+
+	// a connection bound as another user is not reused
+	client, err := pool.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Bind("cn=alice", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+	client.Close()
+	if len(pool.servers[0].idle) != 0 {
+		t.Errorf("expected the connection bound as another user to be closed")
+	}
+
+	pool.Close()
+	client, err = pool.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+	if len(pool.servers[0].idle) != 0 {
+		t.Errorf("expected no connections to be kept after the pool is closed")
+	}
+}
+
+func TestPooledConfigFailover(t *testing.T) {
+	servers, a, b := newFakeServers()
+	a.down = true
+	pool, now := newTestPool(t, testPoolOptions, servers)
+
+	client, err := pool.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+	if a.dials != 1 || b.dials != 1 {
+		t.Fatalf("expected to fail over to the second server, got %d and %d dials", a.dials, b.dials)
+	}
+
+	// the failed server is backed off
+	client, err = pool.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+	if a.dials != 1 {
+		t.Errorf("expected the failed server to be backed off, got %d dials", a.dials)
+	}
+
+	// and tried again once the backoff expires
+	a.down = false
+	*now = now.Add(defaultMinBackoff)
+	client, err = pool.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.dials != 2 {
+		t.Errorf("expected the recovered server to be used, got %d dials", a.dials)
+	}
+
+	// a network failure of a connection backs off its server
+	a.down = true
+	if _, err := client.Search(&ldap.SearchRequest{}); err == nil {
+		t.Fatal("expected the search to fail")
+	}
+	client.Close()
+	if len(pool.servers[0].idle) != 0 {
+		t.Errorf("expected the failed connection to be closed")
+	}
+	if !pool.servers[0].retryAfter.Equal(now.Add(defaultMinBackoff)) {
+		t.Errorf("expected the backoff to restart after the recovery, retrying at %v", pool.servers[0].retryAfter)
+	}
+	client, err = pool.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+	if a.dials != 2 {
+		t.Errorf("expected the failed server to be backed off, got %d dials", a.dials)
+	}
+
+	b.down = true
+	if _, err := pool.Connect(); err == nil || !strings.Contains(err.Error(), "no LDAP server is available") {
+		t.Errorf("expected no server to be available, got %v", err)
+	}
+	if a.dials != 3 {
+		t.Errorf("expected backed off servers to be tried as a last resort, got %d dials", a.dials)
+	}
+	if !pool.servers[0].retryAfter.Equal(now.Add(2 * defaultMinBackoff)) {
+		t.Errorf("expected the backoff to double, retrying at %v", pool.servers[0].retryAfter)
+	}
+}
+
+func TestPooledConfigBindCredentials(t *testing.T) {
+	servers, a, b := newFakeServers()
+	password := "secret"
+	options := testPoolOptions
+	options.BindCredentialsFunc = func() (string, string, error) {
+		if len(password) == 0 {
+			return "", "", fmt.Errorf("credentials unavailable")
+		}
+		return "cn=sync", password, nil
+	}
+	pool, _ := newTestPool(t, options, servers)
+
+	client, err := pool.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+
+	// rotated credentials replace the idle connections bound with the old credentials
+	a.passwords = map[string]string{"cn=sync": "rotated"}
+	password = "rotated"
+	client, err = ConnectMaybeBind(pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+	if a.dials != 2 || a.binds != 2 {
+		t.Errorf("expected a new connection bound with the rotated credentials, got %d dials and %d binds", a.dials, a.binds)
+	}
+
+	// the last credentials are used when they cannot be refreshed
+	password = ""
+	if bindDN, bindPassword := pool.GetBindCredentials(); bindDN != "cn=sync" || bindPassword != "rotated" {
+		t.Errorf("expected the previous credentials, got %s/%s", bindDN, bindPassword)
+	}
+
+	// invalid credentials do not fail over
+	password = "wrong"
+	if _, err := pool.Connect(); err == nil || !strings.Contains(err.Error(), "could not bind") {
+		t.Errorf("expected a bind error, got %v", err)
+	}
+	if b.dials != 0 || pool.servers[0].failures != 0 {
+		t.Errorf("expected invalid credentials not to back off the server")
+	}
+}
+
+func TestNewPooledLDAPClientConfig(t *testing.T) {
+	if _, err := NewPooledLDAPClientConfig(PooledConfigOptions{}); err == nil {
+		t.Errorf("expected an error without URLs")
+	}
+	if _, err := NewPooledLDAPClientConfig(PooledConfigOptions{URLs: []string{"http://a.example.com"}}); err == nil {
+		t.Errorf("expected an error for an unsupported scheme")
+	}
+}
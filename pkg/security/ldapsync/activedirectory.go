@@ -0,0 +1,191 @@
+package ldapsync
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/openshift/library-go/pkg/security/ldapclient"
+	"github.com/openshift/library-go/pkg/security/ldapquery"
+)
+
+// ldapMatchingRuleInChain is the Active Directory matching rule that walks the chain of ancestry
+// of an attribute, matching users that are members of a group through nested groups.
+const ldapMatchingRuleInChain = "1.2.840.113556.1.4.1941"
+
+// ADOptions configures the queries of an ADInterface.
+type ADOptions struct {
+	// UserQuery finds every user entry that may be a group member.
+	UserQuery ldapquery.LDAPQuery
+	// UserNameAttributes are the attributes of a user entry that name the OpenShift user.
+	UserNameAttributes []string
+	// GroupMembershipAttributes are the attributes of a user entry that hold the DNs of its groups.
+	GroupMembershipAttributes []string
+
+	// NestedGroups includes the users of groups nested in a group as its members, using the
+	// LDAP_MATCHING_RULE_IN_CHAIN matching rule of Active Directory.
+	NestedGroups bool
+}
+
+// ADInterface reads groups from an LDAP server using the Active Directory schema, where user
+// entries list the groups they are members of. Groups are identified by the values of the
+// membership attributes, usually their DN.
+type ADInterface struct {
+	clientConfig ldapclient.Config
+	options      ADOptions
+
+	// cachedMembers holds the members of the groups found by ListGroups or ExtractMembers
+	cachedMembers map[string][]*ldap.Entry
+}
+
+var _ LDAPGroupLister = &ADInterface{}
+var _ LDAPGroupMemberExtractor = &ADInterface{}
+var _ LDAPGroupDetector = &ADInterface{}
+
+// NewADInterface creates an interface to the Active Directory groups of an LDAP server.
+func NewADInterface(clientConfig ldapclient.Config, options ADOptions) *ADInterface {
+	return &ADInterface{
+		clientConfig:  clientConfig,
+		options:       options,
+		cachedMembers: make(map[string][]*ldap.Entry),
+	}
+}
+
+func (e *ADInterface) userAttributes() []string {
+	return attributesFor(e.options.UserNameAttributes, e.options.GroupMembershipAttributes)
+}
+
+// ListGroups returns every group a user found by the user query is a direct member of.
+func (e *ADInterface) ListGroups(ctx context.Context) ([]string, error) {
+	users, err := queryForEntries(e.clientConfig, e.options.UserQuery.NewSearchRequest(e.userAttributes()))
+	if err != nil {
+		return nil, err
+	}
+
+	var uids []string
+	members := make(map[string][]*ldap.Entry)
+	for _, user := range users {
+		for _, uid := range attributeValues(user, e.options.GroupMembershipAttributes) {
+			if _, ok := members[uid]; !ok {
+				uids = append(uids, uid)
+			}
+			members[uid] = append(members[uid], user)
+		}
+	}
+	// nested groups have members that are not listed as direct members
+	if !e.options.NestedGroups {
+		for uid, users := range members {
+			e.cachedMembers[uid] = users
+		}
+	}
+	return uids, nil
+}
+
+// ExtractMembers returns the users that are members of the group.
+func (e *ADInterface) ExtractMembers(ctx context.Context, ldapGroupUID string) ([]*ldap.Entry, error) {
+	if members, ok := e.cachedMembers[ldapGroupUID]; ok {
+		return members, nil
+	}
+
+	var members []*ldap.Entry
+	seen := make(map[string]bool)
+	for _, attribute := range e.options.GroupMembershipAttributes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if e.options.NestedGroups {
+			attribute = fmt.Sprintf("%s:%s:", attribute, ldapMatchingRuleInChain)
+		}
+		query := ldapquery.LDAPQueryOnAttribute{LDAPQuery: e.options.UserQuery, QueryAttribute: attribute}
+		request, err := query.NewSearchRequest(ldapGroupUID, e.userAttributes())
+		if err != nil {
+			return nil, err
+		}
+		users, err := queryForEntries(e.clientConfig, request)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			if !seen[user.DN] {
+				seen[user.DN] = true
+				members = append(members, user)
+			}
+		}
+	}
+	e.cachedMembers[ldapGroupUID] = members
+	return members, nil
+}
+
+// Exists returns true if the group has members, since groups are only known through their members.
+func (e *ADInterface) Exists(ctx context.Context, ldapGroupUID string) (bool, error) {
+	members, err := e.ExtractMembers(ctx, ldapGroupUID)
+	if err != nil {
+		return false, err
+	}
+	return len(members) > 0, nil
+}
+
+// AugmentedADOptions configures the queries of an AugmentedADInterface.
+type AugmentedADOptions struct {
+	ADOptions
+
+	// GroupQuery finds group entries. Its QueryAttribute holds the unique identifier of groups,
+	// the value of the membership attributes of users.
+	GroupQuery ldapquery.LDAPQueryOnAttribute
+	// GroupNameAttributes are the attributes of a group entry that name the OpenShift group.
+	GroupNameAttributes []string
+}
+
+// AugmentedADInterface reads groups from an LDAP server using the Active Directory schema, with
+// group entries that name the groups and determine if they exist.
+type AugmentedADInterface struct {
+	*ADInterface
+
+	groupQuery          ldapquery.LDAPQueryOnAttribute
+	groupNameAttributes []string
+
+	// cachedGroups holds group entries by their unique identifier
+	cachedGroups map[string]*ldap.Entry
+}
+
+var _ LDAPGroupLister = &AugmentedADInterface{}
+var _ LDAPGroupMemberExtractor = &AugmentedADInterface{}
+var _ LDAPGroupGetter = &AugmentedADInterface{}
+var _ LDAPGroupDetector = &AugmentedADInterface{}
+
+// NewAugmentedADInterface creates an interface to the augmented Active Directory groups of an LDAP server.
+func NewAugmentedADInterface(clientConfig ldapclient.Config, options AugmentedADOptions) *AugmentedADInterface {
+	return &AugmentedADInterface{
+		ADInterface:         NewADInterface(clientConfig, options.ADOptions),
+		groupQuery:          options.GroupQuery,
+		groupNameAttributes: options.GroupNameAttributes,
+		cachedGroups:        make(map[string]*ldap.Entry),
+	}
+}
+
+// GroupEntryFor returns the entry of the group with the given unique identifier.
+func (e *AugmentedADInterface) GroupEntryFor(ldapGroupUID string) (*ldap.Entry, error) {
+	if group, ok := e.cachedGroups[ldapGroupUID]; ok {
+		return group, nil
+	}
+	request, err := e.groupQuery.NewSearchRequest(ldapGroupUID, attributesFor([]string{e.groupQuery.QueryAttribute}, e.groupNameAttributes))
+	if err != nil {
+		return nil, err
+	}
+	group, err := queryForUniqueEntry(e.clientConfig, request)
+	if err != nil {
+		return nil, err
+	}
+	e.cachedGroups[ldapGroupUID] = group
+	return group, nil
+}
+
+// Exists returns true if the group query finds the group, even if it has no members.
+func (e *AugmentedADInterface) Exists(ctx context.Context, ldapGroupUID string) (bool, error) {
+	_, err := e.GroupEntryFor(ldapGroupUID)
+	if isNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
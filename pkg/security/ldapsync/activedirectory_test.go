@@ -0,0 +1,158 @@
+package ldapsync
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/openshift/library-go/pkg/security/ldapclient"
+	"github.com/openshift/library-go/pkg/security/ldapquery"
+)
+
+func newADClientConfig() ldapclient.Config {
+	alice := newEntry(userDN("alice"), map[string][]string{"uid": {"alice"}, "memberOf": {groupDN("group1"), groupDN("group2")}})
+	bob := newEntry(userDN("bob"), map[string][]string{"uid": {"bob"}, "memberOf": {groupDN("group1")}})
+	carol := newEntry(userDN("carol"), map[string][]string{"uid": {"carol"}, "memberOf": {groupDN("group3")}})
+	memberOf := ldapquery.LDAPQueryOnAttribute{LDAPQuery: userQuery("").LDAPQuery, QueryAttribute: "memberOf"}
+	// group3 is a member of group2
+	inChain := ldapquery.LDAPQueryOnAttribute{LDAPQuery: userQuery("").LDAPQuery, QueryAttribute: "memberOf:" + ldapMatchingRuleInChain + ":"}
+
+	return newTestClientConfig(
+		byDN(
+			newEntry(groupDN("group1"), map[string][]string{"cn": {"group1"}}),
+			newEntry(groupDN("group2"), map[string][]string{"cn": {"group2"}}),
+			newEntry(groupDN("group3"), map[string][]string{"cn": {"group3"}}),
+			newEntry(groupDN("empty"), map[string][]string{"cn": {"empty"}}),
+		),
+		map[string][]*ldap.Entry{
+			userQuery("").Filter:                   {alice, bob, carol},
+			filterFor(memberOf, groupDN("group1")): {alice, bob},
+			filterFor(memberOf, groupDN("group2")): {alice},
+			filterFor(memberOf, groupDN("group3")): {carol},
+			filterFor(inChain, groupDN("group2")):  {alice, carol},
+		},
+	)
+}
+
+func newADOptions() ADOptions {
+	return ADOptions{
+		UserQuery:                 userQuery("").LDAPQuery,
+		UserNameAttributes:        []string{"uid"},
+		GroupMembershipAttributes: []string{"memberOf"},
+	}
+}
+
+func TestADListGroups(t *testing.T) {
+	e := NewADInterface(newADClientConfig(), newADOptions())
+	uids, err := e.ListGroups(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{groupDN("group1"), groupDN("group2"), groupDN("group3")}
+	if !reflect.DeepEqual(uids, expected) {
+		t.Errorf("expected %v, got %v", expected, uids)
+	}
+}
+
+func TestADExtractMembers(t *testing.T) {
+	testCases := []struct {
+		name     string
+		group    string
+		nested   bool
+		list     bool
+		expected []string
+	}{
+		{
+			name:     "direct members",
+			group:    groupDN("group1"),
+			expected: []string{userDN("alice"), userDN("bob")},
+		},
+		{
+			name:     "direct members cached by list",
+			group:    groupDN("group2"),
+			list:     true,
+			expected: []string{userDN("alice")},
+		},
+		{
+			name:     "direct members of group with nested group",
+			group:    groupDN("group2"),
+			expected: []string{userDN("alice")},
+		},
+		{
+			name:     "members in chain",
+			group:    groupDN("group2"),
+			nested:   true,
+			expected: []string{userDN("alice"), userDN("carol")},
+		},
+		{
+			name:     "members in chain after list",
+			group:    groupDN("group2"),
+			nested:   true,
+			list:     true,
+			expected: []string{userDN("alice"), userDN("carol")},
+		},
+		{
+			name:     "no members",
+			group:    groupDN("empty"),
+			nested:   true,
+			expected: []string{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			options := newADOptions()
+			options.NestedGroups = tc.nested
+			e := NewADInterface(newADClientConfig(), options)
+			if tc.list {
+				if _, err := e.ListGroups(context.TODO()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			members, err := e.ExtractMembers(context.TODO(), tc.group)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := entryDNs(members); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestADExists(t *testing.T) {
+	options := AugmentedADOptions{
+		ADOptions:           newADOptions(),
+		GroupQuery:          groupQuery("dn"),
+		GroupNameAttributes: []string{"cn"},
+	}
+	ad := NewADInterface(newADClientConfig(), options.ADOptions)
+	augmented := NewAugmentedADInterface(newADClientConfig(), options)
+
+	testCases := []struct {
+		group             string
+		expected          bool
+		expectedAugmented bool
+	}{
+		{group: groupDN("group1"), expected: true, expectedAugmented: true},
+		{group: groupDN("empty"), expected: false, expectedAugmented: true},
+		{group: groupDN("missing"), expected: false, expectedAugmented: false},
+	}
+	for _, tc := range testCases {
+		if exists, err := ad.Exists(context.TODO(), tc.group); err != nil || exists != tc.expected {
+			t.Errorf("%s: expected %v, got %v (%v)", tc.group, tc.expected, exists, err)
+		}
+		if exists, err := augmented.Exists(context.TODO(), tc.group); err != nil || exists != tc.expectedAugmented {
+			t.Errorf("%s: expected augmented %v, got %v (%v)", tc.group, tc.expectedAugmented, exists, err)
+		}
+	}
+
+	name, err := NewEntryGroupNameMapper(augmented, []string{"cn"}).GroupNameFor(groupDN("group3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "group3" {
+		t.Errorf("expected group3, got %s", name)
+	}
+}
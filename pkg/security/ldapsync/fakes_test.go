@@ -0,0 +1,193 @@
+package ldapsync
+
+import (
+	"context"
+	"sort"
+
+	"github.com/go-ldap/ldap/v3"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	userv1 "github.com/openshift/api/user/v1"
+	userv1client "github.com/openshift/client-go/user/clientset/versioned/typed/user/v1"
+	"github.com/openshift/library-go/pkg/security/ldapclient"
+	"github.com/openshift/library-go/pkg/security/ldapquery"
+	"github.com/openshift/library-go/pkg/security/ldaptestclient"
+	"github.com/openshift/library-go/pkg/security/ldaputil"
+)
+
+const (
+	usersBaseDN  = "ou=users,dc=example,dc=com"
+	groupsBaseDN = "ou=groups,dc=example,dc=com"
+	testHost     = "ldap.example.com:389"
+)
+
+func userDN(name string) string  { return "cn=" + name + "," + usersBaseDN }
+func groupDN(name string) string { return "cn=" + name + "," + groupsBaseDN }
+
+func newEntry(dn string, attributes map[string][]string) *ldap.Entry {
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	entry := &ldap.Entry{DN: dn}
+	for _, name := range names {
+		entry.Attributes = append(entry.Attributes, ldap.NewEntryAttribute(name, attributes[name]))
+	}
+	return entry
+}
+
+// newTestClientConfig returns the config of an LDAP server answering searches with canned entries.
+// Searches of a base object return the entries of dnEntries mapped to its DN and other searches
+// return the entries of filterEntries mapped to their filter. Anything else finds no entries.
+func newTestClientConfig(dnEntries, filterEntries map[string][]*ldap.Entry) ldapclient.Config {
+	return ldaptestclient.NewConfig(&cannedClient{Fake: ldaptestclient.New(), dnEntries: dnEntries, filterEntries: filterEntries})
+}
+
+type cannedClient struct {
+	*ldaptestclient.Fake
+	dnEntries     map[string][]*ldap.Entry
+	filterEntries map[string][]*ldap.Entry
+}
+
+func (c *cannedClient) Search(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	if request.Scope == ldap.ScopeBaseObject {
+		return &ldap.SearchResult{Entries: c.dnEntries[request.BaseDN]}, nil
+	}
+	return &ldap.SearchResult{Entries: c.filterEntries[request.Filter]}, nil
+}
+
+func (c *cannedClient) SearchWithPaging(request *ldap.SearchRequest, pagingSize uint32) (*ldap.SearchResult, error) {
+	return c.Search(request)
+}
+
+// byDN maps entries to their DN.
+func byDN(entries ...*ldap.Entry) map[string][]*ldap.Entry {
+	mapping := make(map[string][]*ldap.Entry)
+	for _, entry := range entries {
+		mapping[entry.DN] = []*ldap.Entry{entry}
+	}
+	return mapping
+}
+
+// filterFor returns the filter of the search for the entries with the given attribute value.
+func filterFor(query ldapquery.LDAPQueryOnAttribute, value string) string {
+	request, err := query.NewSearchRequest(value, nil)
+	if err != nil {
+		panic(err)
+	}
+	return request.Filter
+}
+
+func userQuery(attribute string) ldapquery.LDAPQueryOnAttribute {
+	return ldapquery.LDAPQueryOnAttribute{
+		LDAPQuery: ldapquery.LDAPQuery{
+			BaseDN: usersBaseDN,
+			Scope:  ldaputil.ScopeWholeSubtree,
+			Filter: "objectClass=inetOrgPerson",
+		},
+		QueryAttribute: attribute,
+	}
+}
+
+func groupQuery(attribute string) ldapquery.LDAPQueryOnAttribute {
+	return ldapquery.LDAPQueryOnAttribute{
+		LDAPQuery: ldapquery.LDAPQuery{
+			BaseDN:   groupsBaseDN,
+			Scope:    ldaputil.ScopeWholeSubtree,
+			Filter:   "(objectClass=groupOfNames)",
+			PageSize: 10,
+		},
+		QueryAttribute: attribute,
+	}
+}
+
+// fakeGroupClient stores OpenShift groups in memory.
+type fakeGroupClient struct {
+	userv1client.GroupInterface
+	groups map[string]*userv1.Group
+}
+
+func newFakeGroupClient(groups ...*userv1.Group) *fakeGroupClient {
+	c := &fakeGroupClient{groups: make(map[string]*userv1.Group)}
+	for _, group := range groups {
+		c.groups[group.Name] = group
+	}
+	return c
+}
+
+var groupResource = schema.GroupResource{Group: "user.openshift.io", Resource: "groups"}
+
+func (c *fakeGroupClient) Get(ctx context.Context, name string, opts metav1.GetOptions) (*userv1.Group, error) {
+	group, ok := c.groups[name]
+	if !ok {
+		return nil, errors.NewNotFound(groupResource, name)
+	}
+	return group.DeepCopy(), nil
+}
+
+func (c *fakeGroupClient) Create(ctx context.Context, group *userv1.Group, opts metav1.CreateOptions) (*userv1.Group, error) {
+	if _, ok := c.groups[group.Name]; ok {
+		return nil, errors.NewAlreadyExists(groupResource, group.Name)
+	}
+	c.groups[group.Name] = group.DeepCopy()
+	return group, nil
+}
+
+func (c *fakeGroupClient) Update(ctx context.Context, group *userv1.Group, opts metav1.UpdateOptions) (*userv1.Group, error) {
+	if _, ok := c.groups[group.Name]; !ok {
+		return nil, errors.NewNotFound(groupResource, group.Name)
+	}
+	c.groups[group.Name] = group.DeepCopy()
+	return group, nil
+}
+
+func (c *fakeGroupClient) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	if _, ok := c.groups[name]; !ok {
+		return errors.NewNotFound(groupResource, name)
+	}
+	delete(c.groups, name)
+	return nil
+}
+
+func (c *fakeGroupClient) List(ctx context.Context, opts metav1.ListOptions) (*userv1.GroupList, error) {
+	selector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(c.groups))
+	for name := range c.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	list := &userv1.GroupList{}
+	for _, name := range names {
+		if group := c.groups[name]; selector.Matches(labels.Set(group.Labels)) {
+			list.Items = append(list.Items, *group.DeepCopy())
+		}
+	}
+	return list, nil
+}
+
+// syncedGroup returns an OpenShift group synced from the LDAP server at host.
+func syncedGroup(name, host, ldapGroupUID string, users ...string) *userv1.Group {
+	return &userv1.Group{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      map[string]string{LDAPHostLabel: hostLabelValue(host)},
+			Annotations: map[string]string{LDAPURLAnnotation: host, LDAPUIDAnnotation: ldapGroupUID},
+		},
+		Users: users,
+	}
+}
+
+func entryDNs(entries []*ldap.Entry) []string {
+	dns := []string{}
+	for _, entry := range entries {
+		dns = append(dns, entry.DN)
+	}
+	return dns
+}
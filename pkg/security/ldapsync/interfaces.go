@@ -0,0 +1,57 @@
+// Package ldapsync synchronizes groups on an LDAP server with OpenShift groups.
+//
+// The LDAP schemas supported are RFC2307, where group entries list their
+// members, Active Directory, where user entries list the groups they are
+// members of, and augmented Active Directory, which additionally has group
+// entries to name the groups. Each schema is implemented by an interface that
+// lists groups, extracts their members and detects whether they still exist.
+// LDAPGroupSyncer uses them to create and update OpenShift groups, and
+// LDAPGroupPruner to delete the OpenShift groups whose LDAP group is gone.
+package ldapsync
+
+import (
+	"context"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+const (
+	// LDAPURLAnnotation is the host:port of the LDAP server an OpenShift group was synced from.
+	LDAPURLAnnotation = "openshift.io/ldap.url"
+	// LDAPUIDAnnotation is the unique identifier of the LDAP group an OpenShift group was synced from.
+	LDAPUIDAnnotation = "openshift.io/ldap.uid"
+	// LDAPSyncTimeAnnotation is the time an OpenShift group was last synced.
+	LDAPSyncTimeAnnotation = "openshift.io/ldap.sync-time"
+	// LDAPHostLabel is the host of the LDAP server an OpenShift group was synced from.
+	LDAPHostLabel = "openshift.io/ldap.host"
+)
+
+// LDAPGroupLister lists the unique identifiers of the LDAP groups to sync.
+type LDAPGroupLister interface {
+	ListGroups(ctx context.Context) (ldapGroupUIDs []string, err error)
+}
+
+// LDAPGroupMemberExtractor retrieves the user entries of the members of an LDAP group.
+type LDAPGroupMemberExtractor interface {
+	ExtractMembers(ctx context.Context, ldapGroupUID string) (members []*ldap.Entry, err error)
+}
+
+// LDAPGroupGetter retrieves the entry of an LDAP group.
+type LDAPGroupGetter interface {
+	GroupEntryFor(ldapGroupUID string) (group *ldap.Entry, err error)
+}
+
+// LDAPGroupDetector determines whether an LDAP group exists.
+type LDAPGroupDetector interface {
+	Exists(ctx context.Context, ldapGroupUID string) (bool, error)
+}
+
+// LDAPGroupNameMapper maps an LDAP group to the name of an OpenShift group.
+type LDAPGroupNameMapper interface {
+	GroupNameFor(ldapGroupUID string) (openShiftGroupName string, err error)
+}
+
+// LDAPUserNameMapper maps an LDAP user entry to the name of an OpenShift user.
+type LDAPUserNameMapper interface {
+	UserNameFor(ldapUser *ldap.Entry) (openShiftUserName string, err error)
+}
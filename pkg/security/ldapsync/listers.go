@@ -0,0 +1,141 @@
+package ldapsync
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	userv1client "github.com/openshift/client-go/user/clientset/versioned/typed/user/v1"
+)
+
+// NewWhitelistGroupLister returns a lister that lists exactly the given LDAP group UIDs.
+func NewWhitelistGroupLister(ldapGroupUIDs []string) LDAPGroupLister {
+	return &whitelistGroupLister{ldapGroupUIDs: ldapGroupUIDs}
+}
+
+type whitelistGroupLister struct {
+	ldapGroupUIDs []string
+}
+
+func (l *whitelistGroupLister) ListGroups(ctx context.Context) ([]string, error) {
+	return l.ldapGroupUIDs, nil
+}
+
+// NewBlacklistGroupLister returns a lister that lists the groups of baseLister except the given
+// LDAP group UIDs.
+func NewBlacklistGroupLister(blacklist []string, baseLister LDAPGroupLister) LDAPGroupLister {
+	return &blacklistGroupLister{blacklist: sets.New[string](blacklist...), baseLister: baseLister}
+}
+
+type blacklistGroupLister struct {
+	blacklist  sets.Set[string]
+	baseLister LDAPGroupLister
+}
+
+func (l *blacklistGroupLister) ListGroups(ctx context.Context) ([]string, error) {
+	uids, err := l.baseLister.ListGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var allowed []string
+	for _, uid := range uids {
+		if !l.blacklist.Has(uid) {
+			allowed = append(allowed, uid)
+		}
+	}
+	return allowed, nil
+}
+
+// OpenShiftGroupLister lists the LDAP groups that OpenShift groups were synced from. It also maps
+// those LDAP groups to the names of their OpenShift groups.
+type OpenShiftGroupLister struct {
+	// whitelist and blacklist filter OpenShift group names. An empty whitelist allows every group.
+	whitelist sets.Set[string]
+	blacklist sets.Set[string]
+	// host is the host:port of the LDAP server
+	host   string
+	client userv1client.GroupInterface
+
+	// groupNames maps LDAP group UIDs to the OpenShift groups listed
+	groupNames map[string]string
+}
+
+var _ LDAPGroupLister = &OpenShiftGroupLister{}
+var _ LDAPGroupNameMapper = &OpenShiftGroupLister{}
+
+// NewOpenShiftGroupLister returns a lister for the OpenShift groups synced from the LDAP server at
+// host. If whitelist is not empty, only the OpenShift groups it names are listed. The groups named
+// by blacklist are never listed.
+func NewOpenShiftGroupLister(whitelist, blacklist []string, host string, client userv1client.GroupInterface) *OpenShiftGroupLister {
+	return &OpenShiftGroupLister{
+		whitelist:  sets.New[string](whitelist...),
+		blacklist:  sets.New[string](blacklist...),
+		host:       host,
+		client:     client,
+		groupNames: make(map[string]string),
+	}
+}
+
+// ListGroups returns the LDAP group UIDs of the OpenShift groups synced from the LDAP server.
+func (l *OpenShiftGroupLister) ListGroups(ctx context.Context) ([]string, error) {
+	selector := labels.SelectorFromSet(labels.Set{LDAPHostLabel: hostLabelValue(l.host)})
+	groups, err := l.client.List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	var uids []string
+	listed := sets.New[string]()
+	for i := range groups.Items {
+		group := &groups.Items[i]
+		if l.blacklist.Has(group.Name) || (l.whitelist.Len() > 0 && !l.whitelist.Has(group.Name)) {
+			continue
+		}
+		if err := validateGroupOwnership(group.ObjectMeta, l.host); err != nil {
+			return nil, err
+		}
+		uid := group.Annotations[LDAPUIDAnnotation]
+		l.groupNames[uid] = group.Name
+		listed.Insert(group.Name)
+		uids = append(uids, uid)
+	}
+	if missing := l.whitelist.Difference(l.blacklist).Difference(listed); missing.Len() > 0 {
+		return nil, fmt.Errorf("the groups %v were not synced from the LDAP server %s", sets.List(missing), l.host)
+	}
+	return uids, nil
+}
+
+// GroupNameFor returns the name of the OpenShift group synced from an LDAP group listed by ListGroups.
+func (l *OpenShiftGroupLister) GroupNameFor(ldapGroupUID string) (string, error) {
+	name, ok := l.groupNames[ldapGroupUID]
+	if !ok {
+		return "", fmt.Errorf("no OpenShift group was synced from the LDAP group %s", ldapGroupUID)
+	}
+	return name, nil
+}
+
+// validateGroupOwnership returns an error if an OpenShift group was not synced from the LDAP server at host.
+func validateGroupOwnership(group metav1.ObjectMeta, host string) error {
+	if value := group.Labels[LDAPHostLabel]; value != hostLabelValue(host) {
+		return fmt.Errorf("group %q: %s label did not match sync host: wanted %s, got %s", group.Name, LDAPHostLabel, hostLabelValue(host), value)
+	}
+	if value := group.Annotations[LDAPURLAnnotation]; value != host {
+		return fmt.Errorf("group %q: %s annotation did not match sync host: wanted %s, got %s", group.Name, LDAPURLAnnotation, host, value)
+	}
+	if len(group.Annotations[LDAPUIDAnnotation]) == 0 {
+		return fmt.Errorf("group %q: %s annotation expected", group.Name, LDAPUIDAnnotation)
+	}
+	return nil
+}
+
+// hostLabelValue returns the host of host:port, since label values cannot hold a port.
+func hostLabelValue(host string) string {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		return name
+	}
+	return host
+}
@@ -0,0 +1,71 @@
+package ldapsync
+
+import (
+	"fmt"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/openshift/library-go/pkg/security/ldaputil"
+)
+
+// NewUserNameMapper returns a mapper that names users by the first of the attributes their entry has.
+func NewUserNameMapper(nameAttributes []string) LDAPUserNameMapper {
+	return &userNameMapper{nameAttributes: nameAttributes}
+}
+
+type userNameMapper struct {
+	nameAttributes []string
+}
+
+func (m *userNameMapper) UserNameFor(ldapUser *ldap.Entry) (string, error) {
+	name := ldaputil.GetAttributeValue(ldapUser, m.nameAttributes)
+	if len(name) == 0 {
+		return "", fmt.Errorf("the user entry %s has none of the name attributes %v", ldapUser.DN, m.nameAttributes)
+	}
+	return name, nil
+}
+
+// NewEntryGroupNameMapper returns a mapper that names groups by the first of the attributes their
+// entry has.
+func NewEntryGroupNameMapper(groupGetter LDAPGroupGetter, nameAttributes []string) LDAPGroupNameMapper {
+	return &entryGroupNameMapper{groupGetter: groupGetter, nameAttributes: nameAttributes}
+}
+
+type entryGroupNameMapper struct {
+	groupGetter    LDAPGroupGetter
+	nameAttributes []string
+}
+
+func (m *entryGroupNameMapper) GroupNameFor(ldapGroupUID string) (string, error) {
+	group, err := m.groupGetter.GroupEntryFor(ldapGroupUID)
+	if err != nil {
+		return "", err
+	}
+	name := ldaputil.GetAttributeValue(group, m.nameAttributes)
+	if len(name) == 0 {
+		return "", fmt.Errorf("the group entry %s has none of the name attributes %v", group.DN, m.nameAttributes)
+	}
+	return name, nil
+}
+
+// NewUserDefinedGroupNameMapper returns a mapper that names groups by an explicit mapping from
+// their unique identifier. Groups that are not in the mapping are named by fallback, or by their
+// unique identifier if fallback is nil.
+func NewUserDefinedGroupNameMapper(mapping map[string]string, fallback LDAPGroupNameMapper) LDAPGroupNameMapper {
+	return &userDefinedGroupNameMapper{mapping: mapping, fallback: fallback}
+}
+
+type userDefinedGroupNameMapper struct {
+	mapping  map[string]string
+	fallback LDAPGroupNameMapper
+}
+
+func (m *userDefinedGroupNameMapper) GroupNameFor(ldapGroupUID string) (string, error) {
+	if name, ok := m.mapping[ldapGroupUID]; ok {
+		return name, nil
+	}
+	if m.fallback != nil {
+		return m.fallback.GroupNameFor(ldapGroupUID)
+	}
+	return ldapGroupUID, nil
+}
@@ -0,0 +1,82 @@
+package ldapsync
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	userv1client "github.com/openshift/client-go/user/clientset/versioned/typed/user/v1"
+)
+
+// LDAPGroupPruner deletes the OpenShift groups whose LDAP group no longer exists.
+type LDAPGroupPruner struct {
+	// GroupLister lists the LDAP groups OpenShift groups were synced from, usually an OpenShiftGroupLister.
+	GroupLister LDAPGroupLister
+	// GroupDetector determines whether the LDAP groups exist.
+	GroupDetector LDAPGroupDetector
+	// GroupNameMapper names the OpenShift groups of LDAP groups.
+	GroupNameMapper LDAPGroupNameMapper
+	// GroupClient deletes OpenShift groups.
+	GroupClient userv1client.GroupInterface
+	// Host is the host:port of the LDAP server the groups were synced from.
+	Host string
+	// DryRun returns the groups that would be pruned without deleting them.
+	DryRun bool
+}
+
+// Prune deletes the OpenShift groups of listed LDAP groups that no longer exist, and returns their
+// names. Only groups synced from Host are deleted.
+func (p *LDAPGroupPruner) Prune(ctx context.Context) ([]string, []error) {
+	ldapGroupUIDs, err := p.GroupLister.ListGroups(ctx)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	var pruned []string
+	var errs []error
+	for _, ldapGroupUID := range ldapGroupUIDs {
+		exists, err := p.GroupDetector.Exists(ctx, ldapGroupUID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to determine if LDAP group %s exists: %w", ldapGroupUID, err))
+			continue
+		}
+		if exists {
+			continue
+		}
+
+		name, err := p.GroupNameMapper.GroupNameFor(ldapGroupUID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to name LDAP group %s: %w", ldapGroupUID, err))
+			continue
+		}
+		group, err := p.GroupClient.Get(ctx, name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := validateGroupOwnership(group.ObjectMeta, p.Host); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if uid := group.Annotations[LDAPUIDAnnotation]; uid != ldapGroupUID {
+			errs = append(errs, fmt.Errorf("group %q: %s annotation did not match LDAP UID: wanted %s, got %s", name, LDAPUIDAnnotation, ldapGroupUID, uid))
+			continue
+		}
+
+		if !p.DryRun {
+			if err := p.GroupClient.Delete(ctx, name, metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &group.UID}}); err != nil && !errors.IsNotFound(err) {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		klog.V(2).Infof("Pruned group %s of LDAP group %s", name, ldapGroupUID)
+		pruned = append(pruned, name)
+	}
+	return pruned, errs
+}
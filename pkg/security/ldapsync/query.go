@@ -0,0 +1,64 @@
+package ldapsync
+
+import (
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/openshift/library-go/pkg/security/ldapclient"
+	"github.com/openshift/library-go/pkg/security/ldapquery"
+)
+
+// queryForEntries runs a search on a new connection to the LDAP server.
+func queryForEntries(clientConfig ldapclient.Config, request *ldap.SearchRequest) ([]*ldap.Entry, error) {
+	client, err := ldapclient.ConnectMaybeBind(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	return ldapquery.QueryForEntries(client, request)
+}
+
+// queryForUniqueEntry runs a search for a single entry on a new connection to the LDAP server.
+func queryForUniqueEntry(clientConfig ldapclient.Config, request *ldap.SearchRequest) (*ldap.Entry, error) {
+	client, err := ldapclient.ConnectMaybeBind(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	return ldapquery.QueryForUniqueEntry(client, request)
+}
+
+// isNotFound returns true if the error means an entry does not exist or is outside of the search base.
+func isNotFound(err error) bool {
+	return ldapquery.IsEntryNotFoundError(err) || ldapquery.IsNoSuchObjectError(err) || ldapquery.IsQueryOutOfBoundsError(err)
+}
+
+// attributesFor returns the unique attributes to request in a search.
+func attributesFor(attributeLists ...[]string) []string {
+	var attributes []string
+	seen := make(map[string]bool)
+	for _, list := range attributeLists {
+		for _, attribute := range list {
+			if len(attribute) == 0 || seen[attribute] {
+				continue
+			}
+			seen[attribute] = true
+			attributes = append(attributes, attribute)
+		}
+	}
+	return attributes
+}
+
+// attributeValues returns the values of the given attributes of an entry, treating "dn" as the DN.
+func attributeValues(entry *ldap.Entry, attributes []string) []string {
+	var values []string
+	for _, attribute := range attributes {
+		if strings.EqualFold(attribute, "dn") {
+			values = append(values, entry.DN)
+			continue
+		}
+		values = append(values, entry.GetAttributeValues(attribute)...)
+	}
+	return values
+}
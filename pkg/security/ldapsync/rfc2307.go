@@ -0,0 +1,190 @@
+package ldapsync
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-ldap/ldap/v3"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/security/ldapclient"
+	"github.com/openshift/library-go/pkg/security/ldapquery"
+	"github.com/openshift/library-go/pkg/security/ldaputil"
+)
+
+// RFC2307Options configures the queries of an RFC2307Interface.
+type RFC2307Options struct {
+	// GroupQuery finds group entries. Its QueryAttribute holds the unique identifier of groups.
+	GroupQuery ldapquery.LDAPQueryOnAttribute
+	// GroupNameAttributes are the attributes of a group entry that name the OpenShift group.
+	GroupNameAttributes []string
+	// GroupMembershipAttributes are the attributes of a group entry that hold its members.
+	GroupMembershipAttributes []string
+
+	// UserQuery finds user entries from the values of the membership attributes.
+	UserQuery ldapquery.LDAPQueryOnAttribute
+	// UserNameAttributes are the attributes of a user entry that name the OpenShift user.
+	UserNameAttributes []string
+
+	// NestedGroups resolves members that are groups, found with GroupQuery, to their members.
+	NestedGroups bool
+
+	// TolerateMemberNotFoundErrors skips members that no user query finds.
+	TolerateMemberNotFoundErrors bool
+	// TolerateMemberOutOfScopeErrors skips members outside of the base DN of the user query.
+	TolerateMemberOutOfScopeErrors bool
+}
+
+// RFC2307Interface reads groups from an LDAP server using the RFC2307 schema, where group entries
+// list their members.
+type RFC2307Interface struct {
+	clientConfig ldapclient.Config
+	options      RFC2307Options
+
+	// cachedGroups holds group entries by their unique identifier
+	cachedGroups map[string]*ldap.Entry
+	// cachedUsers holds user entries by the membership value that found them
+	cachedUsers map[string]*ldap.Entry
+}
+
+var _ LDAPGroupLister = &RFC2307Interface{}
+var _ LDAPGroupMemberExtractor = &RFC2307Interface{}
+var _ LDAPGroupGetter = &RFC2307Interface{}
+var _ LDAPGroupDetector = &RFC2307Interface{}
+
+// NewRFC2307Interface creates an interface to the RFC2307 groups of an LDAP server.
+func NewRFC2307Interface(clientConfig ldapclient.Config, options RFC2307Options) *RFC2307Interface {
+	return &RFC2307Interface{
+		clientConfig: clientConfig,
+		options:      options,
+		cachedGroups: make(map[string]*ldap.Entry),
+		cachedUsers:  make(map[string]*ldap.Entry),
+	}
+}
+
+func (e *RFC2307Interface) groupAttributes() []string {
+	return attributesFor([]string{e.options.GroupQuery.QueryAttribute}, e.options.GroupNameAttributes, e.options.GroupMembershipAttributes)
+}
+
+// ListGroups returns the unique identifiers of every group the group query finds.
+func (e *RFC2307Interface) ListGroups(ctx context.Context) ([]string, error) {
+	groups, err := queryForEntries(e.clientConfig, e.options.GroupQuery.LDAPQuery.NewSearchRequest(e.groupAttributes()))
+	if err != nil {
+		return nil, err
+	}
+	uids := make([]string, 0, len(groups))
+	for _, group := range groups {
+		uid := ldaputil.GetAttributeValue(group, []string{e.options.GroupQuery.QueryAttribute})
+		if len(uid) == 0 {
+			return nil, fmt.Errorf("unable to find LDAP group UID for %s", group.DN)
+		}
+		e.cachedGroups[uid] = group
+		uids = append(uids, uid)
+	}
+	return uids, nil
+}
+
+// GroupEntryFor returns the entry of the group with the given unique identifier.
+func (e *RFC2307Interface) GroupEntryFor(ldapGroupUID string) (*ldap.Entry, error) {
+	if group, ok := e.cachedGroups[ldapGroupUID]; ok {
+		return group, nil
+	}
+	request, err := e.options.GroupQuery.NewSearchRequest(ldapGroupUID, e.groupAttributes())
+	if err != nil {
+		return nil, err
+	}
+	group, err := queryForUniqueEntry(e.clientConfig, request)
+	if err != nil {
+		return nil, err
+	}
+	e.cachedGroups[ldapGroupUID] = group
+	return group, nil
+}
+
+// Exists returns true if the group query finds the group.
+func (e *RFC2307Interface) Exists(ctx context.Context, ldapGroupUID string) (bool, error) {
+	_, err := e.GroupEntryFor(ldapGroupUID)
+	if isNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ExtractMembers returns the user entries of the members of a group. With NestedGroups, the
+// members of groups that are members are included.
+func (e *RFC2307Interface) ExtractMembers(ctx context.Context, ldapGroupUID string) ([]*ldap.Entry, error) {
+	var members []*ldap.Entry
+	seenUsers := make(map[string]bool)
+	visitedGroups := make(map[string]bool)
+	if err := e.extractMembers(ctx, ldapGroupUID, visitedGroups, seenUsers, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (e *RFC2307Interface) extractMembers(ctx context.Context, ldapGroupUID string, visitedGroups, seenUsers map[string]bool, members *[]*ldap.Entry) error {
+	visitedGroups[ldapGroupUID] = true
+	group, err := e.GroupEntryFor(ldapGroupUID)
+	if err != nil {
+		return err
+	}
+
+	for _, value := range attributeValues(group, e.options.GroupMembershipAttributes) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		user, err := e.userEntryFor(value)
+		if err == nil {
+			if !seenUsers[user.DN] {
+				seenUsers[user.DN] = true
+				*members = append(*members, user)
+			}
+			continue
+		}
+		if !isNotFound(err) {
+			return err
+		}
+
+		if e.options.NestedGroups {
+			if visitedGroups[value] {
+				klog.V(4).Infof("Skipping group %s in %s, it was already visited", value, ldapGroupUID)
+				continue
+			}
+			if _, groupErr := e.GroupEntryFor(value); groupErr == nil {
+				if err := e.extractMembers(ctx, value, visitedGroups, seenUsers, members); err != nil {
+					return err
+				}
+				continue
+			} else if !isNotFound(groupErr) {
+				return groupErr
+			}
+		}
+
+		switch {
+		case ldapquery.IsQueryOutOfBoundsError(err) && e.options.TolerateMemberOutOfScopeErrors:
+			klog.V(4).Infof("Skipping member %s of group %s outside of the user search base: %v", value, ldapGroupUID, err)
+		case !ldapquery.IsQueryOutOfBoundsError(err) && e.options.TolerateMemberNotFoundErrors:
+			klog.V(4).Infof("Skipping member %s of group %s that was not found: %v", value, ldapGroupUID, err)
+		default:
+			return fmt.Errorf("member %s of group %s: %w", value, ldapGroupUID, err)
+		}
+	}
+	return nil
+}
+
+// userEntryFor finds the user entry for the value of a membership attribute.
+func (e *RFC2307Interface) userEntryFor(value string) (*ldap.Entry, error) {
+	if user, ok := e.cachedUsers[value]; ok {
+		return user, nil
+	}
+	request, err := e.options.UserQuery.NewSearchRequest(value, attributesFor([]string{e.options.UserQuery.QueryAttribute}, e.options.UserNameAttributes))
+	if err != nil {
+		return nil, err
+	}
+	user, err := queryForUniqueEntry(e.clientConfig, request)
+	if err != nil {
+		return nil, err
+	}
+	e.cachedUsers[value] = user
+	return user, nil
+}
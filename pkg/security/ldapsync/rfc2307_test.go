@@ -0,0 +1,180 @@
+package ldapsync
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/openshift/library-go/pkg/security/ldapclient"
+)
+
+func newRFC2307ClientConfig() ldapclient.Config {
+	group1 := newEntry(groupDN("group1"), map[string][]string{"cn": {"group1"}, "member": {userDN("alice"), userDN("bob")}})
+	group2 := newEntry(groupDN("group2"), map[string][]string{"cn": {"group2"}, "member": {userDN("carol"), groupDN("group1")}})
+	group3 := newEntry(groupDN("group3"), map[string][]string{"cn": {"group3"}, "member": {groupDN("group2"), groupDN("group3"), userDN("bob")}})
+	// ghost does not exist and eve is outside of the user search base
+	dangling := newEntry(groupDN("dangling"), map[string][]string{"cn": {"dangling"}, "member": {userDN("alice"), userDN("ghost"), "cn=eve,ou=contractors,dc=example,dc=com"}})
+
+	return newTestClientConfig(
+		byDN(
+			newEntry(userDN("alice"), map[string][]string{"uid": {"alice"}}),
+			newEntry(userDN("bob"), map[string][]string{"uid": {"bob"}}),
+			newEntry(userDN("carol"), map[string][]string{"uid": {"carol"}}),
+			group1, group2, group3, dangling,
+		),
+		map[string][]*ldap.Entry{
+			groupQuery("").Filter: {group1, group2, group3, dangling},
+		},
+	)
+}
+
+func newRFC2307Options() RFC2307Options {
+	return RFC2307Options{
+		GroupQuery:                groupQuery("dn"),
+		GroupNameAttributes:       []string{"cn"},
+		GroupMembershipAttributes: []string{"member"},
+		UserQuery:                 userQuery("dn"),
+		UserNameAttributes:        []string{"uid"},
+	}
+}
+
+func TestRFC2307ListGroups(t *testing.T) {
+	e := NewRFC2307Interface(newRFC2307ClientConfig(), newRFC2307Options())
+	uids, err := e.ListGroups(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{groupDN("group1"), groupDN("group2"), groupDN("group3"), groupDN("dangling")}
+	if !reflect.DeepEqual(uids, expected) {
+		t.Errorf("expected %v, got %v", expected, uids)
+	}
+
+	for uid, exists := range map[string]bool{groupDN("group1"): true, groupDN("missing"): false, userDN("alice"): false} {
+		got, err := e.Exists(context.TODO(), uid)
+		if err != nil {
+			t.Errorf("%s: %v", uid, err)
+		}
+		if got != exists {
+			t.Errorf("%s: expected exists %v, got %v", uid, exists, got)
+		}
+	}
+}
+
+func TestRFC2307ExtractMembers(t *testing.T) {
+	testCases := []struct {
+		name          string
+		group         string
+		options       func(*RFC2307Options)
+		expected      []string
+		expectedError bool
+	}{
+		{
+			name:     "direct members",
+			group:    groupDN("group1"),
+			expected: []string{userDN("alice"), userDN("bob")},
+		},
+		{
+			name:          "group member without nesting",
+			group:         groupDN("group2"),
+			expectedError: true,
+		},
+		{
+			name:     "group member tolerated without nesting",
+			group:    groupDN("group2"),
+			options:  func(o *RFC2307Options) { o.TolerateMemberOutOfScopeErrors = true },
+			expected: []string{userDN("carol")},
+		},
+		{
+			name:     "nested group",
+			group:    groupDN("group2"),
+			options:  func(o *RFC2307Options) { o.NestedGroups = true },
+			expected: []string{userDN("carol"), userDN("alice"), userDN("bob")},
+		},
+		{
+			name:     "nested groups with cycle",
+			group:    groupDN("group3"),
+			options:  func(o *RFC2307Options) { o.NestedGroups = true },
+			expected: []string{userDN("carol"), userDN("alice"), userDN("bob")},
+		},
+		{
+			name:          "member not found",
+			group:         groupDN("dangling"),
+			options:       func(o *RFC2307Options) { o.TolerateMemberOutOfScopeErrors = true },
+			expectedError: true,
+		},
+		{
+			name:          "member out of scope",
+			group:         groupDN("dangling"),
+			options:       func(o *RFC2307Options) { o.TolerateMemberNotFoundErrors = true },
+			expectedError: true,
+		},
+		{
+			name:  "members not found and out of scope tolerated",
+			group: groupDN("dangling"),
+			options: func(o *RFC2307Options) {
+				o.NestedGroups = true
+				o.TolerateMemberNotFoundErrors = true
+				o.TolerateMemberOutOfScopeErrors = true
+			},
+			expected: []string{userDN("alice")},
+		},
+		{
+			name:          "missing group",
+			group:         groupDN("missing"),
+			expectedError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			options := newRFC2307Options()
+			if tc.options != nil {
+				tc.options(&options)
+			}
+			e := NewRFC2307Interface(newRFC2307ClientConfig(), options)
+			members, err := e.ExtractMembers(context.TODO(), tc.group)
+			if tc.expectedError {
+				if err == nil {
+					t.Fatalf("expected an error, got members %v", entryDNs(members))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := entryDNs(members); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestRFC2307AttributeQueries(t *testing.T) {
+	options := newRFC2307Options()
+	options.GroupQuery = groupQuery("cn")
+	options.GroupMembershipAttributes = []string{"memberUid"}
+	options.UserQuery = userQuery("uid")
+	admins := newEntry(groupDN("admins"), map[string][]string{"cn": {"admins"}, "memberUid": {"alice", "bob"}})
+	clientConfig := newTestClientConfig(nil, map[string][]*ldap.Entry{
+		options.GroupQuery.Filter:             {admins},
+		filterFor(options.UserQuery, "alice"): {newEntry(userDN("alice"), map[string][]string{"uid": {"alice"}})},
+		filterFor(options.UserQuery, "bob"):   {newEntry(userDN("bob"), map[string][]string{"uid": {"bob"}})},
+	})
+
+	e := NewRFC2307Interface(clientConfig, options)
+	uids, err := e.ListGroups(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(uids, []string{"admins"}) {
+		t.Errorf("expected [admins], got %v", uids)
+	}
+	members, err := e.ExtractMembers(context.TODO(), "admins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := entryDNs(members), []string{userDN("alice"), userDN("bob")}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
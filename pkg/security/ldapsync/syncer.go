@@ -0,0 +1,117 @@
+package ldapsync
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	userv1 "github.com/openshift/api/user/v1"
+	userv1client "github.com/openshift/client-go/user/clientset/versioned/typed/user/v1"
+)
+
+// LDAPGroupSyncer creates and updates OpenShift groups with the members of LDAP groups.
+type LDAPGroupSyncer struct {
+	// GroupLister lists the LDAP groups to sync.
+	GroupLister LDAPGroupLister
+	// GroupMemberExtractor retrieves the members of LDAP groups.
+	GroupMemberExtractor LDAPGroupMemberExtractor
+	// UserNameMapper names the OpenShift users of the members.
+	UserNameMapper LDAPUserNameMapper
+	// GroupNameMapper names the OpenShift groups of LDAP groups.
+	GroupNameMapper LDAPGroupNameMapper
+	// GroupClient reads and writes OpenShift groups.
+	GroupClient userv1client.GroupInterface
+	// Host is the host:port of the LDAP server, recorded on the groups that are synced.
+	Host string
+	// DryRun returns the groups that would be synced without writing them.
+	DryRun bool
+}
+
+// Sync creates or updates an OpenShift group for every LDAP group listed, and returns the groups.
+// Groups that cannot be synced are skipped and their errors returned. An existing OpenShift group
+// is only updated if it was synced from the same LDAP group.
+func (s *LDAPGroupSyncer) Sync(ctx context.Context) ([]*userv1.Group, []error) {
+	ldapGroupUIDs, err := s.GroupLister.ListGroups(ctx)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	var groups []*userv1.Group
+	var errs []error
+	for _, ldapGroupUID := range ldapGroupUIDs {
+		group, err := s.syncGroup(ctx, ldapGroupUID)
+		if err != nil {
+			klog.V(2).Infof("Unable to sync LDAP group %s: %v", ldapGroupUID, err)
+			errs = append(errs, err)
+			continue
+		}
+		groups = append(groups, group)
+	}
+	return groups, errs
+}
+
+func (s *LDAPGroupSyncer) syncGroup(ctx context.Context, ldapGroupUID string) (*userv1.Group, error) {
+	members, err := s.GroupMemberExtractor.ExtractMembers(ctx, ldapGroupUID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to extract members of LDAP group %s: %w", ldapGroupUID, err)
+	}
+	var usernames []string
+	seen := sets.New[string]()
+	for _, member := range members {
+		username, err := s.UserNameMapper.UserNameFor(member)
+		if err != nil {
+			return nil, fmt.Errorf("unable to name member %s of LDAP group %s: %w", member.DN, ldapGroupUID, err)
+		}
+		if !seen.Has(username) {
+			seen.Insert(username)
+			usernames = append(usernames, username)
+		}
+	}
+	name, err := s.GroupNameMapper.GroupNameFor(ldapGroupUID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to name LDAP group %s: %w", ldapGroupUID, err)
+	}
+
+	group, err := s.GroupClient.Get(ctx, name, metav1.GetOptions{})
+	exists := true
+	switch {
+	case errors.IsNotFound(err):
+		exists = false
+		group = &userv1.Group{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	case err != nil:
+		return nil, err
+	default:
+		if err := validateGroupOwnership(group.ObjectMeta, s.Host); err != nil {
+			return nil, err
+		}
+		if uid := group.Annotations[LDAPUIDAnnotation]; uid != ldapGroupUID {
+			return nil, fmt.Errorf("group %q: %s annotation did not match LDAP UID: wanted %s, got %s", name, LDAPUIDAnnotation, ldapGroupUID, uid)
+		}
+		group = group.DeepCopy()
+	}
+
+	if group.Labels == nil {
+		group.Labels = make(map[string]string)
+	}
+	if group.Annotations == nil {
+		group.Annotations = make(map[string]string)
+	}
+	group.Labels[LDAPHostLabel] = hostLabelValue(s.Host)
+	group.Annotations[LDAPURLAnnotation] = s.Host
+	group.Annotations[LDAPUIDAnnotation] = ldapGroupUID
+	group.Annotations[LDAPSyncTimeAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	group.Users = usernames
+
+	if s.DryRun {
+		return group, nil
+	}
+	if exists {
+		return s.GroupClient.Update(ctx, group, metav1.UpdateOptions{})
+	}
+	return s.GroupClient.Create(ctx, group, metav1.CreateOptions{})
+}
@@ -0,0 +1,181 @@
+package ldapsync
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func newRFC2307Syncer(client *fakeGroupClient, groups ...string) *LDAPGroupSyncer {
+	options := newRFC2307Options()
+	options.NestedGroups = true
+	e := NewRFC2307Interface(newRFC2307ClientConfig(), options)
+	return &LDAPGroupSyncer{
+		GroupLister:          NewWhitelistGroupLister(groups),
+		GroupMemberExtractor: e,
+		UserNameMapper:       NewUserNameMapper([]string{"uid"}),
+		GroupNameMapper:      NewEntryGroupNameMapper(e, []string{"cn"}),
+		GroupClient:          client,
+		Host:                 testHost,
+	}
+}
+
+func TestSync(t *testing.T) {
+	client := newFakeGroupClient(
+		syncedGroup("group2", "other.example.com:389", groupDN("group2")),
+		syncedGroup("group3", testHost, groupDN("renamed"), "alice"),
+	)
+	syncer := newRFC2307Syncer(client, groupDN("group1"), groupDN("group2"), groupDN("group3"), groupDN("missing"))
+
+	groups, errs := syncer.Sync(context.TODO())
+	if len(errs) != 3 {
+		t.Errorf("expected errors for group2, group3 and the missing group, got %v", errs)
+	}
+	if len(groups) != 1 || groups[0].Name != "group1" {
+		t.Fatalf("expected group1 to be synced, got %v", groups)
+	}
+	group := client.groups["group1"]
+	if group == nil {
+		t.Fatal("expected group1 to be created")
+	}
+	if !reflect.DeepEqual([]string(group.Users), []string{"alice", "bob"}) {
+		t.Errorf("expected users [alice bob], got %v", group.Users)
+	}
+	if group.Labels[LDAPHostLabel] != "ldap.example.com" {
+		t.Errorf("expected host label ldap.example.com, got %q", group.Labels[LDAPHostLabel])
+	}
+	if group.Annotations[LDAPURLAnnotation] != testHost || group.Annotations[LDAPUIDAnnotation] != groupDN("group1") || len(group.Annotations[LDAPSyncTimeAnnotation]) == 0 {
+		t.Errorf("unexpected annotations %v", group.Annotations)
+	}
+	if !reflect.DeepEqual([]string(client.groups["group3"].Users), []string{"alice"}) {
+		t.Errorf("expected group3 synced from another LDAP group to be unchanged, got %v", client.groups["group3"].Users)
+	}
+
+	// an existing group keeps its other labels and is updated with the current members
+	group.Labels["team"] = "a"
+	group.Users = []string{"mallory"}
+	syncer = newRFC2307Syncer(client, groupDN("group1"))
+	if _, errs := syncer.Sync(context.TODO()); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	group = client.groups["group1"]
+	if !reflect.DeepEqual([]string(group.Users), []string{"alice", "bob"}) || group.Labels["team"] != "a" {
+		t.Errorf("unexpected group after update: %#v", group)
+	}
+}
+
+func TestSyncDryRun(t *testing.T) {
+	client := newFakeGroupClient()
+	syncer := newRFC2307Syncer(client, groupDN("group2"))
+	syncer.DryRun = true
+
+	groups, errs := syncer.Sync(context.TODO())
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if len(groups) != 1 || !reflect.DeepEqual([]string(groups[0].Users), []string{"carol", "alice", "bob"}) {
+		t.Errorf("unexpected groups %v", groups)
+	}
+	if len(client.groups) != 0 {
+		t.Errorf("expected no groups to be written, got %v", client.groups)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	for _, dryRun := range []bool{false, true} {
+		client := newFakeGroupClient(
+			syncedGroup("group1", testHost, groupDN("group1")),
+			syncedGroup("stale", testHost, groupDN("stale")),
+			syncedGroup("foreign", "other.example.com:389", groupDN("foreign")),
+			syncedGroup("kept", testHost, groupDN("kept")),
+		)
+		lister := NewOpenShiftGroupLister(nil, []string{"kept"}, testHost, client)
+		pruner := &LDAPGroupPruner{
+			GroupLister:     lister,
+			GroupDetector:   NewRFC2307Interface(newRFC2307ClientConfig(), newRFC2307Options()),
+			GroupNameMapper: lister,
+			GroupClient:     client,
+			Host:            testHost,
+			DryRun:          dryRun,
+		}
+
+		pruned, errs := pruner.Prune(context.TODO())
+		if len(errs) != 0 {
+			t.Fatal(errs)
+		}
+		if !reflect.DeepEqual(pruned, []string{"stale"}) {
+			t.Errorf("expected stale to be pruned, got %v", pruned)
+		}
+		var remaining []string
+		for name := range client.groups {
+			remaining = append(remaining, name)
+		}
+		sort.Strings(remaining)
+		expected := []string{"foreign", "group1", "kept"}
+		if dryRun {
+			expected = []string{"foreign", "group1", "kept", "stale"}
+		}
+		if !reflect.DeepEqual(remaining, expected) {
+			t.Errorf("dry run %v: expected groups %v, got %v", dryRun, expected, remaining)
+		}
+	}
+}
+
+func TestOpenShiftGroupLister(t *testing.T) {
+	client := newFakeGroupClient(
+		syncedGroup("group1", testHost, groupDN("group1")),
+		syncedGroup("group2", testHost, groupDN("group2")),
+		syncedGroup("foreign", "other.example.com:389", groupDN("foreign")),
+	)
+
+	lister := NewOpenShiftGroupLister([]string{"group2"}, nil, testHost, client)
+	uids, err := lister.ListGroups(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(uids, []string{groupDN("group2")}) {
+		t.Errorf("expected group2, got %v", uids)
+	}
+	if name, err := lister.GroupNameFor(groupDN("group2")); err != nil || name != "group2" {
+		t.Errorf("expected group2, got %q (%v)", name, err)
+	}
+	if _, err := lister.GroupNameFor(groupDN("group1")); err == nil {
+		t.Errorf("expected an error naming an unlisted group")
+	}
+
+	if _, err := NewOpenShiftGroupLister([]string{"foreign"}, nil, testHost, client).ListGroups(context.TODO()); err == nil {
+		t.Errorf("expected an error listing a group synced from another host")
+	}
+
+	client.groups["group2"].Annotations[LDAPURLAnnotation] = "ldap.example.com:636"
+	if _, err := NewOpenShiftGroupLister(nil, nil, testHost, client).ListGroups(context.TODO()); err == nil {
+		t.Errorf("expected an error listing a group synced from another port")
+	}
+}
+
+func TestGroupListersAndMappers(t *testing.T) {
+	lister := NewBlacklistGroupLister([]string{"b"}, NewWhitelistGroupLister([]string{"a", "b", "c"}))
+	uids, err := lister.ListGroups(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(uids, []string{"a", "c"}) {
+		t.Errorf("expected [a c], got %v", uids)
+	}
+
+	mapper := NewUserDefinedGroupNameMapper(map[string]string{"a": "alpha"}, nil)
+	for uid, expected := range map[string]string{"a": "alpha", "c": "c"} {
+		if name, err := mapper.GroupNameFor(uid); err != nil || name != expected {
+			t.Errorf("%s: expected %s, got %q (%v)", uid, expected, name, err)
+		}
+	}
+
+	users := NewUserNameMapper([]string{"mail", "uid"})
+	if name, err := users.UserNameFor(newEntry(userDN("alice"), map[string][]string{"uid": {"alice"}})); err != nil || name != "alice" {
+		t.Errorf("expected alice, got %q (%v)", name, err)
+	}
+	if _, err := users.UserNameFor(newEntry(userDN("bob"), map[string][]string{"cn": {"bob"}})); err == nil {
+		t.Errorf("expected an error for a user without name attributes")
+	}
+}
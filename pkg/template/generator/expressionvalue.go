@@ -1,30 +1,46 @@
 package generator
 
 import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
 	"fmt"
+	"io"
 	"math/rand"
-	"regexp"
 	"strconv"
 	"strings"
 )
 
 // ExpressionValueGenerator implements Generator interface. It generates
 // random string based on the input expression. The input expression is
-// a string, which may contain "[a-zA-Z0-9]{length}" constructs,
-// defining range and length of the result random characters.
+// a subset of the regular expression syntax:
 //
-// Examples:
+//   - "[a-zA-Z0-9]" is a character class of ranges. A class may also
+//     contain "\w" (letters, numerals and "_"), "\d" (numerals), "\a"
+//     (letters and numerals) and "\A" (symbols), which may also be used
+//     on their own outside of a class.
+//   - "(a|b)" groups expressions and chooses one of the alternatives.
+//   - "{n}" repeats the preceding character, class or group n times, and
+//     "{n,m}" between n and m times. Repeats are limited to 255.
+//   - "(?=.*X)" requires the value to contain at least one character of the
+//     class or character X. Requirements are only allowed outside of groups
+//     and apply to the whole value.
+//   - "\" escapes any of the characters above to use it literally. All
+//     other characters are copied to the value.
 //
-// from             | value
-// -----------------------------
-// "test[0-9]{1}x"  | "test7x"
-// "[0-1]{8}"       | "01001100"
-// "0x[A-F0-9]{4}"  | "0xB3AF"
-// "[a-zA-Z0-9]{8}" | "hW4yQU5i"
+// Examples:
 //
-// TODO: Support more regexp constructs.
+// from                           | value
+// ---------------------------------------------------
+// "test[0-9]{1}x"                | "test7x"
+// "[0-1]{8}"                     | "01001100"
+// "0x[A-F0-9]{4}"                | "0xB3AF"
+// "[a-zA-Z0-9]{8}"               | "hW4yQU5i"
+// "(dev|prod)-\d{2,4}"           | "prod-718"
+// "(?=.*\A)(?=.*\d)[\a\A]{12}"   | "Jb7q%Ux0e)Ws"
 type ExpressionValueGenerator struct {
 	seed *rand.Rand
+	// source is the source of seed when values are generated from crypto/rand
+	source *cryptoSource
 }
 
 const (
@@ -34,86 +50,510 @@ const (
 	ASCII    = Alphabet + Numerals + Symbols
 )
 
-var (
-	rangeExp      = regexp.MustCompile(`([\\]?[a-zA-Z0-9]\-?[a-zA-Z0-9]?)`)
-	generatorsExp = regexp.MustCompile(`\[([a-zA-Z0-9\-\\]+)\](\{([0-9]+)\})`)
-	expressionExp = regexp.MustCompile(`\[(\\w|\\d|\\a|\\A)|([a-zA-Z0-9]\-[a-zA-Z0-9])+\]`)
+const (
+	// maxRepeat is the largest count allowed in a repeat.
+	maxRepeat = 255
+	// maxValueLength is the largest value an expression may generate.
+	maxValueLength = 4096
+	// maxRequirementAttempts is how many values are generated looking for one that
+	// satisfies the requirements of an expression.
+	maxRequirementAttempts = 100
 )
 
-// NewExpressionValueGenerator creates new ExpressionValueGenerator.
+// ExpressionError is returned by ExpressionValueGenerator when an expression is
+// not valid.
+type ExpressionError struct {
+	// Expression is the invalid expression.
+	Expression string
+	// Position is the offset in characters of the invalid construct in the expression.
+	Position int
+	// Detail describes the problem.
+	Detail string
+}
+
+func (e *ExpressionError) Error() string {
+	return fmt.Sprintf("%s at position %d of expression %q", e.Detail, e.Position, e.Expression)
+}
+
+// NewExpressionValueGenerator creates new ExpressionValueGenerator. If seed is
+// nil, values are generated from a cryptographically secure source.
 func NewExpressionValueGenerator(seed *rand.Rand) ExpressionValueGenerator {
+	if seed == nil {
+		return NewSecureExpressionValueGenerator()
+	}
 	return ExpressionValueGenerator{seed: seed}
 }
 
+// NewSecureExpressionValueGenerator creates new ExpressionValueGenerator that
+// generates values from crypto/rand, suitable for passwords and other secrets.
+func NewSecureExpressionValueGenerator() ExpressionValueGenerator {
+	source := &cryptoSource{reader: cryptorand.Reader}
+	return ExpressionValueGenerator{seed: rand.New(source), source: source}
+}
+
 // GenerateValue generates random string based on the input expression.
 // The input expression is a pseudo-regex formatted string. See
-// ExpressionValueGenerator for more details.
+// ExpressionValueGenerator for more details. Invalid expressions return an
+// *ExpressionError. A generator reading from crypto/rand returns its read errors.
 func (g ExpressionValueGenerator) GenerateValue(expression string) (interface{}, error) {
+	expr, err := parseExpression(expression)
+	if err != nil {
+		return "", err
+	}
+	for attempt := 0; attempt < maxRequirementAttempts; attempt++ {
+		var value strings.Builder
+		expr.node.generate(g.seed, &value)
+		if err := g.source.takeErr(); err != nil {
+			return "", err
+		}
+		if expr.satisfied(value.String()) {
+			return value.String(), nil
+		}
+	}
+	return "", &ExpressionError{
+		Expression: expression,
+		Position:   expr.requirements[0].position,
+		Detail:     fmt.Sprintf("unable to generate a value meeting the requirements in %d attempts", maxRequirementAttempts),
+	}
+}
+
+// cryptoSource is a rand.Source reading from crypto/rand. A rand.Source cannot
+// fail, so the first read error is kept until GenerateValue returns it.
+type cryptoSource struct {
+	reader io.Reader
+	err    error
+}
+
+func (s *cryptoSource) Int63() int64 {
+	return int64(s.Uint64() &^ (1 << 63))
+}
+
+func (s *cryptoSource) Uint64() uint64 {
+	var b [8]byte
+	if _, err := io.ReadFull(s.reader, b[:]); err != nil {
+		if s.err == nil {
+			s.err = fmt.Errorf("unable to read random data: %w", err)
+		}
+		return 0
+	}
+	return binary.BigEndian.Uint64(b[:])
+}
+
+func (*cryptoSource) Seed(int64) {}
+
+// takeErr returns and clears the read error of the source, if any.
+func (s *cryptoSource) takeErr() error {
+	if s == nil {
+		return nil
+	}
+	err := s.err
+	s.err = nil
+	return err
+}
+
+// node is a parsed part of an expression.
+type node interface {
+	// generate appends a random value matching the node to value.
+	generate(seed *rand.Rand, value *strings.Builder)
+	// maxLength is the length of the longest value the node generates.
+	maxLength() int
+	// chars returns every character the node may generate.
+	chars() string
+}
+
+// literal generates itself.
+type literal string
+
+func (n literal) generate(_ *rand.Rand, value *strings.Builder) { value.WriteString(string(n)) }
+func (n literal) maxLength() int                                { return len(n) }
+func (n literal) chars() string                                 { return string(n) }
+
+// class generates one character of its alphabet.
+type class string
+
+func (n class) generate(seed *rand.Rand, value *strings.Builder) {
+	value.WriteByte(n[seed.Intn(len(n))])
+}
+func (n class) maxLength() int { return 1 }
+func (n class) chars() string  { return string(n) }
+
+// sequence generates each of its nodes in order.
+type sequence []node
+
+func (n sequence) generate(seed *rand.Rand, value *strings.Builder) {
+	for _, child := range n {
+		child.generate(seed, value)
+	}
+}
+
+func (n sequence) maxLength() int {
+	length := 0
+	for _, child := range n {
+		length += child.maxLength()
+	}
+	return length
+}
+
+func (n sequence) chars() string {
+	var chars strings.Builder
+	for _, child := range n {
+		chars.WriteString(child.chars())
+	}
+	return chars.String()
+}
+
+// alternation generates one of its nodes.
+type alternation []node
+
+func (n alternation) generate(seed *rand.Rand, value *strings.Builder) {
+	n[seed.Intn(len(n))].generate(seed, value)
+}
+
+func (n alternation) maxLength() int {
+	length := 0
+	for _, child := range n {
+		if l := child.maxLength(); l > length {
+			length = l
+		}
+	}
+	return length
+}
+
+func (n alternation) chars() string { return sequence(n).chars() }
+
+// repeat generates its node between min and max times.
+type repeat struct {
+	node     node
+	min, max int
+}
+
+func (n repeat) generate(seed *rand.Rand, value *strings.Builder) {
+	count := n.min
+	if n.max > n.min {
+		count += seed.Intn(n.max - n.min + 1)
+	}
+	for i := 0; i < count; i++ {
+		n.node.generate(seed, value)
+	}
+}
+
+func (n repeat) maxLength() int { return n.max * n.node.maxLength() }
+func (n repeat) chars() string  { return n.node.chars() }
+
+// requirement is satisfied by values containing any of its characters.
+type requirement struct {
+	chars    string
+	position int
+}
+
+// expression is a parsed expression.
+type expression struct {
+	node         node
+	requirements []requirement
+}
+
+// satisfied returns true if value meets every requirement of the expression.
+func (e *expression) satisfied(value string) bool {
+	for _, r := range e.requirements {
+		if !strings.ContainsAny(value, r.chars) {
+			return false
+		}
+	}
+	return true
+}
+
+// parser is a recursive descent parser for expressions.
+type parser struct {
+	expression string
+	input      []rune
+	pos        int
+
+	requirements []requirement
+}
+
+// parseExpression parses and validates an expression.
+func parseExpression(s string) (*expression, error) {
+	p := &parser{expression: s, input: []rune(s)}
+	n, err := p.parseAlternation(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.input) {
+		return nil, p.errorf(p.pos, "unexpected %q without a matching \"(\"", p.input[p.pos])
+	}
+	if n.maxLength() > maxValueLength {
+		return nil, p.errorf(0, "the expression generates values of up to %d characters, more than the limit of %d", n.maxLength(), maxValueLength)
+	}
+	chars := n.chars()
+	for _, r := range p.requirements {
+		if !strings.ContainsAny(chars, r.chars) {
+			return nil, p.errorf(r.position, "the requirement can never be met by the expression")
+		}
+	}
+	return &expression{node: n, requirements: p.requirements}, nil
+}
+
+func (p *parser) errorf(pos int, format string, args ...interface{}) *ExpressionError {
+	return &ExpressionError{Expression: p.expression, Position: pos, Detail: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) peek(s string) bool {
+	return strings.HasPrefix(string(p.input[p.pos:]), s)
+}
+
+// parseAlternation parses sequences separated by "|" until the end of the
+// input or of the enclosing group.
+func (p *parser) parseAlternation(depth int) (node, error) {
+	var alternatives alternation
+	// empty is the position of the first empty alternative
+	empty := -1
 	for {
-		r := generatorsExp.FindStringIndex(expression)
-		if r == nil {
+		start := p.pos
+		n, err := p.parseSequence(depth)
+		if err != nil {
+			return nil, err
+		}
+		if isEmpty(n) && empty < 0 {
+			empty = start
+		}
+		alternatives = append(alternatives, n)
+		if !p.peek("|") {
 			break
 		}
-		ranges, length, err := rangesAndLength(expression[r[0]:r[1]])
+		p.pos++
+	}
+	if len(alternatives) > 1 && empty >= 0 {
+		return nil, p.errorf(empty, "empty alternative")
+	}
+	if len(alternatives) == 1 {
+		return alternatives[0], nil
+	}
+	return alternatives, nil
+}
+
+// parseSequence parses atoms and their repeats until "|", ")" or the end of the input.
+func (p *parser) parseSequence(depth int) (node, error) {
+	var nodes sequence
+	var text strings.Builder
+	for p.pos < len(p.input) && !p.peek("|") && !p.peek(")") {
+		if p.peek("(?") {
+			if err := p.parseRequirement(depth); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		n, err := p.parseAtom(depth)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
-		err = replaceWithGenerated(
-			&expression,
-			expression[r[0]:r[1]],
-			findExpressionPos(ranges),
-			length,
-			g.seed,
-		)
+		if p.peek("{") {
+			if n, err = p.parseRepeat(n); err != nil {
+				return nil, err
+			}
+		}
+		// adjacent literals are merged so values are built from fewer nodes
+		if l, ok := n.(literal); ok {
+			text.WriteString(string(l))
+			continue
+		}
+		if text.Len() > 0 {
+			nodes = append(nodes, literal(text.String()))
+			text.Reset()
+		}
+		nodes = append(nodes, n)
+	}
+	if text.Len() > 0 {
+		nodes = append(nodes, literal(text.String()))
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return nodes, nil
+}
+
+// parseAtom parses a group, class, escape or literal character.
+func (p *parser) parseAtom(depth int) (node, error) {
+	start := p.pos
+	switch c := p.input[p.pos]; c {
+	case '(':
+		p.pos++
+		n, err := p.parseAlternation(depth + 1)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
+		if !p.peek(")") {
+			return nil, p.errorf(start, "missing \")\" to close the group")
+		}
+		if isEmpty(n) {
+			return nil, p.errorf(start, "empty group")
+		}
+		p.pos++
+		return n, nil
+	case '[':
+		return p.parseClass()
+	case '{':
+		return nil, p.errorf(start, "\"{\" must follow a character, class or group to repeat")
+	case '\\':
+		if p.pos+1 >= len(p.input) {
+			return nil, p.errorf(start, "trailing \"\\\"")
+		}
+		p.pos += 2
+		if alphabet, ok := escapedClass(p.input[start+1]); ok {
+			return class(alphabet), nil
+		}
+		if next := p.input[start+1]; !strings.ContainsRune(Alphabet+Numerals, next) {
+			return literal(string(next)), nil
+		}
+		return nil, p.errorf(start, "unknown escape \"\\%c\"", p.input[start+1])
+	default:
+		p.pos++
+		return literal(string(c)), nil
 	}
-	return expression, nil
 }
 
-// alphabetSlice produces a string slice that contains all characters within
-// a specified range.
-func alphabetSlice(from, to byte) (string, error) {
-	leftPos := strings.Index(ASCII, string(from))
-	rightPos := strings.LastIndex(ASCII, string(to))
-	if leftPos > rightPos {
-		return "", fmt.Errorf("invalid range specified: %s-%s", string(from), string(to))
+// isEmpty returns true if the node is a sequence of nothing.
+func isEmpty(n node) bool {
+	s, ok := n.(sequence)
+	return ok && len(s) == 0
+}
+
+// escapedClass returns the alphabet of the classes \w, \d, \a and \A.
+func escapedClass(c rune) (string, bool) {
+	switch c {
+	case 'w':
+		return Alphabet + Numerals + "_", true
+	case 'd':
+		return Numerals, true
+	case 'a':
+		return Alphabet + Numerals, true
+	case 'A':
+		return Symbols, true
 	}
-	return ASCII[leftPos:rightPos], nil
+	return "", false
 }
 
-// replaceWithGenerated replaces all occurrences of the given expression
-// in the string with random characters of the specified range and length.
-func replaceWithGenerated(s *string, expression string, ranges [][]byte, length int, seed *rand.Rand) error {
+// parseClass parses a character class of ranges and escaped classes.
+func (p *parser) parseClass() (node, error) {
+	start := p.pos
+	p.pos++
 	var alphabet string
-	for _, r := range ranges {
-		switch string(r[0]) + string(r[1]) {
-		case `\w`:
-			alphabet += Alphabet + Numerals + "_"
-		case `\d`:
-			alphabet += Numerals
-		case `\a`:
-			alphabet += Alphabet + Numerals
-		case `\A`:
-			alphabet += Symbols
-		default:
-			slice, err := alphabetSlice(r[0], r[1])
-			if err != nil {
-				return err
+	for {
+		if p.pos >= len(p.input) {
+			return nil, p.errorf(start, "missing \"]\" to close the character class")
+		}
+		if p.peek("]") {
+			p.pos++
+			break
+		}
+		if p.peek("\\") && p.pos+1 < len(p.input) {
+			if chars, ok := escapedClass(p.input[p.pos+1]); ok {
+				alphabet += chars
+				p.pos += 2
+				continue
+			}
+		}
+		if p.pos+2 < len(p.input) && isRangeChar(p.input[p.pos]) && p.input[p.pos+1] == '-' && isRangeChar(p.input[p.pos+2]) {
+			slice, err := alphabetSlice(byte(p.input[p.pos]), byte(p.input[p.pos+2]))
+			if err != nil || len(slice) == 0 {
+				return nil, p.errorf(p.pos, "invalid range %c-%c", p.input[p.pos], p.input[p.pos+2])
 			}
 			alphabet += slice
+			p.pos += 3
+			continue
 		}
+		return nil, p.errorf(p.pos, "a character class may only contain ranges such as a-z and the classes \\w, \\d, \\a and \\A")
 	}
-	result := make([]byte, length)
-	alphabet = removeDuplicateChars(alphabet)
-	for i := 0; i < length; i++ {
-		result[i] = alphabet[seed.Intn(len(alphabet))]
+	if len(alphabet) == 0 {
+		return nil, p.errorf(start, "empty character class")
+	}
+	return class(removeDuplicateChars(alphabet)), nil
+}
+
+func isRangeChar(c rune) bool {
+	return c < 128 && strings.ContainsRune(Alphabet+Numerals, c)
+}
+
+// parseRepeat parses "{n}" or "{n,m}" following n.
+func (p *parser) parseRepeat(n node) (node, error) {
+	start := p.pos
+	end := start
+	for end < len(p.input) && p.input[end] != '}' {
+		end++
+	}
+	if end == len(p.input) {
+		return nil, p.errorf(start, "missing \"}\" to close the repeat")
+	}
+	body := string(p.input[start+1 : end])
+	p.pos = end + 1
+
+	bounds := strings.Split(body, ",")
+	if len(bounds) > 2 {
+		return nil, p.errorf(start, "invalid repeat {%s}, expected {n} or {n,m}", body)
+	}
+	counts := make([]int, len(bounds))
+	for i, bound := range bounds {
+		count, err := strconv.Atoi(bound)
+		if err != nil || count < 0 {
+			return nil, p.errorf(start, "invalid repeat {%s}, expected {n} or {n,m}", body)
+		}
+		counts[i] = count
+	}
+	if len(counts) == 1 {
+		if counts[0] < 1 || counts[0] > maxRepeat {
+			return nil, p.errorf(start, "range must be within [1-%d] characters (%d)", maxRepeat, counts[0])
+		}
+		return repeat{node: n, min: counts[0], max: counts[0]}, nil
+	}
+	if counts[1] < 1 || counts[1] > maxRepeat || counts[0] > counts[1] {
+		return nil, p.errorf(start, "repeat {%s} must be within [0-%d] characters and allow at least 1", body, maxRepeat)
+	}
+	return repeat{node: n, min: counts[0], max: counts[1]}, nil
+}
+
+// parseRequirement parses "(?=.*X)" where X is a class or character.
+func (p *parser) parseRequirement(depth int) error {
+	start := p.pos
+	if !p.peek("(?=.*") {
+		return p.errorf(start, "unsupported group, only requirements (?=.*X) may start with \"(?\"")
+	}
+	if depth > 0 {
+		return p.errorf(start, "requirements (?=.*X) are only allowed outside of groups")
+	}
+	p.pos += len("(?=.*")
+	if p.pos >= len(p.input) || p.peek(")") {
+		return p.errorf(start, "the requirement does not name a class or character")
+	}
+	n, err := p.parseAtom(depth + 1)
+	if err != nil {
+		return err
+	}
+	switch n.(type) {
+	case class, literal:
+	default:
+		return p.errorf(start, "the requirement must be a single class or character")
 	}
-	*s = strings.Replace(*s, expression, string(result), 1)
+	if !p.peek(")") {
+		return p.errorf(start, "missing \")\" to close the requirement")
+	}
+	p.pos++
+	if p.peek("{") {
+		return p.errorf(p.pos, "a requirement cannot be repeated")
+	}
+	p.requirements = append(p.requirements, requirement{chars: n.chars(), position: start})
 	return nil
 }
 
+// alphabetSlice produces a string slice that contains all characters within
+// a specified range.
+func alphabetSlice(from, to byte) (string, error) {
+	leftPos := strings.Index(ASCII, string(from))
+	rightPos := strings.LastIndex(ASCII, string(to))
+	if leftPos > rightPos {
+		return "", fmt.Errorf("invalid range specified: %s-%s", string(from), string(to))
+	}
+	return ASCII[leftPos:rightPos], nil
+}
+
 // removeDuplicateChars removes the duplicate characters from the data slice
 func removeDuplicateChars(input string) string {
 	data := []byte(input)
@@ -130,31 +570,3 @@ func removeDuplicateChars(input string) string {
 	}
 	return string(data)
 }
-
-// findExpressionPos searches the given string for the valid expressions
-// and returns their corresponding indexes.
-func findExpressionPos(s string) [][]byte {
-	matches := rangeExp.FindAllStringIndex(s, -1)
-	result := make([][]byte, len(matches))
-	for i, r := range matches {
-		result[i] = []byte{s[r[0]], s[r[1]-1]}
-	}
-	return result
-}
-
-// rangesAndLength extracts the expression ranges (eg. [A-Z0-9]) and length
-// (eg. {3}). This helper function also validates the expression syntax and
-// its length (must be within 1..255).
-func rangesAndLength(s string) (string, int, error) {
-	expr := s[0:strings.LastIndex(s, "{")]
-	if !expressionExp.MatchString(expr) {
-		return "", 0, fmt.Errorf("malformed expresion syntax: %s", expr)
-	}
-
-	length, _ := strconv.Atoi(s[strings.LastIndex(s, "{")+1 : len(s)-1])
-	// TODO: We do need to set a better limit for the number of generated characters.
-	if length > 0 && length <= 255 {
-		return expr, length, nil
-	}
-	return "", 0, fmt.Errorf("range must be within [1-255] characters (%d)", length)
-}
@@ -1,8 +1,15 @@
 package generator
 
 import (
+	cryptorand "crypto/rand"
+	"errors"
 	"math/rand"
+	"regexp"
+	"strings"
 	"testing"
+	"testing/iotest"
+
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 func TestExpressionValueGenerator(t *testing.T) {
@@ -71,3 +78,123 @@ func TestExpressionValueGeneratorErrors(t *testing.T) {
 		t.Errorf("Expected Invalid range specified error, got %s", v)
 	}
 }
+
+func TestExpressionValueGeneratorSyntax(t *testing.T) {
+	var tests = []struct {
+		Expression string
+		// Matches is a regular expression every generated value must match.
+		Matches string
+		// Contains are the character sets every generated value must contain.
+		Contains []string
+	}{
+		{Expression: "(dev|prod)-[0-8]{3}", Matches: `^(dev|prod)-[0-8]{3}$`},
+		{Expression: "\\d{4,8}", Matches: `^[0-9]{4,8}$`},
+		{Expression: "user_\\w{2}\\a{2}", Matches: `^user_\w{2}[a-zA-Z0-9]{2}$`},
+		{Expression: "[a-f\\d]{0,3}x", Matches: `^[a-f0-9]{0,3}x$`},
+		{Expression: "((ab|c){2}|-){1,2}", Matches: `^((ab|c){2}|-){1,2}$`},
+		{Expression: "a{3}\\(\\|\\)\\{", Matches: `^aaa\(\|\)\{$`},
+		{Expression: "pass.word*+?", Matches: `^pass\.word\*\+\?$`},
+		{Expression: "(?=.*\\A)(?=.*\\d)[\\a\\A]{6}", Matches: `^.{6}$`, Contains: []string{Symbols, Numerals}},
+		{Expression: "(?=.*x)[a-y]{1,4}", Matches: `^[a-x]{1,4}$`, Contains: []string{"x"}},
+		{Expression: "", Matches: `^$`},
+	}
+
+	generator := NewExpressionValueGenerator(rand.New(rand.NewSource(1337)))
+	for _, test := range tests {
+		matches := regexp.MustCompile(test.Matches)
+		for i := 0; i < 100; i++ {
+			value, err := generator.GenerateValue(test.Expression)
+			if err != nil {
+				t.Fatalf("Failed to generate value from %s due to error: %v", test.Expression, err)
+			}
+			if !matches.MatchString(value.(string)) {
+				t.Fatalf("Generated value %q from %s does not match %s", value, test.Expression, test.Matches)
+			}
+			for _, chars := range test.Contains {
+				if !strings.ContainsAny(value.(string), chars) {
+					t.Fatalf("Generated value %q from %s does not contain any of %q", value, test.Expression, chars)
+				}
+			}
+		}
+	}
+}
+
+func TestExpressionValueGeneratorSyntaxErrors(t *testing.T) {
+	var tests = []struct {
+		Expression string
+		Position   int
+		Detail     string
+	}{
+		{"[ABC]{3}", 1, "a character class may only contain ranges"},
+		{"ab[a-z", 2, "missing \"]\""},
+		{"[]", 0, "empty character class"},
+		{"[a-a]", 1, "invalid range a-a"},
+		{"(ab|c", 0, "missing \")\""},
+		{"ab)", 2, "without a matching \"(\""},
+		{"{3}", 0, "must follow a character"},
+		{"a{3}{2}", 4, "must follow a character"},
+		{"a{3", 1, "missing \"}\""},
+		{"a{x}", 1, "invalid repeat {x}"},
+		{"a{1,2,3}", 1, "invalid repeat {1,2,3}"},
+		{"a{4,2}", 1, "must be within [0-255]"},
+		{"a{0,0}", 1, "must be within [0-255]"},
+		{"a{256}", 1, "range must be within [1-255]"},
+		{"\\q", 0, "unknown escape"},
+		{"ab\\", 2, "trailing"},
+		{"(?:ab)", 0, "unsupported group"},
+		{"(a(?=.*\\d))", 2, "only allowed outside of groups"},
+		{"(?=.*\\d){2}a", 8, "cannot be repeated"},
+		{"(?=.*(a|b))a", 0, "single class or character"},
+		{"(?=.*\\A)[a-z]{8}", 0, "can never be met"},
+		{"(\\w{255}){20}", 0, "more than the limit of 4096"},
+		{"a()b", 1, "empty group"},
+		{"(|)", 1, "empty alternative"},
+		{"(a|)", 3, "empty alternative"},
+		{"(|a)", 1, "empty alternative"},
+		{"a|", 2, "empty alternative"},
+	}
+
+	generator := NewExpressionValueGenerator(rand.New(rand.NewSource(1337)))
+	for _, test := range tests {
+		_, err := generator.GenerateValue(test.Expression)
+		expressionErr, ok := err.(*ExpressionError)
+		if !ok {
+			t.Errorf("Expected %s to produce an expression error, got %v", test.Expression, err)
+			continue
+		}
+		if expressionErr.Position != test.Position || !strings.Contains(expressionErr.Detail, test.Detail) {
+			t.Errorf("Expected %s to produce %q at position %d, got %v", test.Expression, test.Detail, test.Position, err)
+		}
+	}
+}
+
+func TestSecureExpressionValueGenerator(t *testing.T) {
+	generator := NewSecureExpressionValueGenerator()
+	values := sets.New[string]()
+	for i := 0; i < 10; i++ {
+		value, err := generator.GenerateValue("(?=.*\\d)[\\a]{16}")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !regexp.MustCompile(`^[a-zA-Z0-9]{16}$`).MatchString(value.(string)) || !strings.ContainsAny(value.(string), Numerals) {
+			t.Fatalf("Unexpected value %q", value)
+		}
+		values.Insert(value.(string))
+	}
+	if values.Len() != 10 {
+		t.Errorf("Expected distinct values, got %v", sets.List(values))
+	}
+}
+
+func TestSecureExpressionValueGeneratorReadError(t *testing.T) {
+	generator := NewSecureExpressionValueGenerator()
+	generator.source.reader = iotest.ErrReader(errors.New("entropy exhausted"))
+	if value, err := generator.GenerateValue("[\\a]{16}"); err == nil || !strings.Contains(err.Error(), "entropy exhausted") {
+		t.Errorf("Expected the read error, got %q and %v", value, err)
+	}
+
+	generator.source.reader = cryptorand.Reader
+	if _, err := generator.GenerateValue("[\\a]{16}"); err != nil {
+		t.Errorf("Expected the read error to be cleared, got %v", err)
+	}
+}
@@ -4,18 +4,39 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/klog/v2"
 )
 
+// stringVisitor is called with each string in an object, the path of the field holding it, and
+// whether that field only accepts strings. It returns the new value of the field and whether the
+// value is a string (true) or JSON to decode (false).
+type stringVisitor func(path *field.Path, stringField bool, in string) (string, bool)
+
 // visitObjectStrings recursively visits all string fields in the object and calls the
 // visitor function on them. The visitor function can be used to modify the
 // value of the string fields.
 func visitObjectStrings(obj interface{}, visitor func(string) (string, bool)) error {
-	return visitValue(reflect.ValueOf(obj), visitor)
+	return visitObjectStringsWithPath(obj, nil, func(_ *field.Path, _ bool, in string) (string, bool) {
+		return visitor(in)
+	})
+}
+
+// visitObjectStringsWithPath is visitObjectStrings with the path of every field, rooted at root.
+// Struct fields are named by their json name, and the content of unstructured objects is visited
+// directly.
+func visitObjectStringsWithPath(obj interface{}, root *field.Path, visitor stringVisitor) error {
+	if u, ok := obj.(*unstructured.Unstructured); ok && u != nil {
+		obj = u.Object
+	}
+	return visitValue(reflect.ValueOf(obj), root, visitor)
 }
 
-func visitValue(v reflect.Value, visitor func(string) (string, bool)) error {
+func visitValue(v reflect.Value, path *field.Path, visitor stringVisitor) error {
 	// you'll never be able to substitute on a nil.  Check the kind first or you'll accidentally
 	// end up panic-ing
 	switch v.Kind() {
@@ -28,14 +49,14 @@ func visitValue(v reflect.Value, visitor func(string) (string, bool)) error {
 	switch v.Kind() {
 
 	case reflect.Ptr, reflect.Interface:
-		err := visitValue(v.Elem(), visitor)
+		err := visitValue(v.Elem(), path, visitor)
 		if err != nil {
 			return err
 		}
 	case reflect.Slice, reflect.Array:
 		vt := v.Type().Elem()
 		for i := 0; i < v.Len(); i++ {
-			val, err := visitUnsettableValues(vt, v.Index(i), visitor)
+			val, err := visitUnsettableValues(vt, v.Index(i), path.Index(i), visitor)
 			if err != nil {
 				return err
 			}
@@ -43,21 +64,33 @@ func visitValue(v reflect.Value, visitor func(string) (string, bool)) error {
 		}
 	case reflect.Struct:
 		for i := 0; i < v.NumField(); i++ {
-			err := visitValue(v.Field(i), visitor)
+			structField := v.Type().Field(i)
+			if !structField.IsExported() {
+				continue
+			}
+			err := visitValue(v.Field(i), fieldPath(path, structField), visitor)
 			if err != nil {
 				return err
 			}
 		}
 	case reflect.Map:
 		vt := v.Type().Elem()
-		for _, oldKey := range v.MapKeys() {
-			newKey, err := visitUnsettableValues(oldKey.Type(), oldKey, visitor)
+		// keys are visited in order so the fields are visited in the same order every time
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface()) })
+		for _, oldKey := range keys {
+			// the fields of unstructured content are named like struct fields
+			keyPath := path.Key(fmt.Sprint(oldKey.Interface()))
+			if vt.Kind() == reflect.Interface {
+				keyPath = path.Child(fmt.Sprint(oldKey.Interface()))
+			}
+			newKey, err := visitUnsettableValues(oldKey.Type(), oldKey, keyPath, visitor)
 			if err != nil {
 				return err
 			}
 
 			oldValue := v.MapIndex(oldKey)
-			newValue, err := visitUnsettableValues(vt, oldValue, visitor)
+			newValue, err := visitUnsettableValues(vt, oldValue, keyPath, visitor)
 			if err != nil {
 				return err
 			}
@@ -68,7 +101,7 @@ func visitValue(v reflect.Value, visitor func(string) (string, bool)) error {
 		if !v.CanSet() {
 			return fmt.Errorf("unable to set String value '%v'", v)
 		}
-		s, asString := visitor(v.String())
+		s, asString := visitor(path, true, v.String())
 		if !asString {
 			return fmt.Errorf("attempted to set String field to non-string value '%v'", s)
 		}
@@ -81,7 +114,7 @@ func visitValue(v reflect.Value, visitor func(string) (string, bool)) error {
 }
 
 // visitUnsettableValues creates a copy of the object you want to modify and returns the modified result
-func visitUnsettableValues(typeOf reflect.Type, original reflect.Value, visitor func(string) (string, bool)) (reflect.Value, error) {
+func visitUnsettableValues(typeOf reflect.Type, original reflect.Value, path *field.Path, visitor stringVisitor) (reflect.Value, error) {
 	val := reflect.New(typeOf).Elem()
 	existing := original
 	// if the value type is interface, we must resolve it to a concrete value prior to setting it back.
@@ -90,8 +123,11 @@ func visitUnsettableValues(typeOf reflect.Type, original reflect.Value, visitor
 	}
 	switch existing.Kind() {
 	case reflect.String:
-		s, asString := visitor(existing.String())
+		s, asString := visitor(path, typeOf.Kind() == reflect.String, existing.String())
 
+		if !asString && typeOf.Kind() == reflect.String {
+			return original, fmt.Errorf("attempted to set String field to non-string value '%v'", s)
+		}
 		if asString {
 			val = reflect.ValueOf(s)
 		} else {
@@ -113,8 +149,22 @@ func visitUnsettableValues(typeOf reflect.Type, original reflect.Value, visitor
 		if existing.IsValid() && existing.Kind() != reflect.Invalid {
 			val.Set(existing)
 		}
-		visitValue(val, visitor)
+		visitValue(val, path, visitor)
 	}
 
 	return val, nil
 }
+
+// fieldPath returns the path of a struct field, using its json name. Embedded structs without a
+// json name, like the TypeMeta of API objects, do not add to the path.
+func fieldPath(path *field.Path, structField reflect.StructField) *field.Path {
+	name, _, _ := strings.Cut(structField.Tag.Get("json"), ",")
+	switch {
+	case len(name) > 0 && name != "-":
+		return path.Child(name)
+	case structField.Anonymous:
+		return path
+	default:
+		return path.Child(structField.Name)
+	}
+}
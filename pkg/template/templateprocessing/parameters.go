@@ -0,0 +1,277 @@
+package templateprocessing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	templatev1 "github.com/openshift/api/template/v1"
+)
+
+// ParameterType is the type of value a template parameter holds.
+type ParameterType string
+
+const (
+	// ParameterTypeString accepts any value. It is the type of constraints which declare none.
+	ParameterTypeString ParameterType = "string"
+	// ParameterTypeInt accepts a base 10 integer.
+	ParameterTypeInt ParameterType = "int"
+	// ParameterTypeBool accepts "true" or "false".
+	ParameterTypeBool ParameterType = "bool"
+	// ParameterTypeBase64 accepts standard base64 encoded data.
+	ParameterTypeBase64 ParameterType = "base64"
+	// ParameterTypeJSON accepts any JSON value.
+	ParameterTypeJSON ParameterType = "json"
+)
+
+// ParameterConstraint declares the type and allowed values of a template parameter.
+//
+// Parameters of type string and base64 are always substituted as strings, including with the
+// ${{PARAMETER}} syntax. The value of int, bool and json parameters is decoded as JSON when
+// substituted with ${{PARAMETER}}, and may not be substituted that way into a field that only
+// accepts strings. Parameters without a constraint keep the legacy behavior of json parameters.
+type ParameterConstraint struct {
+	// Type is the type of the parameter value. Defaults to string.
+	Type ParameterType
+	// Pattern, if set, is a regular expression the whole value must match.
+	Pattern string
+	// Enum, if set, lists the values the parameter may have.
+	Enum []string
+}
+
+// Validate returns an error if value is not allowed by the constraint.
+func (c ParameterConstraint) Validate(value string) error {
+	switch c.Type {
+	case "", ParameterTypeString:
+	case ParameterTypeInt:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+	case ParameterTypeBool:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("must be true or false")
+		}
+	case ParameterTypeBase64:
+		if _, err := base64.StdEncoding.DecodeString(value); err != nil {
+			return fmt.Errorf("must be base64 encoded: %v", err)
+		}
+	case ParameterTypeJSON:
+		if !json.Valid([]byte(value)) {
+			return fmt.Errorf("must be valid JSON")
+		}
+	default:
+		return fmt.Errorf("has an unknown type %q", c.Type)
+	}
+	if len(c.Pattern) > 0 {
+		pattern, err := compilePattern(c.Pattern)
+		if err != nil {
+			return fmt.Errorf("has an invalid pattern %q: %v", c.Pattern, err)
+		}
+		if !pattern.MatchString(value) {
+			return fmt.Errorf("must match %q", c.Pattern)
+		}
+	}
+	if len(c.Enum) > 0 {
+		for _, allowed := range c.Enum {
+			if value == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %q", c.Enum)
+	}
+	return nil
+}
+
+// nonString returns true if ${{PARAMETER}} substitutes the value as JSON rather than a string.
+func (c ParameterConstraint) nonString() bool {
+	switch c.Type {
+	case "", ParameterTypeString, ParameterTypeBase64:
+		return false
+	default:
+		return true
+	}
+}
+
+// nonStringParameter returns true if ${{PARAMETER}} substitutes the value of the named parameter
+// as JSON rather than a string, which is the case for all parameters without a constraint.
+func (p *Processor) nonStringParameter(name string) bool {
+	constraint, ok := p.Constraints[name]
+	return !ok || constraint.nonString()
+}
+
+// compiledPatterns caches the regular expressions of constraint patterns, which are declared once
+// but validated for every processed template.
+var compiledPatterns sync.Map
+
+// compilePattern compiles a constraint pattern that must match the whole value.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	type result struct {
+		regexp *regexp.Regexp
+		err    error
+	}
+	if cached, ok := compiledPatterns.Load(pattern); ok {
+		return cached.(result).regexp, cached.(result).err
+	}
+	compiled, err := regexp.Compile("^(?:" + pattern + ")$")
+	compiledPatterns.Store(pattern, result{regexp: compiled, err: err})
+	return compiled, err
+}
+
+// SubstitutionSite is a place in a template where a parameter is substituted.
+type SubstitutionSite struct {
+	// Kind and Name identify the object the parameter is substituted into. They are empty for the
+	// message and labels of the template.
+	Kind string
+	Name string
+	// Path is the field the parameter is substituted into, such as
+	// template.objects[0].metadata.labels[app].
+	Path *field.Path
+	// Parameter is the name of the substituted parameter.
+	Parameter string
+	// NonString is true for the ${{PARAMETER}} syntax, which replaces the whole field.
+	NonString bool
+	// StringField is true if the field only accepts strings, as in typed objects.
+	StringField bool
+}
+
+// String describes the site for error messages.
+func (s SubstitutionSite) String() string {
+	if len(s.Kind) == 0 {
+		return fmt.Sprintf("parameter %s", s.Parameter)
+	}
+	return fmt.Sprintf("parameter %s in %s %q", s.Parameter, s.Kind, s.Name)
+}
+
+// SubstitutionSites lists every place Process would substitute a parameter of the template,
+// without changing the template. Objects that cannot be decoded are returned as errors.
+func (p *Processor) SubstitutionSites(template *templatev1.Template) ([]SubstitutionSite, field.ErrorList) {
+	var errs field.ErrorList
+	paramMap := make(map[string]templatev1.Parameter)
+	for _, param := range template.Parameters {
+		paramMap[param.Name] = param
+	}
+	templatePath := field.NewPath("template")
+
+	var sites []SubstitutionSite
+	record := func(kind, name string) stringVisitor {
+		return func(path *field.Path, stringField bool, in string) (string, bool) {
+			for _, param := range referencedParameters(paramMap, in) {
+				sites = append(sites, SubstitutionSite{
+					Kind:        kind,
+					Name:        name,
+					Path:        path,
+					Parameter:   param.name,
+					NonString:   param.nonString,
+					StringField: stringField,
+				})
+			}
+			return in, true
+		}
+	}
+
+	// the message and labels of the template use the value of ${{PARAMETER}} as a string
+	record("", "")(templatePath.Child("message"), false, template.Message)
+	keys := make([]string, 0, len(template.ObjectLabels))
+	for k := range template.ObjectLabels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		path := templatePath.Child("labels").Key(k)
+		record("", "")(path, false, k)
+		record("", "")(path, false, template.ObjectLabels[k])
+	}
+
+	objectsPath := templatePath.Child("objects")
+	for i, item := range template.Objects {
+		var obj runtime.Object
+		if len(item.Raw) > 0 {
+			decodedObj, err := runtime.Decode(unstructured.UnstructuredJSONScheme, item.Raw)
+			if err != nil {
+				errs = append(errs, field.Invalid(objectsPath.Index(i), item, fmt.Sprintf("unable to handle object: %v", err)))
+				continue
+			}
+			obj = decodedObj
+		} else if item.Object != nil {
+			obj = item.Object.DeepCopyObject()
+		} else {
+			continue
+		}
+		var name string
+		if accessor, err := meta.Accessor(obj); err == nil {
+			name = accessor.GetName()
+		}
+		visitObjectStringsWithPath(obj, objectsPath.Index(i), record(obj.GetObjectKind().GroupVersionKind().Kind, name))
+	}
+	return sites, errs
+}
+
+// referencedParameter is a parameter referenced in a string.
+type referencedParameter struct {
+	name      string
+	nonString bool
+}
+
+// referencedParameters returns the parameters EvaluateParameterSubstitution substitutes in a string.
+func referencedParameters(params map[string]templatev1.Parameter, in string) []referencedParameter {
+	for _, match := range nonStringParameterExp.FindAllStringSubmatch(in, -1) {
+		if _, found := params[match[1]]; found {
+			return []referencedParameter{{name: match[1], nonString: true}}
+		}
+	}
+	var refs []referencedParameter
+	for _, match := range stringParameterExp.FindAllStringSubmatch(in, -1) {
+		if _, found := params[match[1]]; found {
+			refs = append(refs, referencedParameter{name: match[1]})
+		}
+	}
+	return refs
+}
+
+// ValidateParameterValues checks the parameter values of the template against the constraints of
+// the processor, and that every value fits the fields it is substituted into. Errors name the
+// field and object the value would be substituted into, or the parameter if it is not used.
+func (p *Processor) ValidateParameterValues(template *templatev1.Template) field.ErrorList {
+	var errs field.ErrorList
+	sites, _ := p.SubstitutionSites(template)
+	used := make(map[string]bool)
+	for _, site := range sites {
+		used[site.Parameter] = true
+	}
+
+	parametersPath := field.NewPath("template").Child("parameters")
+	invalid := make(map[string]error)
+	for i, param := range template.Parameters {
+		constraint, ok := p.Constraints[param.Name]
+		if !ok || len(param.Value) == 0 {
+			continue
+		}
+		if err := constraint.Validate(param.Value); err != nil {
+			invalid[param.Name] = err
+			if !used[param.Name] {
+				errs = append(errs, field.Invalid(parametersPath.Index(i).Child("value"), param.Value, fmt.Sprintf("parameter %s %v", param.Name, err)))
+			}
+		}
+	}
+
+	for _, site := range sites {
+		param := GetParameterByName(template, site.Parameter)
+		if err, ok := invalid[site.Parameter]; ok {
+			errs = append(errs, field.Invalid(site.Path, param.Value, fmt.Sprintf("%s %v", site, err)))
+			continue
+		}
+		if site.NonString && site.StringField && p.nonStringParameter(site.Parameter) {
+			errs = append(errs, field.Invalid(site.Path, param.Value, fmt.Sprintf("%s is substituted as a non-string value into a field that only accepts strings", site)))
+		}
+	}
+	return errs
+}
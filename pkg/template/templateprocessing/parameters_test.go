@@ -0,0 +1,278 @@
+package templateprocessing
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	templatev1 "github.com/openshift/api/template/v1"
+)
+
+func TestParameterConstraintValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		constraint ParameterConstraint
+		value      string
+		err        string
+	}{
+		{name: "untyped", constraint: ParameterConstraint{}, value: "anything"},
+		{name: "int", constraint: ParameterConstraint{Type: ParameterTypeInt}, value: "-42"},
+		{name: "not an int", constraint: ParameterConstraint{Type: ParameterTypeInt}, value: "4.2", err: "must be an integer"},
+		{name: "bool", constraint: ParameterConstraint{Type: ParameterTypeBool}, value: "false"},
+		{name: "not a bool", constraint: ParameterConstraint{Type: ParameterTypeBool}, value: "yes", err: "must be true or false"},
+		{name: "base64", constraint: ParameterConstraint{Type: ParameterTypeBase64}, value: "c2VjcmV0"},
+		{name: "not base64", constraint: ParameterConstraint{Type: ParameterTypeBase64}, value: "secret!", err: "must be base64 encoded"},
+		{name: "json", constraint: ParameterConstraint{Type: ParameterTypeJSON}, value: `{"a":[1,2]}`},
+		{name: "not json", constraint: ParameterConstraint{Type: ParameterTypeJSON}, value: `{"a":`, err: "must be valid JSON"},
+		{name: "unknown type", constraint: ParameterConstraint{Type: "float"}, value: "1", err: `unknown type "float"`},
+		{name: "pattern", constraint: ParameterConstraint{Pattern: "[a-z]+"}, value: "abc"},
+		{name: "pattern is anchored", constraint: ParameterConstraint{Pattern: "[a-z]+|x"}, value: "abc1", err: `must match "[a-z]+|x"`},
+		{name: "invalid pattern", constraint: ParameterConstraint{Pattern: "("}, value: "abc", err: "invalid pattern"},
+		{name: "enum", constraint: ParameterConstraint{Type: ParameterTypeInt, Enum: []string{"1", "3"}}, value: "3"},
+		{name: "not in enum", constraint: ParameterConstraint{Enum: []string{"dev", "prod"}}, value: "test", err: `must be one of ["dev" "prod"]`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.constraint.Validate(test.value)
+			switch {
+			case len(test.err) == 0 && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case len(test.err) > 0 && (err == nil || !strings.Contains(err.Error(), test.err)):
+				t.Errorf("expected error %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+const constraintsTemplate = `{
+	"kind": "Template", "apiVersion": "template.openshift.io/v1",
+	"message": "Created ${NAME}",
+	"labels": {"app": "${NAME}"},
+	"objects": [
+		{
+			"kind": "Deployment", "apiVersion": "apps/v1",
+			"metadata": {"name": "${NAME}"},
+			"spec": {
+				"replicas": "${{REPLICAS}}",
+				"paused": "${{PAUSED}}",
+				"template": {"spec": {"containers": [{"name": "web", "env": [{"name": "PIN", "value": "${{PIN}}"}, {"name": "ENV", "value": "${NAME}-${ENVIRONMENT}"}]}]}}
+			}
+		},
+		{
+			"kind": "Secret", "apiVersion": "v1",
+			"metadata": {"name": "credentials"},
+			"data": {"token": "${{TOKEN}}"}
+		}
+	],
+	"parameters": [
+		{"name": "NAME", "value": "web"},
+		{"name": "REPLICAS", "value": "3"},
+		{"name": "PAUSED", "value": "false"},
+		{"name": "PIN", "value": "0042"},
+		{"name": "ENVIRONMENT", "value": "prod"},
+		{"name": "TOKEN", "value": "c2VjcmV0"},
+		{"name": "UNUSED", "value": "1"}
+	]
+}`
+
+var constraints = map[string]ParameterConstraint{
+	"REPLICAS":    {Type: ParameterTypeInt},
+	"PAUSED":      {Type: ParameterTypeBool},
+	"PIN":         {Type: ParameterTypeString, Pattern: "[0-9]{4}"},
+	"ENVIRONMENT": {Enum: []string{"dev", "prod"}},
+	"TOKEN":       {Type: ParameterTypeBase64},
+	"UNUSED":      {Type: ParameterTypeInt},
+}
+
+func decodeTemplate(t *testing.T, data string) *templatev1.Template {
+	t.Helper()
+	template := &templatev1.Template{}
+	if err := runtime.DecodeInto(codecFactory.UniversalDecoder(), []byte(data), template); err != nil {
+		t.Fatal(err)
+	}
+	return template
+}
+
+func TestSubstitutionSites(t *testing.T) {
+	template := decodeTemplate(t, constraintsTemplate)
+	template.Objects = append(template.Objects, runtime.RawExtension{Object: &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "config"},
+		Data:       map[string]string{"replicas": "${{REPLICAS}}", "${NAME}": "name"},
+	}})
+	processor := NewProcessor(nil)
+
+	sites, errs := processor.SubstitutionSites(template)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	var actual []string
+	for _, site := range sites {
+		actual = append(actual, strings.Join([]string{site.Kind, site.Name, site.Path.String(), site.Parameter, boolString(site.NonString, "nonstring"), boolString(site.StringField, "stringfield")}, " "))
+	}
+	expected := []string{
+		"  template.message NAME  ",
+		"  template.labels[app] NAME  ",
+		"Deployment ${NAME} template.objects[0].metadata.name NAME  ",
+		"Deployment ${NAME} template.objects[0].spec.paused PAUSED nonstring ",
+		"Deployment ${NAME} template.objects[0].spec.replicas REPLICAS nonstring ",
+		"Deployment ${NAME} template.objects[0].spec.template.spec.containers[0].env[0].value PIN nonstring ",
+		"Deployment ${NAME} template.objects[0].spec.template.spec.containers[0].env[1].value NAME  ",
+		"Deployment ${NAME} template.objects[0].spec.template.spec.containers[0].env[1].value ENVIRONMENT  ",
+		"Secret credentials template.objects[1].data.token TOKEN nonstring ",
+		"ConfigMap config template.objects[2].data[${NAME}] NAME  stringfield",
+		"ConfigMap config template.objects[2].data[replicas] REPLICAS nonstring stringfield",
+	}
+	// maps are visited in random order
+	if !sameElements(actual, expected) {
+		t.Errorf("unexpected sites:\n%s", strings.Join(actual, "\n"))
+	}
+	if template.Objects[2].Object.(*corev1.ConfigMap).Data["replicas"] != "${{REPLICAS}}" {
+		t.Errorf("the template was modified")
+	}
+}
+
+func boolString(b bool, s string) string {
+	if b {
+		return s
+	}
+	return ""
+}
+
+func sameElements(a, b []string) bool {
+	counts := make(map[string]int)
+	for _, s := range a {
+		counts[s]++
+	}
+	for _, s := range b {
+		counts[s]--
+	}
+	for _, count := range counts {
+		if count != 0 {
+			return false
+		}
+	}
+	return len(a) == len(b)
+}
+
+func TestProcessWithConstraints(t *testing.T) {
+	template := decodeTemplate(t, constraintsTemplate)
+	processor := &Processor{Constraints: constraints}
+	if errs := processor.Process(template); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	objects := make([]map[string]interface{}, 0, len(template.Objects))
+	for _, item := range template.Objects {
+		objects = append(objects, item.Object.(runtime.Unstructured).UnstructuredContent())
+	}
+	spec := objects[0]["spec"].(map[string]interface{})
+	if spec["replicas"] != float64(3) || spec["paused"] != false {
+		t.Errorf("expected typed values, got %#v", spec)
+	}
+	env := spec["template"].(map[string]interface{})["spec"].(map[string]interface{})["containers"].([]interface{})[0].(map[string]interface{})["env"].([]interface{})
+	if pin := env[0].(map[string]interface{})["value"]; pin != "0042" {
+		t.Errorf("expected a string parameter to be substituted as a string, got %#v", pin)
+	}
+	if token := objects[1]["data"].(map[string]interface{})["token"]; token != "c2VjcmV0" {
+		t.Errorf("expected a base64 parameter to be substituted as a string, got %#v", token)
+	}
+}
+
+func TestValidateParameterValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   map[string]string
+		typed    bool
+		expected field.ErrorList
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name:   "invalid values at every site",
+			values: map[string]string{"REPLICAS": "three", "ENVIRONMENT": "test"},
+			expected: field.ErrorList{
+				field.Invalid(field.NewPath("template", "objects").Index(0).Child("spec", "replicas"), "three", `parameter REPLICAS in Deployment "${NAME}" must be an integer`),
+				field.Invalid(field.NewPath("template", "objects").Index(0).Child("spec", "template", "spec", "containers").Index(0).Child("env").Index(1).Child("value"), "test", `parameter ENVIRONMENT in Deployment "${NAME}" must be one of ["dev" "prod"]`),
+			},
+		},
+		{
+			name:   "invalid unused value",
+			values: map[string]string{"UNUSED": "x"},
+			expected: field.ErrorList{
+				field.Invalid(field.NewPath("template", "parameters").Index(6).Child("value"), "x", "parameter UNUSED must be an integer"),
+			},
+		},
+		{
+			name:   "empty values are not validated",
+			values: map[string]string{"UNUSED": ""},
+		},
+		{
+			name:  "non-string value in a string field",
+			typed: true,
+			expected: field.ErrorList{
+				field.Invalid(field.NewPath("template", "objects").Index(2).Child("data").Key("replicas"), "3", `parameter REPLICAS in ConfigMap "config" is substituted as a non-string value into a field that only accepts strings`),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			template := decodeTemplate(t, constraintsTemplate)
+			for i, param := range template.Parameters {
+				if value, ok := test.values[param.Name]; ok {
+					template.Parameters[i].Value = value
+				}
+			}
+			if test.typed {
+				template.Objects = append(template.Objects, runtime.RawExtension{Object: &corev1.ConfigMap{
+					TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+					ObjectMeta: metav1.ObjectMeta{Name: "config"},
+					Data:       map[string]string{"replicas": "${{REPLICAS}}", "pin": "${{PIN}}"},
+				}})
+			}
+			processor := &Processor{Constraints: constraints}
+			errs := processor.ValidateParameterValues(template)
+			if !reflect.DeepEqual(errs, test.expected) {
+				t.Errorf("unexpected errors:\n%v\nexpected:\n%v", errs, test.expected)
+			}
+			if len(test.expected) > 0 {
+				if processErrs := processor.Process(template); !reflect.DeepEqual(processErrs, test.expected) {
+					t.Errorf("expected Process to return the validation errors, got %v", processErrs)
+				}
+			}
+		})
+	}
+}
+
+func TestConstraintWithoutType(t *testing.T) {
+	template := decodeTemplate(t, `{
+	"kind": "Template", "apiVersion": "template.openshift.io/v1",
+	"parameters": [{"name": "PIN", "value": "0042"}, {"name": "COUNT", "value": "3"}]
+}`)
+	template.Objects = append(template.Objects, runtime.RawExtension{Object: &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "config"},
+		Data:       map[string]string{"pin": "${{PIN}}"},
+	}})
+	processor := &Processor{Constraints: map[string]ParameterConstraint{"PIN": {Pattern: "[0-9]{4}"}}}
+
+	if errs := processor.ValidateParameterValues(template); len(errs) > 0 {
+		t.Errorf("expected a parameter with a pattern only to be a string, got %v", errs)
+	}
+	params := map[string]templatev1.Parameter{}
+	for _, param := range template.Parameters {
+		params[param.Name] = param
+	}
+	if out, asString := processor.EvaluateParameterSubstitution(params, "${{PIN}}"); out != "0042" || !asString {
+		t.Errorf("expected a parameter with a pattern only to be substituted as a string, got %q (string: %v)", out, asString)
+	}
+	// parameters without a constraint keep the legacy behavior
+	if out, asString := processor.EvaluateParameterSubstitution(params, "${{COUNT}}"); out != "3" || asString {
+		t.Errorf("expected a parameter without a constraint to be substituted as JSON, got %q (string: %v)", out, asString)
+	}
+}
@@ -1,6 +1,7 @@
 package templateprocessing
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
@@ -26,6 +27,9 @@ var nonStringParameterExp = regexp.MustCompile(`^\$\{\{([a-zA-Z0-9\_]+)\}\}$`)
 // Processor process the Template into the List with substituted parameters
 type Processor struct {
 	Generators map[string]Generator
+	// Constraints declares the type and allowed values of parameters by name.
+	// The value of parameters without a constraint is substituted as JSON with ${{PARAMETER}}.
+	Constraints map[string]ParameterConstraint
 }
 
 // NewProcessor creates new Processor and initializv1es its set of generators.
@@ -34,7 +38,8 @@ func NewProcessor(generators map[string]Generator) *Processor {
 }
 
 // Process transforms Template object into List object. It generates
-// Parameter values using the defined set of generators first, and then it
+// Parameter values using the defined set of generators first, validates
+// them against the constraints of the processor, and then it
 // substitutes all Parameter expression occurrences with their corresponding
 // values (currently in the containers' Environment variables only).
 func (p *Processor) Process(template *templatev1.Template) field.ErrorList {
@@ -43,6 +48,9 @@ func (p *Processor) Process(template *templatev1.Template) field.ErrorList {
 	if errs := p.GenerateParameterValues(template); len(errs) > 0 {
 		return append(templateErrors, errs...)
 	}
+	if errs := p.ValidateParameterValues(template); len(errs) > 0 {
+		return append(templateErrors, errs...)
+	}
 
 	// Place parameters into a map for efficient lookup
 	paramMap := make(map[string]templatev1.Parameter)
@@ -156,14 +164,14 @@ func (p *Processor) EvaluateParameterSubstitution(params map[string]templatev1.P
 	out := in
 	// First check if the value matches the "${{KEY}}" substitution syntax, which
 	// means replace and drop the quotes because the parameter value is to be used
-	// as a non-string value.  If we hit a match here, we're done because the
+	// as a non-string value, unless the parameter is declared as a string.  If we hit a match here, we're done because the
 	// "${{KEY}}" syntax is exact match only, it cannot be used in a value like
 	// "FOO_${{KEY}}_BAR", no substitution will be performed if it is used in that way.
 	for _, match := range nonStringParameterExp.FindAllStringSubmatch(in, -1) {
 		if len(match) > 1 {
 			if paramValue, found := params[match[1]]; found {
 				out = strings.Replace(out, match[0], paramValue.Value, 1)
-				return out, false
+				return out, !p.nonStringParameter(match[1])
 			}
 		}
 	}
@@ -205,7 +213,9 @@ func (p *Processor) SubstituteParameters(params map[string]templatev1.Parameter,
 // "[0-1]{8}"       | "01001100"
 // "0x[A-F0-9]{4}"  | "0xB3AF"
 // "[a-zA-Z0-9]{8}" | "hW4yQU5i"
+//
 // If an error occurs, the parameter that caused the error is returned along with the error message.
+// Invalid expressions are reported against the parameter's from field.
 func (p *Processor) GenerateParameterValues(t *templatev1.Template) field.ErrorList {
 	var errs field.ErrorList
 
@@ -229,6 +239,11 @@ func (p *Processor) GenerateParameterValues(t *templatev1.Template) field.ErrorL
 			}
 			value, err := generator.GenerateValue(param.From)
 			if err != nil {
+				var expressionErr *ExpressionError
+				if errors.As(err, &expressionErr) {
+					errs = append(errs, field.Invalid(templatePath.Child("from"), param.From, fmt.Sprintf("%s at position %d", expressionErr.Detail, expressionErr.Position)))
+					continue
+				}
 				errs = append(errs, field.Invalid(templatePath, param, err.Error()))
 				continue
 			}
@@ -123,6 +123,14 @@ func TestParameterGenerators(t *testing.T) {
 			field.ErrorTypeInvalid,
 			"template.parameters[0]",
 		},
+		{ // Invalid expression, should fail on the from field
+			templatev1.Parameter{Name: "PARAM-fail-expression", Generate: "expression", From: "[a-z]{3"},
+			map[string]generator.Generator{"expression": generator.NewExpressionValueGenerator(rand.New(rand.NewSource(1337)))},
+			false,
+			templatev1.Parameter{Name: "PARAM-fail-expression", Generate: "expression", From: "[a-z]{3"},
+			field.ErrorTypeInvalid,
+			"template.parameters[0].from",
+		},
 		{ // Error required parameter, no value, should fail
 			makeParameter("PARAM-fail-no-val", "", "", true),
 			map[string]generator.Generator{"error": ErrorGenerator{}},
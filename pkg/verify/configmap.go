@@ -14,6 +14,7 @@ import (
 	"k8s.io/klog/v2"
 
 	"github.com/openshift/library-go/pkg/manifest"
+	"github.com/openshift/library-go/pkg/verify/cosign"
 	"github.com/openshift/library-go/pkg/verify/store"
 	"github.com/openshift/library-go/pkg/verify/store/configmap"
 	"github.com/openshift/library-go/pkg/verify/store/parallel"
@@ -33,6 +34,16 @@ const (
 	// must have signed the release image by digest.
 	verifierPublicKeyPrefix = "verifier-public-key-"
 
+	// verifierCosignPublicKeyPrefix is the unique portion of the key used within a config map
+	// identifying data field containing a PEM encoded ECDSA or Ed25519 public key that must
+	// have made a cosign signature of the release image by digest.
+	verifierCosignPublicKeyPrefix = "verifier-cosign-public-key-"
+
+	// verifierCosignKeylessPrefix is the unique portion of the key used within a config map
+	// identifying data field containing a YAML cosign.KeylessConfig. The identity it names
+	// must have made a keyless cosign signature of the release image by digest.
+	verifierCosignKeylessPrefix = "verifier-cosign-keyless-"
+
 	// storePrefix is the unique portion of the key used within a config map identifying
 	// data field containing a URL (scheme http://, or https://) location that contains
 	// signatures.
@@ -109,6 +120,15 @@ func NewFromManifests(manifests []manifest.Manifest, clientBuilder sigstore.HTTP
 //
 //	release image by digest.
 //
+// verifier-cosign-public-key-*: A PEM encoded ECDSA or Ed25519 public key that must have made a
+//
+//	cosign signature of the release image by digest.
+//
+// verifier-cosign-keyless-*: A YAML document with fulcioRoots, rekorPublicKeys, subject, and
+//
+//	optionally issuer. The subject must have made a keyless cosign signature of the release
+//	image by digest, with a certificate from the Fulcio roots and a bundle from the Rekor log.
+//
 // store-*: A URL (scheme file://, http://, or https://) location that contains signatures. These
 //
 //	signatures are in the atomic container signature format. The URL will have the digest
@@ -124,13 +144,35 @@ func NewFromManifests(manifests []manifest.Manifest, clientBuilder sigstore.HTTP
 // for a description of the signature store
 //
 // The returned verifier will require that any new release image will only be considered verified
-// if each provided public key has signed the release image digest. The signature may be in any
-// store and the lookup order is internally defined.
+// if each provided GPG public key has signed the release image digest, or if each provided cosign
+// verifier has signed the release image digest. The signature may be in any store and the lookup
+// order is internally defined. Cosign signatures are read from the stores as JSON encoded
+// cosign.Signature.
 func newFromConfigMapData(src string, data map[string]string, clientBuilder sigstore.HTTPClient) (Interface, error) {
 	verifiers := make(map[string]openpgp.EntityList)
+	cosignVerifiers := make(map[string]cosign.Verifier)
 	var stores []store.Store
 	for k, v := range data {
 		switch {
+		case strings.HasPrefix(k, verifierCosignPublicKeyPrefix):
+			keys, err := cosign.LoadPublicKeys([]byte(v))
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s has an invalid key %q that must be a PEM encoded public key: %v", src, k, err)
+			}
+			if len(keys) != 1 {
+				return nil, fmt.Errorf("%s has an invalid key %q that must be a single PEM encoded public key, found %d", src, k, len(keys))
+			}
+			verifier, err := cosign.NewPublicKeyVerifier(keys[0])
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s has an invalid key %q: %v", src, k, err)
+			}
+			cosignVerifiers[k] = verifier
+		case strings.HasPrefix(k, verifierCosignKeylessPrefix):
+			verifier, err := cosign.NewKeylessVerifierFromConfig([]byte(v))
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s has an invalid key %q that must be a keyless cosign configuration: %v", src, k, err)
+			}
+			cosignVerifiers[k] = verifier
 		case strings.HasPrefix(k, verifierPublicKeyPrefix):
 			keyring, err := loadArmoredOrUnarmoredGPGKeyRing([]byte(v))
 			if err != nil {
@@ -154,17 +196,17 @@ func newFromConfigMapData(src string, data map[string]string, clientBuilder sigs
 				})
 			}
 		default:
-			klog.Warningf("An unexpected key was found in %s and will be ignored (expected store-* or verifier-public-key-*): %s", src, k)
+			klog.Warningf("An unexpected key was found in %s and will be ignored (expected store-*, verifier-public-key-*, verifier-cosign-public-key-*, or verifier-cosign-keyless-*): %s", src, k)
 		}
 	}
 	if len(stores) == 0 {
 		return nil, fmt.Errorf("%s did not provide any signature stores to read from and cannot be used", src)
 	}
-	if len(verifiers) == 0 {
-		return nil, fmt.Errorf("%s did not provide any GPG public keys to verify signatures from and cannot be used", src)
+	if len(verifiers) == 0 && len(cosignVerifiers) == 0 {
+		return nil, fmt.Errorf("%s did not provide any GPG public keys or cosign verifiers to verify signatures from and cannot be used", src)
 	}
 
-	return NewReleaseVerifier(verifiers, &parallel.Store{Stores: stores}), nil
+	return NewReleaseVerifierWithCosign(verifiers, cosignVerifiers, &parallel.Store{Stores: stores}), nil
 }
 
 func loadArmoredOrUnarmoredGPGKeyRing(data []byte) (openpgp.EntityList, error) {
@@ -3,6 +3,11 @@ package verify
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
 	"io"
 	"net/http"
@@ -59,6 +64,16 @@ func Test_newFromConfigMapData(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	cosignKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cosignKeyDER, err := x509.MarshalPKIXPublicKey(cosignKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cosignKeyData := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: cosignKeyDER}))
+
 	tests := []struct {
 		name          string
 		data          map[string]string
@@ -94,6 +109,49 @@ func Test_newFromConfigMapData(t *testing.T) {
 			want:          true,
 			wantVerifiers: 1,
 		},
+		{
+			name: "loads cosign configuration",
+			data: map[string]string{
+				"verifier-cosign-public-key-release": cosignKeyData,
+				"store-local":                        "file://../testdata/signatures",
+			},
+			want:          true,
+			wantVerifiers: 0,
+		},
+		{
+			name: "loads gpg and cosign configuration",
+			data: map[string]string{
+				"verifier-public-key-redhat":         string(redhatData),
+				"verifier-cosign-public-key-release": cosignKeyData,
+				"store-local":                        "file://../testdata/signatures",
+			},
+			want:          true,
+			wantVerifiers: 1,
+		},
+		{
+			name: "rejects invalid cosign key",
+			data: map[string]string{
+				"verifier-cosign-public-key-release": string(redhatData),
+				"store-local":                        "file://../testdata/signatures",
+			},
+			wantErr: true,
+		},
+		{
+			name: "rejects several cosign keys",
+			data: map[string]string{
+				"verifier-cosign-public-key-release": cosignKeyData + cosignKeyData,
+				"store-local":                        "file://../testdata/signatures",
+			},
+			wantErr: true,
+		},
+		{
+			name: "rejects invalid keyless configuration",
+			data: map[string]string{
+				"verifier-cosign-keyless-release": "subject: someone@example.com",
+				"store-local":                     "file://../testdata/signatures",
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
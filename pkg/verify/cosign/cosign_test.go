@@ -0,0 +1,386 @@
+package cosign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testDigest = "sha256:e3f12513a4b22a2d7c0e7c9207f52128113758d9d68c7d06b11a0ac7672966f7"
+
+func testPayload(digest string) []byte {
+	return []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"quay.io/openshift-release-dev/ocp-release"},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":{"creator":"test"}}`, digest))
+}
+
+func sign(t *testing.T, key crypto.Signer, message []byte) []byte {
+	t.Helper()
+	var signature []byte
+	var err error
+	switch key.(type) {
+	case *ecdsa.PrivateKey:
+		digest := sha256.Sum256(message)
+		signature, err = key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	default:
+		signature, err = key.Sign(rand.Reader, message, crypto.Hash(0))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signature
+}
+
+func publicKeyPEM(t *testing.T, key crypto.PublicKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func newECDSAKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestPublicKeyVerifier(t *testing.T) {
+	ecdsaKey := newECDSAKey(t)
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := testPayload(testDigest)
+
+	tests := []struct {
+		name      string
+		signer    crypto.Signer
+		verifyKey crypto.PublicKey
+		wantErr   bool
+	}{
+		{name: "ecdsa", signer: ecdsaKey, verifyKey: ecdsaKey.Public()},
+		{name: "ed25519", signer: ed25519Key, verifyKey: ed25519Key.Public()},
+		{name: "wrong key", signer: ecdsaKey, verifyKey: newECDSAKey(t).Public(), wantErr: true},
+		{name: "wrong key type", signer: ed25519Key, verifyKey: ecdsaKey.Public(), wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			keys, err := LoadPublicKeys([]byte(publicKeyPEM(t, test.verifyKey)))
+			if err != nil || len(keys) != 1 {
+				t.Fatalf("unable to load key: %v", err)
+			}
+			verifier, err := NewPublicKeyVerifier(keys[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			signature := &Signature{
+				Payload:         payload,
+				Base64Signature: base64.StdEncoding.EncodeToString(sign(t, test.signer, payload)),
+			}
+			// round trip through the store encoding.
+			data, err := signature.Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, isCosign, err := ParseSignature(data)
+			if err != nil || !isCosign {
+				t.Fatalf("unable to parse signature: %v", err)
+			}
+
+			verified, err := verifier.Verify(parsed)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if err := VerifyPayload(verified, testDigest); err != nil {
+				t.Errorf("unexpected payload error: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseSignatureIgnoresOtherFormats(t *testing.T) {
+	for _, data := range [][]byte{
+		[]byte("\x90\x0d\x03binary openpgp message"),
+		[]byte(`{"critical": {}}`),
+	} {
+		if _, isCosign, err := ParseSignature(data); isCosign || err != nil {
+			t.Errorf("expected %q not to be a cosign signature, got %v %v", data, isCosign, err)
+		}
+	}
+}
+
+func TestVerifyPayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		wantErr bool
+	}{
+		{name: "valid", payload: testPayload(testDigest)},
+		{name: "different digest", payload: testPayload("sha256:0000"), wantErr: true},
+		{name: "atomic signature", payload: []byte(`{"critical":{"identity":{"docker-reference":"a"},"image":{"docker-manifest-digest":"` + testDigest + `"},"type":"atomic container signature"}}`), wantErr: true},
+		{name: "no identity", payload: []byte(`{"critical":{"image":{"docker-manifest-digest":"` + testDigest + `"},"type":"cosign container image signature"}}`), wantErr: true},
+		{name: "not json", payload: []byte("nope"), wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := VerifyPayload(test.payload, testDigest); (err != nil) != test.wantErr {
+				t.Errorf("VerifyPayload() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+type testFulcio struct {
+	root    *x509.Certificate
+	rootKey *ecdsa.PrivateKey
+	rekor   *ecdsa.PrivateKey
+}
+
+func newTestFulcio(t *testing.T) *testFulcio {
+	rootKey := newECDSAKey(t)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fulcio"},
+		NotBefore:             time.Now().Add(-24 * time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, rootKey.Public(), rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testFulcio{root: root, rootKey: rootKey, rekor: newECDSAKey(t)}
+}
+
+func (f *testFulcio) config(t *testing.T, subject, issuer string) []byte {
+	return []byte(fmt.Sprintf("fulcioRoots: |\n%s\nrekorPublicKeys: |\n%s\nsubject: %s\nissuer: %s\n",
+		indent(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: f.root.Raw}))),
+		indent(publicKeyPEM(t, f.rekor.Public())),
+		subject, issuer,
+	))
+}
+
+func indent(in string) string {
+	return "  " + strings.ReplaceAll(strings.TrimSpace(in), "\n", "\n  ")
+}
+
+// sign issues a short lived certificate for the subject and returns a signature of the payload with a Rekor bundle
+// logged at integratedTime.
+func (f *testFulcio) sign(t *testing.T, subject, issuer string, payload []byte, integratedTime time.Time) *Signature {
+	key := newECDSAKey(t)
+	subjectURI, err := url.Parse(subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuerValue, err := asn1.Marshal(issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:    big.NewInt(2),
+		NotBefore:       time.Now().Add(-time.Minute),
+		NotAfter:        time.Now().Add(10 * time.Minute),
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		URIs:            []*url.URL{subjectURI},
+		ExtraExtensions: []pkix.Extension{{Id: oidIssuerV2, Value: issuerValue}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, f.root, key.Public(), f.rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rawSignature := sign(t, key, payload)
+	payloadHash := sha256.Sum256(payload)
+	body, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "0.0.1",
+		"kind":       "hashedrekord",
+		"spec": map[string]interface{}{
+			"data":      map[string]interface{}{"hash": map[string]string{"algorithm": "sha256", "value": hex.EncodeToString(payloadHash[:])}},
+			"signature": map[string]interface{}{"content": base64.StdEncoding.EncodeToString(rawSignature)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := &RekorBundle{Payload: RekorPayload{
+		Body:           base64.StdEncoding.EncodeToString(body),
+		IntegratedTime: integratedTime.Unix(),
+		LogIndex:       42,
+		LogID:          "c0d23d6ad406973f9559f3ba2d1ca01f84147d8ffc5b8445c224f98b9591801d",
+	}}
+	canonical := fmt.Sprintf(`{"body":%q,"integratedTime":%d,"logID":%q,"logIndex":42}`, bundle.Payload.Body, bundle.Payload.IntegratedTime, bundle.Payload.LogID)
+	bundle.SignedEntryTimestamp = sign(t, f.rekor, []byte(canonical))
+
+	annotations := map[string]string{
+		SignatureAnnotation:   base64.StdEncoding.EncodeToString(rawSignature),
+		CertificateAnnotation: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+	}
+	bundleBytes, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotations[BundleAnnotation] = string(bundleBytes)
+	signature, err := NewSignatureFromAnnotations(payload, annotations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signature
+}
+
+func TestKeylessVerifier(t *testing.T) {
+	fulcio := newTestFulcio(t)
+	subject := "https://github.com/openshift/release/.github/workflows/sign.yaml@refs/heads/main"
+	issuer := "https://token.actions.githubusercontent.com"
+	payload := testPayload(testDigest)
+
+	tests := []struct {
+		name      string
+		signature func() *Signature
+		subject   string
+		issuer    string
+		wantErr   string
+	}{
+		{
+			name:      "valid",
+			signature: func() *Signature { return fulcio.sign(t, subject, issuer, payload, time.Now()) },
+			subject:   subject,
+			issuer:    issuer,
+		},
+		{
+			name:      "any issuer",
+			signature: func() *Signature { return fulcio.sign(t, subject, issuer, payload, time.Now()) },
+			subject:   subject,
+		},
+		{
+			name:      "wrong subject",
+			signature: func() *Signature { return fulcio.sign(t, "https://example.com/other", issuer, payload, time.Now()) },
+			subject:   subject,
+			wantErr:   "the signing certificate is for https://example.com/other",
+		},
+		{
+			name:      "wrong issuer",
+			signature: func() *Signature { return fulcio.sign(t, subject, "https://example.com", payload, time.Now()) },
+			subject:   subject,
+			issuer:    issuer,
+			wantErr:   "the signing certificate was issued for",
+		},
+		{
+			name:      "logged after the certificate expired",
+			signature: func() *Signature { return fulcio.sign(t, subject, issuer, payload, time.Now().Add(time.Hour)) },
+			subject:   subject,
+			wantErr:   "not trusted when the signature was logged",
+		},
+		{
+			name: "bundle for another signature",
+			signature: func() *Signature {
+				signature := fulcio.sign(t, subject, issuer, payload, time.Now())
+				signature.Bundle = fulcio.sign(t, subject, issuer, payload, time.Now()).Bundle
+				return signature
+			},
+			subject: subject,
+			wantErr: "the Rekor log entry is for a different signature",
+		},
+		{
+			name: "tampered bundle",
+			signature: func() *Signature {
+				signature := fulcio.sign(t, subject, issuer, payload, time.Now())
+				signature.Bundle.Payload.LogIndex++
+				return signature
+			},
+			subject: subject,
+			wantErr: "the Rekor bundle is not signed by a trusted log",
+		},
+		{
+			name: "no bundle",
+			signature: func() *Signature {
+				signature := fulcio.sign(t, subject, issuer, payload, time.Now())
+				signature.Bundle = nil
+				return signature
+			},
+			subject: subject,
+			wantErr: "the signature has no Rekor bundle",
+		},
+		{
+			name: "untrusted root",
+			signature: func() *Signature {
+				other := newTestFulcio(t)
+				other.rekor = fulcio.rekor
+				return other.sign(t, subject, issuer, payload, time.Now())
+			},
+			subject: subject,
+			wantErr: "not trusted when the signature was logged",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verifier, err := NewKeylessVerifierFromConfig(fulcio.config(t, test.subject, test.issuer))
+			if err != nil {
+				t.Fatal(err)
+			}
+			verified, err := verifier.Verify(test.signature())
+			if len(test.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if err := VerifyPayload(verified, testDigest); err != nil {
+					t.Errorf("unexpected payload error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNewKeylessVerifierFromConfig(t *testing.T) {
+	fulcio := newTestFulcio(t)
+	tests := []struct {
+		name    string
+		config  []byte
+		wantErr bool
+	}{
+		{name: "valid", config: fulcio.config(t, "someone@example.com", "")},
+		{name: "requires subject", config: fulcio.config(t, "", ""), wantErr: true},
+		{name: "requires roots", config: []byte("subject: someone@example.com\nrekorPublicKeys: |\n" + indent(publicKeyPEM(t, fulcio.rekor.Public()))), wantErr: true},
+		{name: "unknown field", config: append(fulcio.config(t, "someone@example.com", ""), []byte("other: value\n")...), wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := NewKeylessVerifierFromConfig(test.config); (err != nil) != test.wantErr {
+				t.Errorf("NewKeylessVerifierFromConfig() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
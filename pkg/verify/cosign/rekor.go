@@ -0,0 +1,107 @@
+package cosign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// RekorBundle is the offline proof that a signature was recorded in the Rekor transparency log, as stored in the
+// BundleAnnotation.
+type RekorBundle struct {
+	// SignedEntryTimestamp is the signature of the log over the canonical JSON encoding of Payload.
+	SignedEntryTimestamp []byte
+	Payload              RekorPayload
+}
+
+type RekorPayload struct {
+	// Body is the base64 encoded log entry.
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogIndex       int64  `json:"logIndex"`
+	LogID          string `json:"logID"`
+}
+
+// IntegratedTime returns when the log recorded the entry.
+func (b *RekorBundle) IntegratedTime() time.Time {
+	return time.Unix(b.Payload.IntegratedTime, 0)
+}
+
+// hashedRekord is the part of a hashedrekord log entry that links it to a signature.
+type hashedRekord struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Data struct {
+			Hash struct {
+				Algorithm string `json:"algorithm"`
+				Value     string `json:"value"`
+			} `json:"hash"`
+		} `json:"data"`
+		Signature struct {
+			Content string `json:"content"`
+		} `json:"signature"`
+	} `json:"spec"`
+}
+
+// verifyRekorBundle checks that one of the log keys signed the bundle and that the log entry is for this signature.
+func verifyRekorBundle(bundle *RekorBundle, rekorKeys []crypto.PublicKey, signature *Signature) error {
+	if len(rekorKeys) == 0 {
+		return fmt.Errorf("no Rekor public keys are configured")
+	}
+
+	// the canonical encoding has sorted keys and no whitespace, which is what encoding/json produces for a map.
+	canonical, err := json.Marshal(map[string]interface{}{
+		"body":           bundle.Payload.Body,
+		"integratedTime": bundle.Payload.IntegratedTime,
+		"logIndex":       bundle.Payload.LogIndex,
+		"logID":          bundle.Payload.LogID,
+	})
+	if err != nil {
+		return err
+	}
+	var verifyErrs []error
+	for _, key := range rekorKeys {
+		err := verifySignature(key, canonical, bundle.SignedEntryTimestamp)
+		if err == nil {
+			verifyErrs = nil
+			break
+		}
+		verifyErrs = append(verifyErrs, err)
+	}
+	if len(verifyErrs) > 0 {
+		return fmt.Errorf("the Rekor bundle is not signed by a trusted log: %v", verifyErrs)
+	}
+
+	body, err := base64.StdEncoding.DecodeString(bundle.Payload.Body)
+	if err != nil {
+		return fmt.Errorf("the Rekor bundle body is not valid: %w", err)
+	}
+	entry := &hashedRekord{}
+	if err := json.Unmarshal(body, entry); err != nil {
+		return fmt.Errorf("the Rekor bundle body is not valid: %w", err)
+	}
+	if entry.Kind != "hashedrekord" {
+		return fmt.Errorf("the Rekor log entry has unsupported kind %q", entry.Kind)
+	}
+	payloadHash := sha256.Sum256(signature.Payload)
+	if entry.Spec.Data.Hash.Algorithm != "sha256" || entry.Spec.Data.Hash.Value != hex.EncodeToString(payloadHash[:]) {
+		return fmt.Errorf("the Rekor log entry is for a different payload")
+	}
+	entrySignature, err := base64.StdEncoding.DecodeString(entry.Spec.Signature.Content)
+	if err != nil {
+		return fmt.Errorf("the Rekor log entry signature is not valid: %w", err)
+	}
+	rawSignature, err := base64.StdEncoding.DecodeString(signature.Base64Signature)
+	if err != nil {
+		return fmt.Errorf("the signature is not valid base64: %w", err)
+	}
+	if !bytes.Equal(entrySignature, rawSignature) {
+		return fmt.Errorf("the Rekor log entry is for a different signature")
+	}
+	return nil
+}
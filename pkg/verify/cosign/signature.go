@@ -0,0 +1,164 @@
+// Package cosign verifies cosign signatures of release images.
+//
+// Cosign stores a signature as a layer of an OCI image: the layer is a simple signing payload and the layer
+// annotations carry the base64 encoded signature, the signing certificate and its chain for keyless signing, and the
+// Rekor bundle proving the signature was recorded in the transparency log.  Signature stores hand the layer over as a
+// JSON encoded Signature, so cosign signatures can be mixed with containers/image signatures in a single store.
+//
+// See https://github.com/sigstore/cosign/blob/main/specs/SIGNATURE_SPEC.md for the format.
+package cosign
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// SignatureAnnotation is the layer annotation holding the base64 encoded signature of the payload.
+	SignatureAnnotation = "dev.cosignproject.cosign/signature"
+	// CertificateAnnotation is the layer annotation holding the PEM encoded signing certificate for keyless signing.
+	CertificateAnnotation = "dev.sigstore.cosign/certificate"
+	// ChainAnnotation is the layer annotation holding the PEM encoded intermediate certificates for keyless signing.
+	ChainAnnotation = "dev.sigstore.cosign/chain"
+	// BundleAnnotation is the layer annotation holding the JSON encoded Rekor bundle.
+	BundleAnnotation = "dev.sigstore.cosign/bundle"
+
+	// SimpleSigningMediaType is the media type of the layer holding the payload.
+	SimpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	// SignatureArtifactType is the artifact type of signature manifests attached with the OCI 1.1 referrers API.
+	SignatureArtifactType = "application/vnd.dev.cosign.artifact.sig.v1+json"
+
+	// simpleSigningType is the critical.type of a cosign simple signing payload.
+	simpleSigningType = "cosign container image signature"
+
+	// envelopeType identifies a JSON encoded Signature passed through a store.Callback.
+	envelopeType = "cosign.sigstore.dev/signature"
+)
+
+// Signature is a cosign signature of a single payload.
+type Signature struct {
+	// Payload is the signed simple signing payload.
+	Payload []byte
+	// Base64Signature is the signature of Payload, base64 encoded as in the SignatureAnnotation.
+	Base64Signature string
+	// Certificate is the PEM encoded signing certificate.  Empty for signatures made with a key.
+	Certificate []byte
+	// Chain is the PEM encoded chain of intermediate certificates for Certificate.
+	Chain []byte
+	// Bundle is the Rekor bundle for the signature.  Required for keyless signatures.
+	Bundle *RekorBundle
+}
+
+// envelope is the serialized form of Signature.  Type tells it apart from containers/image signatures, which are
+// OpenPGP messages.
+type envelope struct {
+	Type            string       `json:"type"`
+	Payload         []byte       `json:"payload"`
+	Base64Signature string       `json:"base64Signature"`
+	Certificate     string       `json:"certificate,omitempty"`
+	Chain           string       `json:"chain,omitempty"`
+	Bundle          *RekorBundle `json:"bundle,omitempty"`
+}
+
+// NewSignatureFromAnnotations builds a Signature from an OCI signature image layer.
+func NewSignatureFromAnnotations(payload []byte, annotations map[string]string) (*Signature, error) {
+	ret := &Signature{
+		Payload:         payload,
+		Base64Signature: annotations[SignatureAnnotation],
+		Certificate:     []byte(annotations[CertificateAnnotation]),
+		Chain:           []byte(annotations[ChainAnnotation]),
+	}
+	if len(ret.Base64Signature) == 0 {
+		return nil, fmt.Errorf("the layer has no %s annotation", SignatureAnnotation)
+	}
+	if bundle := annotations[BundleAnnotation]; len(bundle) > 0 {
+		ret.Bundle = &RekorBundle{}
+		if err := json.Unmarshal([]byte(bundle), ret.Bundle); err != nil {
+			return nil, fmt.Errorf("the %s annotation is not valid: %w", BundleAnnotation, err)
+		}
+	}
+	return ret, nil
+}
+
+// Marshal encodes the signature for a store.Callback.
+func (s *Signature) Marshal() ([]byte, error) {
+	return json.Marshal(&envelope{
+		Type:            envelopeType,
+		Payload:         s.Payload,
+		Base64Signature: s.Base64Signature,
+		Certificate:     string(s.Certificate),
+		Chain:           string(s.Chain),
+		Bundle:          s.Bundle,
+	})
+}
+
+// ParseSignature decodes a signature encoded by Marshal.  It returns false if the data is not a cosign signature,
+// which usually means it is a containers/image signature.
+func ParseSignature(data []byte) (*Signature, bool, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return nil, false, nil
+	}
+	in := &envelope{}
+	if err := json.Unmarshal(data, in); err != nil || in.Type != envelopeType {
+		return nil, false, nil
+	}
+	if len(in.Payload) == 0 || len(in.Base64Signature) == 0 {
+		return nil, true, fmt.Errorf("the cosign signature has no payload or signature")
+	}
+	return &Signature{
+		Payload:         in.Payload,
+		Base64Signature: in.Base64Signature,
+		Certificate:     []byte(in.Certificate),
+		Chain:           []byte(in.Chain),
+		Bundle:          in.Bundle,
+	}, true, nil
+}
+
+// A cosign simple signing payload has the following schema:
+//
+//	{
+//		"critical": {
+//			"identity": {
+//				"docker-reference": "quay.io/openshift-release-dev/ocp-release"
+//			},
+//			"image": {
+//				"docker-manifest-digest": "sha256:817a12c32a39bbe394944ba49de563e085f1d3c5266eb8e9723256bc4448680e"
+//			},
+//			"type": "cosign container image signature"
+//		},
+//		"optional": {
+//			"creator": "..."
+//		}
+//	}
+type simpleSigning struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]interface{} `json:"optional"`
+}
+
+// VerifyPayload checks that a verified simple signing payload authenticates the release digest.  If an error is
+// returned the payload does NOT authenticate the release digest and the signature must be ignored.
+func VerifyPayload(payload []byte, releaseDigest string) error {
+	var sig simpleSigning
+	if err := json.Unmarshal(payload, &sig); err != nil {
+		return fmt.Errorf("the cosign payload is not valid JSON: %v", err)
+	}
+	if sig.Critical.Type != simpleSigningType {
+		return fmt.Errorf("the cosign payload is not the correct type")
+	}
+	if len(sig.Critical.Identity.DockerReference) == 0 {
+		return fmt.Errorf("the cosign payload must have an identity")
+	}
+	if sig.Critical.Image.DockerManifestDigest != releaseDigest {
+		return fmt.Errorf("the cosign payload digest does not match")
+	}
+	return nil
+}
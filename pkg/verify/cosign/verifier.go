@@ -0,0 +1,269 @@
+package cosign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	// oidIssuer is the deprecated Fulcio extension holding the OIDC issuer as a raw string.
+	oidIssuer = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	// oidIssuerV2 is the Fulcio extension holding the OIDC issuer as a DER encoded UTF8String.
+	oidIssuerV2 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+)
+
+// Verifier verifies cosign signatures.
+type Verifier interface {
+	// Verify returns the payload if the signature is valid.  The caller must still check that the payload is for
+	// the expected image with VerifyPayload.
+	Verify(signature *Signature) ([]byte, error)
+
+	// String returns a short description of who must have signed, for display in a description of the verifier.
+	String() string
+}
+
+type publicKeyVerifier struct {
+	key crypto.PublicKey
+}
+
+// NewPublicKeyVerifier accepts signatures made with the private key of an ECDSA or Ed25519 public key.
+func NewPublicKeyVerifier(key crypto.PublicKey) (Verifier, error) {
+	switch key.(type) {
+	case *ecdsa.PublicKey, ed25519.PublicKey:
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T, must be ECDSA or Ed25519", key)
+	}
+	return &publicKeyVerifier{key: key}, nil
+}
+
+func (v *publicKeyVerifier) Verify(signature *Signature) ([]byte, error) {
+	rawSignature, err := base64.StdEncoding.DecodeString(signature.Base64Signature)
+	if err != nil {
+		return nil, fmt.Errorf("the signature is not valid base64: %w", err)
+	}
+	if err := verifySignature(v.key, signature.Payload, rawSignature); err != nil {
+		return nil, err
+	}
+	return signature.Payload, nil
+}
+
+func (v *publicKeyVerifier) String() string {
+	switch v.key.(type) {
+	case *ecdsa.PublicKey:
+		return "ECDSA public key"
+	default:
+		return "Ed25519 public key"
+	}
+}
+
+// KeylessConfig is the serialized configuration of a keyless verifier.
+type KeylessConfig struct {
+	// FulcioRoots is one or more PEM encoded root certificates of the Fulcio CA.
+	FulcioRoots string `json:"fulcioRoots"`
+	// RekorPublicKeys is one or more PEM encoded public keys of the Rekor transparency log.
+	RekorPublicKeys string `json:"rekorPublicKeys"`
+	// Subject must be an email address or URI in the subject alternative names of the signing certificate.
+	Subject string `json:"subject"`
+	// Issuer is the OIDC issuer that must have authenticated Subject.  Optional.
+	Issuer string `json:"issuer,omitempty"`
+}
+
+type keylessVerifier struct {
+	roots     *x509.CertPool
+	rekorKeys []crypto.PublicKey
+	subject   string
+	issuer    string
+}
+
+// NewKeylessVerifierFromConfig reads a KeylessConfig from YAML or JSON.
+func NewKeylessVerifierFromConfig(data []byte) (Verifier, error) {
+	config := &KeylessConfig{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, err
+	}
+	return NewKeylessVerifier(*config)
+}
+
+// NewKeylessVerifier accepts signatures made with a short lived certificate issued by Fulcio to the configured
+// identity.  The signature must have a Rekor bundle, because the certificate is only valid at the time it was logged.
+func NewKeylessVerifier(config KeylessConfig) (Verifier, error) {
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(config.FulcioRoots)) {
+		return nil, fmt.Errorf("fulcioRoots must contain at least one PEM encoded certificate")
+	}
+	rekorKeys, err := LoadPublicKeys([]byte(config.RekorPublicKeys))
+	if err != nil {
+		return nil, fmt.Errorf("rekorPublicKeys is not valid: %w", err)
+	}
+	if len(rekorKeys) == 0 {
+		return nil, fmt.Errorf("rekorPublicKeys must contain at least one PEM encoded public key")
+	}
+	if len(config.Subject) == 0 {
+		return nil, fmt.Errorf("subject is required")
+	}
+	return &keylessVerifier{
+		roots:     roots,
+		rekorKeys: rekorKeys,
+		subject:   config.Subject,
+		issuer:    config.Issuer,
+	}, nil
+}
+
+func (v *keylessVerifier) Verify(signature *Signature) ([]byte, error) {
+	if len(signature.Certificate) == 0 {
+		return nil, fmt.Errorf("the signature has no certificate")
+	}
+	if signature.Bundle == nil {
+		return nil, fmt.Errorf("the signature has no Rekor bundle")
+	}
+	certificates, err := parseCertificates(signature.Certificate)
+	if err != nil || len(certificates) != 1 {
+		return nil, fmt.Errorf("the signature must have exactly one signing certificate: %v", err)
+	}
+	certificate := certificates[0]
+	intermediates := x509.NewCertPool()
+	if len(signature.Chain) > 0 {
+		chain, err := parseCertificates(signature.Chain)
+		if err != nil {
+			return nil, fmt.Errorf("the certificate chain is not valid: %w", err)
+		}
+		for _, curr := range chain {
+			intermediates.AddCert(curr)
+		}
+	}
+
+	if err := verifyRekorBundle(signature.Bundle, v.rekorKeys, signature); err != nil {
+		return nil, err
+	}
+	if _, err := certificate.Verify(x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: intermediates,
+		CurrentTime:   signature.Bundle.IntegratedTime(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}); err != nil {
+		return nil, fmt.Errorf("the signing certificate is not trusted when the signature was logged: %w", err)
+	}
+	if err := v.verifyIdentity(certificate); err != nil {
+		return nil, err
+	}
+
+	rawSignature, err := base64.StdEncoding.DecodeString(signature.Base64Signature)
+	if err != nil {
+		return nil, fmt.Errorf("the signature is not valid base64: %w", err)
+	}
+	if err := verifySignature(certificate.PublicKey, signature.Payload, rawSignature); err != nil {
+		return nil, err
+	}
+	return signature.Payload, nil
+}
+
+func (v *keylessVerifier) verifyIdentity(certificate *x509.Certificate) error {
+	subjects := append([]string{}, certificate.EmailAddresses...)
+	for _, uri := range certificate.URIs {
+		subjects = append(subjects, uri.String())
+	}
+	found := false
+	for _, subject := range subjects {
+		if subject == v.subject {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("the signing certificate is for %s, not %s", strings.Join(subjects, ", "), v.subject)
+	}
+
+	if len(v.issuer) == 0 {
+		return nil
+	}
+	issuer := ""
+	for _, extension := range certificate.Extensions {
+		switch {
+		case extension.Id.Equal(oidIssuerV2):
+			if _, err := asn1.Unmarshal(extension.Value, &issuer); err != nil {
+				return fmt.Errorf("the signing certificate has an invalid issuer extension: %w", err)
+			}
+		case extension.Id.Equal(oidIssuer) && len(issuer) == 0:
+			issuer = string(extension.Value)
+		}
+	}
+	if issuer != v.issuer {
+		return fmt.Errorf("the signing certificate was issued for %q, not %q", issuer, v.issuer)
+	}
+	return nil
+}
+
+func (v *keylessVerifier) String() string {
+	if len(v.issuer) == 0 {
+		return fmt.Sprintf("keyless signing by %s", v.subject)
+	}
+	return fmt.Sprintf("keyless signing by %s from %s", v.subject, v.issuer)
+}
+
+// LoadPublicKeys reads PEM encoded PKIX public keys.
+func LoadPublicKeys(data []byte) ([]crypto.PublicKey, error) {
+	var ret []crypto.PublicKey
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return ret, nil
+		}
+		if block.Type != "PUBLIC KEY" {
+			continue
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, key)
+	}
+}
+
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var ret []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return ret, nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, certificate)
+	}
+}
+
+// verifySignature follows cosign: ECDSA signatures are ASN.1 encoded over the SHA-256 digest, Ed25519 signatures
+// are over the message itself.
+func verifySignature(key crypto.PublicKey, message, signature []byte) error {
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(message)
+		if !ecdsa.VerifyASN1(key, digest[:], signature) {
+			return fmt.Errorf("invalid ECDSA signature")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, message, signature) {
+			return fmt.Errorf("invalid Ed25519 signature")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+// Package oci retrieves cosign signatures stored as OCI artifacts in the
+// image registry, next to the signed image.
+//
+// Signatures are found in two places: the manifest cosign tags as
+// "<ALGO>-<DIGEST>.sig" in the repository, and the manifests the OCI 1.1
+// referrers API lists for the image digest with the cosign signature
+// artifact type. Every simple signing layer of those manifests is passed to
+// the callback as a JSON encoded cosign.Signature.
+//
+// Manifests and blobs are read through a registryclient.Context, so an
+// AlternateBlobSourceStrategy lets disconnected clusters read signatures
+// from their mirror registry.
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"k8s.io/klog/v2"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest/schema2"
+	"github.com/distribution/distribution/v3/registry/api/errcode"
+	v2 "github.com/distribution/distribution/v3/registry/api/v2"
+	"github.com/distribution/distribution/v3/registry/client"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/openshift/library-go/pkg/image/reference"
+	"github.com/openshift/library-go/pkg/image/registryclient"
+	"github.com/openshift/library-go/pkg/verify/cosign"
+	"github.com/openshift/library-go/pkg/verify/store"
+)
+
+// maxSignatureSearch prevents unbounded reads on malicious signature stores (if
+// an attacker was able to take ownership of the store to perform DoS on clusters).
+const maxSignatureSearch = 10
+
+// maxPayloadSize is the largest simple signing payload that will be read.
+const maxPayloadSize = 50 * 1024
+
+// Store provides access to cosign signatures stored in an image registry.
+type Store struct {
+	// Context connects to the registry. Its AlternateBlobSourceStrategy, if
+	// any, is consulted for mirrors of Repository.
+	Context *registryclient.Context
+
+	// Repository is the image repository holding the signed images and their
+	// signatures, for example quay.io/openshift-release-dev/ocp-release.
+	Repository reference.DockerImageReference
+
+	// Insecure allows HTTP and unverified HTTPS connections to the registry
+	// of Repository.
+	Insecure bool
+}
+
+// Signatures fetches signatures for the provided digest.
+func (s *Store) Signatures(ctx context.Context, name string, digestString string, fn store.Callback) error {
+	dgst, err := digest.Parse(digestString)
+	if err != nil {
+		return err
+	}
+
+	repo, err := s.Context.Repository(ctx, s.Repository.RegistryURL(), s.Repository.RepositoryName(), s.Insecure)
+	if err != nil {
+		_, err = fn(ctx, nil, err)
+		return err
+	}
+	manifests, err := repo.Manifests(ctx)
+	if err != nil {
+		_, err = fn(ctx, nil, err)
+		return err
+	}
+	search := &signatureSearch{blobs: repo.Blobs(ctx), fn: fn}
+
+	tag := fmt.Sprintf("%s-%s.sig", dgst.Algorithm(), dgst.Encoded())
+	manifest, err := manifests.Get(ctx, "", distribution.WithTag(tag))
+	switch {
+	case err == nil:
+		if done, err := search.manifest(ctx, manifest); done || err != nil {
+			return err
+		}
+	case isNotFound(err):
+		klog.V(4).Infof("No signature tag %s in %s", tag, s.Repository.Exact())
+	default:
+		if done, err := fn(ctx, nil, fmt.Errorf("unable to retrieve signature tag %s from %s: %w", tag, s.Repository.Exact(), err)); done || err != nil {
+			return err
+		}
+	}
+
+	if referrersService, ok := repo.(registryclient.ReferrersService); ok {
+		referrers, err := referrersService.Referrers(ctx, dgst, cosign.SignatureArtifactType)
+		if err != nil {
+			if done, err := fn(ctx, nil, fmt.Errorf("unable to list referrers of %s in %s: %w", dgst, s.Repository.Exact(), err)); done || err != nil {
+				return err
+			}
+		}
+		for _, referrer := range referrers {
+			if search.full() {
+				break
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			manifest, err := manifests.Get(ctx, referrer.Digest)
+			if err != nil {
+				if done, err := fn(ctx, nil, fmt.Errorf("unable to retrieve signature manifest %s from %s: %w", referrer.Digest, s.Repository.Exact(), err)); done || err != nil {
+					return err
+				}
+				continue
+			}
+			if subject, _, err := registryclient.ManifestSubject(manifest); err != nil || subject == nil || subject.Digest != dgst {
+				if done, err := fn(ctx, nil, fmt.Errorf("the signature manifest %s in %s is not for %s", referrer.Digest, s.Repository.Exact(), dgst)); done || err != nil {
+					return err
+				}
+				continue
+			}
+			if done, err := search.manifest(ctx, manifest); done || err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = fn(ctx, nil, fmt.Errorf("%s %s: %w", s.String(), digestString, store.ErrNotFound))
+	return err
+}
+
+// String returns a description of where this store finds
+// signatures.
+func (s *Store) String() string {
+	return fmt.Sprintf("cosign signatures in the registry repository %s", s.Repository.Exact())
+}
+
+// signatureSearch passes the signatures of signature manifests to the callback.
+type signatureSearch struct {
+	blobs distribution.BlobStore
+	fn    store.Callback
+
+	// checked counts the signature layers read so far.
+	checked int
+}
+
+// manifest passes every simple signing layer of a signature manifest to the callback until the
+// search limit is reached, returning true if the callback is done.
+func (s *signatureSearch) manifest(ctx context.Context, manifest distribution.Manifest) (bool, error) {
+	var layers []distribution.Descriptor
+	switch t := manifest.(type) {
+	case *registryclient.DeserializedOCIManifest:
+		layers = t.Layers
+	case *schema2.DeserializedManifest:
+		layers = t.Layers
+	default:
+		return s.fn(ctx, nil, fmt.Errorf("unsupported signature manifest type %T", manifest))
+	}
+
+	for _, layer := range layers {
+		if layer.MediaType != cosign.SimpleSigningMediaType {
+			continue
+		}
+		if s.full() {
+			klog.V(4).Infof("Stopped searching for signatures after %d signatures", s.checked)
+			return false, nil
+		}
+		s.checked++
+		if err := ctx.Err(); err != nil {
+			return true, err
+		}
+
+		data, err := s.signature(ctx, layer)
+		if err != nil {
+			if done, err := s.fn(ctx, nil, err); done || err != nil {
+				return true, err
+			}
+			continue
+		}
+		if done, err := s.fn(ctx, data, nil); done || err != nil {
+			return true, err
+		}
+	}
+	return false, nil
+}
+
+// full returns true once maxSignatureSearch signatures have been read.
+func (s *signatureSearch) full() bool {
+	return s.checked >= maxSignatureSearch
+}
+
+// signature reads the payload of a simple signing layer and encodes it with its annotations.
+func (s *signatureSearch) signature(ctx context.Context, layer distribution.Descriptor) ([]byte, error) {
+	if layer.Size > maxPayloadSize {
+		return nil, fmt.Errorf("the signature payload %s is larger than %d bytes", layer.Digest, maxPayloadSize)
+	}
+	payload, err := s.blobs.Get(ctx, layer.Digest)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve signature payload %s: %w", layer.Digest, err)
+	}
+	signature, err := cosign.NewSignatureFromAnnotations(payload, layer.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("the signature layer %s is not valid: %w", layer.Digest, err)
+	}
+	return signature.Marshal()
+}
+
+// isNotFound returns true if the registry reported that a manifest does not exist.
+func isNotFound(err error) bool {
+	var errs errcode.Errors
+	if errors.As(err, &errs) {
+		for _, err := range errs {
+			if isNotFound(err) {
+				return true
+			}
+		}
+		return false
+	}
+	var errCode errcode.ErrorCode
+	if errors.As(err, &errCode) {
+		return errCode == v2.ErrorCodeManifestUnknown || errCode == v2.ErrorCodeNameUnknown
+	}
+	var errWithDetail errcode.Error
+	if errors.As(err, &errWithDetail) {
+		return errWithDetail.Code == v2.ErrorCodeManifestUnknown || errWithDetail.Code == v2.ErrorCodeNameUnknown
+	}
+	responseError := &client.UnexpectedHTTPResponseError{}
+	if errors.As(err, &responseError) {
+		return responseError.StatusCode == http.StatusNotFound
+	}
+	return false
+}
@@ -0,0 +1,288 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/opencontainers/go-digest"
+
+	"k8s.io/client-go/rest"
+
+	"github.com/openshift/library-go/pkg/image/reference"
+	"github.com/openshift/library-go/pkg/image/registryclient"
+	"github.com/openshift/library-go/pkg/verify/cosign"
+	"github.com/openshift/library-go/pkg/verify/store"
+)
+
+const releaseDigest = "sha256:0000000000000000000000000000000000000000000000000000000000000001"
+
+// fakeRegistry serves manifests by tag or digest, blobs and referrers for a single repository.
+type fakeRegistry struct {
+	repository string
+	manifests  map[string][]byte
+	blobs      map[digest.Digest][]byte
+	referrers  map[string][]registryclient.Referrer
+	requests   []string
+}
+
+func (r *fakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.requests = append(r.requests, req.URL.Path)
+	if req.URL.Path == "/v2/" {
+		return
+	}
+	prefix := fmt.Sprintf("/v2/%s/", r.repository)
+	if !strings.HasPrefix(req.URL.Path, prefix) {
+		http.Error(w, `{"errors":[{"code":"NAME_UNKNOWN"}]}`, http.StatusNotFound)
+		return
+	}
+	parts := strings.SplitN(strings.TrimPrefix(req.URL.Path, prefix), "/", 2)
+	switch parts[0] {
+	case "manifests":
+		if manifest, ok := r.manifests[parts[1]]; ok {
+			w.Header().Set("Content-Type", registryclient.MediaTypeImageManifest)
+			w.Write(manifest)
+			return
+		}
+		http.Error(w, `{"errors":[{"code":"MANIFEST_UNKNOWN"}]}`, http.StatusNotFound)
+	case "blobs":
+		if blob, ok := r.blobs[digest.Digest(parts[1])]; ok {
+			w.Write(blob)
+			return
+		}
+		http.Error(w, `{"errors":[{"code":"BLOB_UNKNOWN"}]}`, http.StatusNotFound)
+	case "referrers":
+		if r.referrers == nil {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		data, _ := json.Marshal(map[string]interface{}{
+			"schemaVersion": 2,
+			"mediaType":     registryclient.MediaTypeImageIndex,
+			"manifests":     r.referrers[parts[1]],
+		})
+		w.Header().Set("Content-Type", registryclient.MediaTypeImageIndex)
+		w.Write(data)
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+// addSignatureManifest stores a signature manifest with one layer per annotation set, and returns its digest.
+func (r *fakeRegistry) addSignatureManifest(t *testing.T, tag string, layers ...map[string]string) digest.Digest {
+	t.Helper()
+	manifest := registryclient.OCIManifest{
+		ArtifactType: cosign.SignatureArtifactType,
+		Config:       distribution.Descriptor{MediaType: "application/vnd.oci.empty.v1+json", Digest: digest.FromString("{}"), Size: 2},
+		Subject:      &distribution.Descriptor{MediaType: registryclient.MediaTypeImageManifest, Digest: releaseDigest, Size: 100},
+	}
+	manifest.SchemaVersion = 2
+	manifest.MediaType = registryclient.MediaTypeImageManifest
+	for i, annotations := range layers {
+		payload := []byte(fmt.Sprintf("payload-%s-%d", tag, i))
+		r.blobs[digest.FromBytes(payload)] = payload
+		manifest.Layers = append(manifest.Layers, distribution.Descriptor{
+			MediaType:   cosign.SimpleSigningMediaType,
+			Digest:      digest.FromBytes(payload),
+			Size:        int64(len(payload)),
+			Annotations: annotations,
+		})
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dgst := digest.FromBytes(data)
+	r.manifests[dgst.String()] = data
+	if len(tag) > 0 {
+		r.manifests[tag] = data
+	}
+	return dgst
+}
+
+func newFakeRegistry(repository string) *fakeRegistry {
+	return &fakeRegistry{
+		repository: repository,
+		manifests:  map[string][]byte{},
+		blobs:      map[digest.Digest][]byte{},
+	}
+}
+
+type alternateStrategy struct {
+	alternates []reference.DockerImageReference
+}
+
+func (s *alternateStrategy) FirstRequest(ctx context.Context, locator reference.DockerImageReference) ([]reference.DockerImageReference, error) {
+	return s.alternates, nil
+}
+
+func (s *alternateStrategy) OnFailure(ctx context.Context, locator reference.DockerImageReference) ([]reference.DockerImageReference, error) {
+	return nil, nil
+}
+
+func TestStore(t *testing.T) {
+	signatureTag := "sha256-0000000000000000000000000000000000000000000000000000000000000001.sig"
+
+	for _, tt := range []struct {
+		name               string
+		source             func(t *testing.T, r *fakeRegistry)
+		mirror             func(t *testing.T, r *fakeRegistry)
+		expectedSignatures []string
+		expectedErrors     []string
+	}{
+		{
+			name: "cosign tag",
+			source: func(t *testing.T, r *fakeRegistry) {
+				r.addSignatureManifest(t, signatureTag, map[string]string{cosign.SignatureAnnotation: "c2lnLTE="}, map[string]string{cosign.SignatureAnnotation: "c2lnLTI="})
+			},
+			expectedSignatures: []string{"payload-" + signatureTag + "-0:c2lnLTE=", "payload-" + signatureTag + "-1:c2lnLTI="},
+		},
+		{
+			name: "referrers with an invalid layer",
+			source: func(t *testing.T, r *fakeRegistry) {
+				first := r.addSignatureManifest(t, "", map[string]string{"unrelated": "annotation"})
+				second := r.addSignatureManifest(t, "second", map[string]string{cosign.SignatureAnnotation: "c2ln"})
+				r.referrers = map[string][]registryclient.Referrer{
+					releaseDigest: {
+						{MediaType: registryclient.MediaTypeImageManifest, ArtifactType: cosign.SignatureArtifactType, Digest: first},
+						{MediaType: registryclient.MediaTypeImageManifest, ArtifactType: "application/vnd.example.sbom", Digest: digest.FromString("sbom")},
+						{MediaType: registryclient.MediaTypeImageManifest, ArtifactType: cosign.SignatureArtifactType, Digest: second},
+					},
+				}
+			},
+			expectedSignatures: []string{"payload-second-0:c2ln"},
+			expectedErrors:     []string{"has no dev.cosignproject.cosign/signature annotation"},
+		},
+		{
+			name:   "no signatures",
+			source: func(t *testing.T, r *fakeRegistry) {},
+		},
+		{
+			name: "mirror",
+			mirror: func(t *testing.T, r *fakeRegistry) {
+				r.addSignatureManifest(t, signatureTag, map[string]string{cosign.SignatureAnnotation: "dGFn"})
+				referred := r.addSignatureManifest(t, "", map[string]string{cosign.SignatureAnnotation: "cmVmZXJyZXI="})
+				r.referrers = map[string][]registryclient.Referrer{
+					releaseDigest: {{MediaType: registryclient.MediaTypeImageManifest, ArtifactType: cosign.SignatureArtifactType, Digest: referred}},
+				}
+			},
+			expectedSignatures: []string{"payload-" + signatureTag + "-0:dGFn", "payload--0:cmVmZXJyZXI="},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			insecureTransport, err := rest.TransportFor(&rest.Config{TLSClientConfig: rest.TLSClientConfig{Insecure: true}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sourceRegistry := newFakeRegistry("openshift/release")
+			if tt.source != nil {
+				tt.source(t, sourceRegistry)
+			}
+			sourceServer := httptest.NewTLSServer(sourceRegistry)
+			defer sourceServer.Close()
+			repository, err := reference.Parse(sourceServer.Listener.Addr().String() + "/openshift/release")
+			if err != nil {
+				t.Fatal(err)
+			}
+			registryContext := registryclient.NewContext(http.DefaultTransport, insecureTransport)
+
+			var mirrorRegistry *fakeRegistry
+			if tt.mirror != nil {
+				mirrorRegistry = newFakeRegistry("mirror/release")
+				tt.mirror(t, mirrorRegistry)
+				mirrorServer := httptest.NewTLSServer(mirrorRegistry)
+				defer mirrorServer.Close()
+				mirror, err := reference.Parse(mirrorServer.Listener.Addr().String() + "/mirror/release")
+				if err != nil {
+					t.Fatal(err)
+				}
+				registryContext = registryContext.WithAlternateBlobSourceStrategy(&alternateStrategy{alternates: []reference.DockerImageReference{mirror}})
+			}
+
+			s := &Store{Context: registryContext, Repository: repository, Insecure: true}
+			var signatures, errs []string
+			notFound := false
+			err = s.Signatures(ctx, "name", releaseDigest, func(ctx context.Context, signature []byte, errIn error) (bool, error) {
+				if errors.Is(errIn, store.ErrNotFound) {
+					notFound = true
+					return false, nil
+				}
+				if errIn != nil {
+					errs = append(errs, errIn.Error())
+					return false, nil
+				}
+				parsed, ok, err := cosign.ParseSignature(signature)
+				if !ok || err != nil {
+					t.Fatalf("expected a cosign signature, got %q: %v", string(signature), err)
+				}
+				signatures = append(signatures, fmt.Sprintf("%s:%s", parsed.Payload, parsed.Base64Signature))
+				return false, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(signatures, tt.expectedSignatures) {
+				t.Errorf("expected signatures %q, got %q", tt.expectedSignatures, signatures)
+			}
+			if len(errs) != len(tt.expectedErrors) {
+				t.Fatalf("expected errors %q, got %q", tt.expectedErrors, errs)
+			}
+			for i, expected := range tt.expectedErrors {
+				if !strings.Contains(errs[i], expected) {
+					t.Errorf("expected error %d to contain %q, got %q", i, expected, errs[i])
+				}
+			}
+			if !notFound {
+				t.Error("expected the store to report that it ran out of signatures")
+			}
+			if mirrorRegistry != nil && len(sourceRegistry.requests) > 0 {
+				t.Errorf("expected all requests to go to the mirror, the source received %v", sourceRegistry.requests)
+			}
+		})
+	}
+}
+
+func TestStoreSearchLimit(t *testing.T) {
+	ctx := context.Background()
+	insecureTransport, err := rest.TransportFor(&rest.Config{TLSClientConfig: rest.TLSClientConfig{Insecure: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := newFakeRegistry("openshift/release")
+	var layers []map[string]string
+	for i := 0; i < 2*maxSignatureSearch; i++ {
+		layers = append(layers, map[string]string{cosign.SignatureAnnotation: "c2ln"})
+	}
+	registry.addSignatureManifest(t, "sha256-0000000000000000000000000000000000000000000000000000000000000001.sig", layers...)
+	server := httptest.NewTLSServer(registry)
+	defer server.Close()
+	repository, err := reference.Parse(server.Listener.Addr().String() + "/openshift/release")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Store{Context: registryclient.NewContext(http.DefaultTransport, insecureTransport), Repository: repository, Insecure: true}
+	count := 0
+	err = s.Signatures(ctx, "name", releaseDigest, func(ctx context.Context, signature []byte, errIn error) (bool, error) {
+		if errIn == nil {
+			count++
+		}
+		return false, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != maxSignatureSearch {
+		t.Errorf("expected %d signatures, got %d", maxSignatureSearch, count)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/klog/v2"
 
+	"github.com/openshift/library-go/pkg/verify/cosign"
 	"github.com/openshift/library-go/pkg/verify/store"
 	"github.com/openshift/library-go/pkg/verify/store/serial"
 	"github.com/openshift/library-go/pkg/verify/util"
@@ -86,8 +87,12 @@ var validReleaseDigest = regexp.MustCompile(`^[a-z0-9]+(?:[.+_-][a-z0-9]+)*:[a-z
 // releaseVerifier implements a signature intersection operation on a provided release
 // digest - all verifiers must have at least one valid signature attesting the release
 // digest. If any failure occurs the caller should assume the content is unverified.
+//
+// Cosign verifiers form a second, independent policy: the release digest is also trusted
+// if all cosign verifiers have at least one valid cosign signature.
 type releaseVerifier struct {
-	verifiers map[string]openpgp.EntityList
+	verifiers       map[string]openpgp.EntityList
+	cosignVerifiers map[string]cosign.Verifier
 
 	// store is the store from which release signatures are retrieved.
 	store store.Store
@@ -98,9 +103,17 @@ type releaseVerifier struct {
 
 // NewReleaseVerifier creates a release verifier for the provided inputs.
 func NewReleaseVerifier(verifiers map[string]openpgp.EntityList, store store.Store) Interface {
+	return NewReleaseVerifierWithCosign(verifiers, nil, store)
+}
+
+// NewReleaseVerifierWithCosign creates a release verifier that trusts a release digest if either
+// all GPG verifiers or all cosign verifiers have signed it. Either set of verifiers may be empty,
+// but not both.
+func NewReleaseVerifierWithCosign(verifiers map[string]openpgp.EntityList, cosignVerifiers map[string]cosign.Verifier, store store.Store) Interface {
 	return &releaseVerifier{
-		verifiers: verifiers,
-		store:     store,
+		verifiers:       verifiers,
+		cosignVerifiers: cosignVerifiers,
+		store:           store,
 
 		signatureCache: make(map[string][][]byte),
 	}
@@ -123,10 +136,18 @@ func (v *releaseVerifier) String() string {
 	}
 	sort.Strings(keys)
 
+	var cosignKeys []string
+	for name := range v.cosignVerifiers {
+		cosignKeys = append(cosignKeys, name)
+	}
+	sort.Strings(cosignKeys)
+
 	var builder strings.Builder
 	builder.Grow(256)
-	fmt.Fprintf(&builder, "All release image digests must have GPG signatures from")
-	if len(keys) == 0 {
+	if len(keys) > 0 || len(cosignKeys) == 0 {
+		fmt.Fprintf(&builder, "All release image digests must have GPG signatures from")
+	}
+	if len(keys) == 0 && len(cosignKeys) == 0 {
 		fmt.Fprint(&builder, " <ERROR: no verifiers>")
 	}
 	for _, name := range keys {
@@ -151,6 +172,17 @@ func (v *releaseVerifier) String() string {
 		}
 		fmt.Fprint(&builder, ")")
 	}
+	if len(cosignKeys) > 0 {
+		if len(keys) > 0 {
+			fmt.Fprint(&builder, ", or")
+		} else {
+			fmt.Fprint(&builder, "All release image digests must have")
+		}
+		fmt.Fprint(&builder, " cosign signatures from")
+		for _, name := range cosignKeys {
+			fmt.Fprintf(&builder, " %s (%s)", name, v.cosignVerifiers[name])
+		}
+	}
 	fmt.Fprintf(&builder, " - will check for signatures in containers/image format at")
 	if v.store == nil {
 		fmt.Fprint(&builder, " <ERROR: no store>")
@@ -164,7 +196,7 @@ func (v *releaseVerifier) String() string {
 // matching release digest in any of the provided locations for all verifiers, or returns
 // an error.
 func (v *releaseVerifier) Verify(ctx context.Context, releaseDigest string) error {
-	if len(v.verifiers) == 0 || v.store == nil {
+	if (len(v.verifiers) == 0 && len(v.cosignVerifiers) == 0) || v.store == nil {
 		return fmt.Errorf("the release verifier is incorrectly configured, unable to verify digests")
 	}
 	if len(releaseDigest) == 0 {
@@ -182,8 +214,15 @@ func (v *releaseVerifier) Verify(ctx context.Context, releaseDigest string) erro
 	for k, v := range v.verifiers {
 		remaining[k] = v
 	}
+	remainingCosign := make(map[string]cosign.Verifier, len(v.cosignVerifiers))
+	for k, v := range v.cosignVerifiers {
+		remainingCosign[k] = v
+	}
+	satisfied := func() bool {
+		return (len(v.verifiers) > 0 && len(remaining) == 0) || (len(v.cosignVerifiers) > 0 && len(remainingCosign) == 0)
+	}
 
-	var signedWith [][]byte
+	var signedWith, cosignSignedWith [][]byte
 	var errs []error
 	err := v.store.Signatures(ctx, "", releaseDigest, func(ctx context.Context, signature []byte, errIn error) (done bool, err error) {
 		if errIn != nil {
@@ -191,6 +230,32 @@ func (v *releaseVerifier) Verify(ctx context.Context, releaseDigest string) erro
 			errs = append(errs, fmt.Errorf("%s: %w", time.Now().Format(time.RFC3339), errIn))
 			return false, nil
 		}
+
+		cosignSignature, isCosign, err := cosign.ParseSignature(signature)
+		if err != nil {
+			klog.V(4).Infof("cosign signature for %s is not valid: %v", releaseDigest, err)
+			errs = append(errs, fmt.Errorf("%s: %w", time.Now().Format(time.RFC3339), err))
+			return false, nil
+		}
+		if isCosign {
+			for k, verifier := range remainingCosign {
+				payload, err := verifier.Verify(cosignSignature)
+				if err != nil {
+					klog.V(4).Infof("cosign verifier %q could not verify signature for %s: %v", k, releaseDigest, err)
+					errs = append(errs, fmt.Errorf("%s: %w", time.Now().Format(time.RFC3339), err))
+					continue
+				}
+				if err := cosign.VerifyPayload(payload, releaseDigest); err != nil {
+					klog.V(4).Infof("cosign signature for %s is not valid: %v", releaseDigest, err)
+					errs = append(errs, fmt.Errorf("%s: %w", time.Now().Format(time.RFC3339), err))
+					continue
+				}
+				delete(remainingCosign, k)
+				cosignSignedWith = append(cosignSignedWith, signature)
+			}
+			return satisfied(), nil
+		}
+
 		for k, keyring := range remaining {
 			content, _, err := verifySignatureWithKeyring(bytes.NewReader(signature), keyring)
 			if err != nil {
@@ -206,18 +271,21 @@ func (v *releaseVerifier) Verify(ctx context.Context, releaseDigest string) erro
 			delete(remaining, k)
 			signedWith = append(signedWith, signature)
 		}
-		return len(remaining) == 0, nil
+		return satisfied(), nil
 	})
 	if err != nil {
 		klog.V(4).Infof("Failed to retrieve signatures for %s: %v", releaseDigest, err)
 		errs = append(errs, fmt.Errorf("%s: %w", time.Now().Format(time.RFC3339), err))
 	}
 
-	if len(remaining) > 0 {
-		remainingKeyRings := make([]string, 0, len(remaining))
+	if !satisfied() {
+		remainingKeyRings := make([]string, 0, len(remaining)+len(remainingCosign))
 		for k := range remaining {
 			remainingKeyRings = append(remainingKeyRings, k)
 		}
+		for k := range remainingCosign {
+			remainingKeyRings = append(remainingKeyRings, k)
+		}
 		err := &wrapError{
 			msg: fmt.Sprintf("unable to verify %s against keyrings: %s", releaseDigest, strings.Join(remainingKeyRings, ", ")),
 			err: errors.NewAggregate(errs),
@@ -226,6 +294,10 @@ func (v *releaseVerifier) Verify(ctx context.Context, releaseDigest string) erro
 		return err
 	}
 
+	// only keep the signatures of the policy that was satisfied.
+	if len(v.verifiers) == 0 || len(remaining) > 0 {
+		signedWith = cosignSignedWith
+	}
 	v.cacheVerification(releaseDigest, signedWith)
 
 	return nil
@@ -3,6 +3,11 @@ package verify
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -15,6 +20,7 @@ import (
 
 	"golang.org/x/crypto/openpgp"
 
+	"github.com/openshift/library-go/pkg/verify/cosign"
 	"github.com/openshift/library-go/pkg/verify/store"
 	"github.com/openshift/library-go/pkg/verify/store/memory"
 	"github.com/openshift/library-go/pkg/verify/store/serial"
@@ -296,3 +302,121 @@ func Test_ReleaseVerifier_Signatures(t *testing.T) {
 		t.Fatalf("%d %#v", len(sigs), sigs)
 	}
 }
+
+func newCosignSignature(t *testing.T, key *ecdsa.PrivateKey, releaseDigest string) []byte {
+	t.Helper()
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"quay.io/openshift-release-dev/ocp-release"},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, releaseDigest))
+	digest := sha256.Sum256(payload)
+	rawSignature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := (&cosign.Signature{Payload: payload, Base64Signature: base64.StdEncoding.EncodeToString(rawSignature)}).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func Test_ReleaseVerifier_VerifyCosign(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "keyrings", "redhat.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	redhatPublic, err := openpgp.ReadArmoredKeyRing(bytes.NewBuffer(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gpgSignature, err := os.ReadFile(filepath.Join("testdata", "signatures", "sha256=e3f12513a4b22a2d7c0e7c9207f52128113758d9d68c7d06b11a0ac7672966f7", "signature-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	releaseDigest := "sha256:e3f12513a4b22a2d7c0e7c9207f52128113758d9d68c7d06b11a0ac7672966f7"
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cosignVerifier, err := cosign.NewPublicKeyVerifier(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherCosignVerifier, err := cosign.NewPublicKeyVerifier(otherKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cosignSignature := newCosignSignature(t, key, releaseDigest)
+
+	tests := []struct {
+		name            string
+		verifiers       map[string]openpgp.EntityList
+		cosignVerifiers map[string]cosign.Verifier
+		signatures      [][]byte
+		wantErr         bool
+	}{
+		{
+			name:            "cosign signature",
+			cosignVerifiers: map[string]cosign.Verifier{"key": cosignVerifier},
+			signatures:      [][]byte{cosignSignature},
+		},
+		{
+			name:            "cosign signature after an unrelated gpg signature",
+			cosignVerifiers: map[string]cosign.Verifier{"key": cosignVerifier},
+			signatures:      [][]byte{gpgSignature, cosignSignature},
+		},
+		{
+			name:            "cosign policy satisfied without gpg signature",
+			verifiers:       map[string]openpgp.EntityList{"redhat": redhatPublic},
+			cosignVerifiers: map[string]cosign.Verifier{"key": cosignVerifier},
+			signatures:      [][]byte{cosignSignature},
+		},
+		{
+			name:            "gpg policy satisfied without cosign signature",
+			verifiers:       map[string]openpgp.EntityList{"redhat": redhatPublic},
+			cosignVerifiers: map[string]cosign.Verifier{"key": cosignVerifier},
+			signatures:      [][]byte{gpgSignature},
+		},
+		{
+			name:            "every cosign verifier must sign",
+			cosignVerifiers: map[string]cosign.Verifier{"key": cosignVerifier, "other": otherCosignVerifier},
+			signatures:      [][]byte{cosignSignature},
+			wantErr:         true,
+		},
+		{
+			name:            "cosign signature for another digest",
+			cosignVerifiers: map[string]cosign.Verifier{"key": cosignVerifier},
+			signatures:      [][]byte{newCosignSignature(t, key, "sha256:0000000000000000000000000000000000000000000000000000000000000000")},
+			wantErr:         true,
+		},
+		{
+			name:            "cosign signature from another key",
+			cosignVerifiers: map[string]cosign.Verifier{"key": cosignVerifier},
+			signatures:      [][]byte{newCosignSignature(t, otherKey, releaseDigest)},
+			wantErr:         true,
+		},
+		{
+			name:            "gpg signature does not satisfy cosign policy",
+			cosignVerifiers: map[string]cosign.Verifier{"key": cosignVerifier},
+			signatures:      [][]byte{gpgSignature},
+			wantErr:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewReleaseVerifierWithCosign(tt.verifiers, tt.cosignVerifiers, &memory.Store{
+				Data: map[string][][]byte{releaseDigest: tt.signatures},
+			})
+			err := v.Verify(context.Background(), releaseDigest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("releaseVerifier.Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(v.Signatures()[releaseDigest]) != 1 {
+				t.Errorf("expected the signature to be cached, got %#v", v.Signatures())
+			}
+		})
+	}
+}